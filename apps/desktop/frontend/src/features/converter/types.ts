@@ -9,10 +9,40 @@ export interface VideoStream {
   codec: string;
   width: number;
   height: number;
+  rotation?: number;
   fps: number;
   bitrate: number;
 }
 
+export interface MediaStream {
+  index: number;
+  typeIndex: number;
+  type: "video" | "audio" | "subtitle" | "data" | "attachment" | string;
+  codec: string;
+  profile?: string;
+  language?: string;
+  title?: string;
+  default: boolean;
+  disposition?: string[];
+  width?: number;
+  height?: number;
+  rotation?: number;
+  fps?: number;
+  pixFmt?: string;
+  channels?: number;
+  channelLayout?: string;
+  sampleRate?: number;
+  filename?: string;
+  mimeType?: string;
+  bitrate?: number;
+}
+
+export interface MediaChapter {
+  title: string;
+  startTime: number;
+  endTime: number;
+}
+
 export interface MediaInfo {
   duration: number;
   format: string;
@@ -20,6 +50,9 @@ export interface MediaInfo {
   bitrate: number;
   videoStream?: VideoStream;
   audioStream?: AudioStream;
+  streams?: MediaStream[];
+  tags?: Record<string, string>;
+  chapters?: MediaChapter[];
 }
 
 export interface TrimOptions {
@@ -27,6 +60,46 @@ export interface TrimOptions {
   endTime: number;
 }
 
+export interface CropRect {
+  x: number;
+  y: number;
+  width: number;
+  height: number;
+}
+
+export interface VideoFilterOptions {
+  crop?: CropRect;
+  rotate?: 0 | 90 | 180 | 270;
+  flipH?: boolean;
+  flipV?: boolean;
+  width?: number;
+  height?: number;
+  padAspect?: string;
+  padColor?: string;
+  fps?: number;
+}
+
+export interface ConversionOptions {
+  params?: Record<string, string>;
+  streams?: number[];
+  trim?: TrimOptions;
+  videoFilters?: VideoFilterOptions;
+}
+
+export interface TargetSizeOptions {
+  targetBytes: number;
+  videoCodec?: "h264" | "vp9";
+  audioBitrateKbps?: number;
+}
+
+export interface TargetSizeResult {
+  targetBytes: number;
+  videoBitrateKbps: number;
+  audioBitrateKbps: number;
+  outputBytes?: number;
+  deviation?: number;
+}
+
 export interface ConversionPreset {
   id: string;
   name: string;
@@ -37,6 +110,28 @@ export interface ConversionPreset {
   options?: Record<string, unknown>;
 }
 
+export interface ConcatOptions {
+  inputPaths: string[];
+  crossfade?: number;
+  reencode: boolean;
+  reason?: string;
+}
+
+export interface AnimationOptions {
+  format?: "gif" | "webp";
+  fps?: number;
+  width?: number;
+  loop: number;
+  dither?:
+    | "sierra2_4a"
+    | "floyd_steinberg"
+    | "sierra2"
+    | "bayer"
+    | "heckbert"
+    | "none";
+  quality?: number;
+}
+
 export interface ConversionJob {
   id: string;
   inputPath: string;
@@ -44,6 +139,16 @@ export interface ConversionJob {
   presetId?: string;
   customArgs?: string[];
   trimOptions?: TrimOptions;
+  videoFilters?: VideoFilterOptions;
+  streams?: number[];
+  concat?: ConcatOptions;
+  animation?: AnimationOptions;
+  targetSize?: TargetSizeOptions;
+  pass?: number;
+  sizeResult?: TargetSizeResult;
+  estimatedBytes?: number;
+  inputBytes?: number;
+  outputBytes?: number;
   state: string;
   progress: number;
   duration?: number;
@@ -57,5 +162,7 @@ export interface ConversionProgress {
   state: string;
   progress: number;
   speed: number;
+  pass?: number;
+  estimatedBytes?: number;
   error?: string;
 }
@@ -17,6 +17,10 @@ import type {
   ConversionJob,
   MediaInfo,
   ConversionProgress,
+  TargetSizeOptions,
+  AnimationOptions,
+  ConversionOptions,
+  VideoFilterOptions,
 } from "@/features/converter/types";
 
 export type {
@@ -84,24 +88,79 @@ export const getConversionPresetsByCategory = (cat: string) =>
   App.GetConversionPresetsByCategory(cat) as Promise<ConversionPreset[]>;
 export const analyzeMediaFile = (path: string) =>
   App.AnalyzeMediaFile(path) as Promise<MediaInfo>;
+export const getConversionPreset = (id: string) =>
+  App.GetConversionPreset(id) as Promise<ConversionPreset>;
 export const startConversion = (
   input: string,
   output: string,
-  preset: string
-) => App.StartConversion(input, output, preset) as Promise<ConversionJob>;
+  preset: string,
+  options: ConversionOptions = {}
+) =>
+  App.StartConversion(
+    input,
+    output,
+    preset,
+    options as Parameters<typeof App.StartConversion>[3]
+  ) as Promise<ConversionJob>;
 export const startConversionWithTrim = (
   input: string,
   output: string,
   preset: string,
   start: number,
-  end: number
+  end: number,
+  options: ConversionOptions = {}
 ) =>
   App.StartConversionWithTrim(
     input,
     output,
     preset,
     start,
-    end
+    end,
+    options as Parameters<typeof App.StartConversionWithTrim>[5]
+  ) as Promise<ConversionJob>;
+export const startTargetSizeConversion = (
+  input: string,
+  output: string,
+  target: TargetSizeOptions,
+  start = 0,
+  end = 0,
+  options: ConversionOptions = {}
+) =>
+  App.StartTargetSizeConversion(
+    input,
+    output,
+    target,
+    start,
+    end,
+    options as Parameters<typeof App.StartTargetSizeConversion>[5]
+  ) as Promise<ConversionJob>;
+export const startAnimationConversion = (
+  input: string,
+  output: string,
+  options: AnimationOptions,
+  start = 0,
+  end = 0,
+  filters?: VideoFilterOptions
+) =>
+  App.StartAnimationConversion(
+    input,
+    output,
+    options,
+    start,
+    end,
+    filters as Parameters<typeof App.StartAnimationConversion>[5]
+  ) as Promise<ConversionJob>;
+export const joinMediaFiles = (
+  inputs: string[],
+  output: string,
+  preset = "",
+  crossfade = 0
+) =>
+  App.JoinMediaFiles(
+    inputs,
+    output,
+    preset,
+    crossfade
   ) as Promise<ConversionJob>;
 export const startCustomConversion = (
   input: string,
@@ -1,6 +1,7 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 import {core} from '../models';
+import {downloader} from '../models';
 import {updater} from '../models';
 import {app} from '../models';
 import {youtube} from '../models';
@@ -15,42 +16,74 @@ export function CancelAllDownloads():Promise<void>;
 
 export function CancelConversion(arg1:string):Promise<void>;
 
+export function CancelConversionBatch(arg1:string):Promise<void>;
+
 export function CancelDownload(arg1:string):Promise<void>;
 
+export function CheckBinaryIntegrity():Promise<Array<downloader.BinaryIntegrity>>;
+
 export function CheckFFmpeg():Promise<boolean|string>;
 
 export function CheckForUpdate():Promise<updater.UpdateInfo>;
 
+export function CheckYtDlpUpdate():Promise<downloader.YtDlpUpdateInfo>;
+
 export function ClearCompleted():Promise<void>;
 
 export function ClearCompletedConversions():Promise<void>;
 
+export function ClearCookies():Promise<void>;
+
+export function ClearMetadataCache():Promise<void>;
+
+export function DeleteConversionPreset(arg1:string):Promise<void>;
+
 export function DownloadFFmpeg():Promise<void>;
 
 export function DownloadUpdate():Promise<string>;
 
 export function DownloadYtDlp():Promise<void>;
 
+export function DuplicateConversionPreset(arg1:string,arg2:string):Promise<core.ConversionPreset>;
+
+export function ExportConversionPresets(arg1:Array<string>,arg2:string):Promise<void>;
+
 export function FetchMetadata(arg1:string):Promise<core.VideoMetadata>;
 
 export function GenerateWaveform(arg1:string,arg2:number):Promise<Array<number>>;
 
 export function GetAppVersion():Promise<string>;
 
+export function GetBackendStats():Promise<Array<core.BackendStats>>;
+
+export function GetConversionBatch(arg1:string):Promise<core.ConversionBatchReport>;
+
+export function GetConversionBatches():Promise<Array<core.ConversionBatchReport>>;
+
 export function GetConversionJobs():Promise<Array<core.ConversionJob>>;
 
+export function GetConversionPreset(arg1:string):Promise<core.ConversionPreset>;
+
 export function GetConversionPresets():Promise<Array<core.ConversionPreset>>;
 
 export function GetConversionPresetsByCategory(arg1:string):Promise<Array<core.ConversionPreset>>;
 
+export function GetConversionQueueState():Promise<core.ConversionQueueState>;
+
+export function GetCookiesStatus():Promise<downloader.CookieFileInfo>;
+
 export function GetDownloadBackend():Promise<string>;
 
 export function GetFFmpegStatus():Promise<app.FFmpegStatus>;
 
+export function GetPresetCategories():Promise<Array<string>>;
+
 export function GetQueue():Promise<Array<core.QueueItem>>;
 
 export function GetSettings():Promise<core.Settings>;
 
+export function GetThrottleState():Promise<core.ThrottleState>;
+
 export function GetTrendingVideos(arg1:string,arg2:number):Promise<youtube.SearchResponse>;
 
 export function GetUpdateInfo():Promise<updater.UpdateInfo>;
@@ -59,12 +92,30 @@ export function GetYtDlpDefaultFlags():Promise<Record<string, Array<string>>>;
 
 export function GetYtDlpStatus():Promise<app.YtDlpStatus>;
 
+export function ImportConversionPresets(arg1:string):Promise<Array<core.ConversionPreset>>;
+
+export function ImportCookiesFile(arg1:string):Promise<downloader.CookieFileInfo>;
+
 export function ImportURLs(arg1:Array<string>,arg2:string):Promise<app.ImportResult>;
 
+export function InstallToolFromFile(arg1:string,arg2:string):Promise<downloader.ToolInstallResult>;
+
 export function InstallUpdate():Promise<void>;
 
+export function InstallYtDlpVersion(arg1:string):Promise<void>;
+
+export function InvalidateMetadata(arg1:string):Promise<void>;
+
+export function IsSupportedURL(arg1:string):Promise<boolean>;
+
 export function IsValidYouTubeURL(arg1:string):Promise<boolean>;
 
+export function JoinMediaFiles(arg1:Array<string>,arg2:string,arg3:string,arg4:number):Promise<core.ConversionJob>;
+
+export function ListFormats(arg1:string):Promise<Array<core.FormatInfo>>;
+
+export function ListYtDlpVersions():Promise<Array<downloader.YtDlpVersionInfo>>;
+
 export function OnSecondInstance(arg1:options.SecondInstanceData):Promise<void>;
 
 export function OnUrlOpen(arg1:string):Promise<void>;
@@ -75,32 +126,68 @@ export function OpenFolder(arg1:string):Promise<void>;
 
 export function OpenReleasePage():Promise<void>;
 
+export function PauseConversions():Promise<void>;
+
+export function PinYtDlpVersion(arg1:string):Promise<void>;
+
+export function RefreshMetadata(arg1:string):Promise<core.VideoMetadata>;
+
 export function RemoveConversionJob(arg1:string):Promise<void>;
 
 export function RemoveFromQueue(arg1:string):Promise<void>;
 
+export function RemoveYtDlpVersion(arg1:string):Promise<void>;
+
 export function ResetSettings():Promise<core.Settings>;
 
+export function ResumeConversions():Promise<void>;
+
 export function RetryDownload(arg1:string):Promise<void>;
 
+export function SaveConversionPreset(arg1:core.ConversionPreset):Promise<core.ConversionPreset>;
+
 export function SaveSettings(arg1:core.Settings):Promise<void>;
 
 export function SearchYouTube(arg1:string,arg2:number):Promise<youtube.SearchResponse>;
 
+export function SelectCookiesFile():Promise<string>;
+
 export function SelectDirectory():Promise<string>;
 
 export function SelectFile(arg1:string,arg2:Array<frontend.FileFilter>):Promise<string>;
 
 export function SelectMediaFile():Promise<string>;
 
+export function SetConversionPriority(arg1:string,arg2:number):Promise<void>;
+
 export function SetPendingDeepLink(arg1:string):Promise<void>;
 
+export function SetQueueItemClip(arg1:string,arg2:number,arg3:number):Promise<void>;
+
+export function SetQueueItemFormat(arg1:string,arg2:string):Promise<void>;
+
+export function SetQueueItemLiveOptions(arg1:string,arg2:core.LiveOptions):Promise<void>;
+
 export function StartAllDownloads():Promise<void>;
 
-export function StartConversion(arg1:string,arg2:string,arg3:string):Promise<core.ConversionJob>;
+export function StartAnimationConversion(arg1:string,arg2:string,arg3:core.AnimationOptions,arg4:number,arg5:number,arg6:core.VideoFilterOptions):Promise<core.ConversionJob>;
+
+export function StartBatchConversion(arg1:string,arg2:Array<string>,arg3:string,arg4:string):Promise<core.ConversionBatch>;
+
+export function StartConversion(arg1:string,arg2:string,arg3:string,arg4:core.ConversionOptions):Promise<core.ConversionJob>;
 
-export function StartConversionWithTrim(arg1:string,arg2:string,arg3:string,arg4:number,arg5:number):Promise<core.ConversionJob>;
+export function StartConversionWithTrim(arg1:string,arg2:string,arg3:string,arg4:number,arg5:number,arg6:core.ConversionOptions):Promise<core.ConversionJob>;
 
 export function StartCustomConversion(arg1:string,arg2:string,arg3:Array<string>):Promise<core.ConversionJob>;
 
 export function StartDownload(arg1:string):Promise<void>;
+
+export function StartFolderConversion(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<core.ConversionBatch>;
+
+export function StartTargetSizeConversion(arg1:string,arg2:string,arg3:core.TargetSizeOptions,arg4:number,arg5:number,arg6:core.ConversionOptions):Promise<core.ConversionJob>;
+
+export function StopRecording(arg1:string):Promise<void>;
+
+export function UseYtDlpVersion(arg1:string):Promise<void>;
+
+export function ValidateYtDlpFlags(arg1:Array<string>):Promise<Array<core.YtDlpFlag>>;
@@ -18,10 +18,18 @@ export function CancelConversion(arg1) {
   return window['go']['app']['App']['CancelConversion'](arg1);
 }
 
+export function CancelConversionBatch(arg1) {
+  return window['go']['app']['App']['CancelConversionBatch'](arg1);
+}
+
 export function CancelDownload(arg1) {
   return window['go']['app']['App']['CancelDownload'](arg1);
 }
 
+export function CheckBinaryIntegrity() {
+  return window['go']['app']['App']['CheckBinaryIntegrity']();
+}
+
 export function CheckFFmpeg() {
   return window['go']['app']['App']['CheckFFmpeg']();
 }
@@ -30,6 +38,10 @@ export function CheckForUpdate() {
   return window['go']['app']['App']['CheckForUpdate']();
 }
 
+export function CheckYtDlpUpdate() {
+  return window['go']['app']['App']['CheckYtDlpUpdate']();
+}
+
 export function ClearCompleted() {
   return window['go']['app']['App']['ClearCompleted']();
 }
@@ -38,6 +50,18 @@ export function ClearCompletedConversions() {
   return window['go']['app']['App']['ClearCompletedConversions']();
 }
 
+export function ClearCookies() {
+  return window['go']['app']['App']['ClearCookies']();
+}
+
+export function ClearMetadataCache() {
+  return window['go']['app']['App']['ClearMetadataCache']();
+}
+
+export function DeleteConversionPreset(arg1) {
+  return window['go']['app']['App']['DeleteConversionPreset'](arg1);
+}
+
 export function DownloadFFmpeg() {
   return window['go']['app']['App']['DownloadFFmpeg']();
 }
@@ -50,6 +74,14 @@ export function DownloadYtDlp() {
   return window['go']['app']['App']['DownloadYtDlp']();
 }
 
+export function DuplicateConversionPreset(arg1, arg2) {
+  return window['go']['app']['App']['DuplicateConversionPreset'](arg1, arg2);
+}
+
+export function ExportConversionPresets(arg1, arg2) {
+  return window['go']['app']['App']['ExportConversionPresets'](arg1, arg2);
+}
+
 export function FetchMetadata(arg1) {
   return window['go']['app']['App']['FetchMetadata'](arg1);
 }
@@ -62,10 +94,26 @@ export function GetAppVersion() {
   return window['go']['app']['App']['GetAppVersion']();
 }
 
+export function GetBackendStats() {
+  return window['go']['app']['App']['GetBackendStats']();
+}
+
+export function GetConversionBatch(arg1) {
+  return window['go']['app']['App']['GetConversionBatch'](arg1);
+}
+
+export function GetConversionBatches() {
+  return window['go']['app']['App']['GetConversionBatches']();
+}
+
 export function GetConversionJobs() {
   return window['go']['app']['App']['GetConversionJobs']();
 }
 
+export function GetConversionPreset(arg1) {
+  return window['go']['app']['App']['GetConversionPreset'](arg1);
+}
+
 export function GetConversionPresets() {
   return window['go']['app']['App']['GetConversionPresets']();
 }
@@ -74,6 +122,14 @@ export function GetConversionPresetsByCategory(arg1) {
   return window['go']['app']['App']['GetConversionPresetsByCategory'](arg1);
 }
 
+export function GetConversionQueueState() {
+  return window['go']['app']['App']['GetConversionQueueState']();
+}
+
+export function GetCookiesStatus() {
+  return window['go']['app']['App']['GetCookiesStatus']();
+}
+
 export function GetDownloadBackend() {
   return window['go']['app']['App']['GetDownloadBackend']();
 }
@@ -82,6 +138,10 @@ export function GetFFmpegStatus() {
   return window['go']['app']['App']['GetFFmpegStatus']();
 }
 
+export function GetPresetCategories() {
+  return window['go']['app']['App']['GetPresetCategories']();
+}
+
 export function GetQueue() {
   return window['go']['app']['App']['GetQueue']();
 }
@@ -90,6 +150,10 @@ export function GetSettings() {
   return window['go']['app']['App']['GetSettings']();
 }
 
+export function GetThrottleState() {
+  return window['go']['app']['App']['GetThrottleState']();
+}
+
 export function GetTrendingVideos(arg1, arg2) {
   return window['go']['app']['App']['GetTrendingVideos'](arg1, arg2);
 }
@@ -106,18 +170,54 @@ export function GetYtDlpStatus() {
   return window['go']['app']['App']['GetYtDlpStatus']();
 }
 
+export function ImportConversionPresets(arg1) {
+  return window['go']['app']['App']['ImportConversionPresets'](arg1);
+}
+
+export function ImportCookiesFile(arg1) {
+  return window['go']['app']['App']['ImportCookiesFile'](arg1);
+}
+
 export function ImportURLs(arg1, arg2) {
   return window['go']['app']['App']['ImportURLs'](arg1, arg2);
 }
 
+export function InstallToolFromFile(arg1, arg2) {
+  return window['go']['app']['App']['InstallToolFromFile'](arg1, arg2);
+}
+
 export function InstallUpdate() {
   return window['go']['app']['App']['InstallUpdate']();
 }
 
+export function InstallYtDlpVersion(arg1) {
+  return window['go']['app']['App']['InstallYtDlpVersion'](arg1);
+}
+
+export function InvalidateMetadata(arg1) {
+  return window['go']['app']['App']['InvalidateMetadata'](arg1);
+}
+
+export function IsSupportedURL(arg1) {
+  return window['go']['app']['App']['IsSupportedURL'](arg1);
+}
+
 export function IsValidYouTubeURL(arg1) {
   return window['go']['app']['App']['IsValidYouTubeURL'](arg1);
 }
 
+export function JoinMediaFiles(arg1, arg2, arg3, arg4) {
+  return window['go']['app']['App']['JoinMediaFiles'](arg1, arg2, arg3, arg4);
+}
+
+export function ListFormats(arg1) {
+  return window['go']['app']['App']['ListFormats'](arg1);
+}
+
+export function ListYtDlpVersions() {
+  return window['go']['app']['App']['ListYtDlpVersions']();
+}
+
 export function OnSecondInstance(arg1) {
   return window['go']['app']['App']['OnSecondInstance'](arg1);
 }
@@ -138,6 +238,18 @@ export function OpenReleasePage() {
   return window['go']['app']['App']['OpenReleasePage']();
 }
 
+export function PauseConversions() {
+  return window['go']['app']['App']['PauseConversions']();
+}
+
+export function PinYtDlpVersion(arg1) {
+  return window['go']['app']['App']['PinYtDlpVersion'](arg1);
+}
+
+export function RefreshMetadata(arg1) {
+  return window['go']['app']['App']['RefreshMetadata'](arg1);
+}
+
 export function RemoveConversionJob(arg1) {
   return window['go']['app']['App']['RemoveConversionJob'](arg1);
 }
@@ -146,14 +258,26 @@ export function RemoveFromQueue(arg1) {
   return window['go']['app']['App']['RemoveFromQueue'](arg1);
 }
 
+export function RemoveYtDlpVersion(arg1) {
+  return window['go']['app']['App']['RemoveYtDlpVersion'](arg1);
+}
+
 export function ResetSettings() {
   return window['go']['app']['App']['ResetSettings']();
 }
 
+export function ResumeConversions() {
+  return window['go']['app']['App']['ResumeConversions']();
+}
+
 export function RetryDownload(arg1) {
   return window['go']['app']['App']['RetryDownload'](arg1);
 }
 
+export function SaveConversionPreset(arg1) {
+  return window['go']['app']['App']['SaveConversionPreset'](arg1);
+}
+
 export function SaveSettings(arg1) {
   return window['go']['app']['App']['SaveSettings'](arg1);
 }
@@ -162,6 +286,10 @@ export function SearchYouTube(arg1, arg2) {
   return window['go']['app']['App']['SearchYouTube'](arg1, arg2);
 }
 
+export function SelectCookiesFile() {
+  return window['go']['app']['App']['SelectCookiesFile']();
+}
+
 export function SelectDirectory() {
   return window['go']['app']['App']['SelectDirectory']();
 }
@@ -174,20 +302,44 @@ export function SelectMediaFile() {
   return window['go']['app']['App']['SelectMediaFile']();
 }
 
+export function SetConversionPriority(arg1, arg2) {
+  return window['go']['app']['App']['SetConversionPriority'](arg1, arg2);
+}
+
 export function SetPendingDeepLink(arg1) {
   return window['go']['app']['App']['SetPendingDeepLink'](arg1);
 }
 
+export function SetQueueItemClip(arg1, arg2, arg3) {
+  return window['go']['app']['App']['SetQueueItemClip'](arg1, arg2, arg3);
+}
+
+export function SetQueueItemFormat(arg1, arg2) {
+  return window['go']['app']['App']['SetQueueItemFormat'](arg1, arg2);
+}
+
+export function SetQueueItemLiveOptions(arg1, arg2) {
+  return window['go']['app']['App']['SetQueueItemLiveOptions'](arg1, arg2);
+}
+
 export function StartAllDownloads() {
   return window['go']['app']['App']['StartAllDownloads']();
 }
 
-export function StartConversion(arg1, arg2, arg3) {
-  return window['go']['app']['App']['StartConversion'](arg1, arg2, arg3);
+export function StartAnimationConversion(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['app']['App']['StartAnimationConversion'](arg1, arg2, arg3, arg4, arg5, arg6);
+}
+
+export function StartBatchConversion(arg1, arg2, arg3, arg4) {
+  return window['go']['app']['App']['StartBatchConversion'](arg1, arg2, arg3, arg4);
+}
+
+export function StartConversion(arg1, arg2, arg3, arg4) {
+  return window['go']['app']['App']['StartConversion'](arg1, arg2, arg3, arg4);
 }
 
-export function StartConversionWithTrim(arg1, arg2, arg3, arg4, arg5) {
-  return window['go']['app']['App']['StartConversionWithTrim'](arg1, arg2, arg3, arg4, arg5);
+export function StartConversionWithTrim(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['app']['App']['StartConversionWithTrim'](arg1, arg2, arg3, arg4, arg5, arg6);
 }
 
 export function StartCustomConversion(arg1, arg2, arg3) {
@@ -197,3 +349,23 @@ export function StartCustomConversion(arg1, arg2, arg3) {
 export function StartDownload(arg1) {
   return window['go']['app']['App']['StartDownload'](arg1);
 }
+
+export function StartFolderConversion(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['app']['App']['StartFolderConversion'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function StartTargetSizeConversion(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['app']['App']['StartTargetSizeConversion'](arg1, arg2, arg3, arg4, arg5, arg6);
+}
+
+export function StopRecording(arg1) {
+  return window['go']['app']['App']['StopRecording'](arg1);
+}
+
+export function UseYtDlpVersion(arg1) {
+  return window['go']['app']['App']['UseYtDlpVersion'](arg1);
+}
+
+export function ValidateYtDlpFlags(arg1) {
+  return window['go']['app']['App']['ValidateYtDlpFlags'](arg1);
+}
@@ -67,6 +67,28 @@ export namespace app {
 
 export namespace core {
 	
+	export class AnimationOptions {
+	    format?: string;
+	    fps?: number;
+	    width?: number;
+	    loop: number;
+	    dither?: string;
+	    quality?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AnimationOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.format = source["format"];
+	        this.fps = source["fps"];
+	        this.width = source["width"];
+	        this.loop = source["loop"];
+	        this.dither = source["dither"];
+	        this.quality = source["quality"];
+	    }
+	}
 	export class AudioStream {
 	    codec: string;
 	    channels: number;
@@ -85,10 +107,235 @@ export namespace core {
 	        this.bitrate = source["bitrate"];
 	    }
 	}
+	export class BackendStats {
+	    backend: string;
+	    successes: number;
+	    failures: number;
+	    fallbacks: number;
+	    lastError?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BackendStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.backend = source["backend"];
+	        this.successes = source["successes"];
+	        this.failures = source["failures"];
+	        this.fallbacks = source["fallbacks"];
+	        this.lastError = source["lastError"];
+	    }
+	}
+	export class Chapter {
+	    title: string;
+	    startTime: number;
+	    endTime: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Chapter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.title = source["title"];
+	        this.startTime = source["startTime"];
+	        this.endTime = source["endTime"];
+	    }
+	}
+	export class ConcatOptions {
+	    inputPaths: string[];
+	    crossfade?: number;
+	    reencode: boolean;
+	    reason?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConcatOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.inputPaths = source["inputPaths"];
+	        this.crossfade = source["crossfade"];
+	        this.reencode = source["reencode"];
+	        this.reason = source["reason"];
+	    }
+	}
+	export class ConversionBatch {
+	    id: string;
+	    name: string;
+	    presetId: string;
+	    outputDir?: string;
+	    jobIds: string[];
+	    // Go type: time
+	    createdAt: any;
+	    // Go type: time
+	    finishedAt?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConversionBatch(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.presetId = source["presetId"];
+	        this.outputDir = source["outputDir"];
+	        this.jobIds = source["jobIds"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.finishedAt = this.convertValues(source["finishedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ConversionBatchFailure {
+	    jobId: string;
+	    inputPath: string;
+	    error: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConversionBatchFailure(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.jobId = source["jobId"];
+	        this.inputPath = source["inputPath"];
+	        this.error = source["error"];
+	    }
+	}
+	export class ConversionBatchReport {
+	    batchId: string;
+	    name: string;
+	    total: number;
+	    queued: number;
+	    running: number;
+	    completed: number;
+	    failed: number;
+	    cancelled: number;
+	    progress: number;
+	    done: boolean;
+	    inputBytes: number;
+	    outputBytes: number;
+	    failures?: ConversionBatchFailure[];
+	    // Go type: time
+	    createdAt: any;
+	    // Go type: time
+	    finishedAt?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConversionBatchReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.batchId = source["batchId"];
+	        this.name = source["name"];
+	        this.total = source["total"];
+	        this.queued = source["queued"];
+	        this.running = source["running"];
+	        this.completed = source["completed"];
+	        this.failed = source["failed"];
+	        this.cancelled = source["cancelled"];
+	        this.progress = source["progress"];
+	        this.done = source["done"];
+	        this.inputBytes = source["inputBytes"];
+	        this.outputBytes = source["outputBytes"];
+	        this.failures = this.convertValues(source["failures"], ConversionBatchFailure);
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.finishedAt = this.convertValues(source["finishedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MediaStream {
+	    index: number;
+	    typeIndex: number;
+	    type: string;
+	    codec: string;
+	    profile?: string;
+	    language?: string;
+	    title?: string;
+	    default: boolean;
+	    disposition?: string[];
+	    width?: number;
+	    height?: number;
+	    rotation?: number;
+	    fps?: number;
+	    pixFmt?: string;
+	    channels?: number;
+	    channelLayout?: string;
+	    sampleRate?: number;
+	    filename?: string;
+	    mimeType?: string;
+	    bitrate?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MediaStream(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.index = source["index"];
+	        this.typeIndex = source["typeIndex"];
+	        this.type = source["type"];
+	        this.codec = source["codec"];
+	        this.profile = source["profile"];
+	        this.language = source["language"];
+	        this.title = source["title"];
+	        this.default = source["default"];
+	        this.disposition = source["disposition"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.rotation = source["rotation"];
+	        this.fps = source["fps"];
+	        this.pixFmt = source["pixFmt"];
+	        this.channels = source["channels"];
+	        this.channelLayout = source["channelLayout"];
+	        this.sampleRate = source["sampleRate"];
+	        this.filename = source["filename"];
+	        this.mimeType = source["mimeType"];
+	        this.bitrate = source["bitrate"];
+	    }
+	}
 	export class VideoStream {
 	    codec: string;
 	    width: number;
 	    height: number;
+	    rotation?: number;
 	    fps: number;
 	    bitrate: number;
 	
@@ -101,6 +348,7 @@ export namespace core {
 	        this.codec = source["codec"];
 	        this.width = source["width"];
 	        this.height = source["height"];
+	        this.rotation = source["rotation"];
 	        this.fps = source["fps"];
 	        this.bitrate = source["bitrate"];
 	    }
@@ -112,6 +360,9 @@ export namespace core {
 	    bitrate: number;
 	    videoStream?: VideoStream;
 	    audioStream?: AudioStream;
+	    streams: MediaStream[];
+	    tags?: Record<string, string>;
+	    chapters?: Chapter[];
 	
 	    static createFrom(source: any = {}) {
 	        return new MediaInfo(source);
@@ -125,6 +376,9 @@ export namespace core {
 	        this.bitrate = source["bitrate"];
 	        this.videoStream = this.convertValues(source["videoStream"], VideoStream);
 	        this.audioStream = this.convertValues(source["audioStream"], AudioStream);
+	        this.streams = this.convertValues(source["streams"], MediaStream);
+	        this.tags = source["tags"];
+	        this.chapters = this.convertValues(source["chapters"], Chapter);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -145,6 +399,42 @@ export namespace core {
 		    return a;
 		}
 	}
+	export class TargetSizeResult {
+	    targetBytes: number;
+	    videoBitrateKbps: number;
+	    audioBitrateKbps: number;
+	    outputBytes?: number;
+	    deviation?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TargetSizeResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.targetBytes = source["targetBytes"];
+	        this.videoBitrateKbps = source["videoBitrateKbps"];
+	        this.audioBitrateKbps = source["audioBitrateKbps"];
+	        this.outputBytes = source["outputBytes"];
+	        this.deviation = source["deviation"];
+	    }
+	}
+	export class TargetSizeOptions {
+	    targetBytes: number;
+	    videoCodec?: string;
+	    audioBitrateKbps?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TargetSizeOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.targetBytes = source["targetBytes"];
+	        this.videoCodec = source["videoCodec"];
+	        this.audioBitrateKbps = source["audioBitrateKbps"];
+	    }
+	}
 	export class TrimOptions {
 	    startTime: number;
 	    endTime: number;
@@ -159,13 +449,126 @@ export namespace core {
 	        this.endTime = source["endTime"];
 	    }
 	}
+	export class CropRect {
+	    x: number;
+	    y: number;
+	    width: number;
+	    height: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CropRect(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.x = source["x"];
+	        this.y = source["y"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	    }
+	}
+	export class VideoFilterOptions {
+	    crop?: CropRect;
+	    rotate?: number;
+	    flipH?: boolean;
+	    flipV?: boolean;
+	    width?: number;
+	    height?: number;
+	    padAspect?: string;
+	    padColor?: string;
+	    fps?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new VideoFilterOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.crop = this.convertValues(source["crop"], CropRect);
+	        this.rotate = source["rotate"];
+	        this.flipH = source["flipH"];
+	        this.flipV = source["flipV"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.padAspect = source["padAspect"];
+	        this.padColor = source["padColor"];
+	        this.fps = source["fps"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ConversionOptions {
+	    params?: Record<string, string>;
+	    streams?: number[];
+	    trim?: TrimOptions;
+	    videoFilters?: VideoFilterOptions;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConversionOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.params = source["params"];
+	        this.streams = source["streams"];
+	        this.trim = this.convertValues(source["trim"], TrimOptions);
+	        this.videoFilters = this.convertValues(source["videoFilters"], VideoFilterOptions);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class ConversionJob {
 	    id: string;
 	    inputPath: string;
 	    outputPath: string;
 	    presetId?: string;
+	    params?: Record<string, string>;
 	    customArgs?: string[];
 	    trimOptions?: TrimOptions;
+	    videoFilters?: VideoFilterOptions;
+	    streams?: number[];
+	    concat?: ConcatOptions;
+	    animation?: AnimationOptions;
+	    priority: number;
+	    batchId?: string;
+	    targetSize?: TargetSizeOptions;
+	    pass?: number;
+	    sizeResult?: TargetSizeResult;
+	    estimatedBytes?: number;
+	    inputBytes?: number;
+	    outputBytes?: number;
 	    state: string;
 	    progress: number;
 	    duration?: number;
@@ -183,8 +586,21 @@ export namespace core {
 	        this.inputPath = source["inputPath"];
 	        this.outputPath = source["outputPath"];
 	        this.presetId = source["presetId"];
+	        this.params = source["params"];
 	        this.customArgs = source["customArgs"];
 	        this.trimOptions = this.convertValues(source["trimOptions"], TrimOptions);
+	        this.videoFilters = this.convertValues(source["videoFilters"], VideoFilterOptions);
+	        this.streams = source["streams"];
+	        this.concat = this.convertValues(source["concat"], ConcatOptions);
+	        this.animation = this.convertValues(source["animation"], AnimationOptions);
+	        this.priority = source["priority"];
+	        this.batchId = source["batchId"];
+	        this.targetSize = this.convertValues(source["targetSize"], TargetSizeOptions);
+	        this.pass = source["pass"];
+	        this.sizeResult = this.convertValues(source["sizeResult"], TargetSizeResult);
+	        this.estimatedBytes = source["estimatedBytes"];
+	        this.inputBytes = source["inputBytes"];
+	        this.outputBytes = source["outputBytes"];
 	        this.state = source["state"];
 	        this.progress = source["progress"];
 	        this.duration = source["duration"];
@@ -211,6 +627,68 @@ export namespace core {
 		    return a;
 		}
 	}
+	export class PresetParamChoice {
+	    value: string;
+	    label: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PresetParamChoice(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.value = source["value"];
+	        this.label = source["label"];
+	    }
+	}
+	export class PresetParam {
+	    name: string;
+	    label: string;
+	    type: string;
+	    default: string;
+	    min?: number;
+	    max?: number;
+	    step?: number;
+	    unit?: string;
+	    help?: string;
+	    choices?: PresetParamChoice[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PresetParam(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.label = source["label"];
+	        this.type = source["type"];
+	        this.default = source["default"];
+	        this.min = source["min"];
+	        this.max = source["max"];
+	        this.step = source["step"];
+	        this.unit = source["unit"];
+	        this.help = source["help"];
+	        this.choices = this.convertValues(source["choices"], PresetParamChoice);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class ConversionPreset {
 	    id: string;
 	    name: string;
@@ -218,7 +696,10 @@ export namespace core {
 	    category: string;
 	    outputExt: string;
 	    ffmpegArgs?: string[];
+	    params?: PresetParam[];
 	    options?: Record<string, any>;
+	    source?: string;
+	    basedOn?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new ConversionPreset(source);
@@ -232,9 +713,173 @@ export namespace core {
 	        this.category = source["category"];
 	        this.outputExt = source["outputExt"];
 	        this.ffmpegArgs = source["ffmpegArgs"];
+	        this.params = this.convertValues(source["params"], PresetParam);
 	        this.options = source["options"];
+	        this.source = source["source"];
+	        this.basedOn = source["basedOn"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ConversionQueueState {
+	    paused: boolean;
+	    maxConcurrent: number;
+	    running: number;
+	    queued: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConversionQueueState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.paused = source["paused"];
+	        this.maxConcurrent = source["maxConcurrent"];
+	        this.running = source["running"];
+	        this.queued = source["queued"];
 	    }
 	}
+	export class FormatInfo {
+	    id: string;
+	    ext: string;
+	    hasVideo: boolean;
+	    hasAudio: boolean;
+	    width?: number;
+	    height?: number;
+	    fps?: number;
+	    videoCodec?: string;
+	    audioCodec?: string;
+	    bitrate?: number;
+	    fileSize?: number;
+	    sampleRate?: number;
+	    audioChannels?: number;
+	    hdr: boolean;
+	    note?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FormatInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.ext = source["ext"];
+	        this.hasVideo = source["hasVideo"];
+	        this.hasAudio = source["hasAudio"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.fps = source["fps"];
+	        this.videoCodec = source["videoCodec"];
+	        this.audioCodec = source["audioCodec"];
+	        this.bitrate = source["bitrate"];
+	        this.fileSize = source["fileSize"];
+	        this.sampleRate = source["sampleRate"];
+	        this.audioChannels = source["audioChannels"];
+	        this.hdr = source["hdr"];
+	        this.note = source["note"];
+	    }
+	}
+	export class FormatSummary {
+	    count: number;
+	    maxHeight?: number;
+	    maxFps?: number;
+	    hdr: boolean;
+	    heights?: number[];
+	    maxAudioBitrate?: number;
+	    videoCodecs?: string[];
+	    audioCodecs?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new FormatSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.count = source["count"];
+	        this.maxHeight = source["maxHeight"];
+	        this.maxFps = source["maxFps"];
+	        this.hdr = source["hdr"];
+	        this.heights = source["heights"];
+	        this.maxAudioBitrate = source["maxAudioBitrate"];
+	        this.videoCodecs = source["videoCodecs"];
+	        this.audioCodecs = source["audioCodecs"];
+	    }
+	}
+	export class LiveOptions {
+	    fromStart: boolean;
+	    maxDurationSeconds?: number;
+	    waitForStart: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new LiveOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.fromStart = source["fromStart"];
+	        this.maxDurationSeconds = source["maxDurationSeconds"];
+	        this.waitForStart = source["waitForStart"];
+	    }
+	}
+	
+	
+	export class NetworkSettings {
+	    proxyUrl?: string;
+	    noProxy?: string[];
+	    ipVersion?: string;
+	    caBundlePath?: string;
+	    connectTimeoutSeconds?: number;
+	    readTimeoutSeconds?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new NetworkSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.proxyUrl = source["proxyUrl"];
+	        this.noProxy = source["noProxy"];
+	        this.ipVersion = source["ipVersion"];
+	        this.caBundlePath = source["caBundlePath"];
+	        this.connectTimeoutSeconds = source["connectTimeoutSeconds"];
+	        this.readTimeoutSeconds = source["readTimeoutSeconds"];
+	    }
+	}
+	export class PlaylistContext {
+	    id: string;
+	    title?: string;
+	    index?: number;
+	    count?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PlaylistContext(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.title = source["title"];
+	        this.index = source["index"];
+	        this.count = source["count"];
+	    }
+	}
+	
 	
 	export class VideoMetadata {
 	    id: string;
@@ -243,6 +888,20 @@ export namespace core {
 	    duration: number;
 	    thumbnail: string;
 	    description?: string;
+	    site?: string;
+	    liveStatus?: string;
+	    scheduledStart?: number;
+	    uploadDate?: string;
+	    channelId?: string;
+	    channelUrl?: string;
+	    viewCount?: number;
+	    likeCount?: number;
+	    categories?: string[];
+	    tags?: string[];
+	    chapters?: Chapter[];
+	    availability?: string;
+	    playlist?: PlaylistContext;
+	    formats?: FormatSummary;
 	
 	    static createFrom(source: any = {}) {
 	        return new VideoMetadata(source);
@@ -256,17 +915,55 @@ export namespace core {
 	        this.duration = source["duration"];
 	        this.thumbnail = source["thumbnail"];
 	        this.description = source["description"];
+	        this.site = source["site"];
+	        this.liveStatus = source["liveStatus"];
+	        this.scheduledStart = source["scheduledStart"];
+	        this.uploadDate = source["uploadDate"];
+	        this.channelId = source["channelId"];
+	        this.channelUrl = source["channelUrl"];
+	        this.viewCount = source["viewCount"];
+	        this.likeCount = source["likeCount"];
+	        this.categories = source["categories"];
+	        this.tags = source["tags"];
+	        this.chapters = this.convertValues(source["chapters"], Chapter);
+	        this.availability = source["availability"];
+	        this.playlist = this.convertValues(source["playlist"], PlaylistContext);
+	        this.formats = this.convertValues(source["formats"], FormatSummary);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class QueueItem {
 	    id: string;
 	    url: string;
 	    state: string;
 	    format: string;
+	    formatId?: string;
+	    live?: LiveOptions;
+	    clip?: TrimOptions;
 	    metadata?: VideoMetadata;
 	    savePath: string;
 	    filePath?: string;
 	    error?: string;
+	    errorCode?: string;
+	    errorHint?: string;
+	    backend?: string;
 	    // Go type: time
 	    createdAt: any;
 	    // Go type: time
@@ -282,10 +979,16 @@ export namespace core {
 	        this.url = source["url"];
 	        this.state = source["state"];
 	        this.format = source["format"];
+	        this.formatId = source["formatId"];
+	        this.live = this.convertValues(source["live"], LiveOptions);
+	        this.clip = this.convertValues(source["clip"], TrimOptions);
 	        this.metadata = this.convertValues(source["metadata"], VideoMetadata);
 	        this.savePath = source["savePath"];
 	        this.filePath = source["filePath"];
 	        this.error = source["error"];
+	        this.errorCode = source["errorCode"];
+	        this.errorHint = source["errorHint"];
+	        this.backend = source["backend"];
 	        this.createdAt = this.convertValues(source["createdAt"], null);
 	        this.updatedAt = this.convertValues(source["updatedAt"], null);
 	    }
@@ -314,12 +1017,21 @@ export namespace core {
 	    defaultFormat: string;
 	    defaultAudioQuality: string;
 	    defaultVideoQuality: string;
+	    preferredVideoCodec?: string;
 	    maxConcurrentDownloads: number;
+	    maxConcurrentConversions: number;
 	    ffmpegPath?: string;
 	    ffprobePath?: string;
 	    downloadBackend: string;
+	    backendFallback: boolean;
 	    ytDlpPath?: string;
 	    ytDlpExtraFlags?: string[];
+	    ytDlpVersion?: string;
+	    ytDlpReleaseUrl?: string;
+	    toolMirrorUrl?: string;
+	    cookiesFile?: string;
+	    allowedSites: string[];
+	    network: NetworkSettings;
 	    language?: string;
 	    themeMode?: string;
 	    accentColor?: string;
@@ -337,21 +1049,217 @@ export namespace core {
 	        this.defaultFormat = source["defaultFormat"];
 	        this.defaultAudioQuality = source["defaultAudioQuality"];
 	        this.defaultVideoQuality = source["defaultVideoQuality"];
+	        this.preferredVideoCodec = source["preferredVideoCodec"];
 	        this.maxConcurrentDownloads = source["maxConcurrentDownloads"];
+	        this.maxConcurrentConversions = source["maxConcurrentConversions"];
 	        this.ffmpegPath = source["ffmpegPath"];
 	        this.ffprobePath = source["ffprobePath"];
 	        this.downloadBackend = source["downloadBackend"];
+	        this.backendFallback = source["backendFallback"];
 	        this.ytDlpPath = source["ytDlpPath"];
 	        this.ytDlpExtraFlags = source["ytDlpExtraFlags"];
+	        this.ytDlpVersion = source["ytDlpVersion"];
+	        this.ytDlpReleaseUrl = source["ytDlpReleaseUrl"];
+	        this.toolMirrorUrl = source["toolMirrorUrl"];
+	        this.cookiesFile = source["cookiesFile"];
+	        this.allowedSites = source["allowedSites"];
+	        this.network = this.convertValues(source["network"], NetworkSettings);
 	        this.language = source["language"];
 	        this.themeMode = source["themeMode"];
 	        this.accentColor = source["accentColor"];
 	        this.logLevel = source["logLevel"];
 	        this.updateChannel = source["updateChannel"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	export class ThrottleState {
+	    throttled: boolean;
+	    concurrency: number;
+	    maxConcurrency: number;
+	    backoffUntil?: number;
+	    requestDelaySeconds: number;
+	    rateLimitCount: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ThrottleState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.throttled = source["throttled"];
+	        this.concurrency = source["concurrency"];
+	        this.maxConcurrency = source["maxConcurrency"];
+	        this.backoffUntil = source["backoffUntil"];
+	        this.requestDelaySeconds = source["requestDelaySeconds"];
+	        this.rateLimitCount = source["rateLimitCount"];
+	    }
+	}
+	
+	
+	
+	export class YtDlpFlag {
+	    index: number;
+	    flag: string;
+	    name: string;
+	    value?: string;
+	    class: string;
+	    reason?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new YtDlpFlag(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.index = source["index"];
+	        this.flag = source["flag"];
+	        this.name = source["name"];
+	        this.value = source["value"];
+	        this.class = source["class"];
+	        this.reason = source["reason"];
+	    }
 	}
+
+}
+
+export namespace downloader {
+	
+	export class BinaryIntegrity {
+	    name: string;
+	    path: string;
+	    expected: string;
+	    actual?: string;
+	    ok: boolean;
+	    error?: string;
 	
+	    static createFrom(source: any = {}) {
+	        return new BinaryIntegrity(source);
+	    }
 	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.expected = source["expected"];
+	        this.actual = source["actual"];
+	        this.ok = source["ok"];
+	        this.error = source["error"];
+	    }
+	}
+	export class CookieFileInfo {
+	    path: string;
+	    cookieCount: number;
+	    expiredCount: number;
+	    domains: string[];
+	    hasYouTube: boolean;
+	    // Go type: time
+	    earliestExpiry?: any;
+	    warnings?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new CookieFileInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.cookieCount = source["cookieCount"];
+	        this.expiredCount = source["expiredCount"];
+	        this.domains = source["domains"];
+	        this.hasYouTube = source["hasYouTube"];
+	        this.earliestExpiry = this.convertValues(source["earliestExpiry"], null);
+	        this.warnings = source["warnings"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ToolInstallResult {
+	    tool: string;
+	    version: string;
+	    paths: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ToolInstallResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tool = source["tool"];
+	        this.version = source["version"];
+	        this.paths = source["paths"];
+	    }
+	}
+	export class YtDlpUpdateInfo {
+	    currentVersion: string;
+	    latestVersion: string;
+	    pinnedVersion?: string;
+	    updateAvailable: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new YtDlpUpdateInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.currentVersion = source["currentVersion"];
+	        this.latestVersion = source["latestVersion"];
+	        this.pinnedVersion = source["pinnedVersion"];
+	        this.updateAvailable = source["updateAvailable"];
+	    }
+	}
+	export class YtDlpVersionInfo {
+	    version: string;
+	    path: string;
+	    active: boolean;
+	    pinned: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new YtDlpVersionInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.path = source["path"];
+	        this.active = source["active"];
+	        this.pinned = source["pinned"];
+	    }
+	}
 
 }
 
@@ -4,10 +4,13 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"log/slog"
 	"net/url"
+	"os"
 	"path/filepath"
 	goruntime "runtime"
+	"slices"
 	"strings"
 	"time"
 
@@ -19,6 +22,8 @@ import (
 	"ybdownloader/internal/infra/downloader"
 	"ybdownloader/internal/infra/fs"
 	"ybdownloader/internal/infra/logging"
+	"ybdownloader/internal/infra/network"
+	"ybdownloader/internal/infra/presets"
 	"ybdownloader/internal/infra/queue"
 	"ybdownloader/internal/infra/settings"
 	"ybdownloader/internal/infra/updater"
@@ -51,6 +56,7 @@ type App struct {
 	downloader       core.Downloader
 	queueManager     core.QueueManager
 	converterService core.ConverterService
+	presetStore      core.PresetStore
 	youtubeSearcher  YouTubeSearcher
 	appUpdater       AppUpdater
 	ytdlpManager     *downloader.YtDlpManager
@@ -90,19 +96,41 @@ func New(version string) (*App, error) {
 	ytdlpDl := downloader.NewYtDlpDownloader(ytdlpMgr, ffmpegMgr, filesystem, getSettings)
 
 	delegating := downloader.NewDelegatingDownloader(builtinDl, ytdlpDl, getSettings)
+	delegating.SetMetadataCache(newMetadataCache(filesystem))
+
+	presetStore, err := presets.NewStore(filesystem, version)
+	if err != nil {
+		slog.Warn("preset store init failed", "error", err)
+	}
+
+	appUpdater := updater.NewUpdater(version)
+	appUpdater.SetTransport(network.NewTransport(getSettings))
 
 	app := &App{
 		version:       version,
 		fs:            filesystem,
 		settingsStore: store,
+		presetStore:   presetStore,
 		downloader:    delegating,
-		appUpdater:    updater.NewUpdater(version),
+		appUpdater:    appUpdater,
 		ytdlpManager:  ytdlpMgr,
 	}
 
 	return app, nil
 }
 
+// newMetadataCache persists metadata under the config directory, falling
+// back to memory only when it is unavailable.
+func newMetadataCache(filesystem core.FileSystem) *downloader.MetadataCache {
+	var dir string
+	if configDir, err := filesystem.GetConfigDir(); err == nil {
+		dir = filepath.Join(configDir, "cache", "metadata")
+	} else {
+		slog.Warn("metadata cache will not persist", "error", err)
+	}
+	return downloader.NewMetadataCache(dir, downloader.DefaultMetadataCacheSize, downloader.DefaultMetadataCacheTTL)
+}
+
 func initLogging(filesystem core.FileSystem, store core.SettingsStore) error {
 	configDir, err := filesystem.GetConfigDir()
 	if err != nil {
@@ -171,13 +199,17 @@ func (a *App) Startup(ctx context.Context) {
 
 	ffmpegManager := downloader.NewFFmpegManager(a.fs, a.settingsStore.Load, a.settingsStore.Save)
 	if ffmpegPath, err := ffmpegManager.GetFFmpegPath(); err == nil {
-		a.converterService = converter.New(ffmpegPath, a.emit)
+		a.converterService = a.newConverterService(ffmpegPath)
 		slog.Info("converter service initialized", "ffmpegPath", ffmpegPath)
 	} else {
 		slog.Warn("converter service not initialized - FFmpeg not found", "error", err)
 	}
 
-	a.youtubeSearcher = ytsearch.NewSearcher()
+	go a.reportTamperedBinaries()
+
+	searcher := ytsearch.NewSearcher()
+	searcher.SetTransport(network.NewTransport(a.settingsStore.Load))
+	a.youtubeSearcher = searcher
 	slog.Debug("youtube searcher initialized")
 
 	s, _ := a.settingsStore.Load()
@@ -314,6 +346,10 @@ func (a *App) SaveSettings(s *core.Settings) error {
 		a.syncUpdateChannel(s.UpdateChannel)
 	}
 
+	if a.converterService != nil {
+		a.converterService.SetMaxConcurrent(s.MaxConcurrentConversions)
+	}
+
 	slog.Debug("settings saved")
 	return nil
 }
@@ -326,15 +362,18 @@ func (a *App) ResetSettings() (*core.Settings, error) {
 }
 
 func (a *App) AddToQueue(url string, format string) (*core.QueueItem, error) {
-	if !isValidYouTubeURL(url) {
-		return nil, core.ErrInvalidURL
-	}
-
 	s, err := a.settingsStore.Load()
 	if err != nil {
 		return nil, err
 	}
 
+	if _, err := downloader.ClassifyURL(url, s.AllowedSites); err != nil {
+		if errors.Is(err, core.ErrUnsupportedSite) {
+			return nil, core.NewAppError(core.ErrCodeUnsupportedSite, "This site is not in the allowed sites list", err)
+		}
+		return nil, core.ErrInvalidURL
+	}
+
 	if a.queueManager == nil {
 		return nil, core.NewAppError(core.ErrCodeDownloadFailed, "Downloader not initialized", nil)
 	}
@@ -370,8 +409,8 @@ func (a *App) ImportURLs(urls []string, format string) ImportResult {
 			continue
 		}
 
-		// Validate YouTube URL
-		if !isValidYouTubeURL(url) {
+		// Validate against YouTube and the allowed sites
+		if _, err := downloader.ClassifyURL(url, s.AllowedSites); err != nil {
 			result.Invalid++
 			continue
 		}
@@ -406,6 +445,16 @@ func (a *App) IsValidYouTubeURL(url string) bool {
 	return isValidYouTubeURL(url)
 }
 
+// IsSupportedURL checks if a URL is YouTube or one of the allowed sites.
+func (a *App) IsSupportedURL(url string) bool {
+	s, err := a.settingsStore.Load()
+	if err != nil {
+		return isValidYouTubeURL(url)
+	}
+	_, err = downloader.ClassifyURL(url, s.AllowedSites)
+	return err == nil
+}
+
 // normalizeURL cleans up a URL string.
 func normalizeURL(url string) string {
 	// Trim whitespace
@@ -485,6 +534,96 @@ func (a *App) FetchMetadata(url string) (*core.VideoMetadata, error) {
 	return a.downloader.FetchMetadata(a.ctx, url)
 }
 
+// RefreshMetadata fetches metadata for a URL again, replacing any cached copy.
+func (a *App) RefreshMetadata(url string) (*core.VideoMetadata, error) {
+	if a.downloader == nil {
+		return nil, core.NewAppError(core.ErrCodeDownloadFailed, "Downloader not initialized", nil)
+	}
+	if cacher, ok := a.downloader.(core.MetadataCacher); ok {
+		return cacher.RefreshMetadata(a.ctx, url)
+	}
+	return a.downloader.FetchMetadata(a.ctx, url)
+}
+
+// InvalidateMetadata forgets cached metadata for a URL.
+func (a *App) InvalidateMetadata(url string) {
+	if cacher, ok := a.downloader.(core.MetadataCacher); ok {
+		cacher.InvalidateMetadata(url)
+	}
+}
+
+// ClearMetadataCache forgets all cached metadata.
+func (a *App) ClearMetadataCache() error {
+	if cacher, ok := a.downloader.(core.MetadataCacher); ok {
+		return cacher.ClearMetadataCache()
+	}
+	return nil
+}
+
+// ListFormats lists every stream available for a URL so one can be pinned.
+func (a *App) ListFormats(url string) ([]core.FormatInfo, error) {
+	if a.downloader == nil {
+		return nil, core.NewAppError(core.ErrCodeDownloadFailed, "Downloader not initialized", nil)
+	}
+	lister, ok := a.downloader.(core.FormatLister)
+	if !ok {
+		return nil, core.NewAppError(core.ErrCodeDownloadFailed, "Downloader cannot list formats", nil)
+	}
+	return lister.ListFormats(a.ctx, url)
+}
+
+// SetQueueItemFormat pins a format ID from ListFormats on a queue item.
+// Pass an empty formatID to go back to automatic selection.
+func (a *App) SetQueueItemFormat(id, formatID string) error {
+	if a.queueManager == nil {
+		return core.ErrQueueItemNotFound
+	}
+	return a.queueManager.SetFormatID(id, strings.TrimSpace(formatID))
+}
+
+// SetQueueItemLiveOptions sets how a live stream or premiere is recorded.
+func (a *App) SetQueueItemLiveOptions(id string, opts *core.LiveOptions) error {
+	if a.queueManager == nil {
+		return core.ErrQueueItemNotFound
+	}
+	return a.queueManager.SetLiveOptions(id, opts)
+}
+
+// SetQueueItemClip limits a queue item to the range from startTime to
+// endTime in seconds. An endTime of 0 runs to the end of the video; passing
+// 0 for both downloads the whole video.
+func (a *App) SetQueueItemClip(id string, startTime, endTime float64) error {
+	if a.queueManager == nil {
+		return core.ErrQueueItemNotFound
+	}
+
+	var clip *core.TrimOptions
+	if startTime > 0 || endTime > 0 {
+		clip = &core.TrimOptions{
+			StartTime: startTime,
+			EndTime:   endTime,
+		}
+	}
+	return a.queueManager.SetClip(id, clip)
+}
+
+// GetThrottleState returns how the queue is adapting to rate limits. Changes
+// are also emitted as "queue:throttle" events.
+func (a *App) GetThrottleState() core.ThrottleState {
+	if a.queueManager == nil {
+		return core.ThrottleState{}
+	}
+	return a.queueManager.ThrottleState()
+}
+
+// StopRecording ends a live recording and keeps what was captured.
+func (a *App) StopRecording(id string) error {
+	if a.queueManager == nil {
+		return core.ErrQueueItemNotFound
+	}
+	return a.queueManager.StopRecording(id)
+}
+
 // SelectDirectory opens a native directory picker dialog.
 func (a *App) SelectDirectory() (string, error) {
 	return runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
@@ -568,8 +707,19 @@ func (a *App) DownloadFFmpeg() error {
 func (a *App) reinitializeConverter() {
 	manager := downloader.NewFFmpegManager(a.fs, a.settingsStore.Load, a.settingsStore.Save)
 	if ffmpegPath, err := manager.GetFFmpegPath(); err == nil {
-		a.converterService = converter.New(ffmpegPath, a.emit)
+		a.converterService = a.newConverterService(ffmpegPath)
+	}
+}
+
+// newConverterService creates a converter that runs as many conversions at
+// once as the settings allow.
+func (a *App) newConverterService(ffmpegPath string) *converter.Service {
+	service := converter.New(ffmpegPath, a.emit)
+	if s, err := a.settingsStore.Load(); err == nil {
+		service.SetMaxConcurrent(s.MaxConcurrentConversions)
 	}
+	service.SetUserPresets(a.userPresets())
+	return service
 }
 
 // CheckFFmpeg checks if FFmpeg is available (legacy, for compatibility).
@@ -620,13 +770,7 @@ func (a *App) GetYtDlpStatus() YtDlpStatus {
 // DownloadYtDlp downloads and installs yt-dlp for the current platform.
 // Also ensures a JS runtime (deno) is available for YouTube signature solving.
 func (a *App) DownloadYtDlp() error {
-	err := a.ytdlpManager.DownloadYtDlp(a.ctx, func(percent float64, status string) {
-		a.emit("ytdlp:progress", map[string]interface{}{
-			"percent": percent,
-			"status":  status,
-		})
-	})
-	if err != nil {
+	if err := a.ytdlpManager.DownloadYtDlp(a.ctx, a.emitYtDlpProgress); err != nil {
 		return err
 	}
 
@@ -652,6 +796,114 @@ func (a *App) DownloadYtDlp() error {
 	return nil
 }
 
+// CheckYtDlpUpdate reports whether a newer yt-dlp release is available.
+func (a *App) CheckYtDlpUpdate() (*downloader.YtDlpUpdateInfo, error) {
+	return a.ytdlpManager.CheckForUpdate(a.ctx)
+}
+
+// ListYtDlpVersions lists the managed yt-dlp versions, newest first.
+func (a *App) ListYtDlpVersions() ([]downloader.YtDlpVersionInfo, error) {
+	return a.ytdlpManager.InstalledVersions()
+}
+
+// InstallYtDlpVersion installs a yt-dlp release and switches to it if it
+// passes a smoke test. An empty version installs the latest release.
+func (a *App) InstallYtDlpVersion(version string) error {
+	_, err := a.ytdlpManager.InstallVersion(a.ctx, strings.TrimSpace(version), a.emitYtDlpProgress)
+	return err
+}
+
+// UseYtDlpVersion switches to an installed yt-dlp version.
+func (a *App) UseYtDlpVersion(version string) error {
+	return a.ytdlpManager.UseVersion(version)
+}
+
+// RemoveYtDlpVersion deletes an installed yt-dlp version that is not in use.
+func (a *App) RemoveYtDlpVersion(version string) error {
+	return a.ytdlpManager.RemoveVersion(version)
+}
+
+// PinYtDlpVersion keeps yt-dlp at a version, installing it first if needed.
+// An empty version unpins and follows the latest release again.
+func (a *App) PinYtDlpVersion(version string) error {
+	version = strings.TrimSpace(version)
+	if version != "" {
+		if _, err := a.ytdlpManager.InstallVersion(a.ctx, version, a.emitYtDlpProgress); err != nil {
+			return err
+		}
+	}
+
+	s, err := a.settingsStore.Load()
+	if err != nil {
+		return err
+	}
+	s.YtDlpVersion = version
+	return a.settingsStore.Save(s)
+}
+
+func (a *App) emitYtDlpProgress(percent float64, status string) {
+	a.emit("ytdlp:progress", map[string]interface{}{
+		"percent": percent,
+		"status":  status,
+	})
+}
+
+// InstallToolFromFile installs ffmpeg, yt-dlp or deno from a local zip,
+// tar archive or binary, for machines without internet access.
+func (a *App) InstallToolFromFile(tool, path string) (*downloader.ToolInstallResult, error) {
+	path = strings.TrimSpace(path)
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return nil, core.NewAppError(core.ErrCodeFilesystemError, "File not found: "+path, err)
+	}
+
+	switch tool {
+	case downloader.ToolFFmpeg:
+		manager := downloader.NewFFmpegManager(a.fs, a.settingsStore.Load, a.settingsStore.Save)
+		result, err := manager.InstallFromFile(a.ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		a.reinitializeConverter()
+		return result, nil
+	case downloader.ToolYtDlp:
+		return a.ytdlpManager.InstallFromFile(a.ctx, path)
+	case downloader.ToolDeno:
+		return a.ytdlpManager.InstallDenoFromFile(a.ctx, path)
+	default:
+		return nil, errors.New("unknown tool: " + tool)
+	}
+}
+
+// CheckBinaryIntegrity re-hashes the binaries installed by the app and
+// compares them with the hashes recorded at install time.
+func (a *App) CheckBinaryIntegrity() ([]downloader.BinaryIntegrity, error) {
+	configDir, err := a.fs.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return downloader.VerifyInstalledBinaries(filepath.Join(configDir, "bin"))
+}
+
+// reportTamperedBinaries warns the frontend about installed binaries that
+// changed since they were installed.
+func (a *App) reportTamperedBinaries() {
+	results, err := a.CheckBinaryIntegrity()
+	if err != nil {
+		slog.Warn("failed to check binary integrity", "error", err)
+		return
+	}
+	var tampered []downloader.BinaryIntegrity
+	for _, r := range results {
+		if !r.OK {
+			slog.Error("installed binary changed since install", "binary", r.Name, "expected", r.Expected, "actual", r.Actual, "error", r.Error)
+			tampered = append(tampered, r)
+		}
+	}
+	if len(tampered) > 0 {
+		a.emit("binaries:tampered", tampered)
+	}
+}
+
 // GetDownloadBackend returns the currently active download backend name.
 func (a *App) GetDownloadBackend() string {
 	s, err := a.settingsStore.Load()
@@ -661,14 +913,30 @@ func (a *App) GetDownloadBackend() string {
 	return string(s.DownloadBackend)
 }
 
-// GetYtDlpDefaultFlags returns the default flags yt-dlp uses for each format.
+// GetBackendStats returns download successes and failures per backend since
+// startup.
+func (a *App) GetBackendStats() []core.BackendStats {
+	reporter, ok := a.downloader.(core.BackendReporter)
+	if !ok {
+		return nil
+	}
+	return reporter.BackendStats()
+}
+
+// GetYtDlpDefaultFlags returns the flags the app passes to yt-dlp, so the UI
+// can show the effective command: "common" for every download, one entry per
+// format and "extra" for the user's allowed extra flags.
 func (a *App) GetYtDlpDefaultFlags() map[string][]string {
-	return map[string][]string{
-		"common": {"--newline", "--no-colors", "--no-playlist", "--no-overwrites", "--windows-filenames"},
-		"mp3":    {"-x", "--audio-format", "mp3", "--format-sort", "acodec:aac"},
-		"m4a":    {"-x", "--audio-format", "m4a", "--format-sort", "acodec:aac"},
-		"mp4":    {"--format-sort", "vcodec:h264,acodec:aac", "--merge-output-format", "mp4", "--remux-video", "mp4"},
+	s, err := a.settingsStore.Load()
+	if err != nil {
+		s = core.DefaultSettings("")
 	}
+	return downloader.YtDlpDefaultFlags(s)
+}
+
+// ValidateYtDlpFlags classifies extra yt-dlp flags before they are saved.
+func (a *App) ValidateYtDlpFlags(flags []string) []core.YtDlpFlag {
+	return core.ParseYtDlpFlags(flags)
 }
 
 // emit sends an event to the frontend.
@@ -692,13 +960,283 @@ func isValidYouTubeURL(url string) bool {
 	return err == nil
 }
 
+// ============================================================================
+// Cookie Methods
+// ============================================================================
+
+// cookiesFileName is the private copy of the imported cookies in the config dir.
+const cookiesFileName = "cookies.txt"
+
+// SelectCookiesFile opens a file picker for a Netscape cookies.txt export.
+func (a *App) SelectCookiesFile() (string, error) {
+	return a.SelectFile("Select Cookies File", []runtime.FileFilter{
+		{DisplayName: "Cookies Files", Pattern: "*.txt"},
+		{DisplayName: "All Files", Pattern: "*.*"},
+	})
+}
+
+// ImportCookiesFile validates a cookies.txt file, copies it into the config
+// directory and enables it for both download backends.
+func (a *App) ImportCookiesFile(path string) (*downloader.CookieFileInfo, error) {
+	info, err := downloader.ValidateCookieFile(path)
+	if err != nil {
+		return nil, core.NewAppError(core.ErrCodeCookiesInvalid, "Invalid cookies file", err)
+	}
+
+	configDir, err := a.fs.GetConfigDir()
+	if err != nil {
+		return nil, core.NewAppError(core.ErrCodeFilesystemError, "Failed to get config directory", err)
+	}
+	data, err := os.ReadFile(path) //nolint:gosec // user-selected cookies file
+	if err != nil {
+		return nil, core.NewAppError(core.ErrCodeFilesystemError, "Failed to read cookies file", err)
+	}
+	dest := filepath.Join(configDir, cookiesFileName)
+	if err := os.WriteFile(dest, data, 0o600); err != nil {
+		return nil, core.NewAppError(core.ErrCodeFilesystemError, "Failed to store cookies file", err)
+	}
+
+	s, err := a.settingsStore.Load()
+	if err != nil {
+		return nil, err
+	}
+	s.CookiesFile = dest
+	if err := a.settingsStore.Save(s); err != nil {
+		return nil, err
+	}
+
+	info.Path = dest
+	slog.Info("cookies imported", "cookies", info.CookieCount, "expired", info.ExpiredCount, "warnings", len(info.Warnings))
+	return info, nil
+}
+
+// GetCookiesStatus re-validates the configured cookies file so expiry
+// warnings stay current. It returns nil when no cookies are configured.
+func (a *App) GetCookiesStatus() (*downloader.CookieFileInfo, error) {
+	s, err := a.settingsStore.Load()
+	if err != nil {
+		return nil, err
+	}
+	if s.CookiesFile == "" {
+		return nil, nil
+	}
+	info, err := downloader.ValidateCookieFile(s.CookiesFile)
+	if err != nil {
+		return nil, core.NewAppError(core.ErrCodeCookiesInvalid, "Invalid cookies file", err)
+	}
+	return info, nil
+}
+
+// ClearCookies disables cookies and deletes the imported copy.
+func (a *App) ClearCookies() error {
+	s, err := a.settingsStore.Load()
+	if err != nil {
+		return err
+	}
+	if s.CookiesFile == "" {
+		return nil
+	}
+
+	if configDir, err := a.fs.GetConfigDir(); err == nil &&
+		filepath.Clean(s.CookiesFile) == filepath.Join(configDir, cookiesFileName) {
+		if err := os.Remove(s.CookiesFile); err != nil && !os.IsNotExist(err) {
+			slog.Warn("failed to remove cookies file", "error", err)
+		}
+	}
+
+	s.CookiesFile = ""
+	return a.settingsStore.Save(s)
+}
+
 // ============================================================================
 // Converter Methods
 // ============================================================================
 
-// GetConversionPresets returns all available conversion presets.
+// GetConversionPresets returns the built-in presets, with any the user
+// modified replaced, followed by the user's own presets.
 func (a *App) GetConversionPresets() []core.ConversionPreset {
-	return core.GetDefaultPresets()
+	if a.converterService != nil {
+		return a.converterService.GetPresets()
+	}
+	return core.MergePresets(core.GetDefaultPresets(), a.userPresets())
+}
+
+// GetConversionPreset returns one preset, including its parameters, so the
+// frontend can build a form for them.
+func (a *App) GetConversionPreset(id string) (*core.ConversionPreset, error) {
+	if a.converterService == nil {
+		return nil, core.NewAppError(core.ErrCodeFFmpegNotFound, "Converter not initialized", nil)
+	}
+	preset, err := a.converterService.GetPreset(id)
+	if err != nil {
+		return nil, core.NewAppError(core.ErrCodeInvalidPreset, "Preset not found", err)
+	}
+	return preset, nil
+}
+
+// GetPresetCategories returns the categories of all presets.
+func (a *App) GetPresetCategories() []string {
+	return core.PresetCategories(a.GetConversionPresets())
+}
+
+// SaveConversionPreset creates or updates a user preset after FFmpeg
+// accepts its arguments. A preset without an ID is created; saving a
+// built-in's ID stores a modified copy that replaces it.
+func (a *App) SaveConversionPreset(preset core.ConversionPreset) (*core.ConversionPreset, error) {
+	if a.presetStore == nil {
+		return nil, core.NewAppError(core.ErrCodeSettingsError, "Preset storage is not available", nil)
+	}
+	if a.converterService == nil {
+		return nil, core.NewAppError(core.ErrCodeFFmpegNotFound, "Converter not initialized", nil)
+	}
+	if preset.ID == "" {
+		preset.ID = "user-" + genID()
+	} else if core.IsBuiltInPreset(preset.ID) && preset.BasedOn == "" {
+		preset.BasedOn = preset.ID
+	}
+
+	if err := preset.Validate(); err != nil {
+		return nil, core.NewAppError(core.ErrCodeInvalidPreset, "The preset cannot be used", err)
+	}
+	ctx, cancel := context.WithTimeout(a.ctx, 30*time.Second)
+	defer cancel()
+	if err := a.converterService.ValidatePreset(ctx, preset); err != nil {
+		return nil, core.NewAppError(core.ErrCodeInvalidPreset, "The preset cannot be used", err)
+	}
+	if err := a.presetStore.Save(preset); err != nil {
+		return nil, err
+	}
+	return a.refreshPreset(preset.ID)
+}
+
+// DuplicateConversionPreset saves a copy of any preset as a new user preset,
+// named name or "<original> (copy)".
+func (a *App) DuplicateConversionPreset(id, name string) (*core.ConversionPreset, error) {
+	if a.presetStore == nil {
+		return nil, core.NewAppError(core.ErrCodeSettingsError, "Preset storage is not available", nil)
+	}
+	all := a.GetConversionPresets()
+	i := slices.IndexFunc(all, func(p core.ConversionPreset) bool { return p.ID == id })
+	if i < 0 {
+		return nil, core.NewAppError(core.ErrCodeInvalidPreset, "Preset not found", nil)
+	}
+	preset := all[i]
+	preset.ID = "user-" + genID()
+	preset.BasedOn = id
+	preset.Name = strings.TrimSpace(name)
+	if preset.Name == "" {
+		preset.Name = all[i].Name + " (copy)"
+	}
+	if err := a.presetStore.Save(preset); err != nil {
+		return nil, core.NewAppError(core.ErrCodeInvalidPreset, "The preset cannot be copied", err)
+	}
+	return a.refreshPreset(preset.ID)
+}
+
+// DeleteConversionPreset deletes a user preset. Deleting a modified
+// built-in restores the original.
+func (a *App) DeleteConversionPreset(id string) error {
+	if a.presetStore == nil {
+		return core.NewAppError(core.ErrCodeSettingsError, "Preset storage is not available", nil)
+	}
+	if err := a.presetStore.Delete(id); err != nil {
+		return err
+	}
+	a.refreshPresets()
+	return nil
+}
+
+// ImportConversionPresets adds the presets in a shared file. Presets with
+// the ID of a saved one replace it, so importing an updated file updates
+// them. Nothing is imported unless FFmpeg accepts every preset.
+func (a *App) ImportConversionPresets(path string) ([]core.ConversionPreset, error) {
+	if a.presetStore == nil {
+		return nil, core.NewAppError(core.ErrCodeSettingsError, "Preset storage is not available", nil)
+	}
+	if a.converterService == nil {
+		return nil, core.NewAppError(core.ErrCodeFFmpegNotFound, "Converter not initialized", nil)
+	}
+	imported, err := presets.ReadFile(path)
+	if err != nil {
+		return nil, core.NewAppError(core.ErrCodeInvalidPreset, "Cannot read the preset file", err)
+	}
+
+	ctx, cancel := context.WithTimeout(a.ctx, 2*time.Minute)
+	defer cancel()
+	for i := range imported {
+		err := imported[i].Validate()
+		if err == nil {
+			err = a.converterService.ValidatePreset(ctx, imported[i])
+		}
+		if err != nil {
+			return nil, core.NewAppError(core.ErrCodeInvalidPreset, "The preset file contains a preset that cannot be used", err)
+		}
+	}
+	if err := a.presetStore.Save(imported...); err != nil {
+		return nil, err
+	}
+	a.refreshPresets()
+	return imported, nil
+}
+
+// ExportConversionPresets writes the presets with the given IDs to a file
+// that can be shared and imported. With no IDs, all user presets are
+// exported.
+func (a *App) ExportConversionPresets(ids []string, path string) error {
+	all := a.GetConversionPresets()
+	var selected []core.ConversionPreset
+	if len(ids) == 0 {
+		for _, p := range all {
+			if p.Source != core.PresetBuiltIn {
+				selected = append(selected, p)
+			}
+		}
+	}
+	for _, id := range ids {
+		i := slices.IndexFunc(all, func(p core.ConversionPreset) bool { return p.ID == id })
+		if i < 0 {
+			return core.NewAppError(core.ErrCodeInvalidPreset, "Preset not found: "+id, nil)
+		}
+		selected = append(selected, all[i])
+	}
+	if len(selected) == 0 {
+		return core.NewAppError(core.ErrCodeInvalidPreset, "There are no presets to export", nil)
+	}
+	if err := presets.WriteFile(path, selected, a.version); err != nil {
+		return core.NewAppError(core.ErrCodeFilesystemError, "Cannot write the preset file", err)
+	}
+	return nil
+}
+
+// userPresets returns the saved user presets, or none if they cannot be read.
+func (a *App) userPresets() []core.ConversionPreset {
+	if a.presetStore == nil {
+		return nil
+	}
+	list, err := a.presetStore.List()
+	if err != nil {
+		slog.Warn("failed to load user presets", "error", err)
+		return nil
+	}
+	return list
+}
+
+// refreshPresets hands the saved user presets to the converter.
+func (a *App) refreshPresets() {
+	if a.converterService != nil {
+		a.converterService.SetUserPresets(a.userPresets())
+	}
+}
+
+// refreshPreset refreshes the converter's presets and returns the one with id.
+func (a *App) refreshPreset(id string) (*core.ConversionPreset, error) {
+	a.refreshPresets()
+	for _, p := range a.GetConversionPresets() {
+		if p.ID == id {
+			return &p, nil
+		}
+	}
+	return nil, core.NewAppError(core.ErrCodeInvalidPreset, "Preset not found after saving", nil)
 }
 
 // GetConversionPresetsByCategory returns presets filtered by category.
@@ -719,29 +1257,89 @@ func (a *App) AnalyzeMediaFile(filePath string) (*core.MediaInfo, error) {
 	return a.converterService.AnalyzeFile(ctx, filePath)
 }
 
-// StartConversion starts a new conversion job.
-func (a *App) StartConversion(inputPath, outputPath, presetID string) (*core.ConversionJob, error) {
+// StartConversion starts a new conversion job. opts sets the preset's
+// parameters by name (those not given use their defaults), the input stream
+// indexes (MediaStream.Index) to keep in output order, a trim range, and
+// video filters that crop, rotate, flip, scale, pad or change the frame
+// rate ahead of the preset's own.
+func (a *App) StartConversion(inputPath, outputPath, presetID string, opts core.ConversionOptions) (*core.ConversionJob, error) {
 	if a.converterService == nil {
 		return nil, core.NewAppError(core.ErrCodeFFmpegNotFound, "Converter not initialized", nil)
 	}
-	return a.converterService.StartConversion(genID(), inputPath, outputPath, presetID, nil)
+
+	job, err := a.converterService.StartConversionWithParams(genID(), inputPath, outputPath, presetID, opts)
+	switch {
+	case errors.Is(err, core.ErrInvalidPreset):
+		return nil, core.NewAppError(core.ErrCodeInvalidPreset, "Invalid preset settings", err)
+	case err != nil:
+		return nil, core.NewAppError(core.ErrCodeConversionFailed, "Cannot start the conversion", err)
+	}
+	return job, nil
+}
+
+// StartConversionWithTrim starts a new conversion job of the range from
+// startTime to endTime, replacing any trim range in opts.
+func (a *App) StartConversionWithTrim(inputPath, outputPath, presetID string, startTime, endTime float64, opts core.ConversionOptions) (*core.ConversionJob, error) {
+	opts.Trim = trimRange(startTime, endTime)
+	return a.StartConversion(inputPath, outputPath, presetID, opts)
 }
 
-// StartConversionWithTrim starts a new conversion job with trim options.
-func (a *App) StartConversionWithTrim(inputPath, outputPath, presetID string, startTime, endTime float64) (*core.ConversionJob, error) {
+// StartTargetSizeConversion starts a two-pass H.264 or VP9 encode whose
+// bitrate is chosen to fit the output in target.TargetBytes. The job's
+// SizeResult reports the achieved size once it completes. opts selects
+// input streams and video filters as for StartConversion; its trim range is
+// replaced by startTime and endTime, and it takes no preset parameters.
+func (a *App) StartTargetSizeConversion(inputPath, outputPath string, target core.TargetSizeOptions, startTime, endTime float64, opts core.ConversionOptions) (*core.ConversionJob, error) {
 	if a.converterService == nil {
 		return nil, core.NewAppError(core.ErrCodeFFmpegNotFound, "Converter not initialized", nil)
 	}
+	if err := target.Validate(); err != nil {
+		return nil, core.NewAppError(core.ErrCodeConversionFailed, "Invalid target size", err)
+	}
+	opts.Trim = trimRange(startTime, endTime)
+	return a.converterService.StartTargetSizeConversion(genID(), inputPath, outputPath, target, opts)
+}
 
-	var trim *core.TrimOptions
-	if startTime > 0 || endTime > 0 {
-		trim = &core.TrimOptions{
-			StartTime: startTime,
-			EndTime:   endTime,
-		}
+// JoinMediaFiles joins inputPaths, in order, into one output. Matching
+// inputs are stream-copied when the output keeps their container; others
+// are re-encoded with the preset. crossfade blends neighbouring audio for
+// that many seconds and needs an audio preset.
+func (a *App) JoinMediaFiles(inputPaths []string, outputPath, presetID string, crossfade float64) (*core.ConversionJob, error) {
+	if a.converterService == nil {
+		return nil, core.NewAppError(core.ErrCodeFFmpegNotFound, "Converter not initialized", nil)
+	}
+	job, err := a.converterService.StartConcatConversion(genID(), inputPaths, outputPath, presetID, crossfade)
+	if err != nil {
+		return nil, core.NewAppError(core.ErrCodeConversionFailed, "Cannot join these files", err)
+	}
+	return job, nil
+}
+
+// StartAnimationConversion makes a GIF or animated WebP of the input
+// between startTime and endTime. filters, which may be nil, crop, rotate,
+// flip, scale or pad the frames as for StartConversion; the frame rate is
+// set in opts. The job's EstimatedBytes reports the expected size,
+// extrapolated from a short sample, before the full encode.
+func (a *App) StartAnimationConversion(inputPath, outputPath string, opts core.AnimationOptions, startTime, endTime float64, filters *core.VideoFilterOptions) (*core.ConversionJob, error) {
+	if a.converterService == nil {
+		return nil, core.NewAppError(core.ErrCodeFFmpegNotFound, "Converter not initialized", nil)
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, core.NewAppError(core.ErrCodeConversionFailed, "Invalid animation settings", err)
 	}
+	return a.converterService.StartAnimationConversion(genID(), inputPath, outputPath, opts, trimRange(startTime, endTime), filters)
+}
 
-	return a.converterService.StartConversionWithTrim(genID(), inputPath, outputPath, presetID, nil, trim)
+// trimRange returns trim options for a start and end time, or nil when
+// neither is set.
+func trimRange(startTime, endTime float64) *core.TrimOptions {
+	if startTime <= 0 && endTime <= 0 {
+		return nil
+	}
+	return &core.TrimOptions{
+		StartTime: startTime,
+		EndTime:   endTime,
+	}
 }
 
 // StartCustomConversion starts a conversion with custom FFmpeg arguments.
@@ -786,6 +1384,89 @@ func (a *App) RemoveConversionJob(id string) error {
 	return a.converterService.RemoveJob(id)
 }
 
+// SetConversionPriority changes a queued conversion's priority; higher
+// priorities start first.
+func (a *App) SetConversionPriority(id string, priority int) error {
+	if a.converterService == nil {
+		return core.NewAppError(core.ErrCodeFFmpegNotFound, "Converter not initialized", nil)
+	}
+	return a.converterService.SetJobPriority(id, priority)
+}
+
+// PauseConversions stops queued conversions from starting. Running
+// conversions finish.
+func (a *App) PauseConversions() {
+	if a.converterService != nil {
+		a.converterService.PauseQueue()
+	}
+}
+
+// ResumeConversions starts queued conversions again.
+func (a *App) ResumeConversions() {
+	if a.converterService != nil {
+		a.converterService.ResumeQueue()
+	}
+}
+
+// GetConversionQueueState returns whether the conversion queue is paused and
+// how many jobs are running and waiting.
+func (a *App) GetConversionQueueState() core.ConversionQueueState {
+	if a.converterService == nil {
+		return core.ConversionQueueState{}
+	}
+	return a.converterService.QueueState()
+}
+
+// StartBatchConversion queues the given files for conversion with one preset
+// as a named batch. An empty outputDir writes each output next to its input.
+func (a *App) StartBatchConversion(name string, inputs []string, presetID, outputDir string) (*core.ConversionBatch, error) {
+	if a.converterService == nil {
+		return nil, core.NewAppError(core.ErrCodeFFmpegNotFound, "Converter not initialized", nil)
+	}
+	return a.converterService.StartBatchConversion(genID(), name, inputs, presetID, outputDir)
+}
+
+// StartFolderConversion queues the files in dir matching pattern (e.g.
+// "*.mkv" or "*.mp4, *.mov") as a batch named after the folder unless a
+// name is given.
+func (a *App) StartFolderConversion(name, dir, pattern, presetID, outputDir string) (*core.ConversionBatch, error) {
+	if a.converterService == nil {
+		return nil, core.NewAppError(core.ErrCodeFFmpegNotFound, "Converter not initialized", nil)
+	}
+	inputs, err := converter.FindBatchInputs(dir, pattern)
+	if err != nil {
+		return nil, core.NewAppError(core.ErrCodeFilesystemError, "No files to convert", err)
+	}
+	if name == "" {
+		name = filepath.Base(dir)
+	}
+	return a.converterService.StartBatchConversion(genID(), name, inputs, presetID, outputDir)
+}
+
+// GetConversionBatches returns progress and summaries of all batches.
+func (a *App) GetConversionBatches() []core.ConversionBatchReport {
+	if a.converterService == nil {
+		return []core.ConversionBatchReport{}
+	}
+	return a.converterService.GetBatchReports()
+}
+
+// GetConversionBatch returns the progress and summary of one batch.
+func (a *App) GetConversionBatch(id string) (*core.ConversionBatchReport, error) {
+	if a.converterService == nil {
+		return nil, core.NewAppError(core.ErrCodeFFmpegNotFound, "Converter not initialized", nil)
+	}
+	return a.converterService.GetBatchReport(id)
+}
+
+// CancelConversionBatch cancels every queued and running job of a batch.
+func (a *App) CancelConversionBatch(id string) error {
+	if a.converterService == nil {
+		return core.NewAppError(core.ErrCodeFFmpegNotFound, "Converter not initialized", nil)
+	}
+	return a.converterService.CancelBatch(id)
+}
+
 // ClearCompletedConversions removes all completed conversion jobs.
 func (a *App) ClearCompletedConversions() {
 	if a.converterService != nil {
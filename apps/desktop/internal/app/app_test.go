@@ -3,10 +3,17 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 
 	"ybdownloader/internal/core"
 	"ybdownloader/internal/infra/downloader"
+	"ybdownloader/internal/infra/presets"
 	"ybdownloader/internal/infra/updater"
 	ytsearch "ybdownloader/internal/infra/youtube"
 )
@@ -111,6 +118,15 @@ func (m *mockDownloader) Download(_ context.Context, _ *core.QueueItem, _ func(c
 	return m.downloadError
 }
 
+type mockFormatListerDownloader struct {
+	mockDownloader
+	formats []core.FormatInfo
+}
+
+func (m *mockFormatListerDownloader) ListFormats(_ context.Context, _ string) ([]core.FormatInfo, error) {
+	return m.formats, nil
+}
+
 // Mock QueueManager for testing
 type mockQueueManager struct {
 	items      map[string]*core.QueueItem
@@ -199,6 +215,46 @@ func (m *mockQueueManager) RetryItem(id string) error {
 	return nil
 }
 
+func (m *mockQueueManager) SetFormatID(id, formatID string) error {
+	item, ok := m.items[id]
+	if !ok {
+		return core.ErrQueueItemNotFound
+	}
+	item.FormatID = formatID
+	return nil
+}
+
+func (m *mockQueueManager) SetLiveOptions(id string, opts *core.LiveOptions) error {
+	item, ok := m.items[id]
+	if !ok {
+		return core.ErrQueueItemNotFound
+	}
+	item.Live = opts
+	return nil
+}
+
+func (m *mockQueueManager) SetClip(id string, clip *core.TrimOptions) error {
+	item, ok := m.items[id]
+	if !ok {
+		return core.ErrQueueItemNotFound
+	}
+	item.Clip = clip
+	return nil
+}
+
+func (m *mockQueueManager) ThrottleState() core.ThrottleState {
+	return core.ThrottleState{Concurrency: 2, MaxConcurrency: 2}
+}
+
+func (m *mockQueueManager) StopRecording(id string) error {
+	item, ok := m.items[id]
+	if !ok {
+		return core.ErrQueueItemNotFound
+	}
+	item.State = core.StateStopRequested
+	return nil
+}
+
 func (m *mockQueueManager) ClearCompleted() error {
 	for id, item := range m.items {
 		if item.State == core.StateCompleted {
@@ -214,17 +270,51 @@ func (m *mockQueueManager) FetchMetadata(ctx context.Context, id string) error {
 
 func (m *mockQueueManager) Shutdown() {}
 
+// Mock PresetStore for testing
+type mockPresetStore struct {
+	presets []core.ConversionPreset
+}
+
+func (m *mockPresetStore) List() ([]core.ConversionPreset, error) {
+	return slices.Clone(m.presets), nil
+}
+
+func (m *mockPresetStore) Save(presets ...core.ConversionPreset) error {
+	for _, p := range presets {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+		m.presets = slices.DeleteFunc(m.presets, func(o core.ConversionPreset) bool { return o.ID == p.ID })
+		m.presets = append(m.presets, p)
+	}
+	return nil
+}
+
+func (m *mockPresetStore) Delete(id string) error {
+	n := len(m.presets)
+	m.presets = slices.DeleteFunc(m.presets, func(p core.ConversionPreset) bool { return p.ID == id })
+	if len(m.presets) == n {
+		return errors.New("preset not found: " + id)
+	}
+	return nil
+}
+
 // Mock ConverterService for testing
 type mockConverterService struct {
-	jobs       map[string]*core.ConversionJob
-	presets    []core.ConversionPreset
-	startError error
+	jobs          map[string]*core.ConversionJob
+	presets       []core.ConversionPreset
+	startError    error
+	batches       map[string]*core.ConversionBatch
+	maxConcurrent int
+	paused        bool
+	validateError error
 }
 
 func newMockConverterService() *mockConverterService {
 	return &mockConverterService{
 		jobs:    make(map[string]*core.ConversionJob),
-		presets: core.GetDefaultPresets(),
+		presets: core.MergePresets(core.GetDefaultPresets(), nil),
+		batches: make(map[string]*core.ConversionBatch),
 	}
 }
 
@@ -251,6 +341,17 @@ func (m *mockConverterService) GetPreset(id string) (*core.ConversionPreset, err
 	return nil, core.NewAppError(core.ErrCodeGeneric, "preset not found", nil)
 }
 
+func (m *mockConverterService) SetUserPresets(presets []core.ConversionPreset) {
+	m.presets = core.MergePresets(core.GetDefaultPresets(), presets)
+}
+
+func (m *mockConverterService) ValidatePreset(_ context.Context, preset core.ConversionPreset) error {
+	if m.validateError != nil {
+		return m.validateError
+	}
+	return preset.Validate()
+}
+
 func (m *mockConverterService) AnalyzeFile(ctx context.Context, filePath string) (*core.MediaInfo, error) {
 	return &core.MediaInfo{
 		Duration: 120.5,
@@ -290,6 +391,60 @@ func (m *mockConverterService) StartConversionWithTrim(id, inputPath, outputPath
 	return m.StartConversion(id, inputPath, outputPath, presetID, customArgs)
 }
 
+func (m *mockConverterService) StartConversionWithParams(id, inputPath, outputPath, presetID string, opts core.ConversionOptions) (*core.ConversionJob, error) {
+	params := opts.Params
+	if preset, err := m.GetPreset(presetID); err == nil {
+		if params, err = preset.ResolveParams(params); err != nil {
+			return nil, fmt.Errorf("%w: %w", core.ErrInvalidPreset, err)
+		}
+	}
+	if opts.VideoFilters != nil {
+		if err := opts.VideoFilters.Validate(0, 0); err != nil {
+			return nil, err
+		}
+	}
+	job, err := m.StartConversion(id, inputPath, outputPath, presetID, nil)
+	if job != nil {
+		job.Params = params
+		job.Streams = opts.Streams
+		job.TrimOptions = opts.Trim
+		job.VideoFilters = opts.VideoFilters
+	}
+	return job, err
+}
+
+func (m *mockConverterService) StartTargetSizeConversion(id, inputPath, outputPath string, target core.TargetSizeOptions, opts core.ConversionOptions) (*core.ConversionJob, error) {
+	job, err := m.StartConversion(id, inputPath, outputPath, "", nil)
+	if job != nil {
+		job.TargetSize = &target
+		job.Streams = opts.Streams
+		job.TrimOptions = opts.Trim
+		job.VideoFilters = opts.VideoFilters
+	}
+	return job, err
+}
+
+func (m *mockConverterService) StartConcatConversion(id string, inputPaths []string, outputPath, presetID string, crossfade float64) (*core.ConversionJob, error) {
+	if len(inputPaths) < 2 {
+		return nil, errors.New("joining needs at least two inputs")
+	}
+	job, err := m.StartConversion(id, inputPaths[0], outputPath, presetID, nil)
+	if job != nil {
+		job.Concat = &core.ConcatOptions{InputPaths: inputPaths, Crossfade: crossfade}
+	}
+	return job, err
+}
+
+func (m *mockConverterService) StartAnimationConversion(id, inputPath, outputPath string, opts core.AnimationOptions, trim *core.TrimOptions, filters *core.VideoFilterOptions) (*core.ConversionJob, error) {
+	job, err := m.StartConversion(id, inputPath, outputPath, "", nil)
+	if job != nil {
+		job.Animation = &opts
+		job.TrimOptions = trim
+		job.VideoFilters = filters
+	}
+	return job, err
+}
+
 func (m *mockConverterService) CancelConversion(id string) error {
 	if job, ok := m.jobs[id]; ok {
 		job.State = core.ConversionCancelled
@@ -326,6 +481,65 @@ func (m *mockConverterService) ClearCompletedJobs() {
 	}
 }
 
+func (m *mockConverterService) SetJobPriority(id string, priority int) error {
+	job, ok := m.jobs[id]
+	if !ok {
+		return core.NewAppError(core.ErrCodeGeneric, "job not found", nil)
+	}
+	job.Priority = priority
+	return nil
+}
+
+func (m *mockConverterService) SetMaxConcurrent(n int) { m.maxConcurrent = n }
+func (m *mockConverterService) PauseQueue()            { m.paused = true }
+func (m *mockConverterService) ResumeQueue()           { m.paused = false }
+
+func (m *mockConverterService) QueueState() core.ConversionQueueState {
+	return core.ConversionQueueState{Paused: m.paused, MaxConcurrent: m.maxConcurrent}
+}
+
+func (m *mockConverterService) StartBatchConversion(id, name string, inputs []string, presetID, outputDir string) (*core.ConversionBatch, error) {
+	if m.startError != nil {
+		return nil, m.startError
+	}
+	batch := &core.ConversionBatch{ID: id, Name: name, PresetID: presetID, OutputDir: outputDir}
+	for i, input := range inputs {
+		job, _ := m.StartConversion(id+"-"+strconv.Itoa(i+1), input, "", presetID, nil)
+		job.BatchID = id
+		batch.JobIDs = append(batch.JobIDs, job.ID)
+	}
+	m.batches[id] = batch
+	return batch, nil
+}
+
+func (m *mockConverterService) GetBatchReport(id string) (*core.ConversionBatchReport, error) {
+	batch, ok := m.batches[id]
+	if !ok {
+		return nil, core.NewAppError(core.ErrCodeGeneric, "batch not found", nil)
+	}
+	return &core.ConversionBatchReport{BatchID: id, Name: batch.Name, Total: len(batch.JobIDs)}, nil
+}
+
+func (m *mockConverterService) GetBatchReports() []core.ConversionBatchReport {
+	reports := make([]core.ConversionBatchReport, 0, len(m.batches))
+	for id := range m.batches {
+		report, _ := m.GetBatchReport(id)
+		reports = append(reports, *report)
+	}
+	return reports
+}
+
+func (m *mockConverterService) CancelBatch(id string) error {
+	batch, ok := m.batches[id]
+	if !ok {
+		return core.NewAppError(core.ErrCodeGeneric, "batch not found", nil)
+	}
+	for _, jobID := range batch.JobIDs {
+		_ = m.CancelConversion(jobID)
+	}
+	return nil
+}
+
 func (m *mockConverterService) GenerateWaveform(ctx context.Context, filePath string, numSamples int) ([]float64, error) {
 	result := make([]float64, numSamples)
 	for i := range result {
@@ -843,6 +1057,58 @@ func TestApp_RetryDownload_NilManager(t *testing.T) {
 	}
 }
 
+func TestApp_SetQueueItemFormat(t *testing.T) {
+	qm := newMockQueueManager()
+	qm.items["id1"] = core.NewQueueItem("id1", "https://www.youtube.com/watch?v=dQw4w9WgXcQ", core.FormatMP4, "/tmp")
+	app := &App{queueManager: qm}
+
+	if err := app.SetQueueItemFormat("id1", " 137 "); err != nil {
+		t.Fatalf("SetQueueItemFormat() error = %v", err)
+	}
+	if qm.items["id1"].FormatID != "137" {
+		t.Errorf("FormatID = %q, want 137", qm.items["id1"].FormatID)
+	}
+}
+
+func TestApp_SetQueueItemFormat_NilManager(t *testing.T) {
+	app := &App{queueManager: nil}
+
+	if err := app.SetQueueItemFormat("some-id", "137"); err == nil {
+		t.Error("SetQueueItemFormat() expected error for nil queue manager")
+	}
+}
+
+func TestApp_ListFormats_NilDownloader(t *testing.T) {
+	app := &App{ctx: context.Background()}
+
+	if _, err := app.ListFormats("https://www.youtube.com/watch?v=dQw4w9WgXcQ"); err == nil {
+		t.Error("ListFormats() expected error for nil downloader")
+	}
+}
+
+func TestApp_ListFormats_UnsupportedDownloader(t *testing.T) {
+	app := &App{ctx: context.Background(), downloader: &mockDownloader{}}
+
+	if _, err := app.ListFormats("https://www.youtube.com/watch?v=dQw4w9WgXcQ"); err == nil {
+		t.Error("ListFormats() expected error when downloader cannot list formats")
+	}
+}
+
+func TestApp_ListFormats(t *testing.T) {
+	dl := &mockFormatListerDownloader{
+		formats: []core.FormatInfo{{ID: "137", Ext: "mp4", HasVideo: true, Height: 1080}},
+	}
+	app := &App{ctx: context.Background(), downloader: dl}
+
+	formats, err := app.ListFormats("https://www.youtube.com/watch?v=dQw4w9WgXcQ")
+	if err != nil {
+		t.Fatalf("ListFormats() error = %v", err)
+	}
+	if len(formats) != 1 || formats[0].ID != "137" {
+		t.Errorf("ListFormats() = %+v", formats)
+	}
+}
+
 func TestApp_ClearCompleted_NilManager(t *testing.T) {
 	app := &App{
 		queueManager: nil,
@@ -897,7 +1163,7 @@ func TestApp_StartConversion_NilService(t *testing.T) {
 		converterService: nil,
 	}
 
-	_, err := app.StartConversion("/input.mp3", "/output.mp4", "preset-id")
+	_, err := app.StartConversion("/input.mp3", "/output.mp4", "preset-id", core.ConversionOptions{})
 	if err == nil {
 		t.Error("StartConversion() expected error for nil service")
 	}
@@ -1023,7 +1289,7 @@ func TestApp_StartConversionWithTrim_NilService(t *testing.T) {
 		converterService: nil,
 	}
 
-	_, err := app.StartConversionWithTrim("/input.mp3", "/output.mp4", "preset-id", 0, 60)
+	_, err := app.StartConversionWithTrim("/input.mp3", "/output.mp4", "preset-id", 0, 60, core.ConversionOptions{})
 	if err == nil {
 		t.Error("StartConversionWithTrim() expected error for nil service")
 	}
@@ -1223,7 +1489,7 @@ func TestApp_StartConversionWithTrim_WithNoTrim(t *testing.T) {
 	}
 
 	// With 0 start and end, should still fail due to nil service
-	_, err := app.StartConversionWithTrim("/input.mp3", "/output.mp4", "preset", 0, 0)
+	_, err := app.StartConversionWithTrim("/input.mp3", "/output.mp4", "preset", 0, 0, core.ConversionOptions{})
 	if err == nil {
 		t.Error("expected error for nil service")
 	}
@@ -1514,7 +1780,7 @@ func TestApp_StartConversion_WithMockConverterService(t *testing.T) {
 		converterService: cs,
 	}
 
-	job, err := app.StartConversion("/input.mp4", "/output.mp3", "mp3-320")
+	job, err := app.StartConversion("/input.mp4", "/output.mp3", "mp3-320", core.ConversionOptions{})
 	if err != nil {
 		t.Fatalf("StartConversion() error = %v", err)
 	}
@@ -1656,7 +1922,7 @@ func TestApp_StartConversionWithTrim_WithMockConverterService(t *testing.T) {
 		converterService: cs,
 	}
 
-	job, err := app.StartConversionWithTrim("/input.mp4", "/output.mp3", "mp3-320", 10.0, 60.0)
+	job, err := app.StartConversionWithTrim("/input.mp4", "/output.mp3", "mp3-320", 10.0, 60.0, core.ConversionOptions{})
 	if err != nil {
 		t.Fatalf("StartConversionWithTrim() error = %v", err)
 	}
@@ -1906,23 +2172,35 @@ func TestGetDownloadBackend_loadError(t *testing.T) {
 }
 
 func TestGetYtDlpDefaultFlags(t *testing.T) {
-	app := &App{}
+	settings := core.DefaultSettings("/tmp/test")
+	settings.YtDlpExtraFlags = []string{"--embed-subs", "--exec", "echo hi"}
+	app := &App{settingsStore: &mockSettingsStore{settings: settings}}
 	flags := app.GetYtDlpDefaultFlags()
 
-	if len(flags) != 4 {
-		t.Errorf("GetYtDlpDefaultFlags() returned %d keys, want 4", len(flags))
-	}
-	if _, ok := flags["common"]; !ok {
-		t.Error("missing 'common' key")
+	for _, key := range []string{"common", "mp3", "m4a", "mp4", "webm", "extra"} {
+		if len(flags[key]) == 0 {
+			t.Errorf("missing %q flags", key)
+		}
 	}
-	if _, ok := flags["mp3"]; !ok {
-		t.Error("missing 'mp3' key")
+	if !slices.Contains(flags["common"], "--newline") || !slices.Contains(flags["common"], "-o") {
+		t.Errorf("common = %v, want the managed output flags", flags["common"])
 	}
-	if _, ok := flags["m4a"]; !ok {
-		t.Error("missing 'm4a' key")
+	if !slices.Equal(flags["extra"], []string{"--embed-subs"}) {
+		t.Errorf("extra = %v, want only the allowed flag", flags["extra"])
 	}
-	if _, ok := flags["mp4"]; !ok {
-		t.Error("missing 'mp4' key")
+}
+
+func TestApp_ValidateYtDlpFlags(t *testing.T) {
+	app := &App{}
+	flags := app.ValidateYtDlpFlags([]string{"--embed-subs", "--quiet", "--exec", "ls"})
+	if len(flags) != 3 {
+		t.Fatalf("ValidateYtDlpFlags() = %+v, want 3 flags", flags)
+	}
+	want := []core.YtDlpFlagClass{core.YtDlpFlagAllowed, core.YtDlpFlagConflicting, core.YtDlpFlagDangerous}
+	for i, f := range flags {
+		if f.Class != want[i] {
+			t.Errorf("flags[%d] = %+v, want %s", i, f, want[i])
+		}
 	}
 }
 
@@ -2372,3 +2650,605 @@ func TestYtDlpStatus_struct(t *testing.T) {
 		t.Error("expected HasJSRuntime true")
 	}
 }
+
+const testCookiesFile = "# Netscape HTTP Cookie File\n" +
+	".youtube.com\tTRUE\t/\tTRUE\t4102444800\tSID\tabc\n"
+
+func TestApp_ImportCookiesFile(t *testing.T) {
+	configDir := t.TempDir()
+	src := filepath.Join(t.TempDir(), "export.txt")
+	if err := os.WriteFile(src, []byte(testCookiesFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store := &mockSettingsStore{}
+	app := &App{settingsStore: store, fs: &mockFileSystem{configDir: configDir}}
+
+	info, err := app.ImportCookiesFile(src)
+	if err != nil {
+		t.Fatalf("ImportCookiesFile() error = %v", err)
+	}
+	want := filepath.Join(configDir, cookiesFileName)
+	if info.Path != want || store.settings.CookiesFile != want {
+		t.Errorf("cookies path = %q / %q, want %q", info.Path, store.settings.CookiesFile, want)
+	}
+	if info.CookieCount != 1 || !info.HasYouTube {
+		t.Errorf("info = %+v", info)
+	}
+
+	status, err := app.GetCookiesStatus()
+	if err != nil || status == nil {
+		t.Fatalf("GetCookiesStatus() = %v, %v", status, err)
+	}
+
+	if err := app.ClearCookies(); err != nil {
+		t.Fatalf("ClearCookies() error = %v", err)
+	}
+	if store.settings.CookiesFile != "" {
+		t.Error("ClearCookies() did not reset setting")
+	}
+	if _, err := os.Stat(want); !os.IsNotExist(err) {
+		t.Error("ClearCookies() did not remove imported file")
+	}
+}
+
+func TestApp_ImportCookiesFile_Invalid(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "bad.txt")
+	if err := os.WriteFile(src, []byte("not a cookie file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store := &mockSettingsStore{}
+	app := &App{settingsStore: store, fs: &mockFileSystem{configDir: t.TempDir()}}
+
+	_, err := app.ImportCookiesFile(src)
+	var appErr *core.AppError
+	if !errors.As(err, &appErr) || appErr.Code != core.ErrCodeCookiesInvalid {
+		t.Fatalf("ImportCookiesFile() error = %v, want %s", err, core.ErrCodeCookiesInvalid)
+	}
+	if store.settings != nil && store.settings.CookiesFile != "" {
+		t.Error("invalid cookies should not be saved")
+	}
+}
+
+func TestApp_GetCookiesStatus_None(t *testing.T) {
+	app := &App{settingsStore: &mockSettingsStore{}}
+	info, err := app.GetCookiesStatus()
+	if err != nil || info != nil {
+		t.Errorf("GetCookiesStatus() = %v, %v, want nil, nil", info, err)
+	}
+}
+
+func TestApp_AddToQueue_AllowedSites(t *testing.T) {
+	store := &mockSettingsStore{}
+	app := &App{settingsStore: store, queueManager: newMockQueueManager()}
+
+	if _, err := app.AddToQueue("https://vimeo.com/76979871", "mp4"); err != nil {
+		t.Errorf("AddToQueue() for allowed site error = %v", err)
+	}
+
+	_, err := app.AddToQueue("https://example.org/video/1", "mp4")
+	var appErr *core.AppError
+	if !errors.As(err, &appErr) || appErr.Code != core.ErrCodeUnsupportedSite {
+		t.Errorf("AddToQueue() error = %v, want %s", err, core.ErrCodeUnsupportedSite)
+	}
+
+	if !app.IsSupportedURL("https://soundcloud.com/artist/track") {
+		t.Error("IsSupportedURL() = false for default allowed site")
+	}
+	if app.IsSupportedURL("https://example.org/video/1") {
+		t.Error("IsSupportedURL() = true for site outside the allow-list")
+	}
+}
+
+func TestApp_LiveRecordingControls(t *testing.T) {
+	qm := newMockQueueManager()
+	qm.items["live"] = &core.QueueItem{ID: "live", State: core.StateQueued}
+	app := &App{queueManager: qm}
+
+	opts := &core.LiveOptions{FromStart: true, MaxDurationSeconds: 600, WaitForStart: true}
+	if err := app.SetQueueItemLiveOptions("live", opts); err != nil {
+		t.Fatalf("SetQueueItemLiveOptions() error = %v", err)
+	}
+	if qm.items["live"].Live != opts {
+		t.Error("SetQueueItemLiveOptions() did not reach queue manager")
+	}
+
+	if err := app.StopRecording("live"); err != nil {
+		t.Fatalf("StopRecording() error = %v", err)
+	}
+	if qm.items["live"].State != core.StateStopRequested {
+		t.Errorf("State = %q, want %q", qm.items["live"].State, core.StateStopRequested)
+	}
+
+	nilApp := &App{}
+	if err := nilApp.StopRecording("live"); err != core.ErrQueueItemNotFound {
+		t.Errorf("StopRecording() with nil manager error = %v", err)
+	}
+}
+
+func TestApp_SetQueueItemClip(t *testing.T) {
+	qm := newMockQueueManager()
+	qm.items["a"] = &core.QueueItem{ID: "a"}
+	app := &App{queueManager: qm}
+
+	if err := app.SetQueueItemClip("a", 90, 210); err != nil {
+		t.Fatalf("SetQueueItemClip() error = %v", err)
+	}
+	if clip := qm.items["a"].Clip; clip == nil || clip.StartTime != 90 || clip.EndTime != 210 {
+		t.Errorf("Clip = %+v, want 90-210", clip)
+	}
+
+	if err := app.SetQueueItemClip("a", 0, 0); err != nil {
+		t.Fatalf("SetQueueItemClip() error = %v", err)
+	}
+	if qm.items["a"].Clip != nil {
+		t.Errorf("Clip = %+v, want nil", qm.items["a"].Clip)
+	}
+
+	nilApp := &App{}
+	if err := nilApp.SetQueueItemClip("a", 1, 2); err != core.ErrQueueItemNotFound {
+		t.Errorf("SetQueueItemClip() with nil manager error = %v", err)
+	}
+}
+
+func TestApp_GetThrottleState(t *testing.T) {
+	app := &App{queueManager: newMockQueueManager()}
+	if got := app.GetThrottleState(); got.MaxConcurrency != 2 {
+		t.Errorf("GetThrottleState() = %+v", got)
+	}
+	if got := (&App{}).GetThrottleState(); got != (core.ThrottleState{}) {
+		t.Errorf("GetThrottleState() with nil manager = %+v", got)
+	}
+}
+
+type mockCachingDownloader struct {
+	mockDownloader
+	refreshed   []string
+	invalidated []string
+	cleared     bool
+}
+
+func (m *mockCachingDownloader) RefreshMetadata(ctx context.Context, url string) (*core.VideoMetadata, error) {
+	m.refreshed = append(m.refreshed, url)
+	return m.FetchMetadata(ctx, url)
+}
+
+func (m *mockCachingDownloader) InvalidateMetadata(url string) {
+	m.invalidated = append(m.invalidated, url)
+}
+
+func (m *mockCachingDownloader) ClearMetadataCache() error {
+	m.cleared = true
+	return nil
+}
+
+func TestApp_MetadataCache(t *testing.T) {
+	dl := &mockCachingDownloader{}
+	app := &App{ctx: context.Background(), downloader: dl}
+	url := "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+
+	meta, err := app.RefreshMetadata(url)
+	if err != nil || meta.Title != "Test Video" {
+		t.Fatalf("RefreshMetadata() = %+v, %v", meta, err)
+	}
+	app.InvalidateMetadata(url)
+	if err := app.ClearMetadataCache(); err != nil {
+		t.Fatalf("ClearMetadataCache() error = %v", err)
+	}
+	if len(dl.refreshed) != 1 || len(dl.invalidated) != 1 || !dl.cleared {
+		t.Errorf("downloader calls = %+v", dl)
+	}
+
+	// Downloaders without a cache just fetch.
+	app.downloader = &mockDownloader{}
+	if _, err := app.RefreshMetadata(url); err != nil {
+		t.Errorf("RefreshMetadata() without cache error = %v", err)
+	}
+	if err := app.ClearMetadataCache(); err != nil {
+		t.Errorf("ClearMetadataCache() without cache error = %v", err)
+	}
+}
+
+func TestApp_YtDlpVersions(t *testing.T) {
+	fs := &mockFileSystem{configDir: t.TempDir()}
+	store := &mockSettingsStore{settings: &core.Settings{YtDlpVersion: "2024.08.06"}}
+	app := &App{
+		ctx:           context.Background(),
+		fs:            fs,
+		settingsStore: store,
+		ytdlpManager:  downloader.NewYtDlpManager(fs, store.Load),
+	}
+
+	versions, err := app.ListYtDlpVersions()
+	if err != nil || len(versions) != 0 {
+		t.Errorf("ListYtDlpVersions() = %+v, %v, want none", versions, err)
+	}
+	if err := app.UseYtDlpVersion("2024.08.06"); err == nil {
+		t.Error("UseYtDlpVersion() accepted a version that is not installed")
+	}
+
+	if err := app.PinYtDlpVersion(""); err != nil {
+		t.Fatalf("PinYtDlpVersion(\"\") error = %v", err)
+	}
+	if store.settings.YtDlpVersion != "" {
+		t.Errorf("YtDlpVersion = %q, want unpinned", store.settings.YtDlpVersion)
+	}
+}
+
+func TestApp_CheckBinaryIntegrity(t *testing.T) {
+	configDir := t.TempDir()
+	app := &App{fs: &mockFileSystem{configDir: configDir}}
+
+	results, err := app.CheckBinaryIntegrity()
+	if err != nil || len(results) != 0 {
+		t.Errorf("CheckBinaryIntegrity() = %+v, %v, want nothing recorded", results, err)
+	}
+
+	binDir := filepath.Join(configDir, "bin")
+	if err := os.MkdirAll(binDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(binDir, "ffmpeg"), []byte("patched"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	record := `{"ffmpeg": {"sha256": "0000000000000000000000000000000000000000000000000000000000000000"}}`
+	if err := os.WriteFile(filepath.Join(binDir, "checksums.json"), []byte(record), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err = app.CheckBinaryIntegrity()
+	if err != nil || len(results) != 1 || results[0].OK {
+		t.Errorf("CheckBinaryIntegrity() = %+v, %v, want ffmpeg mismatch", results, err)
+	}
+	app.reportTamperedBinaries() // No context: must not emit or panic
+}
+
+func TestApp_InstallToolFromFile_Errors(t *testing.T) {
+	app := &App{fs: &mockFileSystem{configDir: t.TempDir()}}
+
+	if _, err := app.InstallToolFromFile("ffmpeg", filepath.Join(t.TempDir(), "missing.zip")); err == nil {
+		t.Error("InstallToolFromFile() accepted a missing file")
+	}
+
+	file := filepath.Join(t.TempDir(), "tool.zip")
+	if err := os.WriteFile(file, []byte("zip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := app.InstallToolFromFile("node", file)
+	if err == nil || err.Error() != "unknown tool: node" {
+		t.Errorf("InstallToolFromFile() error = %v, want unknown tool", err)
+	}
+}
+
+func TestApp_GetBackendStats(t *testing.T) {
+	app := &App{downloader: &mockDownloader{}}
+	if stats := app.GetBackendStats(); stats != nil {
+		t.Errorf("GetBackendStats() = %+v, want nil for a downloader without stats", stats)
+	}
+
+	app.downloader = downloader.NewDelegatingDownloader(nil, &downloader.YtDlpDownloader{}, nil)
+	stats := app.GetBackendStats()
+	if len(stats) != 1 || stats[0].Backend != core.BackendYtDlp {
+		t.Errorf("GetBackendStats() = %+v, want yt-dlp", stats)
+	}
+}
+
+func TestApp_ConversionQueue(t *testing.T) {
+	cs := newMockConverterService()
+	app := &App{
+		ctx:              context.Background(),
+		converterService: cs,
+		settingsStore:    &mockSettingsStore{settings: &core.Settings{MaxConcurrentConversions: 3}},
+	}
+
+	app.PauseConversions()
+	if !app.GetConversionQueueState().Paused {
+		t.Error("PauseConversions() did not pause the queue")
+	}
+	app.ResumeConversions()
+	if app.GetConversionQueueState().Paused {
+		t.Error("ResumeConversions() did not resume the queue")
+	}
+
+	if err := app.SaveSettings(&core.Settings{MaxConcurrentConversions: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if cs.maxConcurrent != 4 {
+		t.Errorf("maxConcurrent = %d after SaveSettings, want 4", cs.maxConcurrent)
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.mkv", "b.mkv", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	batch, err := app.StartFolderConversion("", dir, "*.mkv", "audio-mp3-192", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch.Name != filepath.Base(dir) || len(batch.JobIDs) != 2 {
+		t.Errorf("StartFolderConversion() = %+v, want the two mkv files named after the folder", batch)
+	}
+	if err := app.SetConversionPriority(batch.JobIDs[1], 5); err != nil || cs.jobs[batch.JobIDs[1]].Priority != 5 {
+		t.Errorf("SetConversionPriority() = %v", err)
+	}
+	if reports := app.GetConversionBatches(); len(reports) != 1 || reports[0].Total != 2 {
+		t.Errorf("GetConversionBatches() = %+v", reports)
+	}
+	if err := app.CancelConversionBatch(batch.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range batch.JobIDs {
+		if cs.jobs[id].State != core.ConversionCancelled {
+			t.Errorf("job %s state = %s after CancelConversionBatch", id, cs.jobs[id].State)
+		}
+	}
+
+	if _, err := app.StartFolderConversion("", dir, "*.mp4", "audio-mp3-192", ""); err == nil {
+		t.Error("StartFolderConversion() accepted a pattern that matches nothing")
+	}
+}
+
+func TestApp_ConversionQueue_NilService(t *testing.T) {
+	app := &App{ctx: context.Background()}
+	app.PauseConversions()
+	app.ResumeConversions()
+	if state := app.GetConversionQueueState(); state != (core.ConversionQueueState{}) {
+		t.Errorf("GetConversionQueueState() = %+v, want zero", state)
+	}
+	if _, err := app.StartBatchConversion("", []string{"a.mkv"}, "audio-mp3-192", ""); err == nil {
+		t.Error("StartBatchConversion() should fail without a converter")
+	}
+	if err := app.SetConversionPriority("id", 1); err == nil {
+		t.Error("SetConversionPriority() should fail without a converter")
+	}
+	if reports := app.GetConversionBatches(); len(reports) != 0 {
+		t.Errorf("GetConversionBatches() = %+v, want empty", reports)
+	}
+}
+
+func TestApp_StartConversion_Params(t *testing.T) {
+	app := &App{ctx: context.Background(), converterService: newMockConverterService()}
+
+	preset, err := app.GetConversionPreset("video-mp4-h264")
+	if err != nil || len(preset.Params) == 0 {
+		t.Fatalf("GetConversionPreset() = %+v, %v, want its parameters", preset, err)
+	}
+	if _, err := app.GetConversionPreset("missing"); err == nil {
+		t.Error("GetConversionPreset() found a missing preset")
+	}
+
+	job, err := app.StartConversion("/in.mkv", "/out.mp4", "video-mp4-h264", core.ConversionOptions{Params: map[string]string{"crf": "20"}, Streams: []int{0, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Params["crf"] != "20" || job.Params["height"] != "ih" {
+		t.Errorf("Params = %v", job.Params)
+	}
+	if !slices.Equal(job.Streams, []int{0, 2}) {
+		t.Errorf("Streams = %v, want the selection passed on", job.Streams)
+	}
+
+	_, err = app.StartConversion("/in.mkv", "/out.mp4", "video-mp4-h264", core.ConversionOptions{Params: map[string]string{"crf": "-1"}})
+	var appErr *core.AppError
+	if !errors.As(err, &appErr) || appErr.Code != core.ErrCodeInvalidPreset {
+		t.Errorf("StartConversion() error = %v, want %s", err, core.ErrCodeInvalidPreset)
+	}
+
+	if _, err := (&App{}).GetConversionPreset("video-mp4-h264"); err == nil {
+		t.Error("GetConversionPreset() should fail without a converter")
+	}
+}
+
+func TestApp_StartConversion_VideoFilters(t *testing.T) {
+	app := &App{ctx: context.Background(), converterService: newMockConverterService()}
+
+	filters := &core.VideoFilterOptions{Crop: &core.CropRect{Width: 640, Height: 360}, Rotate: 90}
+	job, err := app.StartConversionWithTrim("/in.mkv", "/out.mp4", "video-mp4-h264", 0, 30, core.ConversionOptions{VideoFilters: filters})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.VideoFilters != filters {
+		t.Errorf("VideoFilters = %+v, want the filters recorded", job.VideoFilters)
+	}
+	if job.TrimOptions == nil || job.TrimOptions.EndTime != 30 {
+		t.Errorf("TrimOptions = %+v, want the range passed on", job.TrimOptions)
+	}
+
+	_, err = app.StartConversion("/in.mkv", "/out.mp4", "video-mp4-h264", core.ConversionOptions{VideoFilters: &core.VideoFilterOptions{Rotate: 45}})
+	var appErr *core.AppError
+	if !errors.As(err, &appErr) || appErr.Code != core.ErrCodeConversionFailed {
+		t.Errorf("StartConversion() error = %v, want %s", err, core.ErrCodeConversionFailed)
+	}
+}
+
+func TestApp_StartTargetSizeConversion(t *testing.T) {
+	app := &App{ctx: context.Background(), converterService: newMockConverterService()}
+
+	filters := &core.VideoFilterOptions{Height: 720}
+	job, err := app.StartTargetSizeConversion("/in.mkv", "", core.TargetSizeOptions{TargetBytes: 25_000_000}, 5, 0, core.ConversionOptions{Streams: []int{0, 2}, VideoFilters: filters})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(job.Streams) != 2 || job.VideoFilters != filters {
+		t.Errorf("Streams = %v, VideoFilters = %+v, want the options passed on", job.Streams, job.VideoFilters)
+	}
+	if job.TargetSize.VideoCodec != core.TargetCodecH264 || job.TargetSize.AudioBitrateKbps != core.DefaultTargetAudioBitrate {
+		t.Errorf("TargetSize = %+v, want defaults filled in", job.TargetSize)
+	}
+	if job.TrimOptions == nil || job.TrimOptions.StartTime != 5 {
+		t.Errorf("TrimOptions = %+v", job.TrimOptions)
+	}
+
+	_, err = app.StartTargetSizeConversion("/in.mkv", "", core.TargetSizeOptions{TargetBytes: 1, VideoCodec: "av1"}, 0, 0, core.ConversionOptions{})
+	var appErr *core.AppError
+	if !errors.As(err, &appErr) || appErr.Code != core.ErrCodeConversionFailed {
+		t.Errorf("StartTargetSizeConversion() error = %v, want %s", err, core.ErrCodeConversionFailed)
+	}
+	if _, err := (&App{}).StartTargetSizeConversion("/in.mkv", "", core.TargetSizeOptions{TargetBytes: 1}, 0, 0, core.ConversionOptions{}); err == nil {
+		t.Error("StartTargetSizeConversion() should fail without a converter")
+	}
+}
+
+func TestApp_JoinMediaFiles(t *testing.T) {
+	app := &App{ctx: context.Background(), converterService: newMockConverterService()}
+
+	job, err := app.JoinMediaFiles([]string{"/a.mp3", "/b.mp3"}, "", "audio-mp3-192", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Concat == nil || len(job.Concat.InputPaths) != 2 || job.Concat.Crossfade != 2 {
+		t.Errorf("Concat = %+v", job.Concat)
+	}
+
+	_, err = app.JoinMediaFiles([]string{"/a.mp3"}, "", "", 0)
+	var appErr *core.AppError
+	if !errors.As(err, &appErr) || appErr.Code != core.ErrCodeConversionFailed {
+		t.Errorf("JoinMediaFiles() error = %v, want %s", err, core.ErrCodeConversionFailed)
+	}
+	if _, err := (&App{}).JoinMediaFiles([]string{"/a.mp3", "/b.mp3"}, "", "", 0); err == nil {
+		t.Error("JoinMediaFiles() should fail without a converter")
+	}
+}
+
+func TestApp_StartAnimationConversion(t *testing.T) {
+	app := &App{ctx: context.Background(), converterService: newMockConverterService()}
+
+	filters := &core.VideoFilterOptions{Crop: &core.CropRect{Width: 640, Height: 640}}
+	job, err := app.StartAnimationConversion("/in.mp4", "/out.webp", core.AnimationOptions{Format: core.AnimationWebP, Width: 320}, 5, 9, filters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.VideoFilters != filters {
+		t.Errorf("VideoFilters = %+v, want the filters passed on", job.VideoFilters)
+	}
+	if job.Animation == nil || job.Animation.FPS != 15 || job.Animation.Quality != 75 {
+		t.Errorf("Animation = %+v, want defaults filled in", job.Animation)
+	}
+	if job.TrimOptions == nil || job.TrimOptions.StartTime != 5 || job.TrimOptions.EndTime != 9 {
+		t.Errorf("TrimOptions = %+v", job.TrimOptions)
+	}
+
+	_, err = app.StartAnimationConversion("/in.mp4", "", core.AnimationOptions{Dither: "noise"}, 0, 0, nil)
+	var appErr *core.AppError
+	if !errors.As(err, &appErr) || appErr.Code != core.ErrCodeConversionFailed {
+		t.Errorf("StartAnimationConversion() error = %v, want %s", err, core.ErrCodeConversionFailed)
+	}
+	if _, err := (&App{}).StartAnimationConversion("/in.mp4", "", core.AnimationOptions{}, 0, 0, nil); err == nil {
+		t.Error("StartAnimationConversion() should fail without a converter")
+	}
+}
+
+func TestApp_ConversionPresets(t *testing.T) {
+	cs := newMockConverterService()
+	store := &mockPresetStore{}
+	app := &App{ctx: context.Background(), converterService: cs, presetStore: store, version: "1.2.3"}
+
+	saved, err := app.SaveConversionPreset(core.ConversionPreset{
+		Name:       "Podcast",
+		Category:   "Speech",
+		OutputExt:  ".MP3",
+		FFmpegArgs: []string{"-vn", "-ac", "1", "-b:a", "64k"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(saved.ID, "user-") || saved.Source != core.PresetUser || saved.Category != "speech" || saved.OutputExt != "mp3" {
+		t.Errorf("SaveConversionPreset() = %+v, want a normalized user preset", saved)
+	}
+	if !slices.Contains(app.GetPresetCategories(), "speech") {
+		t.Errorf("GetPresetCategories() = %v, want speech", app.GetPresetCategories())
+	}
+
+	// Editing a built-in stores a modified copy in its place.
+	edited := core.GetDefaultPresets()[1]
+	edited.FFmpegArgs = []string{"-vn", "-codec:a", "libmp3lame", "-b:a", "160k"}
+	if got, err := app.SaveConversionPreset(edited); err != nil || got.Source != core.PresetModified || got.BasedOn != edited.ID {
+		t.Fatalf("SaveConversionPreset(built-in) = %+v, %v", got, err)
+	}
+	if got := app.GetConversionPresets(); len(got) != len(core.GetDefaultPresets())+1 || got[1].FFmpegArgs[4] != "160k" {
+		t.Errorf("GetConversionPresets() does not show the modified built-in in place")
+	}
+
+	copied, err := app.DuplicateConversionPreset(edited.ID, "")
+	if err != nil || copied.Name != edited.Name+" (copy)" || copied.BasedOn != edited.ID {
+		t.Fatalf("DuplicateConversionPreset() = %+v, %v", copied, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "team.json")
+	if err := app.ExportConversionPresets(nil, path); err != nil {
+		t.Fatal(err)
+	}
+	exported, err := presets.ReadFile(path)
+	if err != nil || len(exported) != 3 {
+		t.Fatalf("exported %d presets, %v; want the 3 user presets", len(exported), err)
+	}
+
+	if err := app.DeleteConversionPreset(edited.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := app.GetConversionPresets()[1]; got.Source != core.PresetBuiltIn {
+		t.Errorf("deleting the modified preset left %+v, want the built-in restored", got)
+	}
+
+	other := &App{ctx: context.Background(), converterService: newMockConverterService(), presetStore: &mockPresetStore{}}
+	imported, err := other.ImportConversionPresets(path)
+	if err != nil || len(imported) != 3 {
+		t.Fatalf("ImportConversionPresets() = %d presets, %v", len(imported), err)
+	}
+	if got := other.GetConversionPresets(); len(got) != len(core.GetDefaultPresets())+2 {
+		t.Errorf("GetConversionPresets() after import has %d presets", len(got))
+	}
+}
+
+func TestApp_ConversionPresets_Errors(t *testing.T) {
+	cs := newMockConverterService()
+	app := &App{ctx: context.Background(), converterService: cs, presetStore: &mockPresetStore{}}
+
+	bad := core.ConversionPreset{Name: "Sneaky", FFmpegArgs: []string{"-i", "/etc/passwd"}}
+	_, err := app.SaveConversionPreset(bad)
+	var appErr *core.AppError
+	if !errors.As(err, &appErr) || appErr.Code != core.ErrCodeInvalidPreset {
+		t.Errorf("SaveConversionPreset() error = %v, want INVALID_PRESET", err)
+	}
+
+	cs.validateError = errors.New("Unknown encoder 'libnope'")
+	if _, err := app.SaveConversionPreset(core.ConversionPreset{Name: "Nope", FFmpegArgs: []string{"-c:a", "libnope"}}); err == nil {
+		t.Error("SaveConversionPreset() saved a preset FFmpeg rejected")
+	}
+
+	path := filepath.Join(t.TempDir(), "presets.json")
+	if err := presets.WriteFile(path, []core.ConversionPreset{{ID: "a", Name: "A", FFmpegArgs: []string{"-c:a", "libnope"}}}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.ImportConversionPresets(path); err == nil {
+		t.Error("ImportConversionPresets() imported a preset FFmpeg rejected")
+	}
+	hostile := []core.ConversionPreset{{ID: "h", Name: "Hostile", FFmpegArgs: []string{"-c:a", "aac", filepath.Join(t.TempDir(), "owned")}}}
+	if err := presets.WriteFile(path, hostile, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.ImportConversionPresets(path); !errors.As(err, &appErr) || appErr.Code != core.ErrCodeInvalidPreset {
+		t.Errorf("ImportConversionPresets() error = %v, want a preset writing its own output refused", err)
+	}
+	if list, _ := app.presetStore.List(); len(list) != 0 {
+		t.Errorf("failed saves left presets behind: %+v", list)
+	}
+
+	if _, err := app.DuplicateConversionPreset("missing", ""); err == nil {
+		t.Error("DuplicateConversionPreset() copied a missing preset")
+	}
+	if err := app.ExportConversionPresets(nil, path); err == nil {
+		t.Error("ExportConversionPresets() exported with no user presets")
+	}
+
+	noStore := &App{ctx: context.Background(), converterService: cs}
+	if _, err := noStore.SaveConversionPreset(core.ConversionPreset{Name: "A", FFmpegArgs: []string{"-vn"}}); err == nil {
+		t.Error("SaveConversionPreset() worked without a preset store")
+	}
+	if got := noStore.GetConversionPresets(); len(got) != len(core.GetDefaultPresets()) {
+		t.Errorf("GetConversionPresets() = %d presets without a store", len(got))
+	}
+}
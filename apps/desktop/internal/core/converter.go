@@ -1,5 +1,13 @@
 package core
 
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // ConversionPreset represents a predefined conversion configuration.
 type ConversionPreset struct {
 	ID          string                 `json:"id"`
@@ -7,8 +15,11 @@ type ConversionPreset struct {
 	Description string                 `json:"description"`
 	Category    string                 `json:"category"` // audio, video, gif, etc.
 	OutputExt   string                 `json:"outputExt"`
-	FFmpegArgs  []string               `json:"ffmpegArgs,omitempty"`
+	FFmpegArgs  []string               `json:"ffmpegArgs,omitempty"` // May hold {name} placeholders for Params
+	Params      []PresetParam          `json:"params,omitempty"`
 	Options     map[string]interface{} `json:"options,omitempty"`
+	Source      PresetSource           `json:"source,omitempty"`  // Set when listing; not saved
+	BasedOn     string                 `json:"basedOn,omitempty"` // ID of the preset this one was copied from
 }
 
 // TrimOptions specifies the start and end time for trimming media.
@@ -17,20 +28,319 @@ type TrimOptions struct {
 	EndTime   float64 `json:"endTime"`   // End time in seconds (0 = end of file)
 }
 
+// Validate checks that the range starts at or after zero and, when an end is
+// set, ends after it starts.
+func (t *TrimOptions) Validate() error {
+	if t.StartTime < 0 || t.EndTime < 0 {
+		return fmt.Errorf("time range cannot be negative")
+	}
+	if t.EndTime > 0 && t.EndTime <= t.StartTime {
+		return fmt.Errorf("end time must be after start time")
+	}
+	return nil
+}
+
+// Length returns the length of the range in seconds. When the range runs to
+// the end, total is the full duration; the result is 0 if it is unknown.
+func (t *TrimOptions) Length(total float64) float64 {
+	end := t.EndTime
+	if end == 0 {
+		end = total
+	}
+	return max(end-t.StartTime, 0)
+}
+
+// CropRect is a rectangle of the input frame, in pixels.
+type CropRect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// maxFilterSize is the largest width or height VideoFilterOptions may ask
+// for.
+const maxFilterSize = 8192
+
+// VideoFilterOptions changes the geometry and frame rate of the video ahead
+// of a preset's own filters. They apply in field order: crop, rotate and
+// flip, scale, pad, then frame rate. Sizes must be even, as most encoders
+// require.
+type VideoFilterOptions struct {
+	Crop      *CropRect `json:"crop,omitempty"`
+	Rotate    int       `json:"rotate,omitempty"` // Clockwise degrees: 0, 90, 180 or 270
+	FlipH     bool      `json:"flipH,omitempty"`
+	FlipV     bool      `json:"flipV,omitempty"`
+	Width     int       `json:"width,omitempty"`     // Pixels after rotating; 0 follows Height, keeping the aspect ratio
+	Height    int       `json:"height,omitempty"`    // Pixels after rotating; 0 follows Width, keeping the aspect ratio
+	PadAspect string    `json:"padAspect,omitempty"` // Aspect ratio such as "16:9" to pad out to, centring the picture
+	PadColor  string    `json:"padColor,omitempty"`  // FFmpeg colour name or hex code; default black
+	FPS       float64   `json:"fps,omitempty"`       // Output frame rate; 0 keeps the input's
+}
+
+// Validate checks the options against an input of width by height pixels.
+// Checks needing the input's size are skipped while it is unknown (0).
+func (o *VideoFilterOptions) Validate(width, height int) error {
+	if c := o.Crop; c != nil {
+		if c.X < 0 || c.Y < 0 || c.Width <= 0 || c.Height <= 0 {
+			return fmt.Errorf("crop must have a size and start inside the frame")
+		}
+		if c.Width%2 != 0 || c.Height%2 != 0 {
+			return fmt.Errorf("crop size must be even, not %dx%d", c.Width, c.Height)
+		}
+		if width > 0 && height > 0 && (c.X+c.Width > width || c.Y+c.Height > height) {
+			return fmt.Errorf("crop %dx%d at %d,%d is outside the %dx%d frame", c.Width, c.Height, c.X, c.Y, width, height)
+		}
+	}
+	if !slices.Contains([]int{0, 90, 180, 270}, o.Rotate) {
+		return fmt.Errorf("rotation must be 0, 90, 180 or 270 degrees")
+	}
+	for _, n := range []int{o.Width, o.Height} {
+		if n < 0 || n > maxFilterSize || n%2 != 0 {
+			return fmt.Errorf("width and height must be even and at most %d pixels", maxFilterSize)
+		}
+	}
+	if o.PadAspect != "" {
+		if _, _, err := parseAspect(o.PadAspect); err != nil {
+			return err
+		}
+	}
+	if o.PadColor != "" && !isColorName(o.PadColor) {
+		return fmt.Errorf("invalid pad colour: %s", o.PadColor)
+	}
+	if o.FPS < 0 || o.FPS > 240 {
+		return fmt.Errorf("frame rate must be between 0 and 240")
+	}
+	return nil
+}
+
+// Filter returns the FFmpeg filter chain for the options, or "" when they
+// change nothing. Options must be valid.
+func (o *VideoFilterOptions) Filter() string {
+	var filters []string
+	if c := o.Crop; c != nil {
+		filters = append(filters, fmt.Sprintf("crop=%d:%d:%d:%d", c.Width, c.Height, c.X, c.Y))
+	}
+	switch o.Rotate {
+	case 90:
+		filters = append(filters, "transpose=clock")
+	case 180:
+		filters = append(filters, "hflip", "vflip")
+	case 270:
+		filters = append(filters, "transpose=cclock")
+	}
+	if o.FlipH {
+		filters = append(filters, "hflip")
+	}
+	if o.FlipV {
+		filters = append(filters, "vflip")
+	}
+	if o.Width > 0 || o.Height > 0 {
+		// -2 keeps the aspect ratio and an even size; setsar keeps players
+		// from stretching an explicit size back to the old shape.
+		w, h := o.Width, o.Height
+		if w == 0 {
+			w = -2
+		}
+		if h == 0 {
+			h = -2
+		}
+		filters = append(filters, fmt.Sprintf("scale=%d:%d", w, h), "setsar=1")
+	}
+	if o.PadAspect != "" {
+		num, den, _ := parseAspect(o.PadAspect) //nolint:errcheck // checked by Validate
+		color := o.PadColor
+		if color == "" {
+			color = "black"
+		}
+		filters = append(filters, fmt.Sprintf("pad=w='ceil(max(iw,ih*%d/%d)/2)*2':h='ceil(max(ih,iw*%d/%d)/2)*2':x=(ow-iw)/2:y=(oh-ih)/2:color=%s",
+			num, den, den, num, color))
+	}
+	if o.FPS > 0 {
+		filters = append(filters, "fps="+strconv.FormatFloat(o.FPS, 'f', -1, 64))
+	}
+	return strings.Join(filters, ",")
+}
+
+// parseAspect parses an aspect ratio written as "W:H".
+func parseAspect(s string) (int, int, error) {
+	w, h, ok := strings.Cut(s, ":")
+	num, err1 := strconv.Atoi(w)
+	den, err2 := strconv.Atoi(h)
+	if !ok || err1 != nil || err2 != nil || num <= 0 || den <= 0 || num > 100 || den > 100 {
+		return 0, 0, fmt.Errorf("aspect ratio must look like 16:9, not %q", s)
+	}
+	return num, den, nil
+}
+
+// isColorName reports whether s is safe to use as an FFmpeg colour: a name
+// or hex code, optionally with an @alpha suffix.
+func isColorName(s string) bool {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '#' || r == '@' || r == '.') {
+			return false
+		}
+	}
+	return len(s) <= 32
+}
+
+// ConversionOptions are the optional settings of a preset conversion.
+type ConversionOptions struct {
+	Params       map[string]string   `json:"params,omitempty"`       // Values of the preset's parameters by name; others use their defaults
+	Streams      []int               `json:"streams,omitempty"`      // Input stream indexes to keep, in output order; none keeps FFmpeg's default choice
+	Trim         *TrimOptions        `json:"trim,omitempty"`         // Range of the input to convert
+	VideoFilters *VideoFilterOptions `json:"videoFilters,omitempty"` // Applied ahead of the preset's own video filters
+}
+
+// Codecs for target-size encodes.
+const (
+	TargetCodecH264 = "h264" // x264 in MP4 with AAC audio
+	TargetCodecVP9  = "vp9"  // VP9 in WebM with Opus audio
+)
+
+// DefaultTargetAudioBitrate is the audio bitrate of target-size encodes in
+// kbps unless one is given.
+const DefaultTargetAudioBitrate = 128
+
+// TargetSizeOptions asks for a two-pass encode sized to fit in TargetBytes.
+// The video bitrate is whatever the duration leaves after the audio.
+type TargetSizeOptions struct {
+	TargetBytes      int64  `json:"targetBytes"`
+	VideoCodec       string `json:"videoCodec,omitempty"`       // TargetCodecH264 (default) or TargetCodecVP9
+	AudioBitrateKbps int    `json:"audioBitrateKbps,omitempty"` // Default DefaultTargetAudioBitrate
+}
+
+// Validate fills in defaults and checks the target and codec.
+func (o *TargetSizeOptions) Validate() error {
+	if o.TargetBytes <= 0 {
+		return fmt.Errorf("target size must be positive")
+	}
+	if o.VideoCodec == "" {
+		o.VideoCodec = TargetCodecH264
+	}
+	if o.VideoCodec != TargetCodecH264 && o.VideoCodec != TargetCodecVP9 {
+		return fmt.Errorf("unsupported codec for a target size: %s", o.VideoCodec)
+	}
+	if o.AudioBitrateKbps == 0 {
+		o.AudioBitrateKbps = DefaultTargetAudioBitrate
+	}
+	if o.AudioBitrateKbps < 32 || o.AudioBitrateKbps > 512 {
+		return fmt.Errorf("audio bitrate must be between 32 and 512 kbps")
+	}
+	return nil
+}
+
+// OutputExt returns the container extension for the codec.
+func (o *TargetSizeOptions) OutputExt() string {
+	if o.VideoCodec == TargetCodecVP9 {
+		return "webm"
+	}
+	return "mp4"
+}
+
+// TargetSizeResult reports the bitrates chosen for a target-size encode and,
+// once it completes, how close the output came to the target.
+type TargetSizeResult struct {
+	TargetBytes      int64   `json:"targetBytes"`
+	VideoBitrateKbps int     `json:"videoBitrateKbps"` // Per video stream
+	AudioBitrateKbps int     `json:"audioBitrateKbps"` // Per audio stream; 0 when the output has no audio
+	OutputBytes      int64   `json:"outputBytes,omitempty"`
+	Deviation        float64 `json:"deviation,omitempty"` // Output size above (+) or below (-) the target, in percent
+}
+
+// Animation output formats.
+const (
+	AnimationGIF  = "gif"
+	AnimationWebP = "webp"
+)
+
+// AnimationDithers are the paletteuse dithering modes offered for GIFs.
+var AnimationDithers = []string{"sierra2_4a", "floyd_steinberg", "sierra2", "bayer", "heckbert", "none"}
+
+// AnimationOptions describes a GIF or animated WebP encode. GIFs are made
+// in two steps, generating a palette for the clip and then encoding with
+// it.
+type AnimationOptions struct {
+	Format  string `json:"format,omitempty"`  // AnimationGIF (default) or AnimationWebP
+	FPS     int    `json:"fps,omitempty"`     // Default 15
+	Width   int    `json:"width,omitempty"`   // Pixels; 0 keeps the source width
+	Loop    int    `json:"loop"`              // Extra plays after the first: 0 loops forever, -1 plays once
+	Dither  string `json:"dither,omitempty"`  // GIF only; one of AnimationDithers, default sierra2_4a
+	Quality int    `json:"quality,omitempty"` // WebP only, 1-100; default 75
+}
+
+// Validate fills in defaults and checks the ranges.
+func (o *AnimationOptions) Validate() error {
+	if o.Format == "" {
+		o.Format = AnimationGIF
+	}
+	if o.Format != AnimationGIF && o.Format != AnimationWebP {
+		return fmt.Errorf("unsupported animation format: %s", o.Format)
+	}
+	if o.FPS == 0 {
+		o.FPS = 15
+	}
+	if o.FPS < 1 || o.FPS > 50 {
+		return fmt.Errorf("frame rate must be between 1 and 50")
+	}
+	if o.Width != 0 && (o.Width < 16 || o.Width > 3840) {
+		return fmt.Errorf("width must be between 16 and 3840 pixels")
+	}
+	if o.Loop < -1 {
+		return fmt.Errorf("invalid loop count: %d", o.Loop)
+	}
+	if o.Dither == "" {
+		o.Dither = AnimationDithers[0]
+	}
+	if !slices.Contains(AnimationDithers, o.Dither) {
+		return fmt.Errorf("unknown dithering mode: %s", o.Dither)
+	}
+	if o.Quality == 0 {
+		o.Quality = 75
+	}
+	if o.Quality < 1 || o.Quality > 100 {
+		return fmt.Errorf("quality must be between 1 and 100")
+	}
+	return nil
+}
+
+// ConcatOptions describes a job joining several inputs, in order. Inputs
+// with matching streams are stream-copied; others are re-encoded with the
+// job's preset.
+type ConcatOptions struct {
+	InputPaths []string `json:"inputPaths"`
+	Crossfade  float64  `json:"crossfade,omitempty"` // Seconds of audio crossfade between inputs; joins audio only and needs a preset
+	Reencode   bool     `json:"reencode"`            // Set once the inputs are analysed
+	Reason     string   `json:"reason,omitempty"`    // Why the inputs are re-encoded rather than stream-copied
+}
+
 // ConversionJob represents a single file conversion job.
 type ConversionJob struct {
-	ID          string          `json:"id"`
-	InputPath   string          `json:"inputPath"`
-	OutputPath  string          `json:"outputPath"`
-	PresetID    string          `json:"presetId,omitempty"`
-	CustomArgs  []string        `json:"customArgs,omitempty"`
-	TrimOptions *TrimOptions    `json:"trimOptions,omitempty"`
-	State       ConversionState `json:"state"`
-	Progress    float64         `json:"progress"`
-	Duration    float64         `json:"duration,omitempty"` // Total duration in seconds
-	CurrentTime float64         `json:"currentTime,omitempty"`
-	Error       string          `json:"error,omitempty"`
-	InputInfo   *MediaInfo      `json:"inputInfo,omitempty"`
+	ID             string              `json:"id"`
+	InputPath      string              `json:"inputPath"`
+	OutputPath     string              `json:"outputPath"`
+	PresetID       string              `json:"presetId,omitempty"`
+	Params         map[string]string   `json:"params,omitempty"` // Resolved values of the preset's parameters
+	CustomArgs     []string            `json:"customArgs,omitempty"`
+	TrimOptions    *TrimOptions        `json:"trimOptions,omitempty"`
+	VideoFilters   *VideoFilterOptions `json:"videoFilters,omitempty"`
+	Streams        []int               `json:"streams,omitempty"`   // Input stream indexes to keep, in output order; empty keeps FFmpeg's default choice
+	Concat         *ConcatOptions      `json:"concat,omitempty"`    // Set for jobs joining several inputs; InputPath is the first
+	Animation      *AnimationOptions   `json:"animation,omitempty"` // Set for GIF and animated WebP jobs
+	Priority       int                 `json:"priority"`            // Queued jobs with a higher priority start first
+	BatchID        string              `json:"batchId,omitempty"`   // Set for jobs created by a batch conversion
+	TargetSize     *TargetSizeOptions  `json:"targetSize,omitempty"`
+	Pass           int                 `json:"pass,omitempty"`           // Current pass of a two-pass encode or GIF (palette, then encode), 1 or 2
+	SizeResult     *TargetSizeResult   `json:"sizeResult,omitempty"`     // Set for target-size encodes once the bitrate is known
+	EstimatedBytes int64               `json:"estimatedBytes,omitempty"` // Animation size extrapolated from a sample before the full encode
+	InputBytes     int64               `json:"inputBytes,omitempty"`     // Size of the input, recorded when the job completes
+	OutputBytes    int64               `json:"outputBytes,omitempty"`    // Size of the output, recorded when the job completes
+	State          ConversionState     `json:"state"`
+	Progress       float64             `json:"progress"`
+	Duration       float64             `json:"duration,omitempty"` // Total duration in seconds
+	CurrentTime    float64             `json:"currentTime,omitempty"`
+	Error          string              `json:"error,omitempty"`
+	InputInfo      *MediaInfo          `json:"inputInfo,omitempty"`
 }
 
 // ConversionState represents the state of a conversion job.
@@ -45,23 +355,171 @@ const (
 	ConversionCancelled  ConversionState = "cancelled"
 )
 
+// IsFinished reports whether a job in this state will not run again.
+func (s ConversionState) IsFinished() bool {
+	return s == ConversionCompleted || s == ConversionFailed || s == ConversionCancelled
+}
+
+// ConversionQueueState describes the conversion queue.
+type ConversionQueueState struct {
+	Paused        bool `json:"paused"` // Queued jobs wait; running jobs finish
+	MaxConcurrent int  `json:"maxConcurrent"`
+	Running       int  `json:"running"`
+	Queued        int  `json:"queued"`
+}
+
+// ConversionBatch groups the jobs created by one batch conversion.
+type ConversionBatch struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	PresetID   string     `json:"presetId"`
+	OutputDir  string     `json:"outputDir,omitempty"` // Empty writes each output next to its input
+	JobIDs     []string   `json:"jobIds"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"` // Set once no job is queued or running
+}
+
+// ConversionBatchReport summarizes the jobs of a batch. Jobs removed from
+// the queue are no longer counted.
+type ConversionBatchReport struct {
+	BatchID     string                   `json:"batchId"`
+	Name        string                   `json:"name"`
+	Total       int                      `json:"total"`
+	Queued      int                      `json:"queued"`
+	Running     int                      `json:"running"`
+	Completed   int                      `json:"completed"`
+	Failed      int                      `json:"failed"`
+	Cancelled   int                      `json:"cancelled"`
+	Progress    float64                  `json:"progress"` // 0-100; finished jobs count as 100
+	Done        bool                     `json:"done"`
+	InputBytes  int64                    `json:"inputBytes"`  // Size of the completed jobs' inputs
+	OutputBytes int64                    `json:"outputBytes"` // Size of the completed jobs' outputs
+	Failures    []ConversionBatchFailure `json:"failures,omitempty"`
+	CreatedAt   time.Time                `json:"createdAt"`
+	FinishedAt  *time.Time               `json:"finishedAt,omitempty"`
+}
+
+// ConversionBatchFailure names a batch input that failed to convert.
+type ConversionBatchFailure struct {
+	JobID     string `json:"jobId"`
+	InputPath string `json:"inputPath"`
+	Error     string `json:"error"`
+}
+
 // MediaInfo contains information about a media file.
 type MediaInfo struct {
 	Duration    float64      `json:"duration"`
 	Format      string       `json:"format"`
 	Size        int64        `json:"size"`
 	Bitrate     int64        `json:"bitrate"`
-	VideoStream *VideoStream `json:"videoStream,omitempty"`
-	AudioStream *AudioStream `json:"audioStream,omitempty"`
+	VideoStream *VideoStream `json:"videoStream,omitempty"` // First video stream
+	AudioStream *AudioStream `json:"audioStream,omitempty"` // First audio stream
+
+	Streams  []MediaStream     `json:"streams"`
+	Tags     map[string]string `json:"tags,omitempty"` // Container tags, e.g. title or encoder
+	Chapters []Chapter         `json:"chapters,omitempty"`
+}
+
+// Stream types reported by ffprobe.
+const (
+	StreamVideo      = "video"
+	StreamAudio      = "audio"
+	StreamSubtitle   = "subtitle"
+	StreamData       = "data"
+	StreamAttachment = "attachment"
+)
+
+// MediaStream describes one stream of a media file.
+type MediaStream struct {
+	Index       int      `json:"index"`     // Index in the file, as used by -map 0:<index>
+	TypeIndex   int      `json:"typeIndex"` // Index among streams of the same type, e.g. the 2nd audio track is 1
+	Type        string   `json:"type"`
+	Codec       string   `json:"codec"`
+	Profile     string   `json:"profile,omitempty"`  // Codec profile, e.g. "High" or "LC"
+	Language    string   `json:"language,omitempty"` // ISO 639-2 code, e.g. "eng"
+	Title       string   `json:"title,omitempty"`
+	Default     bool     `json:"default"`
+	Disposition []string `json:"disposition,omitempty"` // Other set flags, e.g. "forced" or "hearing_impaired"
+
+	// Video
+	Width    int     `json:"width,omitempty"`
+	Height   int     `json:"height,omitempty"`
+	Rotation int     `json:"rotation,omitempty"` // As for VideoStream
+	FPS      float64 `json:"fps,omitempty"`
+	PixFmt   string  `json:"pixFmt,omitempty"` // e.g. "yuv420p"
+
+	// Audio
+	Channels      int    `json:"channels,omitempty"`
+	ChannelLayout string `json:"channelLayout,omitempty"`
+	SampleRate    int    `json:"sampleRate,omitempty"`
+
+	// Attachments, e.g. fonts in MKV files
+	Filename string `json:"filename,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+
+	Bitrate int64 `json:"bitrate,omitempty"`
+}
+
+// StreamMapArgs returns the -map arguments keeping only the streams with
+// the given indexes, in that order. No indexes leaves the choice to FFmpeg.
+func (m *MediaInfo) StreamMapArgs(indexes []int) ([]string, error) {
+	var args []string
+	for i, index := range indexes {
+		if slices.Contains(indexes[:i], index) {
+			return nil, fmt.Errorf("stream %d is selected twice", index)
+		}
+		if !slices.ContainsFunc(m.Streams, func(s MediaStream) bool { return s.Index == index }) {
+			return nil, fmt.Errorf("the input has no stream %d", index)
+		}
+		args = append(args, "-map", "0:"+strconv.Itoa(index))
+	}
+	return args, nil
+}
+
+// SelectedStreams returns how many streams of type typ an output keeps:
+// those in indexes, or the one FFmpeg picks by default when indexes is
+// empty.
+func (m *MediaInfo) SelectedStreams(typ string, indexes []int) int {
+	if len(indexes) == 0 {
+		if m.HasStream(typ, nil) {
+			return 1
+		}
+		return 0
+	}
+	n := 0
+	for _, s := range m.Streams {
+		if s.Type == typ && slices.Contains(indexes, s.Index) {
+			n++
+		}
+	}
+	return n
+}
+
+// HasStream reports whether a stream of type typ is in indexes, or in the
+// file when indexes is empty.
+func (m *MediaInfo) HasStream(typ string, indexes []int) bool {
+	return slices.ContainsFunc(m.Streams, func(s MediaStream) bool {
+		return s.Type == typ && (len(indexes) == 0 || slices.Contains(indexes, s.Index))
+	})
 }
 
 // VideoStream contains video stream information.
 type VideoStream struct {
-	Codec   string  `json:"codec"`
-	Width   int     `json:"width"`
-	Height  int     `json:"height"`
-	FPS     float64 `json:"fps"`
-	Bitrate int64   `json:"bitrate"`
+	Codec    string  `json:"codec"`
+	Width    int     `json:"width"`
+	Height   int     `json:"height"`
+	Rotation int     `json:"rotation,omitempty"` // Clockwise degrees players rotate the frames by: 0, 90, 180 or 270
+	FPS      float64 `json:"fps"`
+	Bitrate  int64   `json:"bitrate"`
+}
+
+// DisplaySize returns the size of the frames once rotated, which is what
+// FFmpeg's filters see since it applies the rotation first.
+func (v *VideoStream) DisplaySize() (int, int) {
+	if v.Rotation == 90 || v.Rotation == 270 {
+		return v.Height, v.Width
+	}
+	return v.Width, v.Height
 }
 
 // AudioStream contains audio stream information.
@@ -74,12 +532,14 @@ type AudioStream struct {
 
 // ConversionProgress represents progress update for a conversion.
 type ConversionProgress struct {
-	JobID       string          `json:"jobId"`
-	State       ConversionState `json:"state"`
-	Progress    float64         `json:"progress"`
-	CurrentTime float64         `json:"currentTime"`
-	Speed       float64         `json:"speed"` // Processing speed (e.g., 2.5x)
-	Error       string          `json:"error,omitempty"`
+	JobID          string          `json:"jobId"`
+	State          ConversionState `json:"state"`
+	Progress       float64         `json:"progress"`
+	CurrentTime    float64         `json:"currentTime"`
+	Speed          float64         `json:"speed"`                    // Processing speed (e.g., 2.5x)
+	Pass           int             `json:"pass,omitempty"`           // Pass of a two-pass encode; Progress covers both
+	EstimatedBytes int64           `json:"estimatedBytes,omitempty"` // Estimated animation size, once sampled
+	Error          string          `json:"error,omitempty"`
 }
 
 // GetDefaultPresets returns the built-in conversion presets.
@@ -100,7 +560,8 @@ func GetDefaultPresets() []ConversionPreset {
 			Description: "Convert to MP3 at 192kbps",
 			Category:    "audio",
 			OutputExt:   "mp3",
-			FFmpegArgs:  []string{"-vn", "-codec:a", "libmp3lame", "-b:a", "192k"},
+			FFmpegArgs:  []string{"-vn", "-codec:a", "libmp3lame", "-b:a", "{bitrate}k"},
+			Params:      []PresetParam{audioBitrateParam("192", "96", "128", "160", "192", "256", "320")},
 		},
 		{
 			ID:          "audio-mp3-128",
@@ -116,7 +577,8 @@ func GetDefaultPresets() []ConversionPreset {
 			Description: "Convert to AAC at 256kbps",
 			Category:    "audio",
 			OutputExt:   "m4a",
-			FFmpegArgs:  []string{"-vn", "-codec:a", "aac", "-b:a", "256k"},
+			FFmpegArgs:  []string{"-vn", "-codec:a", "aac", "-b:a", "{bitrate}k"},
+			Params:      []PresetParam{audioBitrateParam("256", "96", "128", "192", "256", "320")},
 		},
 		{
 			ID:          "audio-flac",
@@ -140,7 +602,11 @@ func GetDefaultPresets() []ConversionPreset {
 			Description: "Convert to OGG Vorbis at quality 6",
 			Category:    "audio",
 			OutputExt:   "ogg",
-			FFmpegArgs:  []string{"-vn", "-codec:a", "libvorbis", "-q:a", "6"},
+			FFmpegArgs:  []string{"-vn", "-codec:a", "libvorbis", "-q:a", "{quality}"},
+			Params: []PresetParam{{
+				Name: "quality", Label: "Quality", Type: PresetParamInt, Default: "6",
+				Min: float64Ptr(0), Max: float64Ptr(10), Step: 1, Help: "Higher is better and larger",
+			}},
 		},
 		// Video Presets
 		{
@@ -149,7 +615,8 @@ func GetDefaultPresets() []ConversionPreset {
 			Description: "H.264 video, widely compatible",
 			Category:    "video",
 			OutputExt:   "mp4",
-			FFmpegArgs:  []string{"-codec:v", "libx264", "-preset", "medium", "-crf", "23", "-codec:a", "aac", "-b:a", "128k"},
+			FFmpegArgs:  []string{"-vf", "scale=-2:{height}", "-codec:v", "libx264", "-preset", "{speed}", "-crf", "{crf}", "-codec:a", "aac", "-b:a", "{audioBitrate}k"},
+			Params:      []PresetParam{crfParam("23", 51), speedParam(), heightParam(), audioBitrateParam("128", "96", "128", "192", "256").named("audioBitrate", "Audio bitrate")},
 		},
 		{
 			ID:          "video-mp4-h264-hq",
@@ -165,7 +632,8 @@ func GetDefaultPresets() []ConversionPreset {
 			Description: "H.265/HEVC video, smaller file size",
 			Category:    "video",
 			OutputExt:   "mp4",
-			FFmpegArgs:  []string{"-codec:v", "libx265", "-preset", "medium", "-crf", "28", "-codec:a", "aac", "-b:a", "128k"},
+			FFmpegArgs:  []string{"-vf", "scale=-2:{height}", "-codec:v", "libx265", "-preset", "{speed}", "-crf", "{crf}", "-codec:a", "aac", "-b:a", "128k"},
+			Params:      []PresetParam{crfParam("28", 51), speedParam(), heightParam()},
 		},
 		{
 			ID:          "video-webm",
@@ -173,7 +641,8 @@ func GetDefaultPresets() []ConversionPreset {
 			Description: "VP9 video for web",
 			Category:    "video",
 			OutputExt:   "webm",
-			FFmpegArgs:  []string{"-codec:v", "libvpx-vp9", "-crf", "30", "-b:v", "0", "-codec:a", "libopus", "-b:a", "128k"},
+			FFmpegArgs:  []string{"-vf", "scale=-2:{height}", "-codec:v", "libvpx-vp9", "-crf", "{crf}", "-b:v", "0", "-codec:a", "libopus", "-b:a", "128k"},
+			Params:      []PresetParam{crfParam("30", 63), heightParam()},
 		},
 		{
 			ID:          "video-avi",
@@ -215,7 +684,11 @@ func GetDefaultPresets() []ConversionPreset {
 			Description: "Animated GIF, 15 FPS, max 480px",
 			Category:    "gif",
 			OutputExt:   "gif",
-			FFmpegArgs:  []string{"-vf", "fps=15,scale=480:-1:flags=lanczos,split[s0][s1];[s0]palettegen[p];[s1][p]paletteuse", "-loop", "0"},
+			FFmpegArgs:  []string{"-vf", "fps={fps},scale={width}:-1:flags=lanczos,split[s0][s1];[s0]palettegen[p];[s1][p]paletteuse", "-loop", "0"},
+			Params: []PresetParam{
+				{Name: "fps", Label: "Frame rate", Type: PresetParamInt, Default: "15", Min: float64Ptr(1), Max: float64Ptr(50), Step: 1, Unit: "fps"},
+				{Name: "width", Label: "Width", Type: PresetParamInt, Default: "480", Min: float64Ptr(16), Max: float64Ptr(3840), Step: 16, Unit: "px"},
+			},
 		},
 		{
 			ID:          "gif-small",
@@ -248,3 +721,57 @@ func GetDefaultPresets() []ConversionPreset {
 		},
 	}
 }
+
+func float64Ptr(f float64) *float64 { return &f }
+
+// crfParam is an x264/x265/VP9 constant rate factor up to maxCRF.
+func crfParam(def string, maxCRF float64) PresetParam {
+	return PresetParam{
+		Name: "crf", Label: "Quality (CRF)", Type: PresetParamInt, Default: def,
+		Min: float64Ptr(0), Max: float64Ptr(maxCRF), Step: 1,
+		Help: "Lower is better quality and a larger file",
+	}
+}
+
+// speedParam is an x264/x265 encoder preset.
+func speedParam() PresetParam {
+	p := PresetParam{Name: "speed", Label: "Encoding speed", Type: PresetParamEnum, Default: "medium",
+		Help: "Slower presets make smaller files at the same quality"}
+	for _, v := range []string{"ultrafast", "superfast", "veryfast", "faster", "fast", "medium", "slow", "slower", "veryslow"} {
+		p.Choices = append(p.Choices, PresetParamChoice{Value: v, Label: v})
+	}
+	return p
+}
+
+// heightParam scales to a height, keeping the aspect ratio; "ih" keeps the
+// source height.
+func heightParam() PresetParam {
+	return PresetParam{
+		Name: "height", Label: "Resolution", Type: PresetParamEnum, Default: "ih",
+		Choices: []PresetParamChoice{
+			{Value: "ih", Label: "Source"},
+			{Value: "2160", Label: "2160p"},
+			{Value: "1440", Label: "1440p"},
+			{Value: "1080", Label: "1080p"},
+			{Value: "720", Label: "720p"},
+			{Value: "540", Label: "540p"},
+			{Value: "480", Label: "480p"},
+			{Value: "360", Label: "360p"},
+		},
+	}
+}
+
+// audioBitrateParam offers the given bitrates in kbps.
+func audioBitrateParam(def string, kbps ...string) PresetParam {
+	p := PresetParam{Name: "bitrate", Label: "Bitrate", Type: PresetParamEnum, Default: def, Unit: "kbps"}
+	for _, v := range kbps {
+		p.Choices = append(p.Choices, PresetParamChoice{Value: v, Label: v + " kbps"})
+	}
+	return p
+}
+
+// named returns the parameter under another name and label.
+func (p PresetParam) named(name, label string) PresetParam {
+	p.Name, p.Label = name, label
+	return p
+}
@@ -1,6 +1,9 @@
 package core
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestGetDefaultPresets(t *testing.T) {
 	presets := GetDefaultPresets()
@@ -246,3 +249,201 @@ func TestConversionPreset_WithOptions(t *testing.T) {
 	}
 	t.Error("trim-copy preset not found")
 }
+
+func TestTrimOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		trim    TrimOptions
+		wantErr bool
+	}{
+		{"range", TrimOptions{StartTime: 90, EndTime: 210}, false},
+		{"to end", TrimOptions{StartTime: 90}, false},
+		{"from start", TrimOptions{EndTime: 30}, false},
+		{"negative start", TrimOptions{StartTime: -1, EndTime: 30}, true},
+		{"end before start", TrimOptions{StartTime: 30, EndTime: 10}, true},
+		{"empty range", TrimOptions{StartTime: 30, EndTime: 30}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.trim.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTrimOptions_Length(t *testing.T) {
+	tests := []struct {
+		trim  TrimOptions
+		total float64
+		want  float64
+	}{
+		{TrimOptions{StartTime: 90, EndTime: 210}, 0, 120},
+		{TrimOptions{StartTime: 90}, 600, 510},
+		{TrimOptions{StartTime: 90}, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.trim.Length(tt.total); got != tt.want {
+			t.Errorf("%+v.Length(%v) = %v, want %v", tt.trim, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestVideoFilterOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    VideoFilterOptions
+		wantErr bool
+	}{
+		{"empty", VideoFilterOptions{}, false},
+		{"crop inside", VideoFilterOptions{Crop: &CropRect{X: 100, Y: 60, Width: 1280, Height: 720}}, false},
+		{"crop outside", VideoFilterOptions{Crop: &CropRect{X: 800, Width: 1280, Height: 720}}, true},
+		{"odd crop", VideoFilterOptions{Crop: &CropRect{Width: 641, Height: 360}}, true},
+		{"negative crop", VideoFilterOptions{Crop: &CropRect{X: -1, Width: 640, Height: 360}}, true},
+		{"odd width", VideoFilterOptions{Width: 853}, true},
+		{"too tall", VideoFilterOptions{Height: 10000}, true},
+		{"bad rotation", VideoFilterOptions{Rotate: 45}, true},
+		{"aspect", VideoFilterOptions{PadAspect: "9:16", PadColor: "#202020"}, false},
+		{"bad aspect", VideoFilterOptions{PadAspect: "wide"}, true},
+		{"filter in colour", VideoFilterOptions{PadAspect: "1:1", PadColor: "black,drawtext=x"}, true},
+		{"frame rate", VideoFilterOptions{FPS: 29.97}, false},
+		{"frame rate too high", VideoFilterOptions{FPS: 1000}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(1920, 1080); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	outside := VideoFilterOptions{Crop: &CropRect{X: 800, Width: 1280, Height: 720}}
+	if err := outside.Validate(0, 0); err != nil {
+		t.Errorf("Validate() with an unknown size = %v, want the bounds check skipped", err)
+	}
+}
+
+func TestVideoFilterOptions_Filter(t *testing.T) {
+	tests := []struct {
+		name string
+		opts VideoFilterOptions
+		want string
+	}{
+		{"empty", VideoFilterOptions{}, ""},
+		{"crop and scale", VideoFilterOptions{Crop: &CropRect{X: 10, Y: 20, Width: 640, Height: 480}, Width: 320},
+			"crop=640:480:10:20,scale=320:-2,setsar=1"},
+		{"rotate and flip", VideoFilterOptions{Rotate: 270, FlipH: true}, "transpose=cclock,hflip"},
+		{"upside down", VideoFilterOptions{Rotate: 180}, "hflip,vflip"},
+		{"pad", VideoFilterOptions{Height: 720, PadAspect: "16:9"},
+			"scale=-2:720,setsar=1,pad=w='ceil(max(iw,ih*16/9)/2)*2':h='ceil(max(ih,iw*9/16)/2)*2':x=(ow-iw)/2:y=(oh-ih)/2:color=black"},
+		{"frame rate", VideoFilterOptions{Rotate: 90, FPS: 23.976}, "transpose=clock,fps=23.976"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.Filter(); got != tt.want {
+				t.Errorf("Filter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargetSizeOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      TargetSizeOptions
+		wantExt   string
+		wantAudio int
+		wantErr   bool
+	}{
+		{"defaults", TargetSizeOptions{TargetBytes: 25_000_000}, "mp4", DefaultTargetAudioBitrate, false},
+		{"vp9", TargetSizeOptions{TargetBytes: 8_000_000, VideoCodec: TargetCodecVP9, AudioBitrateKbps: 96}, "webm", 96, false},
+		{"no size", TargetSizeOptions{}, "", 0, true},
+		{"unknown codec", TargetSizeOptions{TargetBytes: 1, VideoCodec: "av1"}, "", 0, true},
+		{"audio too low", TargetSizeOptions{TargetBytes: 1, AudioBitrateKbps: 8}, "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := tt.opts.OutputExt(); got != tt.wantExt {
+				t.Errorf("OutputExt() = %q, want %q", got, tt.wantExt)
+			}
+			if tt.opts.AudioBitrateKbps != tt.wantAudio {
+				t.Errorf("AudioBitrateKbps = %d, want %d", tt.opts.AudioBitrateKbps, tt.wantAudio)
+			}
+		})
+	}
+}
+
+func TestAnimationOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    AnimationOptions
+		want    AnimationOptions
+		wantErr bool
+	}{
+		{"defaults", AnimationOptions{}, AnimationOptions{Format: AnimationGIF, FPS: 15, Dither: "sierra2_4a", Quality: 75}, false},
+		{"webp", AnimationOptions{Format: AnimationWebP, FPS: 24, Width: 480, Loop: -1, Quality: 60},
+			AnimationOptions{Format: AnimationWebP, FPS: 24, Width: 480, Loop: -1, Dither: "sierra2_4a", Quality: 60}, false},
+		{"unknown format", AnimationOptions{Format: "apng"}, AnimationOptions{}, true},
+		{"frame rate too high", AnimationOptions{FPS: 60}, AnimationOptions{}, true},
+		{"too narrow", AnimationOptions{Width: 8}, AnimationOptions{}, true},
+		{"bad loop", AnimationOptions{Loop: -2}, AnimationOptions{}, true},
+		{"unknown dither", AnimationOptions{Dither: "noise"}, AnimationOptions{}, true},
+		{"quality out of range", AnimationOptions{Quality: 101}, AnimationOptions{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && tt.opts != tt.want {
+				t.Errorf("Validate() left %+v, want %+v", tt.opts, tt.want)
+			}
+		})
+	}
+}
+
+func TestMediaInfo_StreamMapArgs(t *testing.T) {
+	info := &MediaInfo{Streams: []MediaStream{
+		{Index: 0, Type: StreamVideo},
+		{Index: 1, Type: StreamAudio, Language: "eng"},
+		{Index: 2, Type: StreamAudio, Language: "deu"},
+		{Index: 3, Type: StreamSubtitle},
+	}}
+	tests := []struct {
+		name    string
+		indexes []int
+		want    []string
+		wantErr bool
+	}{
+		{"default", nil, nil, false},
+		{"reordered", []int{0, 2, 1}, []string{"-map", "0:0", "-map", "0:2", "-map", "0:1"}, false},
+		{"missing", []int{0, 4}, nil, true},
+		{"twice", []int{1, 1}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := info.StreamMapArgs(tt.indexes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StreamMapArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("StreamMapArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if !info.HasStream(StreamSubtitle, nil) || info.HasStream(StreamSubtitle, []int{0, 1}) || !info.HasStream(StreamAudio, []int{2}) {
+		t.Error("HasStream() does not follow the selection")
+	}
+	if info.SelectedStreams(StreamAudio, nil) != 1 || info.SelectedStreams(StreamAudio, []int{0, 1, 2}) != 2 || info.SelectedStreams(StreamSubtitle, []int{0}) != 0 {
+		t.Error("SelectedStreams() does not count the streams FFmpeg keeps")
+	}
+}
@@ -17,6 +17,21 @@ var (
 	ErrInvalidFormat       = errors.New("invalid format")
 	ErrSavePathNotWritable = errors.New("save path is not writable")
 	ErrCancelled           = errors.New("operation cancelled")
+	ErrCookiesInvalid      = errors.New("cookies file is invalid")
+	ErrAuthRequired        = errors.New("sign-in required")
+	ErrUnsupportedSite     = errors.New("unsupported site")
+	ErrLiveNotStarted      = errors.New("live stream has not started")
+	ErrVideoPrivate        = errors.New("video is private")
+	ErrVideoRemoved        = errors.New("video was removed")
+	ErrGeoBlocked          = errors.New("video is not available in this country")
+	ErrAgeRestricted       = errors.New("video is age-restricted")
+	ErrRateLimited         = errors.New("rate limited")
+	ErrNetwork             = errors.New("network error")
+	ErrDiskFull            = errors.New("disk is full")
+	ErrYtDlpOutdated       = errors.New("yt-dlp is outdated")
+	ErrChecksumMismatch    = errors.New("checksum mismatch")
+	ErrInvalidYtDlpFlags   = errors.New("invalid yt-dlp flags")
+	ErrInvalidPreset       = errors.New("invalid conversion preset")
 )
 
 type AppError struct {
@@ -39,15 +54,81 @@ func NewAppError(code, message string, err error) *AppError {
 }
 
 const (
-	ErrCodeInvalidURL       = "INVALID_URL"
-	ErrCodeVideoNotFound    = "VIDEO_NOT_FOUND"
-	ErrCodeDownloadFailed   = "DOWNLOAD_FAILED"
-	ErrCodeConversionFailed = "CONVERSION_FAILED"
-	ErrCodeFFmpegMissing    = "FFMPEG_MISSING"
-	ErrCodeFFmpegNotFound   = "FFMPEG_NOT_FOUND"
-	ErrCodeQueueError       = "QUEUE_ERROR"
-	ErrCodeSettingsError    = "SETTINGS_ERROR"
-	ErrCodeFilesystemError  = "FILESYSTEM_ERROR"
-	ErrCodeYtDlpNotFound    = "YTDLP_NOT_FOUND"
-	ErrCodeGeneric          = "GENERIC_ERROR"
+	ErrCodeInvalidURL        = "INVALID_URL"
+	ErrCodeVideoNotFound     = "VIDEO_NOT_FOUND"
+	ErrCodeDownloadFailed    = "DOWNLOAD_FAILED"
+	ErrCodeConversionFailed  = "CONVERSION_FAILED"
+	ErrCodeFFmpegMissing     = "FFMPEG_MISSING"
+	ErrCodeFFmpegNotFound    = "FFMPEG_NOT_FOUND"
+	ErrCodeQueueError        = "QUEUE_ERROR"
+	ErrCodeSettingsError     = "SETTINGS_ERROR"
+	ErrCodeFilesystemError   = "FILESYSTEM_ERROR"
+	ErrCodeYtDlpNotFound     = "YTDLP_NOT_FOUND"
+	ErrCodeCookiesInvalid    = "COOKIES_INVALID"
+	ErrCodeAuthRequired      = "AUTH_REQUIRED"
+	ErrCodeUnsupportedSite   = "UNSUPPORTED_SITE"
+	ErrCodeLiveNotStarted    = "LIVE_NOT_STARTED"
+	ErrCodeVideoPrivate      = "VIDEO_PRIVATE"
+	ErrCodeVideoRemoved      = "VIDEO_REMOVED"
+	ErrCodeGeoBlocked        = "GEO_BLOCKED"
+	ErrCodeAgeRestricted     = "AGE_RESTRICTED"
+	ErrCodeRateLimited       = "RATE_LIMITED"
+	ErrCodeNetworkError      = "NETWORK_ERROR"
+	ErrCodeDiskFull          = "DISK_FULL"
+	ErrCodeYtDlpOutdated     = "YTDLP_OUTDATED"
+	ErrCodeChecksumMismatch  = "CHECKSUM_MISMATCH"
+	ErrCodeInvalidYtDlpFlags = "INVALID_YTDLP_FLAGS"
+	ErrCodeInvalidPreset     = "INVALID_PRESET"
+	ErrCodeGeneric           = "GENERIC_ERROR"
 )
+
+// errorHints suggest how to fix a failure, by error code.
+var errorHints = map[string]string{
+	ErrCodeVideoNotFound:     "Check that the URL is correct and the video still exists",
+	ErrCodeVideoPrivate:      "Ask the uploader for access, then import cookies from an account that can watch it",
+	ErrCodeVideoRemoved:      "The video cannot be downloaded anymore",
+	ErrCodeGeoBlocked:        "Use a proxy in a country where the video is available (Settings > Network)",
+	ErrCodeAgeRestricted:     "Import a cookies.txt file from a signed-in account in Settings",
+	ErrCodeRateLimited:       "Wait a few minutes before retrying, or lower the number of concurrent downloads",
+	ErrCodeNetworkError:      "Check your internet connection and proxy settings, then retry",
+	ErrCodeConversionFailed:  "Reinstall FFmpeg from Settings, or pick a different output format",
+	ErrCodeFFmpegNotFound:    "Install FFmpeg from Settings",
+	ErrCodeDiskFull:          "Free up disk space or choose another download folder",
+	ErrCodeYtDlpOutdated:     "Update yt-dlp from Settings",
+	ErrCodeYtDlpNotFound:     "Install yt-dlp from Settings",
+	ErrCodeChecksumMismatch:  "The download was corrupted or tampered with and was quarantined. Reinstall it from Settings",
+	ErrCodeInvalidYtDlpFlags: "Remove the listed flags from the extra yt-dlp flags in Settings",
+	ErrCodeInvalidPreset:     "Fix the FFmpeg arguments of the preset and save it again",
+	ErrCodeAuthRequired:      "Import a cookies.txt file from an account that can watch the video in Settings",
+	ErrCodeCookiesInvalid:    "Export a fresh cookies.txt from your browser and import it in Settings",
+	ErrCodeLiveNotStarted:    "Retry when the stream starts, or enable waiting for it in the item's live options",
+	ErrCodeUnsupportedSite:   "Add the site to the allowed sites in Settings, or check that the URL is correct",
+}
+
+// ErrorHint returns a remediation hint for an error code, or "".
+func ErrorHint(code string) string {
+	return errorHints[code]
+}
+
+// IsTransientError reports whether a failure with this code may succeed
+// when retried later without any change.
+func IsTransientError(code string) bool {
+	switch code {
+	case ErrCodeRateLimited, ErrCodeNetworkError, ErrCodeDownloadFailed, ErrCodeGeneric:
+		return true
+	}
+	return false
+}
+
+// AsAppError returns the *AppError in err's chain. Other errors are wrapped
+// with fallbackCode and their own text as the message.
+func AsAppError(err error, fallbackCode string) *AppError {
+	if err == nil {
+		return nil
+	}
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return &AppError{Code: fallbackCode, Message: err.Error(), Err: err}
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -110,3 +111,44 @@ func TestErrorCodes(t *testing.T) {
 		}
 	}
 }
+
+func TestAsAppError(t *testing.T) {
+	if AsAppError(nil, ErrCodeDownloadFailed) != nil {
+		t.Error("AsAppError(nil) should be nil")
+	}
+
+	typed := NewAppError(ErrCodeDiskFull, "disk full", nil)
+	if got := AsAppError(fmt.Errorf("wrapped: %w", typed), ErrCodeDownloadFailed); got != typed {
+		t.Errorf("AsAppError() = %v, want the wrapped AppError", got)
+	}
+
+	plain := errors.New("boom")
+	got := AsAppError(plain, ErrCodeDownloadFailed)
+	if got.Code != ErrCodeDownloadFailed || got.Message != "boom" || !errors.Is(got, plain) {
+		t.Errorf("AsAppError() = %+v, want fallback code with original message", got)
+	}
+}
+
+func TestErrorHint(t *testing.T) {
+	for _, code := range []string{
+		ErrCodeVideoPrivate, ErrCodeVideoRemoved, ErrCodeGeoBlocked, ErrCodeAgeRestricted,
+		ErrCodeRateLimited, ErrCodeNetworkError, ErrCodeConversionFailed, ErrCodeDiskFull, ErrCodeYtDlpOutdated,
+		ErrCodeAuthRequired, ErrCodeCookiesInvalid, ErrCodeLiveNotStarted, ErrCodeUnsupportedSite,
+	} {
+		if ErrorHint(code) == "" {
+			t.Errorf("ErrorHint(%q) is empty", code)
+		}
+	}
+	if ErrorHint("UNKNOWN") != "" {
+		t.Error("ErrorHint() should be empty for unknown codes")
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	if !IsTransientError(ErrCodeRateLimited) || !IsTransientError(ErrCodeNetworkError) {
+		t.Error("rate limits and network errors should be transient")
+	}
+	if IsTransientError(ErrCodeVideoPrivate) || IsTransientError(ErrCodeDiskFull) {
+		t.Error("private videos and full disks should not be transient")
+	}
+}
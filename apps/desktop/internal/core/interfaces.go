@@ -1,18 +1,60 @@
 package core
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Downloader interface {
 	FetchMetadata(ctx context.Context, url string) (*VideoMetadata, error)
 	Download(ctx context.Context, item *QueueItem, onProgress func(DownloadProgress)) error
 }
 
+// FormatLister is implemented by downloaders that can enumerate the streams
+// available for a URL.
+type FormatLister interface {
+	ListFormats(ctx context.Context, url string) ([]FormatInfo, error)
+}
+
+// RequestPacer is implemented by downloaders that can space out their own
+// requests to a site, e.g. with yt-dlp's --sleep-requests.
+type RequestPacer interface {
+	SetRequestDelay(delay time.Duration)
+}
+
+// RecordingStopper is implemented by downloaders that can end a live
+// recording early while keeping what has been captured so far.
+type RecordingStopper interface {
+	StopRecording(itemID string) error
+}
+
+// MetadataCacher is implemented by downloaders that cache metadata lookups
+// and can be told to refresh or forget them.
+type MetadataCacher interface {
+	RefreshMetadata(ctx context.Context, url string) (*VideoMetadata, error)
+	InvalidateMetadata(url string)
+	ClearMetadataCache() error
+}
+
+// BackendReporter is implemented by downloaders that route between backends
+// and track how each one performs.
+type BackendReporter interface {
+	BackendStats() []BackendStats
+}
+
 type SettingsStore interface {
 	Load() (*Settings, error)
 	Save(settings *Settings) error
 	Reset() error
 }
 
+// PresetStore persists user conversion presets.
+type PresetStore interface {
+	List() ([]ConversionPreset, error)
+	Save(presets ...ConversionPreset) error // Adds presets or replaces those with the same ID
+	Delete(id string) error
+}
+
 type FileSystem interface {
 	GetConfigDir() (string, error)
 	GetMusicDir() (string, error)
@@ -40,6 +82,11 @@ type QueueManager interface {
 	CancelItem(id string) error
 	CancelAll() error
 	RetryItem(id string) error
+	SetFormatID(id, formatID string) error
+	SetLiveOptions(id string, opts *LiveOptions) error
+	SetClip(id string, clip *TrimOptions) error
+	ThrottleState() ThrottleState
+	StopRecording(id string) error
 	ClearCompleted() error
 	FetchMetadata(ctx context.Context, id string) error
 	Shutdown()
@@ -49,12 +96,27 @@ type ConverterService interface {
 	GetPresets() []ConversionPreset
 	GetPresetsByCategory(category string) []ConversionPreset
 	GetPreset(id string) (*ConversionPreset, error)
+	SetUserPresets(presets []ConversionPreset)
+	ValidatePreset(ctx context.Context, preset ConversionPreset) error
 	AnalyzeFile(ctx context.Context, filePath string) (*MediaInfo, error)
 	StartConversion(id, inputPath, outputPath, presetID string, customArgs []string) (*ConversionJob, error)
 	StartConversionWithTrim(id, inputPath, outputPath, presetID string, customArgs []string, trim *TrimOptions) (*ConversionJob, error)
+	StartConversionWithParams(id, inputPath, outputPath, presetID string, opts ConversionOptions) (*ConversionJob, error)
+	StartTargetSizeConversion(id, inputPath, outputPath string, target TargetSizeOptions, opts ConversionOptions) (*ConversionJob, error)
+	StartConcatConversion(id string, inputPaths []string, outputPath, presetID string, crossfade float64) (*ConversionJob, error)
+	StartAnimationConversion(id, inputPath, outputPath string, opts AnimationOptions, trim *TrimOptions, filters *VideoFilterOptions) (*ConversionJob, error)
 	CancelConversion(id string) error
 	GetJob(id string) (*ConversionJob, error)
 	GetAllJobs() []*ConversionJob
+	SetJobPriority(id string, priority int) error
+	SetMaxConcurrent(n int)
+	PauseQueue()
+	ResumeQueue()
+	QueueState() ConversionQueueState
+	StartBatchConversion(id, name string, inputs []string, presetID, outputDir string) (*ConversionBatch, error)
+	GetBatchReport(id string) (*ConversionBatchReport, error)
+	GetBatchReports() []ConversionBatchReport
+	CancelBatch(id string) error
 	RemoveJob(id string) error
 	ClearCompletedJobs()
 	GenerateWaveform(ctx context.Context, filePath string, numSamples int) ([]float64, error)
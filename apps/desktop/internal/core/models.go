@@ -1,6 +1,10 @@
 package core
 
-import "time"
+import (
+	"slices"
+	"strings"
+	"time"
+)
 
 type DownloadState string
 
@@ -14,6 +18,12 @@ const (
 	StateFailed           DownloadState = "failed"
 	StateCancelRequested  DownloadState = "cancel_requested"
 	StateCancelled        DownloadState = "cancelled"
+	StateThrottled        DownloadState = "throttled" // Waiting out a rate-limit backoff before starting
+
+	// Live stream states
+	StateWaitingForLive DownloadState = "waiting_for_live" // Scheduled stream or premiere has not started
+	StateRecording      DownloadState = "recording"
+	StateStopRequested  DownloadState = "stop_requested" // Finishing a recording and keeping what was captured
 )
 
 func (s DownloadState) IsTerminal() bool {
@@ -21,7 +31,12 @@ func (s DownloadState) IsTerminal() bool {
 }
 
 func (s DownloadState) IsActive() bool {
-	return s == StateFetchingMetadata || s == StateDownloading || s == StateConverting || s == StateCancelRequested
+	switch s {
+	case StateFetchingMetadata, StateDownloading, StateConverting, StateCancelRequested, StateThrottled,
+		StateWaitingForLive, StateRecording, StateStopRequested:
+		return true
+	}
+	return false
 }
 
 type Format string
@@ -50,12 +65,151 @@ func (f Format) IsAudioOnly() bool {
 }
 
 type VideoMetadata struct {
-	ID          string  `json:"id"`
-	Title       string  `json:"title"`
-	Author      string  `json:"author"`
-	Duration    float64 `json:"duration"`
-	Thumbnail   string  `json:"thumbnail"`
-	Description string  `json:"description,omitempty"`
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Author      string     `json:"author"`
+	Duration    float64    `json:"duration"`
+	Thumbnail   string     `json:"thumbnail"`
+	Description string     `json:"description,omitempty"`
+	Site        string     `json:"site,omitempty"` // Extractor name, e.g. "youtube", "vimeo", "soundcloud"
+	LiveStatus  LiveStatus `json:"liveStatus,omitempty"`
+	// ScheduledStart is the Unix time an upcoming stream or premiere begins.
+	ScheduledStart int64 `json:"scheduledStart,omitempty"`
+
+	UploadDate   string           `json:"uploadDate,omitempty"` // YYYY-MM-DD
+	ChannelID    string           `json:"channelId,omitempty"`
+	ChannelURL   string           `json:"channelUrl,omitempty"`
+	ViewCount    int64            `json:"viewCount,omitempty"`
+	LikeCount    int64            `json:"likeCount,omitempty"`
+	Categories   []string         `json:"categories,omitempty"`
+	Tags         []string         `json:"tags,omitempty"`
+	Chapters     []Chapter        `json:"chapters,omitempty"`
+	Availability string           `json:"availability,omitempty"` // e.g. "public", "unlisted", "needs_auth"
+	Playlist     *PlaylistContext `json:"playlist,omitempty"`     // Set when the video was reached through a playlist
+	Formats      *FormatSummary   `json:"formats,omitempty"`
+}
+
+// Clone returns a deep copy, so cached metadata can be handed out safely.
+func (m *VideoMetadata) Clone() *VideoMetadata {
+	if m == nil {
+		return nil
+	}
+	c := *m
+	c.Categories = slices.Clone(m.Categories)
+	c.Tags = slices.Clone(m.Tags)
+	c.Chapters = slices.Clone(m.Chapters)
+	if m.Playlist != nil {
+		playlist := *m.Playlist
+		c.Playlist = &playlist
+	}
+	if m.Formats != nil {
+		formats := *m.Formats
+		formats.Heights = slices.Clone(m.Formats.Heights)
+		formats.VideoCodecs = slices.Clone(m.Formats.VideoCodecs)
+		formats.AudioCodecs = slices.Clone(m.Formats.AudioCodecs)
+		c.Formats = &formats
+	}
+	return &c
+}
+
+// Chapter is a titled section of a video, in seconds from the start.
+type Chapter struct {
+	Title     string  `json:"title"`
+	StartTime float64 `json:"startTime"`
+	EndTime   float64 `json:"endTime"`
+}
+
+// PlaylistContext describes the playlist a video belongs to.
+type PlaylistContext struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+	Index int    `json:"index,omitempty"` // 1-based position in the playlist
+	Count int    `json:"count,omitempty"`
+}
+
+// FormatSummary condenses the available formats for display and filtering.
+type FormatSummary struct {
+	Count           int      `json:"count"`
+	MaxHeight       int      `json:"maxHeight,omitempty"`
+	MaxFPS          float64  `json:"maxFps,omitempty"`
+	HDR             bool     `json:"hdr"`
+	Heights         []int    `json:"heights,omitempty"` // Distinct video heights, highest first
+	MaxAudioBitrate int64    `json:"maxAudioBitrate,omitempty"`
+	VideoCodecs     []string `json:"videoCodecs,omitempty"` // Codec families, e.g. "avc1", "vp9", "av01"
+	AudioCodecs     []string `json:"audioCodecs,omitempty"`
+}
+
+// SummarizeFormats builds a FormatSummary, or nil when there are no formats.
+func SummarizeFormats(formats []FormatInfo) *FormatSummary {
+	if len(formats) == 0 {
+		return nil
+	}
+	summary := &FormatSummary{Count: len(formats)}
+	for _, f := range formats {
+		if f.HasVideo {
+			summary.MaxHeight = max(summary.MaxHeight, f.Height)
+			summary.MaxFPS = max(summary.MaxFPS, f.FPS)
+			summary.HDR = summary.HDR || f.HDR
+			if f.Height > 0 && !slices.Contains(summary.Heights, f.Height) {
+				summary.Heights = append(summary.Heights, f.Height)
+			}
+			summary.VideoCodecs = appendCodecFamily(summary.VideoCodecs, f.VideoCodec)
+		}
+		if f.HasAudio {
+			if !f.HasVideo {
+				summary.MaxAudioBitrate = max(summary.MaxAudioBitrate, f.Bitrate)
+			}
+			summary.AudioCodecs = appendCodecFamily(summary.AudioCodecs, f.AudioCodec)
+		}
+	}
+	slices.SortFunc(summary.Heights, func(a, b int) int { return b - a })
+	return summary
+}
+
+// appendCodecFamily adds the part of codec before the first dot, so
+// "avc1.640028" and "avc1.4d401f" count once.
+func appendCodecFamily(families []string, codec string) []string {
+	family, _, _ := strings.Cut(codec, ".")
+	if family == "" || family == "none" || slices.Contains(families, family) {
+		return families
+	}
+	return append(families, family)
+}
+
+// LiveStatus distinguishes live and upcoming streams from regular videos.
+type LiveStatus string
+
+const (
+	LiveStatusNone     LiveStatus = ""
+	LiveStatusLive     LiveStatus = "is_live"
+	LiveStatusUpcoming LiveStatus = "is_upcoming"
+)
+
+// LiveOptions controls how a live stream or premiere is recorded.
+type LiveOptions struct {
+	FromStart          bool `json:"fromStart"`                    // Record from the beginning of the stream instead of from now
+	MaxDurationSeconds int  `json:"maxDurationSeconds,omitempty"` // Stop after this long; 0 records until the stream ends
+	WaitForStart       bool `json:"waitForStart"`                 // Wait for a scheduled stream to begin
+}
+
+// FormatInfo describes one downloadable stream of a video, normalized across
+// download backends so a specific one can be pinned on a queue item.
+type FormatInfo struct {
+	ID            string  `json:"id"`
+	Ext           string  `json:"ext"`
+	HasVideo      bool    `json:"hasVideo"`
+	HasAudio      bool    `json:"hasAudio"`
+	Width         int     `json:"width,omitempty"`
+	Height        int     `json:"height,omitempty"`
+	FPS           float64 `json:"fps,omitempty"`
+	VideoCodec    string  `json:"videoCodec,omitempty"`
+	AudioCodec    string  `json:"audioCodec,omitempty"`
+	Bitrate       int64   `json:"bitrate,omitempty"` // bits per second
+	FileSize      int64   `json:"fileSize,omitempty"`
+	SampleRate    int     `json:"sampleRate,omitempty"`
+	AudioChannels int     `json:"audioChannels,omitempty"`
+	HDR           bool    `json:"hdr"`
+	Note          string  `json:"note,omitempty"`
 }
 
 type DownloadProgress struct {
@@ -67,19 +221,43 @@ type DownloadProgress struct {
 	Speed           int64         `json:"speed"`
 	ETA             int64         `json:"eta"`
 	Error           string        `json:"error,omitempty"`
+	// ElapsedSeconds is the recorded duration so far; live recordings have
+	// no known total, so they report this and DownloadedBytes instead of Percent.
+	ElapsedSeconds float64 `json:"elapsedSeconds,omitempty"`
+	// Fragment and FragmentCount track fragmented (HLS/DASH) downloads.
+	Fragment      int `json:"fragment,omitempty"`
+	FragmentCount int `json:"fragmentCount,omitempty"`
+	// Stage names the post-processing step while converting, e.g. "Merger".
+	Stage string `json:"stage,omitempty"`
+}
+
+// ThrottleState describes how the queue is adapting after being rate limited.
+type ThrottleState struct {
+	Throttled           bool    `json:"throttled"`
+	Concurrency         int     `json:"concurrency"`            // Downloads currently allowed at once
+	MaxConcurrency      int     `json:"maxConcurrency"`         // Configured MaxConcurrentDownloads
+	BackoffUntil        int64   `json:"backoffUntil,omitempty"` // Unix time when the global pause ends
+	RequestDelaySeconds float64 `json:"requestDelaySeconds"`    // Gap enforced between metadata requests
+	RateLimitCount      int     `json:"rateLimitCount"`         // Rate limits since throttling began
 }
 
 type QueueItem struct {
-	ID        string         `json:"id"`
-	URL       string         `json:"url"`
-	State     DownloadState  `json:"state"`
-	Format    Format         `json:"format"`
-	Metadata  *VideoMetadata `json:"metadata,omitempty"`
-	SavePath  string         `json:"savePath"`
-	FilePath  string         `json:"filePath,omitempty"`
-	Error     string         `json:"error,omitempty"`
-	CreatedAt time.Time      `json:"createdAt"`
-	UpdatedAt time.Time      `json:"updatedAt"`
+	ID        string          `json:"id"`
+	URL       string          `json:"url"`
+	State     DownloadState   `json:"state"`
+	Format    Format          `json:"format"`
+	FormatID  string          `json:"formatId,omitempty"` // Pinned stream; bypasses automatic selection
+	Live      *LiveOptions    `json:"live,omitempty"`     // Set for live streams and premieres
+	Clip      *TrimOptions    `json:"clip,omitempty"`     // Download only this time range
+	Metadata  *VideoMetadata  `json:"metadata,omitempty"`
+	SavePath  string          `json:"savePath"`
+	FilePath  string          `json:"filePath,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	ErrorCode string          `json:"errorCode,omitempty"` // core.ErrCode* of the last failure
+	ErrorHint string          `json:"errorHint,omitempty"` // How the user can fix the last failure
+	Backend   DownloadBackend `json:"backend,omitempty"`   // Backend that completed the download
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
 }
 
 func NewQueueItem(id, url string, format Format, savePath string) *QueueItem {
@@ -102,6 +280,15 @@ const (
 	BackendYtDlp   DownloadBackend = "yt-dlp"
 )
 
+// BackendStats counts download outcomes for one backend since startup.
+type BackendStats struct {
+	Backend   DownloadBackend `json:"backend"`
+	Successes int             `json:"successes"`
+	Failures  int             `json:"failures"`
+	Fallbacks int             `json:"fallbacks"` // Successes after the other backend failed the item
+	LastError string          `json:"lastError,omitempty"`
+}
+
 type AudioQuality string
 
 const (
@@ -120,3 +307,13 @@ const (
 	VideoQuality1080p VideoQuality = "1080p"
 	VideoQualityBest  VideoQuality = "best"
 )
+
+// VideoCodec is the preferred video codec when several streams of the same
+// resolution are available.
+type VideoCodec string
+
+const (
+	VideoCodecH264 VideoCodec = "h264"
+	VideoCodecVP9  VideoCodec = "vp9"
+	VideoCodecAV1  VideoCodec = "av1"
+)
@@ -44,6 +44,7 @@ func TestDownloadState_IsActive(t *testing.T) {
 		{StateFailed, false},
 		{StateCancelRequested, true},
 		{StateCancelled, false},
+		{StateThrottled, true},
 	}
 
 	for _, tt := range tests {
@@ -231,3 +232,54 @@ func TestQueueItem_Fields(t *testing.T) {
 		t.Errorf("Metadata.Title = %q, want %q", item.Metadata.Title, "Video")
 	}
 }
+
+func TestSummarizeFormats(t *testing.T) {
+	if SummarizeFormats(nil) != nil {
+		t.Error("SummarizeFormats(nil) should be nil")
+	}
+
+	summary := SummarizeFormats([]FormatInfo{
+		{HasVideo: true, Height: 720, FPS: 30, VideoCodec: "avc1.4d401f"},
+		{HasVideo: true, Height: 2160, FPS: 60, VideoCodec: "vp09.00.51.08", HDR: true},
+		{HasVideo: true, Height: 720, FPS: 60, VideoCodec: "avc1.640028"},
+		{HasAudio: true, Bitrate: 128000, AudioCodec: "mp4a.40.2"},
+		{HasAudio: true, Bitrate: 160000, AudioCodec: "opus"},
+		{HasVideo: true, HasAudio: true, Height: 360, Bitrate: 500000, VideoCodec: "avc1.42001E", AudioCodec: "mp4a.40.2"},
+	})
+	if summary.Count != 6 || summary.MaxHeight != 2160 || summary.MaxFPS != 60 || !summary.HDR {
+		t.Errorf("summary = %+v", summary)
+	}
+	if len(summary.Heights) != 3 || summary.Heights[0] != 2160 || summary.Heights[2] != 360 {
+		t.Errorf("Heights = %v, want [2160 720 360]", summary.Heights)
+	}
+	if summary.MaxAudioBitrate != 160000 {
+		t.Errorf("MaxAudioBitrate = %d, want audio-only maximum", summary.MaxAudioBitrate)
+	}
+	if len(summary.VideoCodecs) != 2 || len(summary.AudioCodecs) != 2 {
+		t.Errorf("codecs = %v / %v", summary.VideoCodecs, summary.AudioCodecs)
+	}
+}
+
+func TestVideoMetadata_Clone(t *testing.T) {
+	var nilMeta *VideoMetadata
+	if nilMeta.Clone() != nil {
+		t.Error("Clone() of nil should be nil")
+	}
+
+	orig := &VideoMetadata{
+		Title:    "a",
+		Tags:     []string{"x"},
+		Chapters: []Chapter{{Title: "Intro"}},
+		Playlist: &PlaylistContext{ID: "PL1"},
+		Formats:  &FormatSummary{Heights: []int{720}},
+	}
+	c := orig.Clone()
+	c.Tags[0] = "y"
+	c.Chapters[0].Title = "Outro"
+	c.Playlist.ID = "PL2"
+	c.Formats.Heights[0] = 1080
+
+	if orig.Tags[0] != "x" || orig.Chapters[0].Title != "Intro" || orig.Playlist.ID != "PL1" || orig.Formats.Heights[0] != 720 {
+		t.Errorf("Clone() shares data with the original: %+v", orig)
+	}
+}
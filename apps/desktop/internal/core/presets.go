@@ -0,0 +1,427 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PresetFileVersion is the current version of the preset file format, used
+// both for the saved user presets and for exported files. Files from older
+// versions are migrated when read.
+const PresetFileVersion = 1
+
+// PresetFile is the on-disk form of a list of presets.
+type PresetFile struct {
+	Version    int                `json:"version"`
+	AppVersion string             `json:"appVersion,omitempty"` // App that wrote the file, for support requests
+	Presets    []ConversionPreset `json:"presets"`
+}
+
+// PresetSource tells where a preset comes from.
+type PresetSource string
+
+const (
+	PresetBuiltIn  PresetSource = "builtin"
+	PresetUser     PresetSource = "user"
+	PresetModified PresetSource = "modified" // A user preset replacing the built-in with the same ID
+)
+
+// CustomPresetCategory is used for user presets saved without a category.
+const CustomPresetCategory = "custom"
+
+// reservedPresetArgs are set by the converter for every job.
+var reservedPresetArgs = []string{"-i", "-y", "-n", "-progress"}
+
+// presetOptions are the FFmpeg output options a preset may use, by name
+// without stream specifier, and whether each takes a value. Presets are
+// shared as files, so anything else is refused: options that pick the
+// muxer, write or attach files, or read configuration from disk would
+// run with the user's rights once the preset is checked or used.
+var presetOptions = map[string]bool{
+	"vn": false, "an": false, "sn": false, "dn": false, "shortest": false,
+
+	"c": true, "codec": true, "vcodec": true, "acodec": true, "scodec": true,
+	"b": true, "ab": true, "q": true, "qscale": true, "crf": true, "cq": true, "qp": true,
+	"qmin": true, "qmax": true, "maxrate": true, "minrate": true, "bufsize": true,
+	"preset": true, "tune": true, "profile": true, "level": true, "pix_fmt": true,
+	"g": true, "bf": true, "keyint_min": true, "sc_threshold": true, "rc": true,
+	"r": true, "s": true, "aspect": true, "fps_mode": true, "vsync": true,
+	"ar": true, "ac": true, "sample_fmt": true, "channel_layout": true,
+	"compression_level": true, "lossless": true, "quality": true, "deadline": true,
+	"cpu-used": true, "row-mt": true, "tile-columns": true, "speed": true,
+	"vbr": true, "application": true, "cutoff": true,
+	"color_primaries": true, "color_trc": true, "colorspace": true, "color_range": true,
+	"vf": true, "af": true, "filter": true, "filter_complex": true, "lavfi": true,
+	"map": true, "map_metadata": true, "map_chapters": true, "metadata": true, "disposition": true,
+	"movflags": true, "tag": true, "strict": true, "threads": true,
+	"frames": true, "vframes": true, "aframes": true, "update": true, "loop": true,
+}
+
+// presetFilterOptions are the options in presetOptions whose value is a
+// filtergraph.
+var presetFilterOptions = []string{"vf", "af", "filter", "filter_complex", "lavfi"}
+
+// deniedFilters read or write files, load libraries or listen for commands,
+// so presets cannot use them.
+var deniedFilters = []string{
+	"movie", "amovie", "frei0r", "frei0r_src", "ladspa", "lv2", "sendcmd", "asendcmd", "zmq", "azmq",
+	"metadata", "ametadata", "signature", "vidstabdetect", "vidstabtransform", "psnr", "ssim",
+	"vmafmotion", "libvmaf", "lut1d", "lut3d", "subtitles", "ass", "arnndn", "sr", "derain",
+	"dnn_processing", "dnn_detect", "dnn_classify", "ocr", "lensfun",
+}
+
+// deniedFilterKeys are filter options that name a file to read or write.
+var deniedFilterKeys = []string{"file", "filename", "textfile", "stats_file", "log_path"}
+
+// checkPresetArgs checks that args only hold allowed options, each with its
+// value, and filtergraphs without denied filters. Values may still hold
+// {name} placeholders.
+func checkPresetArgs(args []string) error {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if slices.Contains(reservedPresetArgs, arg) {
+			return fmt.Errorf("%s is set by the converter", arg)
+		}
+		name, _, _ := strings.Cut(strings.TrimPrefix(arg, "-"), ":")
+		if !strings.HasPrefix(arg, "-") || name == "" {
+			return fmt.Errorf("%q is not an option; the output path is set by the converter", arg)
+		}
+		value, ok := presetOptions[name]
+		if !ok {
+			return fmt.Errorf("%s is not an allowed option", arg)
+		}
+		if !value {
+			continue
+		}
+		if i+1 == len(args) {
+			return fmt.Errorf("%s needs a value", arg)
+		}
+		i++
+		if slices.Contains(presetFilterOptions, name) {
+			if err := checkFiltergraph(args[i]); err != nil {
+				return fmt.Errorf("%s: %w", arg, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkFiltergraph refuses graphs using deniedFilters or deniedFilterKeys.
+// Escaped separators split a filter into more parts than FFmpeg sees, which
+// can only refuse more.
+func checkFiltergraph(graph string) error {
+	for _, filter := range strings.FieldsFunc(graph, func(r rune) bool { return r == ',' || r == ';' }) {
+		// Drop the [label]s around the filter and any @instance name.
+		filter = strings.TrimSpace(filter)
+		for strings.HasPrefix(filter, "[") {
+			_, filter, _ = strings.Cut(filter, "]")
+			filter = strings.TrimSpace(filter)
+		}
+		name, opts, _ := strings.Cut(filter, "=")
+		name, _, _ = strings.Cut(strings.TrimSpace(name), "@")
+		if slices.Contains(deniedFilters, name) {
+			return fmt.Errorf("the %s filter is not allowed in presets", name)
+		}
+		for _, opt := range strings.Split(opts, ":") {
+			if key, _, ok := strings.Cut(opt, "="); ok && slices.Contains(deniedFilterKeys, strings.TrimSpace(key)) {
+				return fmt.Errorf("the %s option of %s is not allowed in presets", key, name)
+			}
+		}
+	}
+	return nil
+}
+
+var presetExtPattern = regexp.MustCompile(`^[a-z0-9]{1,8}$`)
+
+// Validate normalizes a user preset and checks what can be checked without
+// running FFmpeg: a name, a plain output extension, and FFmpeg arguments
+// that leave inputs and output to the converter and only use allowed
+// options, also with each choice of its enum parameters.
+func (p *ConversionPreset) Validate() error {
+	p.ID = strings.TrimSpace(p.ID)
+	p.Name = strings.TrimSpace(p.Name)
+	p.Category = strings.ToLower(strings.TrimSpace(p.Category))
+	p.OutputExt = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(p.OutputExt), "."))
+
+	if p.ID == "" {
+		return fmt.Errorf("preset has no ID")
+	}
+	if p.Name == "" {
+		return fmt.Errorf("preset %s has no name", p.ID)
+	}
+	if p.Category == "" {
+		p.Category = CustomPresetCategory
+	}
+	if p.OutputExt != "" && !presetExtPattern.MatchString(p.OutputExt) {
+		return fmt.Errorf("preset %q: invalid output extension %q", p.Name, p.OutputExt)
+	}
+	if len(p.FFmpegArgs) == 0 {
+		return fmt.Errorf("preset %q has no FFmpeg arguments", p.Name)
+	}
+	if err := checkPresetArgs(p.FFmpegArgs); err != nil {
+		return fmt.Errorf("preset %q: %w", p.Name, err)
+	}
+	if err := p.validateParams(); err != nil {
+		return err
+	}
+	for _, param := range p.Params {
+		for _, c := range param.Choices {
+			if _, err := p.ResolveArgs(map[string]string{param.Name: c.Value}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateParams checks the declared parameters and that every placeholder
+// in the arguments names one of them.
+func (p *ConversionPreset) validateParams() error {
+	declared := make(map[string]bool, len(p.Params))
+	for _, param := range p.Params {
+		if !paramNamePattern.MatchString(param.Name) {
+			return fmt.Errorf("preset %q: invalid parameter name %q", p.Name, param.Name)
+		}
+		if declared[param.Name] {
+			return fmt.Errorf("preset %q: parameter %s is declared twice", p.Name, param.Name)
+		}
+		declared[param.Name] = true
+		if err := param.validate(); err != nil {
+			return fmt.Errorf("preset %q: %w", p.Name, err)
+		}
+	}
+	for _, arg := range p.FFmpegArgs {
+		for _, name := range placeholders(arg) {
+			if !declared[name] {
+				return fmt.Errorf("preset %q: {%s} is not a declared parameter", p.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// ResolveParams checks values against the declared parameters and returns
+// the value of each, using defaults for those not given. Numbers are
+// normalized, e.g. "020" becomes "20".
+func (p *ConversionPreset) ResolveParams(values map[string]string) (map[string]string, error) {
+	for name := range values {
+		if !slices.ContainsFunc(p.Params, func(param PresetParam) bool { return param.Name == name }) {
+			return nil, fmt.Errorf("preset %q has no parameter %q", p.Name, name)
+		}
+	}
+	resolved := make(map[string]string, len(p.Params))
+	for _, param := range p.Params {
+		value, ok := values[param.Name]
+		if !ok {
+			value = param.Default
+		}
+		v, err := param.check(value)
+		if err != nil {
+			return nil, err
+		}
+		resolved[param.Name] = v
+	}
+	return resolved, nil
+}
+
+// ResolveArgs returns the FFmpeg arguments with each {name} placeholder
+// replaced by the parameter's value from values or its default.
+func (p *ConversionPreset) ResolveArgs(values map[string]string) ([]string, error) {
+	resolved, err := p.ResolveParams(values)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, len(p.FFmpegArgs))
+	for i, arg := range p.FFmpegArgs {
+		args[i] = substitute(arg, resolved)
+	}
+	if err := checkPresetArgs(args); err != nil {
+		return nil, fmt.Errorf("preset %q: %w", p.Name, err)
+	}
+	return args, nil
+}
+
+// PresetParamType is the type of a preset parameter's value.
+type PresetParamType string
+
+const (
+	PresetParamInt    PresetParamType = "int"
+	PresetParamNumber PresetParamType = "number"
+	PresetParamEnum   PresetParamType = "enum"
+)
+
+// PresetParam is an adjustable value of a preset, substituted for {name}
+// in its FFmpeg arguments, e.g. "-crf", "{crf}" or "scale=-2:{height}".
+// Values are passed as strings; Min, Max and Step apply to numbers and
+// Choices to enums.
+type PresetParam struct {
+	Name    string              `json:"name"`
+	Label   string              `json:"label"`
+	Type    PresetParamType     `json:"type"`
+	Default string              `json:"default"`
+	Min     *float64            `json:"min,omitempty"`
+	Max     *float64            `json:"max,omitempty"`
+	Step    float64             `json:"step,omitempty"`
+	Unit    string              `json:"unit,omitempty"` // Shown next to the value, e.g. "kbps"
+	Help    string              `json:"help,omitempty"`
+	Choices []PresetParamChoice `json:"choices,omitempty"`
+}
+
+// PresetParamChoice is one allowed value of an enum parameter.
+type PresetParamChoice struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+var (
+	paramNamePattern   = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+	placeholderPattern = regexp.MustCompile(`\{([A-Za-z][A-Za-z0-9_]*)\}`)
+)
+
+func (p PresetParam) validate() error {
+	switch p.Type {
+	case PresetParamInt, PresetParamNumber:
+		if p.Min != nil && p.Max != nil && *p.Min > *p.Max {
+			return fmt.Errorf("parameter %s: min is above max", p.Name)
+		}
+	case PresetParamEnum:
+		if len(p.Choices) == 0 {
+			return fmt.Errorf("parameter %s has no choices", p.Name)
+		}
+	default:
+		return fmt.Errorf("parameter %s: unknown type %q", p.Name, p.Type)
+	}
+	if _, err := p.check(p.Default); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+	return nil
+}
+
+// check validates value and returns it normalized.
+func (p PresetParam) check(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if p.Type == PresetParamEnum {
+		if !slices.ContainsFunc(p.Choices, func(c PresetParamChoice) bool { return c.Value == value }) {
+			return "", fmt.Errorf("%s must be one of %s", p.label(), strings.Join(p.choiceValues(), ", "))
+		}
+		return value, nil
+	}
+
+	var n float64
+	if p.Type == PresetParamInt {
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return "", fmt.Errorf("%s must be a whole number", p.label())
+		}
+		n = float64(i)
+	} else {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("%s must be a number", p.label())
+		}
+		n = f
+	}
+	if (p.Min != nil && n < *p.Min) || (p.Max != nil && n > *p.Max) {
+		return "", fmt.Errorf("%s must be between %s and %s", p.label(), formatBound(p.Min), formatBound(p.Max))
+	}
+	return strconv.FormatFloat(n, 'f', -1, 64), nil
+}
+
+func (p PresetParam) label() string {
+	if p.Label != "" {
+		return p.Label
+	}
+	return p.Name
+}
+
+func (p PresetParam) choiceValues() []string {
+	values := make([]string, len(p.Choices))
+	for i, c := range p.Choices {
+		values[i] = c.Value
+	}
+	return values
+}
+
+func formatBound(b *float64) string {
+	if b == nil {
+		return "any"
+	}
+	return strconv.FormatFloat(*b, 'f', -1, 64)
+}
+
+// placeholders returns the names of the {name} placeholders in arg. FFmpeg's
+// own %{...} expansions, as in drawtext, are not placeholders.
+func placeholders(arg string) []string {
+	var names []string
+	for _, m := range placeholderPattern.FindAllStringSubmatchIndex(arg, -1) {
+		if m[0] > 0 && arg[m[0]-1] == '%' {
+			continue
+		}
+		names = append(names, arg[m[2]:m[3]])
+	}
+	return names
+}
+
+func substitute(arg string, values map[string]string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range placeholderPattern.FindAllStringSubmatchIndex(arg, -1) {
+		value, ok := values[arg[m[2]:m[3]]]
+		if !ok || (m[0] > 0 && arg[m[0]-1] == '%') {
+			continue
+		}
+		b.WriteString(arg[last:m[0]])
+		b.WriteString(value)
+		last = m[1]
+	}
+	b.WriteString(arg[last:])
+	return b.String()
+}
+
+// IsBuiltInPreset reports whether id names a built-in preset.
+func IsBuiltInPreset(id string) bool {
+	return slices.ContainsFunc(GetDefaultPresets(), func(p ConversionPreset) bool { return p.ID == id })
+}
+
+// MergePresets returns the built-in presets, each replaced by the user
+// preset with the same ID if there is one, followed by the other user
+// presets. Every preset's Source is set.
+func MergePresets(builtIn, user []ConversionPreset) []ConversionPreset {
+	overrides := make(map[string]ConversionPreset, len(user))
+	for _, p := range user {
+		overrides[p.ID] = p
+	}
+
+	merged := make([]ConversionPreset, 0, len(builtIn)+len(user))
+	for _, p := range builtIn {
+		if o, ok := overrides[p.ID]; ok {
+			o.Source = PresetModified
+			merged = append(merged, o)
+			delete(overrides, p.ID)
+			continue
+		}
+		p.Source = PresetBuiltIn
+		merged = append(merged, p)
+	}
+	for _, p := range user {
+		if _, ok := overrides[p.ID]; ok {
+			p.Source = PresetUser
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
+
+// PresetCategories lists the categories used by presets in first-seen order.
+func PresetCategories(presets []ConversionPreset) []string {
+	var categories []string
+	for _, p := range presets {
+		if p.Category != "" && !slices.Contains(categories, p.Category) {
+			categories = append(categories, p.Category)
+		}
+	}
+	return categories
+}
@@ -0,0 +1,201 @@
+package core
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestConversionPreset_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		preset  ConversionPreset
+		wantErr bool
+	}{
+		{"valid", ConversionPreset{ID: "a", Name: "A", OutputExt: "mp3", FFmpegArgs: []string{"-vn"}}, false},
+		{"keeps container", ConversionPreset{ID: "a", Name: "A", FFmpegArgs: []string{"-c", "copy"}}, false},
+		{"no id", ConversionPreset{Name: "A", FFmpegArgs: []string{"-vn"}}, true},
+		{"no name", ConversionPreset{ID: "a", Name: "  ", FFmpegArgs: []string{"-vn"}}, true},
+		{"no args", ConversionPreset{ID: "a", Name: "A"}, true},
+		{"path in extension", ConversionPreset{ID: "a", Name: "A", OutputExt: "../mp3", FFmpegArgs: []string{"-vn"}}, true},
+		{"extra input", ConversionPreset{ID: "a", Name: "A", FFmpegArgs: []string{"-i", "other.mp4"}}, true},
+		{"progress", ConversionPreset{ID: "a", Name: "A", FFmpegArgs: []string{"-progress", "http://example.com"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.preset.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	p := ConversionPreset{ID: " a ", Name: " Voice ", Category: " Speech", OutputExt: ".OGG", FFmpegArgs: []string{"-vn"}}
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != "a" || p.Name != "Voice" || p.Category != "speech" || p.OutputExt != "ogg" {
+		t.Errorf("Validate() normalized to %+v", p)
+	}
+	p.Category = ""
+	_ = p.Validate()
+	if p.Category != CustomPresetCategory {
+		t.Errorf("Category = %q, want %q", p.Category, CustomPresetCategory)
+	}
+}
+
+func TestConversionPreset_Validate_Hostile(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		params []PresetParam
+	}{
+		{"second output", []string{"-c:a", "aac", "/home/user/.bashrc"}, nil},
+		{"output after flag", []string{"-vn", "evil.sh"}, nil},
+		{"muxer", []string{"-f", "tee", "-c", "copy"}, nil},
+		{"attachment", []string{"-attach", "/etc/passwd"}, nil},
+		{"dump attachment", []string{"-dump_attachment:t", "/tmp/evil"}, nil},
+		{"preset file", []string{"-fpre", "/tmp/evil.ffpreset"}, nil},
+		{"filter script", []string{"-filter_script:v", "/tmp/graph"}, nil},
+		{"pass log", []string{"-passlogfile", "/home/user/.profile"}, nil},
+		{"encoder params", []string{"-x264-params", "stats=/tmp/evil"}, nil},
+		{"missing value", []string{"-c:a"}, nil},
+		{"reads a file", []string{"-vf", "movie=/etc/passwd[m];[in][m]overlay"}, nil},
+		{"loads a library", []string{"-af", "ladspa=file=/tmp/evil.so"}, nil},
+		{"labelled filter", []string{"-filter_complex", "[0:v] frei0r@x=filter_name=/tmp/evil"}, nil},
+		{"text file", []string{"-vf", "scale=-2:720,drawtext=textfile=/etc/shadow"}, nil},
+		{"stats file", []string{"-lavfi", "[0:v][0:v]psnr=stats_file=/tmp/evil"}, nil},
+		{"placeholder option", []string{"{opt}", "x"}, []PresetParam{{Name: "opt", Type: PresetParamEnum, Default: "-vn", Choices: []PresetParamChoice{{Value: "-vn"}}}}},
+		{"hostile choice", []string{"-vf", "{graph}"}, []PresetParam{{Name: "graph", Type: PresetParamEnum, Default: "null",
+			Choices: []PresetParamChoice{{Value: "null"}, {Value: "movie=/etc/passwd"}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := ConversionPreset{ID: "a", Name: "A", FFmpegArgs: tt.args, Params: tt.params}
+			if err := p.Validate(); err == nil {
+				t.Errorf("Validate() accepted %q", tt.args)
+			}
+		})
+	}
+}
+
+func TestDefaultPresets_AllowedArgs(t *testing.T) {
+	for _, p := range GetDefaultPresets() {
+		if err := checkPresetArgs(p.FFmpegArgs); err != nil {
+			t.Errorf("built-in preset %s: %v", p.ID, err)
+		}
+		if _, err := p.ResolveArgs(nil); err != nil {
+			t.Errorf("built-in preset %s: %v", p.ID, err)
+		}
+	}
+}
+
+func TestMergePresets(t *testing.T) {
+	builtIn := []ConversionPreset{{ID: "mp3", Name: "MP3"}, {ID: "wav", Name: "WAV"}}
+	user := []ConversionPreset{{ID: "mine", Name: "Mine"}, {ID: "wav", Name: "WAV 24-bit"}}
+
+	merged := MergePresets(builtIn, user)
+	var got []string
+	for _, p := range merged {
+		got = append(got, p.Name+":"+string(p.Source))
+	}
+	want := []string{"MP3:builtin", "WAV 24-bit:modified", "Mine:user"}
+	if !slices.Equal(got, want) {
+		t.Errorf("MergePresets() = %v, want %v", got, want)
+	}
+	if builtIn[0].Source != "" || user[0].Source != "" {
+		t.Error("MergePresets() modified its inputs")
+	}
+}
+
+func TestPresetCategories(t *testing.T) {
+	got := PresetCategories(append(GetDefaultPresets(), ConversionPreset{Category: "speech"}, ConversionPreset{Category: "audio"}))
+	if got[0] != "audio" || got[len(got)-1] != "speech" || len(got) != len(slices.Compact(slices.Clone(got))) {
+		t.Errorf("PresetCategories() = %v", got)
+	}
+	if !IsBuiltInPreset("audio-mp3-192") || IsBuiltInPreset("user-1") {
+		t.Error("IsBuiltInPreset() misclassified a preset")
+	}
+}
+
+func TestDefaultPresets_Params(t *testing.T) {
+	for _, p := range GetDefaultPresets() {
+		if err := p.Validate(); err != nil {
+			t.Errorf("built-in preset %s: %v", p.ID, err)
+		}
+		args, err := p.ResolveArgs(nil)
+		if err != nil {
+			t.Errorf("built-in preset %s: ResolveArgs(nil) error = %v", p.ID, err)
+		}
+		for _, arg := range args {
+			if len(placeholders(arg)) > 0 {
+				t.Errorf("built-in preset %s: unresolved argument %q", p.ID, arg)
+			}
+		}
+	}
+}
+
+func TestConversionPreset_ValidateParams(t *testing.T) {
+	crf := PresetParam{Name: "crf", Type: PresetParamInt, Default: "23", Min: float64Ptr(0), Max: float64Ptr(51)}
+	tests := []struct {
+		name    string
+		args    []string
+		params  []PresetParam
+		wantErr bool
+	}{
+		{"declared", []string{"-crf", "{crf}"}, []PresetParam{crf}, false},
+		{"ffmpeg expansion", []string{"-vf", "drawtext=text='%{pts}'"}, nil, false},
+		{"undeclared", []string{"-crf", "{quality}"}, []PresetParam{crf}, true},
+		{"duplicate", []string{"-crf", "{crf}"}, []PresetParam{crf, crf}, true},
+		{"bad name", []string{"-vn"}, []PresetParam{{Name: "a b", Type: PresetParamInt, Default: "1"}}, true},
+		{"unknown type", []string{"-vn"}, []PresetParam{{Name: "x", Type: "color", Default: "red"}}, true},
+		{"default out of range", []string{"-vn"}, []PresetParam{{Name: "x", Type: PresetParamInt, Default: "99", Max: float64Ptr(51)}}, true},
+		{"min above max", []string{"-vn"}, []PresetParam{{Name: "x", Type: PresetParamNumber, Default: "1", Min: float64Ptr(2), Max: float64Ptr(1)}}, true},
+		{"enum without choices", []string{"-vn"}, []PresetParam{{Name: "x", Type: PresetParamEnum}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := ConversionPreset{ID: "a", Name: "A", FFmpegArgs: tt.args, Params: tt.params}
+			if err := p.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConversionPreset_ResolveArgs(t *testing.T) {
+	p := ConversionPreset{
+		ID: "a", Name: "A",
+		FFmpegArgs: []string{"-vf", "scale=-2:{height},drawtext=text='%{pts}'", "-crf", "{crf}", "-b:a", "{bitrate}k", "-af", "volume={gain}"},
+		Params: []PresetParam{
+			{Name: "crf", Type: PresetParamInt, Default: "23", Min: float64Ptr(0), Max: float64Ptr(51)},
+			{Name: "height", Type: PresetParamEnum, Default: "ih", Choices: []PresetParamChoice{{Value: "ih"}, {Value: "720"}}},
+			{Name: "bitrate", Type: PresetParamEnum, Default: "128", Choices: []PresetParamChoice{{Value: "128"}, {Value: "192"}}},
+			{Name: "gain", Type: PresetParamNumber, Default: "1", Min: float64Ptr(0), Max: float64Ptr(4)},
+		},
+	}
+	tests := []struct {
+		name    string
+		values  map[string]string
+		want    []string
+		wantErr bool
+	}{
+		{"defaults", nil, []string{"-vf", "scale=-2:ih,drawtext=text='%{pts}'", "-crf", "23", "-b:a", "128k", "-af", "volume=1"}, false},
+		{"values", map[string]string{"crf": " 018 ", "height": "720", "bitrate": "192", "gain": "1.50"}, []string{"-vf", "scale=-2:720,drawtext=text='%{pts}'", "-crf", "18", "-b:a", "192k", "-af", "volume=1.5"}, false},
+		{"out of range", map[string]string{"crf": "60"}, nil, true},
+		{"not a whole number", map[string]string{"crf": "20.5"}, nil, true},
+		{"not a number", map[string]string{"gain": "loud"}, nil, true},
+		{"not a choice", map[string]string{"height": "1080"}, nil, true},
+		{"unknown parameter", map[string]string{"fps": "30"}, nil, true},
+		{"injection", map[string]string{"height": "720,split"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.ResolveArgs(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ResolveArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,12 @@
 package core
 
-const SettingsVersion = 3
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const SettingsVersion = 6
 
 type UpdateChannel string
 
@@ -9,39 +15,118 @@ const (
 	UpdateChannelBeta   UpdateChannel = "beta"
 )
 
+// MaxConcurrentConversionsLimit caps parallel FFmpeg processes; each can
+// use several cores on its own.
+const MaxConcurrentConversionsLimit = 8
+
 type Settings struct {
-	Version                int             `json:"version"`
-	DefaultSavePath        string          `json:"defaultSavePath"`
-	DefaultFormat          Format          `json:"defaultFormat"`
-	DefaultAudioQuality    AudioQuality    `json:"defaultAudioQuality"`
-	DefaultVideoQuality    VideoQuality    `json:"defaultVideoQuality"`
-	MaxConcurrentDownloads int             `json:"maxConcurrentDownloads"`
-	FFmpegPath             string          `json:"ffmpegPath,omitempty"`
-	FFprobePath            string          `json:"ffprobePath,omitempty"`
-	DownloadBackend        DownloadBackend `json:"downloadBackend"`
-	YtDlpPath              string          `json:"ytDlpPath,omitempty"`
-	YtDlpExtraFlags        []string        `json:"ytDlpExtraFlags,omitempty"`
-	Language               string          `json:"language,omitempty"`
-	ThemeMode              string          `json:"themeMode,omitempty"`
-	AccentColor            string          `json:"accentColor,omitempty"`
-	LogLevel               string          `json:"logLevel,omitempty"`
-	UpdateChannel          UpdateChannel   `json:"updateChannel,omitempty"`
+	Version                  int             `json:"version"`
+	DefaultSavePath          string          `json:"defaultSavePath"`
+	DefaultFormat            Format          `json:"defaultFormat"`
+	DefaultAudioQuality      AudioQuality    `json:"defaultAudioQuality"`
+	DefaultVideoQuality      VideoQuality    `json:"defaultVideoQuality"`
+	PreferredVideoCodec      VideoCodec      `json:"preferredVideoCodec,omitempty"`
+	MaxConcurrentDownloads   int             `json:"maxConcurrentDownloads"`
+	MaxConcurrentConversions int             `json:"maxConcurrentConversions"`
+	FFmpegPath               string          `json:"ffmpegPath,omitempty"`
+	FFprobePath              string          `json:"ffprobePath,omitempty"`
+	DownloadBackend          DownloadBackend `json:"downloadBackend"`
+	BackendFallback          bool            `json:"backendFallback"` // Retry with the other backend when the configured one fails
+	YtDlpPath                string          `json:"ytDlpPath,omitempty"`
+	YtDlpExtraFlags          []string        `json:"ytDlpExtraFlags,omitempty"`
+	YtDlpVersion             string          `json:"ytDlpVersion,omitempty"`    // Pinned release, e.g. "2024.08.06"; empty follows the latest
+	YtDlpReleaseURL          string          `json:"ytDlpReleaseUrl,omitempty"` // Release source in GitHub's layout; empty uses yt-dlp's GitHub releases
+	ToolMirrorURL            string          `json:"toolMirrorUrl,omitempty"`   // Serves tool downloads as <mirror>/<host>/<path>; empty downloads directly
+	CookiesFile              string          `json:"cookiesFile,omitempty"`
+	AllowedSites             []string        `json:"allowedSites"` // Non-YouTube domains accepted for yt-dlp; "*" allows any
+	Network                  NetworkSettings `json:"network"`
+	Language                 string          `json:"language,omitempty"`
+	ThemeMode                string          `json:"themeMode,omitempty"`
+	AccentColor              string          `json:"accentColor,omitempty"`
+	LogLevel                 string          `json:"logLevel,omitempty"`
+	UpdateChannel            UpdateChannel   `json:"updateChannel,omitempty"`
+}
+
+// IPVersion restricts outbound connections to one IP family.
+type IPVersion string
+
+const (
+	IPVersionAny IPVersion = ""
+	IPVersion4   IPVersion = "ipv4"
+	IPVersion6   IPVersion = "ipv6"
+)
+
+// NetworkSettings configures every outbound request: the app's own HTTP
+// clients as well as yt-dlp.
+type NetworkSettings struct {
+	ProxyURL              string    `json:"proxyUrl,omitempty"` // http(s)://, socks5:// or socks5h://, credentials allowed
+	NoProxy               []string  `json:"noProxy,omitempty"`  // Hosts, domain suffixes or CIDRs that bypass the proxy
+	IPVersion             IPVersion `json:"ipVersion,omitempty"`
+	CABundlePath          string    `json:"caBundlePath,omitempty"` // PEM file trusted in addition to the system roots
+	ConnectTimeoutSeconds int       `json:"connectTimeoutSeconds,omitempty"`
+	ReadTimeoutSeconds    int       `json:"readTimeoutSeconds,omitempty"` // Longest wait for response headers or for more body data
+}
+
+// Validate normalizes the network settings and rejects an unusable proxy URL.
+func (n *NetworkSettings) Validate() error {
+	n.ProxyURL = strings.TrimSpace(n.ProxyURL)
+	if n.ProxyURL != "" {
+		u, err := url.Parse(n.ProxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("proxy URL has no host")
+		}
+	}
+
+	var noProxy []string
+	for _, h := range n.NoProxy {
+		if h = strings.TrimSpace(h); h != "" {
+			noProxy = append(noProxy, h)
+		}
+	}
+	n.NoProxy = noProxy
+
+	switch n.IPVersion {
+	case IPVersionAny, IPVersion4, IPVersion6:
+	default:
+		n.IPVersion = IPVersionAny
+	}
+	n.CABundlePath = strings.TrimSpace(n.CABundlePath)
+	n.ConnectTimeoutSeconds = max(n.ConnectTimeoutSeconds, 0)
+	n.ReadTimeoutSeconds = max(n.ReadTimeoutSeconds, 0)
+	return nil
+}
+
+// DefaultAllowedSites lists the non-YouTube sites accepted out of the box.
+func DefaultAllowedSites() []string {
+	return []string{"vimeo.com", "soundcloud.com", "bandcamp.com", "dailymotion.com", "twitch.tv"}
 }
 
 func DefaultSettings(musicDir string) *Settings {
 	return &Settings{
-		Version:                SettingsVersion,
-		DefaultSavePath:        musicDir,
-		DefaultFormat:          FormatMP3,
-		DefaultAudioQuality:    AudioQuality192,
-		DefaultVideoQuality:    VideoQuality720p,
-		MaxConcurrentDownloads: 2,
-		DownloadBackend:        BackendYtDlp,
-		Language:               "en",
-		ThemeMode:              "system",
-		AccentColor:            "purple",
-		LogLevel:               "info",
-		UpdateChannel:          UpdateChannelStable,
+		Version:                  SettingsVersion,
+		DefaultSavePath:          musicDir,
+		DefaultFormat:            FormatMP3,
+		DefaultAudioQuality:      AudioQuality192,
+		DefaultVideoQuality:      VideoQuality720p,
+		PreferredVideoCodec:      VideoCodecH264,
+		MaxConcurrentDownloads:   2,
+		MaxConcurrentConversions: 2,
+		DownloadBackend:          BackendYtDlp,
+		BackendFallback:          true,
+		Language:                 "en",
+		ThemeMode:                "system",
+		AccentColor:              "purple",
+		LogLevel:                 "info",
+		UpdateChannel:            UpdateChannelStable,
+		AllowedSites:             DefaultAllowedSites(),
 	}
 }
 
@@ -52,15 +137,60 @@ func (s *Settings) Validate() error {
 	if s.MaxConcurrentDownloads > 5 {
 		s.MaxConcurrentDownloads = 5
 	}
+	s.MaxConcurrentConversions = min(max(s.MaxConcurrentConversions, 1), MaxConcurrentConversionsLimit)
 	switch s.DownloadBackend {
 	case BackendBuiltin, BackendYtDlp:
 	default:
 		s.DownloadBackend = BackendYtDlp
 	}
+	switch s.PreferredVideoCodec {
+	case VideoCodecH264, VideoCodecVP9, VideoCodecAV1:
+	default:
+		s.PreferredVideoCodec = VideoCodecH264
+	}
 	switch s.UpdateChannel {
 	case UpdateChannelStable, UpdateChannelBeta:
 	default:
 		s.UpdateChannel = UpdateChannelStable
 	}
-	return nil
+	if err := s.validateYtDlpSource(); err != nil {
+		return err
+	}
+	if err := ValidateYtDlpFlags(s.YtDlpExtraFlags); err != nil {
+		return NewAppError(ErrCodeInvalidYtDlpFlags, "Some extra yt-dlp flags cannot be used", err)
+	}
+	return s.Network.Validate()
+}
+
+// validateYtDlpSource normalizes the pinned yt-dlp version and the release
+// and mirror URLs.
+// The version becomes a directory name, so path separators are rejected.
+func (s *Settings) validateYtDlpSource() error {
+	s.YtDlpVersion = strings.TrimSpace(s.YtDlpVersion)
+	if strings.ContainsAny(s.YtDlpVersion, `/\`) || strings.Contains(s.YtDlpVersion, "..") {
+		return fmt.Errorf("invalid yt-dlp version %q", s.YtDlpVersion)
+	}
+
+	var err error
+	if s.YtDlpReleaseURL, err = normalizeDownloadURL("yt-dlp release URL", s.YtDlpReleaseURL); err != nil {
+		return err
+	}
+	s.ToolMirrorURL, err = normalizeDownloadURL("tool mirror URL", s.ToolMirrorURL)
+	return err
+}
+
+// normalizeDownloadURL trims raw and checks that it is empty or an http(s) URL.
+func normalizeDownloadURL(label, raw string) (string, error) {
+	raw = strings.TrimRight(strings.TrimSpace(raw), "/")
+	if raw == "" {
+		return "", nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", label, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%s must be an http(s) URL", label)
+	}
+	return raw, nil
 }
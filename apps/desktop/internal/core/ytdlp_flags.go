@@ -0,0 +1,371 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// YtDlpFlagClass says whether a user-supplied yt-dlp flag may be passed on.
+type YtDlpFlagClass string
+
+const (
+	YtDlpFlagAllowed     YtDlpFlagClass = "allowed"
+	YtDlpFlagConflicting YtDlpFlagClass = "conflicting" // Overrides arguments the app sets or parses
+	YtDlpFlagDangerous   YtDlpFlagClass = "dangerous"   // Runs commands, loads code or replaces binaries
+	YtDlpFlagUnknown     YtDlpFlagClass = "unknown"     // Not a yt-dlp option, or missing its value
+)
+
+// YtDlpFlag is one parsed entry of Settings.YtDlpExtraFlags.
+type YtDlpFlag struct {
+	Index  int            `json:"index"` // Position of the flag in YtDlpExtraFlags
+	Flag   string         `json:"flag"`  // As written, without an inline =value
+	Name   string         `json:"name"`  // Canonical long name; empty when unknown
+	Value  string         `json:"value,omitempty"`
+	Class  YtDlpFlagClass `json:"class"`
+	Reason string         `json:"reason,omitempty"`
+}
+
+// YtDlpFlagsError lists the extra flags that cannot be used.
+type YtDlpFlagsError struct {
+	Flags []YtDlpFlag `json:"flags"`
+}
+
+func (e *YtDlpFlagsError) Error() string {
+	parts := make([]string, len(e.Flags))
+	for i, f := range e.Flags {
+		parts[i] = fmt.Sprintf("%s (%s: %s)", f.Flag, f.Class, f.Reason)
+	}
+	return "invalid yt-dlp flags: " + strings.Join(parts, ", ")
+}
+
+func (e *YtDlpFlagsError) Unwrap() error { return ErrInvalidYtDlpFlags }
+
+// ytDlpOption describes one yt-dlp option. names[0] is the canonical name.
+type ytDlpOption struct {
+	names  []string
+	value  bool
+	class  YtDlpFlagClass
+	reason string
+}
+
+const (
+	reasonOutput   = "the app sets the output location and parses the output"
+	reasonFormat   = "set by the chosen format and quality"
+	reasonSession  = "set from the cookies and network settings"
+	reasonManaged  = "set by the app for every download"
+	reasonNoDL     = "stops the download the app expects"
+	reasonExec     = "runs arbitrary commands"
+	reasonCode     = "loads code or configuration from outside the app"
+	reasonBinaries = "replaces binaries the app manages"
+)
+
+// ytDlpOptions is the part of yt-dlp's option table users are expected to
+// tweak, plus every option that conflicts with the app or is dangerous.
+// Anything else is reported as unknown.
+var ytDlpOptions = []ytDlpOption{
+	// General, network and geo-restriction
+	{names: []string{"--ignore-errors", "-i"}},
+	{names: []string{"--no-abort-on-error"}},
+	{names: []string{"--abort-on-error"}},
+	{names: []string{"--ignore-config"}},
+	{names: []string{"--no-config-locations"}},
+	{names: []string{"--compat-options"}, value: true},
+	{names: []string{"--mark-watched"}},
+	{names: []string{"--no-mark-watched"}},
+	{names: []string{"--source-address"}, value: true},
+	{names: []string{"--impersonate"}, value: true},
+	{names: []string{"--enable-file-urls"}, class: YtDlpFlagDangerous, reason: "lets downloads read local files"},
+	{names: []string{"--geo-verification-proxy"}, value: true},
+	{names: []string{"--xff"}, value: true},
+	{names: []string{"--geo-bypass"}},
+	{names: []string{"--no-geo-bypass"}},
+	{names: []string{"--geo-bypass-country"}, value: true},
+
+	// Video selection
+	{names: []string{"--match-filters"}, value: true},
+	{names: []string{"--no-match-filters"}},
+	{names: []string{"--break-match-filters"}, value: true},
+	{names: []string{"--min-filesize"}, value: true},
+	{names: []string{"--max-filesize"}, value: true},
+	{names: []string{"--date"}, value: true},
+	{names: []string{"--datebefore"}, value: true},
+	{names: []string{"--dateafter"}, value: true},
+	{names: []string{"--age-limit"}, value: true},
+
+	// Download
+	{names: []string{"--concurrent-fragments", "-N"}, value: true},
+	{names: []string{"--limit-rate", "-r"}, value: true},
+	{names: []string{"--throttled-rate"}, value: true},
+	{names: []string{"--retries", "-R"}, value: true},
+	{names: []string{"--file-access-retries"}, value: true},
+	{names: []string{"--fragment-retries"}, value: true},
+	{names: []string{"--retry-sleep"}, value: true},
+	{names: []string{"--skip-unavailable-fragments"}},
+	{names: []string{"--abort-on-unavailable-fragments"}},
+	{names: []string{"--keep-fragments"}},
+	{names: []string{"--buffer-size"}, value: true},
+	{names: []string{"--http-chunk-size"}, value: true},
+	{names: []string{"--hls-use-mpegts"}},
+	{names: []string{"--no-hls-use-mpegts"}},
+
+	// Filesystem
+	{names: []string{"--restrict-filenames"}},
+	{names: []string{"--trim-filenames"}, value: true},
+	{names: []string{"--no-part"}},
+	{names: []string{"--part"}},
+	{names: []string{"--no-mtime"}},
+	{names: []string{"--mtime"}},
+	{names: []string{"--write-description"}},
+	{names: []string{"--write-info-json"}},
+	{names: []string{"--cache-dir"}, value: true},
+	{names: []string{"--no-cache-dir"}},
+	{names: []string{"--rm-cache-dir"}},
+
+	// Thumbnails and subtitles
+	{names: []string{"--write-thumbnail"}},
+	{names: []string{"--write-all-thumbnails"}},
+	{names: []string{"--write-subs"}},
+	{names: []string{"--write-auto-subs"}},
+	{names: []string{"--sub-format"}, value: true},
+	{names: []string{"--sub-langs"}, value: true},
+
+	// Verbosity that keeps the output parseable
+	{names: []string{"--no-warnings"}},
+	{names: []string{"--verbose", "-v"}},
+	{names: []string{"--console-title"}},
+
+	// Workarounds and authentication
+	{names: []string{"--encoding"}, value: true},
+	{names: []string{"--legacy-server-connect"}},
+	{names: []string{"--no-check-certificates"}},
+	{names: []string{"--prefer-insecure"}},
+	{names: []string{"--add-headers"}, value: true},
+	{names: []string{"--sleep-interval", "--min-sleep-interval"}, value: true},
+	{names: []string{"--max-sleep-interval"}, value: true},
+	{names: []string{"--sleep-subtitles"}, value: true},
+	{names: []string{"--username", "-u"}, value: true},
+	{names: []string{"--password", "-p"}, value: true},
+	{names: []string{"--twofactor", "-2"}, value: true},
+	{names: []string{"--netrc"}},
+	{names: []string{"--netrc-location"}, value: true},
+	{names: []string{"--video-password"}, value: true},
+	{names: []string{"--client-certificate"}, value: true},
+	{names: []string{"--client-certificate-key"}, value: true},
+	{names: []string{"--client-certificate-password"}, value: true},
+	{names: []string{"--extractor-retries"}, value: true},
+	{names: []string{"--extractor-args"}, value: true},
+
+	// Post-processing
+	{names: []string{"--keep-video", "-k"}},
+	{names: []string{"--no-keep-video"}},
+	{names: []string{"--post-overwrites"}},
+	{names: []string{"--no-post-overwrites"}},
+	{names: []string{"--embed-subs"}},
+	{names: []string{"--no-embed-subs"}},
+	{names: []string{"--embed-thumbnail"}},
+	{names: []string{"--no-embed-thumbnail"}},
+	{names: []string{"--embed-metadata", "--add-metadata"}},
+	{names: []string{"--no-embed-metadata", "--no-add-metadata"}},
+	{names: []string{"--embed-chapters", "--add-chapters"}},
+	{names: []string{"--no-embed-chapters", "--no-add-chapters"}},
+	{names: []string{"--embed-info-json"}},
+	{names: []string{"--no-embed-info-json"}},
+	{names: []string{"--parse-metadata"}, value: true},
+	{names: []string{"--replace-in-metadata"}, value: true},
+	{names: []string{"--xattrs"}},
+	{names: []string{"--concat-playlist"}, value: true},
+	{names: []string{"--fixup"}, value: true},
+	{names: []string{"--convert-subs"}, value: true},
+	{names: []string{"--convert-thumbnails"}, value: true},
+	{names: []string{"--split-chapters"}},
+	{names: []string{"--no-split-chapters"}},
+	{names: []string{"--remove-chapters"}, value: true},
+	{names: []string{"--no-remove-chapters"}},
+	{names: []string{"--force-keyframes-at-cuts"}},
+	{names: []string{"--no-force-keyframes-at-cuts"}},
+	{names: []string{"--no-exec"}},
+	{names: []string{"--prefer-free-formats"}},
+	{names: []string{"--no-prefer-free-formats"}},
+	{names: []string{"--check-formats"}},
+	{names: []string{"--no-check-formats"}},
+
+	// SponsorBlock
+	{names: []string{"--sponsorblock-mark"}, value: true},
+	{names: []string{"--sponsorblock-remove"}, value: true},
+	{names: []string{"--sponsorblock-chapter-title"}, value: true},
+	{names: []string{"--no-sponsorblock"}},
+	{names: []string{"--sponsorblock-api"}, value: true},
+
+	// Output and progress, which the app parses
+	{names: []string{"--output", "-o"}, value: true, class: YtDlpFlagConflicting, reason: reasonOutput},
+	{names: []string{"--paths", "-P"}, value: true, class: YtDlpFlagConflicting, reason: reasonOutput},
+	{names: []string{"--output-na-placeholder"}, value: true, class: YtDlpFlagConflicting, reason: reasonOutput},
+	{names: []string{"--quiet", "-q"}, class: YtDlpFlagConflicting, reason: reasonOutput},
+	{names: []string{"--no-progress"}, class: YtDlpFlagConflicting, reason: reasonOutput},
+	{names: []string{"--progress"}, class: YtDlpFlagConflicting, reason: reasonOutput},
+	{names: []string{"--progress-template"}, value: true, class: YtDlpFlagConflicting, reason: reasonOutput},
+	{names: []string{"--progress-delta"}, value: true, class: YtDlpFlagConflicting, reason: reasonOutput},
+	{names: []string{"--newline"}, class: YtDlpFlagConflicting, reason: reasonOutput},
+	{names: []string{"--no-colors"}, class: YtDlpFlagConflicting, reason: reasonOutput},
+	{names: []string{"--color"}, value: true, class: YtDlpFlagConflicting, reason: reasonOutput},
+	{names: []string{"--print", "-O"}, value: true, class: YtDlpFlagConflicting, reason: reasonOutput},
+	{names: []string{"--print-json"}, class: YtDlpFlagConflicting, reason: reasonOutput},
+	{names: []string{"--dump-json", "-j"}, class: YtDlpFlagConflicting, reason: reasonOutput},
+	{names: []string{"--dump-single-json", "-J"}, class: YtDlpFlagConflicting, reason: reasonOutput},
+	{names: []string{"--no-overwrites", "-w"}, class: YtDlpFlagConflicting, reason: reasonManaged},
+	{names: []string{"--force-overwrites"}, class: YtDlpFlagConflicting, reason: reasonManaged},
+	{names: []string{"--windows-filenames"}, class: YtDlpFlagConflicting, reason: reasonManaged},
+	{names: []string{"--no-playlist"}, class: YtDlpFlagConflicting, reason: reasonManaged},
+	{names: []string{"--yes-playlist"}, class: YtDlpFlagConflicting, reason: reasonManaged},
+	{names: []string{"--flat-playlist"}, class: YtDlpFlagConflicting, reason: reasonNoDL},
+	{names: []string{"--simulate", "-s"}, class: YtDlpFlagConflicting, reason: reasonNoDL},
+	{names: []string{"--skip-download", "--no-download"}, class: YtDlpFlagConflicting, reason: reasonNoDL},
+	{names: []string{"--list-formats", "-F"}, class: YtDlpFlagConflicting, reason: reasonNoDL},
+	{names: []string{"--list-subs"}, class: YtDlpFlagConflicting, reason: reasonNoDL},
+	{names: []string{"--list-thumbnails"}, class: YtDlpFlagConflicting, reason: reasonNoDL},
+	{names: []string{"--version"}, class: YtDlpFlagConflicting, reason: reasonNoDL},
+	{names: []string{"--help", "-h"}, class: YtDlpFlagConflicting, reason: reasonNoDL},
+	{names: []string{"--batch-file", "-a"}, value: true, class: YtDlpFlagConflicting, reason: "adds URLs to every download"},
+	{names: []string{"--load-info-json"}, value: true, class: YtDlpFlagConflicting, reason: "replaces the URL being downloaded"},
+
+	// Format selection
+	{names: []string{"--format", "-f"}, value: true, class: YtDlpFlagConflicting, reason: reasonFormat},
+	{names: []string{"--format-sort", "-S"}, value: true, class: YtDlpFlagConflicting, reason: reasonFormat},
+	{names: []string{"--merge-output-format"}, value: true, class: YtDlpFlagConflicting, reason: reasonFormat},
+	{names: []string{"--extract-audio", "-x"}, class: YtDlpFlagConflicting, reason: reasonFormat},
+	{names: []string{"--audio-format"}, value: true, class: YtDlpFlagConflicting, reason: reasonFormat},
+	{names: []string{"--audio-quality"}, value: true, class: YtDlpFlagConflicting, reason: reasonFormat},
+	{names: []string{"--remux-video"}, value: true, class: YtDlpFlagConflicting, reason: reasonFormat},
+	{names: []string{"--recode-video"}, value: true, class: YtDlpFlagConflicting, reason: reasonFormat},
+	{names: []string{"--download-sections"}, value: true, class: YtDlpFlagConflicting, reason: "set by the clip range"},
+	{names: []string{"--live-from-start"}, class: YtDlpFlagConflicting, reason: "set by the live recording options"},
+	{names: []string{"--wait-for-video"}, value: true, class: YtDlpFlagConflicting, reason: "set by the live recording options"},
+
+	// Session, set from settings
+	{names: []string{"--cookies"}, value: true, class: YtDlpFlagConflicting, reason: reasonSession},
+	{names: []string{"--cookies-from-browser"}, value: true, class: YtDlpFlagConflicting, reason: reasonSession},
+	{names: []string{"--no-cookies"}, class: YtDlpFlagConflicting, reason: reasonSession},
+	{names: []string{"--proxy"}, value: true, class: YtDlpFlagConflicting, reason: reasonSession},
+	{names: []string{"--socket-timeout"}, value: true, class: YtDlpFlagConflicting, reason: reasonSession},
+	{names: []string{"--force-ipv4", "-4"}, class: YtDlpFlagConflicting, reason: reasonSession},
+	{names: []string{"--force-ipv6", "-6"}, class: YtDlpFlagConflicting, reason: reasonSession},
+	{names: []string{"--sleep-requests"}, value: true, class: YtDlpFlagConflicting, reason: "set while the queue is rate limited"},
+	{names: []string{"--js-runtimes"}, value: true, class: YtDlpFlagConflicting, reason: reasonManaged},
+
+	// Dangerous
+	{names: []string{"--exec"}, value: true, class: YtDlpFlagDangerous, reason: reasonExec},
+	{names: []string{"--exec-before-download"}, value: true, class: YtDlpFlagDangerous, reason: reasonExec},
+	{names: []string{"--netrc-cmd"}, value: true, class: YtDlpFlagDangerous, reason: reasonExec},
+	{names: []string{"--downloader", "--external-downloader"}, value: true, class: YtDlpFlagDangerous, reason: reasonExec},
+	{names: []string{"--downloader-args", "--external-downloader-args"}, value: true, class: YtDlpFlagDangerous, reason: reasonExec},
+	{names: []string{"--postprocessor-args", "--ppa"}, value: true, class: YtDlpFlagDangerous, reason: reasonExec},
+	{names: []string{"--ffmpeg-location"}, value: true, class: YtDlpFlagDangerous, reason: reasonExec},
+	{names: []string{"--use-postprocessor"}, value: true, class: YtDlpFlagDangerous, reason: reasonCode},
+	{names: []string{"--plugin-dirs"}, value: true, class: YtDlpFlagDangerous, reason: reasonCode},
+	{names: []string{"--config-locations"}, value: true, class: YtDlpFlagDangerous, reason: reasonCode},
+	{names: []string{"--print-to-file"}, value: true, class: YtDlpFlagDangerous, reason: "writes to arbitrary files"},
+	{names: []string{"--update", "-U"}, class: YtDlpFlagDangerous, reason: reasonBinaries},
+	{names: []string{"--update-to"}, value: true, class: YtDlpFlagDangerous, reason: reasonBinaries},
+}
+
+// ytDlpOptionIndex maps every option name and alias to its entry.
+var ytDlpOptionIndex = func() map[string]*ytDlpOption {
+	index := make(map[string]*ytDlpOption)
+	for i := range ytDlpOptions {
+		opt := &ytDlpOptions[i]
+		if opt.class == "" {
+			opt.class = YtDlpFlagAllowed
+		}
+		for _, name := range opt.names {
+			index[name] = opt
+		}
+	}
+	return index
+}()
+
+// ParseYtDlpFlags splits extra flags into options with their values and
+// classifies each one. Values may follow as the next element or inline as
+// --name=value, and short options also accept -rVALUE.
+func ParseYtDlpFlags(flags []string) []YtDlpFlag {
+	var parsed []YtDlpFlag
+	for i := 0; i < len(flags); i++ {
+		arg := strings.TrimSpace(flags[i])
+		if arg == "" {
+			continue
+		}
+		f := YtDlpFlag{Index: i, Flag: arg}
+
+		if !strings.HasPrefix(arg, "-") || arg == "-" || arg == "--" {
+			f.Class = YtDlpFlagConflicting
+			f.Reason = "extra URLs and stray values are not allowed"
+			parsed = append(parsed, f)
+			continue
+		}
+
+		name, inline, hasInline := strings.Cut(arg, "=")
+		opt := ytDlpOptionIndex[name]
+		if opt == nil && !strings.HasPrefix(arg, "--") && len(arg) > 2 {
+			// Short option with the value attached, e.g. -r50K
+			if short := ytDlpOptionIndex[arg[:2]]; short != nil && short.value {
+				opt, name, inline, hasInline = short, arg[:2], arg[2:], true
+			}
+		}
+		f.Flag = name
+		if opt == nil {
+			f.Class = YtDlpFlagUnknown
+			f.Reason = "not a supported yt-dlp option"
+			parsed = append(parsed, f)
+			continue
+		}
+		f.Name = opt.names[0]
+		f.Class = opt.class
+		f.Reason = opt.reason
+
+		switch {
+		case opt.value && hasInline:
+			f.Value = inline
+		case opt.value && i+1 < len(flags):
+			i++
+			f.Value = flags[i]
+		case opt.value:
+			f.Class = YtDlpFlagUnknown
+			f.Reason = "missing value"
+		case hasInline:
+			f.Class = YtDlpFlagUnknown
+			f.Reason = "takes no value"
+		}
+		parsed = append(parsed, f)
+	}
+	return parsed
+}
+
+// ValidateYtDlpFlags returns a *YtDlpFlagsError listing every flag that is
+// not allowed.
+func ValidateYtDlpFlags(flags []string) error {
+	var rejected []YtDlpFlag
+	for _, f := range ParseYtDlpFlags(flags) {
+		if f.Class != YtDlpFlagAllowed {
+			rejected = append(rejected, f)
+		}
+	}
+	if len(rejected) > 0 {
+		return &YtDlpFlagsError{Flags: rejected}
+	}
+	return nil
+}
+
+// AllowedYtDlpFlags returns only the allowed flags, normalized to one
+// element per option and value.
+func AllowedYtDlpFlags(flags []string) []string {
+	var args []string
+	for _, f := range ParseYtDlpFlags(flags) {
+		if f.Class != YtDlpFlagAllowed {
+			continue
+		}
+		args = append(args, f.Flag)
+		if ytDlpOptionIndex[f.Flag].value {
+			args = append(args, f.Value)
+		}
+	}
+	return args
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseYtDlpFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		flags     []string
+		wantName  string
+		wantValue string
+		wantClass YtDlpFlagClass
+	}{
+		{"boolean", []string{"--embed-subs"}, "--embed-subs", "", YtDlpFlagAllowed},
+		{"value as next arg", []string{"--limit-rate", "2M"}, "--limit-rate", "2M", YtDlpFlagAllowed},
+		{"inline value", []string{"--sub-langs=en,de"}, "--sub-langs", "en,de", YtDlpFlagAllowed},
+		{"short alias", []string{"-N", "4"}, "--concurrent-fragments", "4", YtDlpFlagAllowed},
+		{"attached short value", []string{"-r50K"}, "--limit-rate", "50K", YtDlpFlagAllowed},
+		{"alias of allowed", []string{"--add-metadata"}, "--embed-metadata", "", YtDlpFlagAllowed},
+		{"output template", []string{"-o", "%(id)s.%(ext)s"}, "--output", "%(id)s.%(ext)s", YtDlpFlagConflicting},
+		{"quiet", []string{"--quiet"}, "--quiet", "", YtDlpFlagConflicting},
+		{"print", []string{"--print=filename"}, "--print", "filename", YtDlpFlagConflicting},
+		{"proxy", []string{"--proxy", "socks5://localhost"}, "--proxy", "socks5://localhost", YtDlpFlagConflicting},
+		{"exec", []string{"--exec", "rm -rf ~"}, "--exec", "rm -rf ~", YtDlpFlagDangerous},
+		{"external downloader", []string{"--downloader", "/tmp/evil"}, "--downloader", "/tmp/evil", YtDlpFlagDangerous},
+		{"self update", []string{"-U"}, "--update", "", YtDlpFlagDangerous},
+		{"config file", []string{"--config-locations", "/tmp/x.conf"}, "--config-locations", "/tmp/x.conf", YtDlpFlagDangerous},
+		{"unknown", []string{"--frobnicate"}, "", "", YtDlpFlagUnknown},
+		{"missing value", []string{"--limit-rate"}, "--limit-rate", "", YtDlpFlagUnknown},
+		{"value on boolean", []string{"--embed-subs=yes"}, "--embed-subs", "", YtDlpFlagUnknown},
+		{"stray URL", []string{"https://example.com/v"}, "", "", YtDlpFlagConflicting},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseYtDlpFlags(tt.flags)
+			if len(got) != 1 {
+				t.Fatalf("ParseYtDlpFlags(%q) = %+v, want one flag", tt.flags, got)
+			}
+			f := got[0]
+			if f.Name != tt.wantName || f.Value != tt.wantValue || f.Class != tt.wantClass {
+				t.Errorf("ParseYtDlpFlags(%q) = %+v, want %s %q %s", tt.flags, f, tt.wantName, tt.wantValue, tt.wantClass)
+			}
+			if f.Class != YtDlpFlagAllowed && f.Reason == "" {
+				t.Error("rejected flag has no reason")
+			}
+		})
+	}
+}
+
+func TestValidateYtDlpFlags(t *testing.T) {
+	if err := ValidateYtDlpFlags([]string{"--embed-thumbnail", "--retries", "5", " "}); err != nil {
+		t.Errorf("ValidateYtDlpFlags() error = %v, want nil", err)
+	}
+
+	err := ValidateYtDlpFlags([]string{"--embed-thumbnail", "--exec", "echo {}", "-q"})
+	var flagsErr *YtDlpFlagsError
+	if !errors.As(err, &flagsErr) || !errors.Is(err, ErrInvalidYtDlpFlags) {
+		t.Fatalf("ValidateYtDlpFlags() error = %v, want *YtDlpFlagsError", err)
+	}
+	if len(flagsErr.Flags) != 2 || flagsErr.Flags[0].Index != 1 || flagsErr.Flags[1].Index != 3 {
+		t.Errorf("rejected = %+v, want --exec at 1 and -q at 3", flagsErr.Flags)
+	}
+}
+
+func TestAllowedYtDlpFlags(t *testing.T) {
+	got := AllowedYtDlpFlags([]string{"--exec", "echo", "--sub-langs=en", "-o", "x", "-r50K", "--embed-subs"})
+	want := []string{"--sub-langs", "en", "-r", "50K", "--embed-subs"}
+	if !slices.Equal(got, want) {
+		t.Errorf("AllowedYtDlpFlags() = %q, want %q", got, want)
+	}
+}
+
+func TestSettings_Validate_YtDlpExtraFlags(t *testing.T) {
+	s := DefaultSettings("/music")
+	s.YtDlpExtraFlags = []string{"--netrc-cmd", "cat ~/.ssh/id_rsa"}
+	var appErr *AppError
+	if err := s.Validate(); !errors.As(err, &appErr) || appErr.Code != ErrCodeInvalidYtDlpFlags {
+		t.Errorf("Validate() error = %v, want INVALID_YTDLP_FLAGS", err)
+	}
+}
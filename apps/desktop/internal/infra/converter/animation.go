@@ -0,0 +1,221 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"ybdownloader/internal/core"
+)
+
+// animationSampleSeconds is the length of the clip encoded to estimate an
+// animation's size before the full encode.
+const animationSampleSeconds = 2.0
+
+// StartAnimationConversion queues a GIF or animated WebP encode of the
+// input, or of the trim range of it. An empty outputPath writes the
+// animation next to the input. filters, which may be nil, apply ahead of
+// the animation's frame rate and width. Before the full encode a short
+// sample is encoded and the job's EstimatedBytes extrapolated from it.
+func (s *Service) StartAnimationConversion(id, inputPath, outputPath string, opts core.AnimationOptions, trim *core.TrimOptions, filters *core.VideoFilterOptions) (*core.ConversionJob, error) {
+	if s.ffmpegPath == "" {
+		return nil, fmt.Errorf("ffmpeg not available")
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if trim != nil {
+		if err := trim.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if filters != nil {
+		if err := filters.Validate(0, 0); err != nil {
+			return nil, err
+		}
+		if filters.FPS > 0 {
+			return nil, fmt.Errorf("set the animation's frame rate rather than a frame rate filter")
+		}
+	}
+	if outputPath == "" {
+		outputPath = defaultOutputPath(inputPath, opts.Format, trim)
+	}
+
+	job := &core.ConversionJob{
+		ID:           id,
+		InputPath:    inputPath,
+		OutputPath:   outputPath,
+		TrimOptions:  trim,
+		VideoFilters: filters,
+		Animation:    &opts,
+		State:        core.ConversionQueued,
+	}
+	return s.enqueue(job, nil), nil
+}
+
+// runAnimation estimates the animation's size from a sample, then encodes
+// it. GIFs take two passes: the palette is generated for the whole clip
+// first and the second pass dithers the frames to it.
+func (s *Service) runAnimation(ctx context.Context, job *core.ConversionJob, info *core.MediaInfo) error {
+	if info.VideoStream == nil {
+		return fmt.Errorf("the input has no video to animate")
+	}
+
+	dir, err := os.MkdirTemp("", "animation-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck // best-effort cleanup
+
+	estimate, err := s.estimateAnimation(ctx, job, dir)
+	if err != nil {
+		return fmt.Errorf("size estimate: %w", err)
+	}
+	if estimate > 0 {
+		s.mu.Lock()
+		job.EstimatedBytes = estimate
+		s.mu.Unlock()
+		s.emitProgress(job.ID, core.ConversionAnalyzing, 0, 0, 0, "")
+	}
+
+	opts := job.Animation
+	if opts.Format == core.AnimationWebP {
+		args := append(inputArgs(job, nil), webpArgs(opts, job.VideoFilters)...)
+		return s.runFFmpeg(ctx, job, append(args, job.OutputPath), 0, 100)
+	}
+
+	palette := filepath.Join(dir, "palette.png")
+	paletteRun := append(inputArgs(job, nil), paletteArgs(opts, job.VideoFilters, palette)...)
+	encodeRun := append([]string{"-i", palette}, inputArgs(job, nil)...)
+	encodeRun = append(encodeRun, gifArgs(opts, job.VideoFilters, 1, 0)...)
+	// The palette pass only reads the frames, so it gets less of the
+	// progress bar than the encode.
+	passes := []struct {
+		args       []string
+		base, span float64
+	}{
+		{paletteRun, 0, 30},
+		{append(encodeRun, job.OutputPath), 30, 70},
+	}
+	for i, p := range passes {
+		s.mu.Lock()
+		job.Pass = i + 1
+		s.mu.Unlock()
+
+		if err := s.runFFmpeg(ctx, job, p.args, p.base, p.span); err != nil {
+			return fmt.Errorf("pass %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// estimateAnimation encodes up to animationSampleSeconds from the middle
+// of the job's range into dir and scales the sample's size to the whole
+// range. It returns 0 when the duration is unknown.
+func (s *Service) estimateAnimation(ctx context.Context, job *core.ConversionJob, dir string) (int64, error) {
+	length := job.Duration
+	if length <= 0 {
+		return 0, nil
+	}
+	sampleLength := min(animationSampleSeconds, length)
+	start := (length - sampleLength) / 2
+	if job.TrimOptions != nil {
+		start += job.TrimOptions.StartTime
+	}
+	sample := &core.ConversionJob{
+		InputPath:   job.InputPath,
+		TrimOptions: &core.TrimOptions{StartTime: start, EndTime: start + sampleLength},
+	}
+
+	opts := job.Animation
+	output := filepath.Join(dir, "sample."+opts.Format)
+	args := inputArgs(sample, nil)
+	if opts.Format == core.AnimationWebP {
+		args = append(args, webpArgs(opts, job.VideoFilters)...)
+	} else {
+		// One command is enough for a sample: split the frames between
+		// palettegen and paletteuse.
+		args = append(args, "-filter_complex", fmt.Sprintf("[0:v]%s,split[s0][s1];[s0]%s[p];[s1][p]%s",
+			animationFilter(opts, job.VideoFilters), paletteGen, paletteUse(opts)), "-loop", strconv.Itoa(opts.Loop))
+	}
+	if err := s.runCheck(ctx, append(args, output)...); err != nil {
+		return 0, err
+	}
+
+	fi, err := os.Stat(output)
+	if err != nil {
+		return 0, err
+	}
+	return int64(float64(fi.Size()) * length / sampleLength), nil
+}
+
+// paletteGen computes a palette weighted towards moving parts of the
+// frames, which keeps static backgrounds from using up the colours.
+const paletteGen = "palettegen=stats_mode=diff"
+
+// animationFilter returns the frame rate and scaling filters for opts,
+// after filters.
+func animationFilter(opts *core.AnimationOptions, filters *core.VideoFilterOptions) string {
+	filter := "fps=" + strconv.Itoa(opts.FPS)
+	if vf := videoFilter(filters); vf != "" {
+		filter = vf + "," + filter
+	}
+	if opts.Width > 0 {
+		filter += ",scale=" + strconv.Itoa(opts.Width) + ":-1:flags=lanczos"
+	}
+	return filter
+}
+
+// paletteArgs returns the arguments of a GIF's first pass, which writes
+// the palette image to palette. palettegen only outputs the palette once
+// it has read every frame, so the frames are also written to a null output
+// whose position FFmpeg reports as the pass's progress.
+func paletteArgs(opts *core.AnimationOptions, filters *core.VideoFilterOptions, palette string) []string {
+	return []string{
+		"-filter_complex", "[0:v]" + animationFilter(opts, filters) + ",split[s0][s1];[s0]" + paletteGen + "[p]",
+		"-map", "[p]", "-update", "1", "-frames:v", "1", palette,
+		"-map", "[s1]", "-f", "null", os.DevNull,
+	}
+}
+
+// gifArgs returns the arguments of a GIF's second pass, which dithers the
+// video input's frames to the palette input's colours.
+func gifArgs(opts *core.AnimationOptions, filters *core.VideoFilterOptions, video, palette int) []string {
+	graph := fmt.Sprintf("[%d:v]%s[x];[x][%d:v]%s", video, animationFilter(opts, filters), palette, paletteUse(opts))
+	return []string{"-filter_complex", graph, "-loop", strconv.Itoa(opts.Loop)}
+}
+
+// paletteUse returns the paletteuse filter with opts' dithering. Only
+// changed rectangles of each frame are redithered, which keeps still areas
+// from shimmering.
+func paletteUse(opts *core.AnimationOptions) string {
+	use := "paletteuse=dither=" + opts.Dither
+	if opts.Dither == "bayer" {
+		use += ":bayer_scale=3"
+	}
+	return use + ":diff_mode=rectangle"
+}
+
+// webpArgs returns the arguments encoding an animated WebP.
+func webpArgs(opts *core.AnimationOptions, filters *core.VideoFilterOptions) []string {
+	return []string{
+		"-vf", animationFilter(opts, filters),
+		"-an", "-c:v", "libwebp_anim", "-lossless", "0",
+		"-quality", strconv.Itoa(opts.Quality),
+		"-loop", webpLoop(opts.Loop),
+	}
+}
+
+// webpLoop converts a loop count to the WebP muxer's, which counts plays
+// rather than repeats. The GIF muxer takes the count as it is.
+func webpLoop(loop int) string {
+	switch loop {
+	case 0:
+		return "0"
+	case -1:
+		return "1"
+	}
+	return strconv.Itoa(loop + 1)
+}
@@ -0,0 +1,118 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"ybdownloader/internal/core"
+)
+
+func TestAnimationArgs(t *testing.T) {
+	gif := &core.AnimationOptions{Format: core.AnimationGIF, FPS: 12, Width: 320, Dither: "bayer"}
+	want := []string{
+		"-filter_complex", "[0:v]fps=12,scale=320:-1:flags=lanczos,split[s0][s1];[s0]palettegen=stats_mode=diff[p]",
+		"-map", "[p]", "-update", "1", "-frames:v", "1", "palette.png",
+		"-map", "[s1]", "-f", "null", os.DevNull,
+	}
+	if got := paletteArgs(gif, nil, "palette.png"); !slices.Equal(got, want) {
+		t.Errorf("paletteArgs() = %q, want %q", got, want)
+	}
+	want = []string{"-filter_complex", "[1:v]fps=12,scale=320:-1:flags=lanczos[x];[x][0:v]paletteuse=dither=bayer:bayer_scale=3:diff_mode=rectangle", "-loop", "0"}
+	if got := gifArgs(gif, nil, 1, 0); !slices.Equal(got, want) {
+		t.Errorf("gifArgs() = %q, want %q", got, want)
+	}
+	flip := &core.VideoFilterOptions{FlipH: true}
+	want = []string{"-filter_complex", "[1:v]hflip,fps=12,scale=320:-1:flags=lanczos[x];[x][0:v]paletteuse=dither=bayer:bayer_scale=3:diff_mode=rectangle", "-loop", "0"}
+	if got := gifArgs(gif, flip, 1, 0); !slices.Equal(got, want) {
+		t.Errorf("gifArgs() with filters = %q, want %q", got, want)
+	}
+
+	webp := &core.AnimationOptions{Format: core.AnimationWebP, FPS: 20, Loop: 2, Quality: 80}
+	want = []string{"-vf", "fps=20", "-an", "-c:v", "libwebp_anim", "-lossless", "0", "-quality", "80", "-loop", "3"}
+	if got := webpArgs(webp, nil); !slices.Equal(got, want) {
+		t.Errorf("webpArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestWebpLoop(t *testing.T) {
+	for loop, want := range map[int]string{0: "0", -1: "1", 1: "2"} {
+		if got := webpLoop(loop); got != want {
+			t.Errorf("webpLoop(%d) = %q, want %q", loop, got, want)
+		}
+	}
+}
+
+// animationProbe reports a 10-second video clip without audio.
+const animationProbe = `#!/bin/sh
+echo '{"format":{"duration":"10.0","format_name":"mp4"},"streams":[{"index":0,"codec_type":"video","codec_name":"h264","width":1280,"height":720,"avg_frame_rate":"30/1"}]}'
+`
+
+func TestStartAnimationConversion(t *testing.T) {
+	rec := &eventRecorder{}
+	s, dir := newConcatService(t)
+	s.emit = rec.emit
+	if err := os.WriteFile(filepath.Join(dir, "ffprobe"), []byte(animationProbe), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	input := writeInputs(t, dir, "clip.mp4")[0]
+
+	job, err := s.StartAnimationConversion("gif", input, "", core.AnimationOptions{Width: 480}, &core.TrimOptions{StartTime: 2, EndTime: 6}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "clip_trimmed.gif"); job.OutputPath != want {
+		t.Errorf("OutputPath = %q, want %q", job.OutputPath, want)
+	}
+	waitFor(t, func() bool { return jobState(s, "gif").IsFinished() })
+
+	got, _ := s.GetJob("gif")
+	// The 2 s sample is 7 bytes, so the 4 s range should be about 14.
+	if got.State != core.ConversionCompleted || got.EstimatedBytes != 14 {
+		t.Fatalf("job = %s %q, estimate %d", got.State, got.Error, got.EstimatedBytes)
+	}
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	runs := strings.Split(strings.TrimSpace(string(args)), "\n")
+	if len(runs) != 3 {
+		t.Fatalf("FFmpeg ran %d times, want the sample, the palette and the encode:\n%s", len(runs), args)
+	}
+	if !strings.Contains(runs[0], "-ss 00:00:03.000 -i "+input+" -t 00:00:02.000") {
+		t.Errorf("sample args = %q, want 2 s from the middle of the range", runs[0])
+	}
+	if !strings.Contains(runs[1], "palettegen") || !strings.Contains(runs[1], "palette.png -map [s1] -f null") {
+		t.Errorf("palette args = %q", runs[1])
+	}
+	if !strings.Contains(runs[2], "paletteuse=dither=sierra2_4a") || !strings.HasSuffix(runs[2], job.OutputPath) {
+		t.Errorf("encode args = %q", runs[2])
+	}
+
+	rec.mu.Lock()
+	var estimated bool
+	for i, event := range rec.events {
+		if p, ok := rec.data[i].(core.ConversionProgress); ok && event == "conversion:progress" && p.State == core.ConversionAnalyzing && p.EstimatedBytes == 14 {
+			estimated = true
+		}
+	}
+	rec.mu.Unlock()
+	if !estimated {
+		t.Error("the estimate was not reported before the encode")
+	}
+}
+
+func TestStartAnimationConversion_Errors(t *testing.T) {
+	s := New("/usr/bin/ffmpeg", nil)
+	if _, err := s.StartAnimationConversion("j", "in.mp4", "", core.AnimationOptions{FPS: 100}, nil, nil); err == nil {
+		t.Error("StartAnimationConversion() accepted 100 fps")
+	}
+	if _, err := s.StartAnimationConversion("j", "in.mp4", "", core.AnimationOptions{}, &core.TrimOptions{StartTime: 5, EndTime: 2}, nil); err == nil {
+		t.Error("StartAnimationConversion() accepted a reversed range")
+	}
+	if _, err := s.StartAnimationConversion("j", "in.mp4", "", core.AnimationOptions{}, nil, &core.VideoFilterOptions{FPS: 10}); err == nil {
+		t.Error("StartAnimationConversion() accepted a frame rate filter")
+	}
+	if _, err := New("", nil).StartAnimationConversion("j", "in.mp4", "", core.AnimationOptions{}, nil, nil); err == nil {
+		t.Error("StartAnimationConversion() worked without ffmpeg")
+	}
+}
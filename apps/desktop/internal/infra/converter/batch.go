@@ -0,0 +1,243 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"ybdownloader/internal/core"
+)
+
+// FindBatchInputs lists the files in dir matching pattern, a glob such as
+// "*.mkv" or a comma-separated list such as "*.mp4, *.mov". Subdirectories
+// are not searched.
+func FindBatchInputs(dir, pattern string) ([]string, error) {
+	if strings.TrimSpace(pattern) == "" {
+		pattern = "*"
+	}
+
+	var inputs []string
+	for _, p := range strings.Split(pattern, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if strings.ContainsAny(p, `/\`) {
+			return nil, fmt.Errorf("pattern %q must not contain a path separator", p)
+		}
+		matches, err := filepath.Glob(filepath.Join(dir, p))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && info.Mode().IsRegular() && !slices.Contains(inputs, m) {
+				inputs = append(inputs, m)
+			}
+		}
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no files in %s match %q", dir, pattern)
+	}
+	slices.Sort(inputs)
+	return inputs, nil
+}
+
+// StartBatchConversion queues a job per input with the given preset as one
+// named batch. Outputs go to outputDir, or next to each input when it is
+// empty. Job IDs are the batch ID with the input's 1-based index appended.
+func (s *Service) StartBatchConversion(id, name string, inputs []string, presetID, outputDir string) (*core.ConversionBatch, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no input files")
+	}
+	if presetID == "" {
+		return nil, fmt.Errorf("a batch conversion needs a preset")
+	}
+	preset, err := s.GetPreset(presetID)
+	if err != nil {
+		return nil, err
+	}
+	if outputDir != "" {
+		if err := os.MkdirAll(outputDir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	if name == "" {
+		name = fmt.Sprintf("%d files", len(inputs))
+	}
+
+	batch := &core.ConversionBatch{
+		ID:        id,
+		Name:      name,
+		PresetID:  presetID,
+		OutputDir: outputDir,
+		CreatedAt: time.Now(),
+	}
+	jobs := make([]*core.ConversionJob, 0, len(inputs))
+	args := make([][]string, 0, len(inputs))
+	used := make(map[string]bool)
+	for i, input := range inputs {
+		var outputPath string
+		if outputDir != "" {
+			outputPath = batchOutputPath(outputDir, input, outputExt(preset, input), used)
+		}
+		job, jobArgs, err := s.newJob(id+"-"+strconv.Itoa(i+1), input, outputPath, presetID, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		job.BatchID = id
+		jobs = append(jobs, job)
+		args = append(args, jobArgs)
+		batch.JobIDs = append(batch.JobIDs, job.ID)
+	}
+
+	s.mu.Lock()
+	s.batches[id] = batch
+	for i, job := range jobs {
+		s.enqueueLocked(job, args[i])
+	}
+	state := s.queueStateLocked()
+	report := s.batchReportLocked(batch)
+	s.mu.Unlock()
+
+	s.emitQueueState(state)
+	s.emitBatch(report)
+	return batch, nil
+}
+
+// batchOutputPath returns outputDir/<input name>.<ext>, numbering it when
+// another input of the batch already uses that name or it is the input
+// itself.
+func batchOutputPath(outputDir, inputPath, ext string, used map[string]bool) string {
+	base := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	path := filepath.Join(outputDir, base+"."+ext)
+	for n := 2; used[path] || path == filepath.Clean(inputPath); n++ {
+		path = filepath.Join(outputDir, fmt.Sprintf("%s_%d.%s", base, n, ext))
+	}
+	used[path] = true
+	return path
+}
+
+// CancelBatch cancels every queued and running job of a batch.
+func (s *Service) CancelBatch(id string) error {
+	s.mu.Lock()
+	batch, ok := s.batches[id]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("batch not found: %s", id)
+	}
+	// Drop queued jobs first so cancelling running ones does not start them.
+	var queued []string
+	for _, jobID := range batch.JobIDs {
+		if _, ok := s.pending[jobID]; ok {
+			delete(s.pending, jobID)
+			queued = append(queued, jobID)
+		}
+	}
+	var running []func()
+	for _, jobID := range batch.JobIDs {
+		if cancel, ok := s.cancelFuncs[jobID]; ok {
+			running = append(running, cancel)
+		}
+	}
+	state := s.queueStateLocked()
+	s.mu.Unlock()
+
+	for _, cancel := range running {
+		cancel()
+	}
+	for _, jobID := range queued {
+		s.updateJobState(jobID, core.ConversionCancelled, 0, "")
+	}
+	s.emitQueueState(state)
+	return nil
+}
+
+// GetBatchReport returns the current summary of a batch.
+func (s *Service) GetBatchReport(id string) (*core.ConversionBatchReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batch, ok := s.batches[id]
+	if !ok {
+		return nil, fmt.Errorf("batch not found: %s", id)
+	}
+	return s.batchReportLocked(batch), nil
+}
+
+// GetBatchReports returns the summaries of all batches, oldest first.
+func (s *Service) GetBatchReports() []core.ConversionBatchReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reports := make([]core.ConversionBatchReport, 0, len(s.batches))
+	for _, batch := range s.batches {
+		reports = append(reports, *s.batchReportLocked(batch))
+	}
+	slices.SortFunc(reports, func(a, b core.ConversionBatchReport) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return reports
+}
+
+// batchReportLocked summarizes batch from its jobs and records when it
+// finished. Callers hold s.mu for writing.
+func (s *Service) batchReportLocked(batch *core.ConversionBatch) *core.ConversionBatchReport {
+	report := &core.ConversionBatchReport{
+		BatchID:   batch.ID,
+		Name:      batch.Name,
+		CreatedAt: batch.CreatedAt,
+	}
+	var progress float64
+	for _, id := range batch.JobIDs {
+		job, ok := s.jobs[id]
+		if !ok {
+			continue
+		}
+		report.Total++
+		switch job.State {
+		case core.ConversionQueued:
+			report.Queued++
+		case core.ConversionCompleted:
+			report.Completed++
+			report.InputBytes += job.InputBytes
+			report.OutputBytes += job.OutputBytes
+		case core.ConversionFailed:
+			report.Failed++
+			report.Failures = append(report.Failures, core.ConversionBatchFailure{
+				JobID:     job.ID,
+				InputPath: job.InputPath,
+				Error:     job.Error,
+			})
+		case core.ConversionCancelled:
+			report.Cancelled++
+		default:
+			report.Running++
+		}
+		if job.State.IsFinished() {
+			progress += 100
+		} else {
+			progress += job.Progress
+		}
+	}
+	if report.Total > 0 {
+		report.Progress = progress / float64(report.Total)
+	}
+
+	report.Done = report.Queued == 0 && report.Running == 0
+	if report.Done && batch.FinishedAt == nil {
+		now := time.Now()
+		batch.FinishedAt = &now
+	}
+	report.FinishedAt = batch.FinishedAt
+	return report
+}
+
+func (s *Service) emitBatch(report *core.ConversionBatchReport) {
+	if s.emit != nil {
+		s.emit("conversion:batch", report)
+	}
+}
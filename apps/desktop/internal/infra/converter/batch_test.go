@@ -0,0 +1,186 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"ybdownloader/internal/core"
+)
+
+func writeInputs(t *testing.T, dir string, names ...string) []string {
+	t.Helper()
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("media"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestFindBatchInputs(t *testing.T) {
+	dir := t.TempDir()
+	writeInputs(t, dir, "b.mkv", "a.mkv", "c.mp4", "notes.txt")
+	if err := os.Mkdir(filepath.Join(dir, "sub.mkv"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+		wantErr bool
+	}{
+		{"*.mkv", []string{"a.mkv", "b.mkv"}, false},
+		{"*.mp4, *.mkv", []string{"a.mkv", "b.mkv", "c.mp4"}, false},
+		{"", []string{"a.mkv", "b.mkv", "c.mp4", "notes.txt"}, false},
+		{"*.avi", nil, true},
+		{"../*.mkv", nil, true},
+		{"[", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := FindBatchInputs(dir, tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FindBatchInputs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var names []string
+			for _, p := range got {
+				names = append(names, filepath.Base(p))
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("FindBatchInputs() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchOutputPath(t *testing.T) {
+	used := make(map[string]bool)
+	out := filepath.Join("out")
+	tests := []struct {
+		input, ext, want string
+	}{
+		{filepath.Join("in", "song.flac"), "mp3", filepath.Join(out, "song.mp3")},
+		{filepath.Join("other", "song.wav"), "mp3", filepath.Join(out, "song_2.mp3")},
+		{filepath.Join("out", "clip.mp4"), "mp4", filepath.Join(out, "clip_2.mp4")},
+	}
+	for _, tt := range tests {
+		if got := batchOutputPath(out, tt.input, tt.ext, used); got != tt.want {
+			t.Errorf("batchOutputPath(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestStartBatchConversion(t *testing.T) {
+	rec := &eventRecorder{}
+	s, dir := newFakeService(t, rec.emit)
+	release(t, dir)
+	inputs := writeInputs(t, dir, "one.mkv", "fail.mkv", "two.mkv")
+	outputDir := filepath.Join(dir, "converted")
+
+	batch, err := s.StartBatchConversion("batch", "Holiday clips", inputs, "audio-mp3-192", outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(batch.JobIDs, []string{"batch-1", "batch-2", "batch-3"}) {
+		t.Errorf("JobIDs = %v", batch.JobIDs)
+	}
+	job, _ := s.GetJob("batch-1")
+	if job.BatchID != "batch" || job.OutputPath != filepath.Join(outputDir, "one.mp3") {
+		t.Errorf("job = %+v, want batch output in %s", job, outputDir)
+	}
+
+	var report *core.ConversionBatchReport
+	waitFor(t, func() bool {
+		report, _ = s.GetBatchReport("batch")
+		return report.Done
+	})
+	if report.Name != "Holiday clips" || report.Total != 3 || report.Completed != 2 || report.Failed != 1 {
+		t.Errorf("report = %+v, want 2 completed and 1 failed", report)
+	}
+	if report.Progress != 100 || report.FinishedAt == nil {
+		t.Errorf("report progress = %v, finished %v", report.Progress, report.FinishedAt)
+	}
+	if report.InputBytes != 10 || report.OutputBytes != 20 {
+		t.Errorf("report sizes = %d -> %d, want 10 -> 20", report.InputBytes, report.OutputBytes)
+	}
+	// The sizes are recorded as each job completes, not read per report.
+	if err := os.Remove(filepath.Join(outputDir, "one.mp3")); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := s.GetBatchReport("batch"); again.OutputBytes != 20 {
+		t.Errorf("report OutputBytes = %d after the output was removed, want 20 recorded", again.OutputBytes)
+	}
+	if len(report.Failures) != 1 || report.Failures[0].InputPath != inputs[1] || report.Failures[0].Error == "" {
+		t.Errorf("Failures = %+v, want fail.mkv", report.Failures)
+	}
+
+	rec.mu.Lock()
+	var last *core.ConversionBatchReport
+	for i, event := range rec.events {
+		if event == "conversion:batch" {
+			last = rec.data[i].(*core.ConversionBatchReport)
+		}
+	}
+	rec.mu.Unlock()
+	if last == nil || !last.Done {
+		t.Errorf("last conversion:batch event = %+v, want the final report", last)
+	}
+
+	if reports := s.GetBatchReports(); len(reports) != 1 || reports[0].BatchID != "batch" {
+		t.Errorf("GetBatchReports() = %+v", reports)
+	}
+}
+
+func TestStartBatchConversion_Errors(t *testing.T) {
+	s := New("/usr/bin/ffmpeg", nil)
+	if _, err := s.StartBatchConversion("b", "", nil, "audio-mp3-192", ""); err == nil {
+		t.Error("StartBatchConversion() accepted no inputs")
+	}
+	if _, err := s.StartBatchConversion("b", "", []string{"a.mkv"}, "", ""); err == nil {
+		t.Error("StartBatchConversion() accepted no preset")
+	}
+	if _, err := s.StartBatchConversion("b", "", []string{"a.mkv"}, "nope", ""); err == nil {
+		t.Error("StartBatchConversion() accepted an unknown preset")
+	}
+	if _, err := New("", nil).StartBatchConversion("b", "", []string{"a.mkv"}, "audio-mp3-192", ""); err == nil {
+		t.Error("StartBatchConversion() worked without ffmpeg")
+	}
+	if len(s.GetAllJobs()) != 0 || len(s.GetBatchReports()) != 0 {
+		t.Error("failed batches left jobs behind")
+	}
+}
+
+func TestCancelBatch(t *testing.T) {
+	s, dir := newFakeService(t, nil)
+	s.SetMaxConcurrent(1)
+	inputs := writeInputs(t, dir, "a.mkv", "b.mkv", "c.mkv")
+
+	batch, err := s.StartBatchConversion("batch", "", inputs, "audio-mp3-192", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch.Name != "3 files" {
+		t.Errorf("Name = %q, want default", batch.Name)
+	}
+	waitFor(t, func() bool { return runningProcesses(dir) == 1 })
+
+	if err := s.CancelBatch("batch"); err != nil {
+		t.Fatal(err)
+	}
+	var report *core.ConversionBatchReport
+	waitFor(t, func() bool {
+		report, _ = s.GetBatchReport("batch")
+		return report.Done
+	})
+	if report.Cancelled != 3 {
+		t.Errorf("report = %+v, want all cancelled", report)
+	}
+	if err := s.CancelBatch("missing"); err == nil {
+		t.Error("CancelBatch() accepted an unknown batch")
+	}
+}
@@ -0,0 +1,327 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"ybdownloader/internal/core"
+)
+
+// concatAudioFormat brings every audio segment to one format, as the concat
+// and acrossfade filters require.
+const concatAudioFormat = "aformat=sample_fmts=fltp:sample_rates=48000:channel_layouts=stereo"
+
+// StartConcatConversion queues a job joining inputPaths, in order, into one
+// output. Inputs whose streams match are stream-copied through the concat
+// demuxer when the output keeps their container; otherwise they are
+// re-encoded with the preset, which is then required. crossfade blends the
+// audio of neighbouring inputs for that many seconds and always re-encodes
+// with an audio preset.
+func (s *Service) StartConcatConversion(id string, inputPaths []string, outputPath, presetID string, crossfade float64) (*core.ConversionJob, error) {
+	if s.ffmpegPath == "" {
+		return nil, fmt.Errorf("ffmpeg not available")
+	}
+	if len(inputPaths) < 2 {
+		return nil, fmt.Errorf("joining needs at least two inputs")
+	}
+	if crossfade < 0 {
+		return nil, fmt.Errorf("crossfade cannot be negative")
+	}
+	if crossfade > 0 && presetID == "" {
+		return nil, fmt.Errorf("a crossfade re-encodes the audio and needs a preset")
+	}
+
+	var args []string
+	ext := strings.TrimPrefix(filepath.Ext(inputPaths[0]), ".")
+	if presetID != "" {
+		preset, err := s.GetPreset(presetID)
+		if err != nil {
+			return nil, err
+		}
+		if args, err = preset.ResolveArgs(nil); err != nil {
+			return nil, fmt.Errorf("%w: %w", core.ErrInvalidPreset, err)
+		}
+		if crossfade > 0 && !slices.Contains(args, "-vn") {
+			return nil, fmt.Errorf("crossfades are only supported when joining audio; choose an audio preset")
+		}
+		ext = outputExt(preset, inputPaths[0])
+	}
+	if outputPath == "" {
+		first := inputPaths[0]
+		base := strings.TrimSuffix(filepath.Base(first), filepath.Ext(first))
+		outputPath = filepath.Join(filepath.Dir(first), base+"_joined."+ext)
+	}
+
+	job := &core.ConversionJob{
+		ID:         id,
+		InputPath:  inputPaths[0],
+		OutputPath: outputPath,
+		PresetID:   presetID,
+		Concat: &core.ConcatOptions{
+			InputPaths: slices.Clone(inputPaths),
+			Crossfade:  crossfade,
+		},
+		State: core.ConversionQueued,
+	}
+	return s.enqueue(job, args), nil
+}
+
+// runConcat analyses every input, then joins them by stream copy or by
+// re-encoding. Progress is measured against the combined duration.
+func (s *Service) runConcat(ctx context.Context, job *core.ConversionJob, presetArgs []string) error {
+	opts := job.Concat
+	infos := make([]*core.MediaInfo, len(opts.InputPaths))
+	var total float64
+	for i, path := range opts.InputPaths {
+		info, err := s.AnalyzeFile(ctx, path)
+		if err != nil {
+			return fmt.Errorf("analysis of %s failed: %w", filepath.Base(path), err)
+		}
+		infos[i] = info
+		total += info.Duration
+	}
+	total -= opts.Crossfade * float64(len(infos)-1)
+
+	reason := concatMismatch(opts.InputPaths, infos)
+	switch {
+	case reason != "":
+	case opts.Crossfade > 0:
+		reason = "crossfades need re-encoding"
+	case !strings.EqualFold(filepath.Ext(job.OutputPath), filepath.Ext(opts.InputPaths[0])):
+		reason = "the output container differs from the inputs'"
+	}
+
+	s.mu.Lock()
+	job.InputInfo = infos[0]
+	job.Duration = max(total, 0)
+	opts.Reencode = reason != ""
+	opts.Reason = reason
+	s.mu.Unlock()
+
+	if reason == "" {
+		return s.concatCopy(ctx, job)
+	}
+	if job.PresetID == "" {
+		return fmt.Errorf("the inputs cannot be joined without re-encoding (%s); choose a preset", reason)
+	}
+	args, err := concatEncodeArgs(job, infos, presetArgs)
+	if err != nil {
+		return err
+	}
+	return s.runFFmpeg(ctx, job, args, 0, 100)
+}
+
+// concatCopy joins the inputs without re-encoding through the concat
+// demuxer.
+func (s *Service) concatCopy(ctx context.Context, job *core.ConversionJob) error {
+	dir, err := os.MkdirTemp("", "concat-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck // best-effort cleanup
+
+	list := filepath.Join(dir, "inputs.txt")
+	if err := os.WriteFile(list, []byte(concatList(job.Concat.InputPaths)), 0o600); err != nil {
+		return err
+	}
+	args := []string{
+		"-y", "-f", "concat", "-safe", "0", "-i", list,
+		"-progress", "pipe:1", "-nostats",
+		"-map", "0", "-c", "copy",
+		job.OutputPath,
+	}
+	return s.runFFmpeg(ctx, job, args, 0, 100)
+}
+
+// concatList returns a concat demuxer script listing paths.
+func concatList(paths []string) string {
+	var b strings.Builder
+	for _, p := range paths {
+		if abs, err := filepath.Abs(p); err == nil {
+			p = abs
+		}
+		b.WriteString("file '" + strings.ReplaceAll(p, "'", `'\''`) + "'\n")
+	}
+	return b.String()
+}
+
+// concatMismatch returns why the inputs cannot be stream-copied into one
+// file, or "" when every input has the same streams with the same codecs,
+// profiles and formats.
+func concatMismatch(paths []string, infos []*core.MediaInfo) string {
+	first := infos[0].Streams
+	for i, info := range infos[1:] {
+		name := filepath.Base(paths[i+1])
+		if len(info.Streams) != len(first) {
+			return fmt.Sprintf("%s has %d streams, %s has %d", name, len(info.Streams), filepath.Base(paths[0]), len(first))
+		}
+		for j, st := range info.Streams {
+			want := first[j]
+			switch {
+			case st.Type != want.Type || st.Codec != want.Codec:
+				return fmt.Sprintf("stream %d of %s is %s %s, not %s %s", j, name, st.Codec, st.Type, want.Codec, want.Type)
+			case st.Profile != want.Profile:
+				return fmt.Sprintf("stream %d of %s uses the %q profile, not %q", j, name, st.Profile, want.Profile)
+			case st.Width != want.Width || st.Height != want.Height:
+				return fmt.Sprintf("%s is %dx%d, not %dx%d", name, st.Width, st.Height, want.Width, want.Height)
+			case math.Abs(st.FPS-want.FPS) > 0.01:
+				return fmt.Sprintf("%s runs at %.3f fps, not %.3f", name, st.FPS, want.FPS)
+			case st.PixFmt != want.PixFmt:
+				return fmt.Sprintf("%s uses the %s pixel format, not %s", name, st.PixFmt, want.PixFmt)
+			case st.SampleRate != want.SampleRate || st.Channels != want.Channels:
+				return fmt.Sprintf("%s has %d Hz %d-channel audio, not %d Hz %d-channel", name, st.SampleRate, st.Channels, want.SampleRate, want.Channels)
+			}
+		}
+	}
+	return ""
+}
+
+// concatEncodeArgs returns the FFmpeg arguments re-encoding the inputs
+// through the concat filter, or acrossfade for crossfaded audio, with the
+// preset's own filters applied to the joined streams.
+func concatEncodeArgs(job *core.ConversionJob, infos []*core.MediaInfo, presetArgs []string) ([]string, error) {
+	rest, vf, af := splitFilterArgs(presetArgs)
+	crossfade := job.Concat.Crossfade
+	video := !slices.Contains(rest, "-vn") && slices.ContainsFunc(infos, func(i *core.MediaInfo) bool { return i.VideoStream != nil })
+	audio := !slices.Contains(rest, "-an") && slices.ContainsFunc(infos, func(i *core.MediaInfo) bool { return i.AudioStream != nil })
+	switch {
+	case !video && !audio:
+		return nil, fmt.Errorf("the inputs have no streams the preset keeps")
+	case video && crossfade > 0:
+		return nil, fmt.Errorf("crossfades are only supported when joining audio; choose an audio preset")
+	}
+	for i, info := range infos {
+		if crossfade > 0 && info.Duration <= crossfade {
+			return nil, fmt.Errorf("%s is shorter than the crossfade", filepath.Base(job.Concat.InputPaths[i]))
+		}
+	}
+
+	args := []string{"-y"}
+	for _, path := range job.Concat.InputPaths {
+		args = append(args, "-i", path)
+	}
+	graph, maps := concatGraph(infos, video, audio, crossfade, vf, af)
+	args = append(args, "-filter_complex", graph)
+	args = append(args, maps...)
+	args = append(args, "-progress", "pipe:1", "-nostats")
+	args = append(args, rest...)
+	return append(args, job.OutputPath), nil
+}
+
+// concatGraph builds the filtergraph joining the inputs and the -map
+// arguments for its outputs. Video is scaled and padded to the first
+// input's size and frame rate; inputs missing a stream get black frames or
+// silence for their duration.
+func concatGraph(infos []*core.MediaInfo, video, audio bool, crossfade float64, vf, af string) (string, []string) {
+	width, height, fps := 1280, 720, "30"
+	if i := slices.IndexFunc(infos, func(i *core.MediaInfo) bool { return i.VideoStream != nil }); i >= 0 {
+		vs := infos[i].VideoStream
+		if vs.Width > 0 && vs.Height > 0 {
+			width, height = vs.Width&^1, vs.Height&^1
+		}
+		if vs.FPS > 0 {
+			fps = strconv.FormatFloat(vs.FPS, 'f', -1, 64)
+		}
+	}
+
+	var parts []string
+	var pads strings.Builder
+	for i, info := range infos {
+		duration := seconds(info.Duration)
+		if video {
+			if info.VideoStream != nil {
+				parts = append(parts, fmt.Sprintf("[%d:v:0]scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=%s[v%d]",
+					i, width, height, width, height, fps, i))
+			} else {
+				parts = append(parts, fmt.Sprintf("color=c=black:s=%dx%d:r=%s:d=%s,setsar=1[v%d]", width, height, fps, duration, i))
+			}
+			fmt.Fprintf(&pads, "[v%d]", i)
+		}
+		if audio {
+			if info.AudioStream != nil {
+				parts = append(parts, fmt.Sprintf("[%d:a:0]%s[a%d]", i, concatAudioFormat, i))
+			} else {
+				parts = append(parts, fmt.Sprintf("anullsrc=r=48000:cl=stereo,atrim=duration=%s,%s[a%d]", duration, concatAudioFormat, i))
+			}
+			fmt.Fprintf(&pads, "[a%d]", i)
+		}
+	}
+
+	if crossfade > 0 {
+		prev := "[a0]"
+		for i := 1; i < len(infos); i++ {
+			out := fmt.Sprintf("[x%d]", i)
+			if i == len(infos)-1 {
+				out = "[acat]"
+			}
+			parts = append(parts, fmt.Sprintf("%s[a%d]acrossfade=d=%s%s", prev, i, seconds(crossfade), out))
+			prev = out
+		}
+	} else {
+		outs := ""
+		if video {
+			outs += "[vcat]"
+		}
+		if audio {
+			outs += "[acat]"
+		}
+		parts = append(parts, fmt.Sprintf("%sconcat=n=%d:v=%d:a=%d%s", pads.String(), len(infos), boolInt(video), boolInt(audio), outs))
+	}
+
+	var maps []string
+	output := func(kind, chain string) {
+		label := "[" + kind + "cat]"
+		if chain != "" {
+			parts = append(parts, label+chain+"["+kind+"out]")
+			label = "[" + kind + "out]"
+		}
+		maps = append(maps, "-map", label)
+	}
+	if video {
+		output("v", vf)
+	}
+	if audio {
+		output("a", af)
+	}
+	return strings.Join(parts, ";"), maps
+}
+
+// splitFilterArgs removes the video and audio filter options from args so
+// they can be applied inside a filtergraph.
+func splitFilterArgs(args []string) (rest []string, vf, af string) {
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-vf", "-filter:v":
+			if i+1 < len(args) {
+				vf = args[i+1]
+				i++
+				continue
+			}
+		case "-af", "-filter:a":
+			if i+1 < len(args) {
+				af = args[i+1]
+				i++
+				continue
+			}
+		}
+		rest = append(rest, args[i])
+	}
+	return rest, vf, af
+}
+
+func seconds(s float64) string {
+	return strconv.FormatFloat(s, 'f', 3, 64)
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,231 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"ybdownloader/internal/core"
+)
+
+func clipInfo(duration float64, streams ...core.MediaStream) *core.MediaInfo {
+	info := &core.MediaInfo{Duration: duration, Streams: streams}
+	for _, st := range streams {
+		switch {
+		case st.Type == core.StreamVideo && info.VideoStream == nil:
+			info.VideoStream = &core.VideoStream{Codec: st.Codec, Width: st.Width, Height: st.Height, FPS: st.FPS}
+		case st.Type == core.StreamAudio && info.AudioStream == nil:
+			info.AudioStream = &core.AudioStream{Codec: st.Codec, Channels: st.Channels, SampleRate: st.SampleRate}
+		}
+	}
+	return info
+}
+
+var (
+	h264HD   = core.MediaStream{Type: core.StreamVideo, Codec: "h264", Profile: "High", Width: 1920, Height: 1080, FPS: 25, PixFmt: "yuv420p"}
+	h264SD   = core.MediaStream{Type: core.StreamVideo, Codec: "h264", Profile: "High", Width: 640, Height: 480, FPS: 25, PixFmt: "yuv420p"}
+	h264NTSC = core.MediaStream{Type: core.StreamVideo, Codec: "h264", Profile: "High", Width: 1920, Height: 1080, FPS: 29.97, PixFmt: "yuv420p"}
+	h264Main = core.MediaStream{Type: core.StreamVideo, Codec: "h264", Profile: "Main", Width: 1920, Height: 1080, FPS: 25, PixFmt: "yuv420p"}
+	h264Hi10 = core.MediaStream{Type: core.StreamVideo, Codec: "h264", Profile: "High", Width: 1920, Height: 1080, FPS: 25, PixFmt: "yuv420p10le"}
+	aacAudio = core.MediaStream{Index: 1, Type: core.StreamAudio, Codec: "aac", Channels: 2, SampleRate: 48000}
+	aac44    = core.MediaStream{Index: 1, Type: core.StreamAudio, Codec: "aac", Channels: 2, SampleRate: 44100}
+	mp3Audio = core.MediaStream{Type: core.StreamAudio, Codec: "mp3", Channels: 2, SampleRate: 44100}
+)
+
+func TestConcatMismatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		second *core.MediaInfo
+		want   string
+	}{
+		{"same", clipInfo(5, h264HD, aacAudio), ""},
+		{"missing audio", clipInfo(5, h264HD), "b.mp4 has 1 streams, a.mp4 has 2"},
+		{"codec", clipInfo(5, h264HD, core.MediaStream{Type: core.StreamAudio, Codec: "opus"}), "stream 1 of b.mp4 is opus audio, not aac audio"},
+		{"size", clipInfo(5, h264SD, aacAudio), "b.mp4 is 640x480, not 1920x1080"},
+		{"frame rate", clipInfo(5, h264NTSC, aacAudio), "b.mp4 runs at 29.970 fps, not 25.000"},
+		{"profile", clipInfo(5, h264Main, aacAudio), `stream 0 of b.mp4 uses the "Main" profile, not "High"`},
+		{"pixel format", clipInfo(5, h264Hi10, aacAudio), "b.mp4 uses the yuv420p10le pixel format, not yuv420p"},
+		{"sample rate", clipInfo(5, h264HD, aac44), "b.mp4 has 44100 Hz 2-channel audio, not 48000 Hz 2-channel"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			infos := []*core.MediaInfo{clipInfo(5, h264HD, aacAudio), tt.second}
+			if got := concatMismatch([]string{"/x/a.mp4", "/x/b.mp4"}, infos); got != tt.want {
+				t.Errorf("concatMismatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConcatList(t *testing.T) {
+	got := concatList([]string{"/media/one.mkv", "/media/it's.mkv"})
+	want := "file '/media/one.mkv'\nfile '/media/it'\\''s.mkv'\n"
+	if filepath.Separator == '/' && got != want {
+		t.Errorf("concatList() = %q, want %q", got, want)
+	}
+}
+
+func TestSplitFilterArgs(t *testing.T) {
+	rest, vf, af := splitFilterArgs([]string{"-vf", "scale=-2:720", "-c:v", "libx264", "-filter:a", "volume=2", "-crf", "23"})
+	if !slices.Equal(rest, []string{"-c:v", "libx264", "-crf", "23"}) || vf != "scale=-2:720" || af != "volume=2" {
+		t.Errorf("splitFilterArgs() = %q, %q, %q", rest, vf, af)
+	}
+}
+
+func TestConcatGraph(t *testing.T) {
+	tests := []struct {
+		name         string
+		infos        []*core.MediaInfo
+		video, audio bool
+		crossfade    float64
+		vf           string
+		wantGraph    string
+		wantMaps     []string
+	}{
+		{
+			name:  "video with a silent clip",
+			infos: []*core.MediaInfo{clipInfo(5, h264HD, aacAudio), clipInfo(2.5, h264SD)},
+			video: true, audio: true, vf: "scale=-2:720",
+			wantGraph: "[0:v:0]scale=1920:1080:force_original_aspect_ratio=decrease,pad=1920:1080:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=25[v0];" +
+				"[0:a:0]" + concatAudioFormat + "[a0];" +
+				"[1:v:0]scale=1920:1080:force_original_aspect_ratio=decrease,pad=1920:1080:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=25[v1];" +
+				"anullsrc=r=48000:cl=stereo,atrim=duration=2.500," + concatAudioFormat + "[a1];" +
+				"[v0][a0][v1][a1]concat=n=2:v=1:a=1[vcat][acat];" +
+				"[vcat]scale=-2:720[vout]",
+			wantMaps: []string{"-map", "[vout]", "-map", "[acat]"},
+		},
+		{
+			name:  "audio crossfade",
+			infos: []*core.MediaInfo{clipInfo(60, mp3Audio), clipInfo(60, mp3Audio), clipInfo(60, mp3Audio)},
+			audio: true, crossfade: 2,
+			wantGraph: "[0:a:0]" + concatAudioFormat + "[a0];[1:a:0]" + concatAudioFormat + "[a1];[2:a:0]" + concatAudioFormat + "[a2];" +
+				"[a0][a1]acrossfade=d=2.000[x1];[x1][a2]acrossfade=d=2.000[acat]",
+			wantMaps: []string{"-map", "[acat]"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph, maps := concatGraph(tt.infos, tt.video, tt.audio, tt.crossfade, tt.vf, "")
+			if graph != tt.wantGraph {
+				t.Errorf("concatGraph() graph =\n%s\nwant\n%s", graph, tt.wantGraph)
+			}
+			if !slices.Equal(maps, tt.wantMaps) {
+				t.Errorf("concatGraph() maps = %q, want %q", maps, tt.wantMaps)
+			}
+		})
+	}
+}
+
+// concatProbe reports H.264/AAC clips, with Opus audio for inputs named
+// "opus".
+const concatProbe = `#!/bin/sh
+case "$*" in
+*opus*) codec=opus;;
+*) codec=aac;;
+esac
+cat <<EOF
+{"format":{"duration":"2.0","format_name":"matroska"},"streams":[
+ {"index":0,"codec_type":"video","codec_name":"h264","width":640,"height":360,"avg_frame_rate":"25/1"},
+ {"index":1,"codec_type":"audio","codec_name":"$codec","channels":2,"sample_rate":"48000"}]}
+EOF
+`
+
+func newConcatService(t *testing.T) (*Service, string) {
+	t.Helper()
+	s, dir := newFakeService(t, nil)
+	// Record the arguments instead of waiting for a release.
+	ffmpeg := "#!/bin/sh\necho \"$*\" >> \"$(dirname \"$0\")/args\"\nfor a; do out=$a; done\necho joined > \"$out\"\n"
+	for name, script := range map[string]string{"ffmpeg": ffmpeg, "ffprobe": concatProbe} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s, dir
+}
+
+func TestStartConcatConversion_Copy(t *testing.T) {
+	s, dir := newConcatService(t)
+	inputs := writeInputs(t, dir, "one.mkv", "two.mkv", "three.mkv")
+
+	job, err := s.StartConcatConversion("join", inputs, "", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "one_joined.mkv"); job.OutputPath != want {
+		t.Errorf("OutputPath = %q, want %q", job.OutputPath, want)
+	}
+	waitFor(t, func() bool { return jobState(s, "join").IsFinished() })
+
+	got, _ := s.GetJob("join")
+	if got.State != core.ConversionCompleted || got.Concat.Reencode || got.Duration != 6 {
+		t.Errorf("job = %+v, concat %+v, want a 6 s stream copy", got, got.Concat)
+	}
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if !strings.Contains(string(args), "-f concat -safe 0") || !strings.Contains(string(args), "-c copy") {
+		t.Errorf("FFmpeg args = %q, want the concat demuxer", args)
+	}
+}
+
+func TestStartConcatConversion_Reencode(t *testing.T) {
+	s, dir := newConcatService(t)
+	inputs := writeInputs(t, dir, "one.mkv", "opus.mkv")
+
+	if _, err := s.StartConcatConversion("nopreset", inputs, "", "", 0); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return jobState(s, "nopreset").IsFinished() })
+	if job, _ := s.GetJob("nopreset"); job.State != core.ConversionFailed || !strings.Contains(job.Error, "opus audio, not aac audio") {
+		t.Errorf("job = %s %q, want a failure explaining the mismatch", job.State, job.Error)
+	}
+
+	if _, err := s.StartConcatConversion("encode", inputs, "", "video-mp4-h264", 0); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return jobState(s, "encode").IsFinished() })
+	job, _ := s.GetJob("encode")
+	if job.State != core.ConversionCompleted || !job.Concat.Reencode || job.Concat.Reason == "" {
+		t.Fatalf("job = %s %q, concat %+v", job.State, job.Error, job.Concat)
+	}
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if !strings.Contains(string(args), "concat=n=2:v=1:a=1[vcat][acat];[vcat]scale=-2:ih[vout] -map [vout] -map [acat]") {
+		t.Errorf("FFmpeg args = %q, want the concat filter with the preset's scale", args)
+	}
+	if strings.Contains(string(args), "-vf") {
+		t.Errorf("FFmpeg args = %q, the preset's -vf should move into the filtergraph", args)
+	}
+
+	if _, err := s.StartConcatConversion("fade", inputs, "", "video-mp4-h264", 1); err == nil || !strings.Contains(err.Error(), "only supported when joining audio") {
+		t.Errorf("StartConcatConversion() error = %v, want crossfades refused for video", err)
+	}
+	if _, err := s.GetJob("fade"); err == nil {
+		t.Error("a refused crossfade was queued")
+	}
+}
+
+func TestStartConcatConversion_Errors(t *testing.T) {
+	s := New("/usr/bin/ffmpeg", nil)
+	tests := []struct {
+		name      string
+		inputs    []string
+		preset    string
+		crossfade float64
+	}{
+		{"one input", []string{"a.mp3"}, "", 0},
+		{"negative crossfade", []string{"a.mp3", "b.mp3"}, "audio-mp3-192", -1},
+		{"crossfade without preset", []string{"a.mp3", "b.mp3"}, "", 2},
+		{"crossfade with video preset", []string{"a.mp4", "b.mp4"}, "video-mp4-h264", 2},
+		{"unknown preset", []string{"a.mp3", "b.mp3"}, "nope", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.StartConcatConversion("j", tt.inputs, "", tt.preset, tt.crossfade); err == nil {
+				t.Error("StartConcatConversion() error = nil")
+			}
+		})
+	}
+	if _, err := New("", nil).StartConcatConversion("j", []string{"a", "b"}, "", "", 0); err == nil {
+		t.Error("StartConcatConversion() worked without ffmpeg")
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -26,6 +28,14 @@ type Service struct {
 	cancelFuncs map[string]context.CancelFunc
 	mu          sync.RWMutex
 	emit        func(event string, data interface{})
+
+	// Queue: jobs wait in pending until a slot is free
+	pending       map[string]*pendingJob
+	seq           uint64
+	running       int
+	maxConcurrent int
+	paused        bool
+	batches       map[string]*core.ConversionBatch
 }
 
 // New creates a new converter service.
@@ -52,22 +62,28 @@ func New(ffmpegPath string, emit func(string, interface{})) *Service {
 	return &Service{
 		ffmpegPath:  ffmpegPath,
 		ffprobePath: ffprobePath,
-		presets:     core.GetDefaultPresets(),
+		presets:     core.MergePresets(core.GetDefaultPresets(), nil),
 		jobs:        make(map[string]*core.ConversionJob),
 		cancelFuncs: make(map[string]context.CancelFunc),
 		emit:        emit,
+
+		pending:       make(map[string]*pendingJob),
+		maxConcurrent: defaultMaxConcurrent,
+		batches:       make(map[string]*core.ConversionBatch),
 	}
 }
 
 // GetPresets returns all available conversion presets.
 func (s *Service) GetPresets() []core.ConversionPreset {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.presets
 }
 
 // GetPresetsByCategory returns presets filtered by category.
 func (s *Service) GetPresetsByCategory(category string) []core.ConversionPreset {
 	var result []core.ConversionPreset
-	for _, p := range s.presets {
+	for _, p := range s.GetPresets() {
 		if p.Category == category {
 			result = append(result, p)
 		}
@@ -77,7 +93,7 @@ func (s *Service) GetPresetsByCategory(category string) []core.ConversionPreset
 
 // GetPreset returns a specific preset by ID.
 func (s *Service) GetPreset(id string) (*core.ConversionPreset, error) {
-	for _, p := range s.presets {
+	for _, p := range s.GetPresets() {
 		if p.ID == id {
 			return &p, nil
 		}
@@ -85,6 +101,90 @@ func (s *Service) GetPreset(id string) (*core.ConversionPreset, error) {
 	return nil, fmt.Errorf("preset not found: %s", id)
 }
 
+// SetUserPresets replaces the user presets offered next to the built-in
+// ones. A user preset with a built-in's ID replaces it.
+func (s *Service) SetUserPresets(presets []core.ConversionPreset) {
+	merged := core.MergePresets(core.GetDefaultPresets(), presets)
+
+	s.mu.Lock()
+	s.presets = merged
+	s.mu.Unlock()
+}
+
+// ValidatePreset checks a preset and then dry-runs its arguments on a short
+// generated clip, so options FFmpeg rejects are caught when the preset is
+// saved rather than when it is first used.
+func (s *Service) ValidatePreset(ctx context.Context, preset core.ConversionPreset) error {
+	if err := preset.Validate(); err != nil {
+		return err
+	}
+	if s.ffmpegPath == "" {
+		return fmt.Errorf("ffmpeg not available")
+	}
+
+	ext := preset.OutputExt
+	if ext == "" {
+		ext = "mkv"
+	}
+	dir, err := os.MkdirTemp("", "preset-check-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck // best-effort cleanup
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// Encode a sample first so stream-copy presets see real codecs.
+	sample := filepath.Join(dir, "sample.mkv")
+	if err := s.runCheck(ctx, "-f", "lavfi", "-i", "testsrc2=duration=0.5:size=320x240:rate=10",
+		"-f", "lavfi", "-i", "sine=duration=0.5", "-t", "0.5", "-c:v", "mpeg4", "-c:a", "aac", sample); err != nil {
+		return fmt.Errorf("preset %q: FFmpeg check could not create a sample: %w", preset.Name, err)
+	}
+
+	// Parameters are checked at their defaults.
+	presetArgs, err := preset.ResolveArgs(nil)
+	if err != nil {
+		return err
+	}
+	args := append([]string{"-i", sample}, presetArgs...)
+	args = append(args, filepath.Join(dir, "check."+ext))
+	if err := s.runCheck(ctx, args...); err != nil {
+		return fmt.Errorf("preset %q rejected by FFmpeg: %w", preset.Name, err)
+	}
+	return nil
+}
+
+// runCheck runs FFmpeg quietly and returns its last error lines on failure.
+func (s *Service) runCheck(ctx context.Context, args ...string) error {
+	args = append([]string{"-hide_banner", "-v", "error", "-y"}, args...)
+	cmd := exec.CommandContext(ctx, s.ffmpegPath, args...) //nolint:gosec // G204: ffmpeg subprocess expected
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("timed out")
+		}
+		return fmt.Errorf("%s", lastLines(string(out), 3))
+	}
+	return nil
+}
+
+// lastLines returns up to n trailing non-empty lines of out, joined by "; ".
+func lastLines(out string, n int) string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	if len(lines) == 0 {
+		return "no output"
+	}
+	return strings.Join(lines, "; ")
+}
+
 // AnalyzeFile analyzes a media file and returns its information.
 func (s *Service) AnalyzeFile(ctx context.Context, filePath string) (*core.MediaInfo, error) {
 	if s.ffprobePath == "" {
@@ -96,6 +196,7 @@ func (s *Service) AnalyzeFile(ctx context.Context, filePath string) (*core.Media
 		"-print_format", "json",
 		"-show_format",
 		"-show_streams",
+		"-show_chapters",
 		filePath,
 	}
 
@@ -107,21 +208,34 @@ func (s *Service) AnalyzeFile(ctx context.Context, filePath string) (*core.Media
 
 	var probeData struct {
 		Format struct {
-			Duration   string `json:"duration"`
-			Size       string `json:"size"`
-			BitRate    string `json:"bit_rate"`
-			FormatName string `json:"format_name"`
+			Duration   string            `json:"duration"`
+			Size       string            `json:"size"`
+			BitRate    string            `json:"bit_rate"`
+			FormatName string            `json:"format_name"`
+			Tags       map[string]string `json:"tags"`
 		} `json:"format"`
 		Streams []struct {
-			CodecType    string `json:"codec_type"`
-			CodecName    string `json:"codec_name"`
-			Width        int    `json:"width"`
-			Height       int    `json:"height"`
-			AvgFrameRate string `json:"avg_frame_rate"`
-			BitRate      string `json:"bit_rate"`
-			Channels     int    `json:"channels"`
-			SampleRate   string `json:"sample_rate"`
+			Index         int               `json:"index"`
+			CodecType     string            `json:"codec_type"`
+			CodecName     string            `json:"codec_name"`
+			Profile       string            `json:"profile"`
+			Width         int               `json:"width"`
+			Height        int               `json:"height"`
+			AvgFrameRate  string            `json:"avg_frame_rate"`
+			PixFmt        string            `json:"pix_fmt"`
+			BitRate       string            `json:"bit_rate"`
+			Channels      int               `json:"channels"`
+			ChannelLayout string            `json:"channel_layout"`
+			SampleRate    string            `json:"sample_rate"`
+			Disposition   map[string]int    `json:"disposition"`
+			Tags          map[string]string `json:"tags"`
+			SideDataList  []probeSideData   `json:"side_data_list"`
 		} `json:"streams"`
+		Chapters []struct {
+			StartTime string            `json:"start_time"`
+			EndTime   string            `json:"end_time"`
+			Tags      map[string]string `json:"tags"`
+		} `json:"chapters"`
 	}
 
 	if err := json.Unmarshal(output, &probeData); err != nil {
@@ -129,7 +243,9 @@ func (s *Service) AnalyzeFile(ctx context.Context, filePath string) (*core.Media
 	}
 
 	info := &core.MediaInfo{
-		Format: probeData.Format.FormatName,
+		Format:  probeData.Format.FormatName,
+		Streams: make([]core.MediaStream, 0, len(probeData.Streams)),
+		Tags:    probeData.Format.Tags,
 	}
 
 	if dur, err := strconv.ParseFloat(probeData.Format.Duration, 64); err == nil {
@@ -142,14 +258,50 @@ func (s *Service) AnalyzeFile(ctx context.Context, filePath string) (*core.Media
 		info.Bitrate = br
 	}
 
+	typeCounts := make(map[string]int)
 	for _, stream := range probeData.Streams {
+		ms := core.MediaStream{
+			Index:         stream.Index,
+			TypeIndex:     typeCounts[stream.CodecType],
+			Type:          stream.CodecType,
+			Codec:         stream.CodecName,
+			Profile:       stream.Profile,
+			Language:      probeTag(stream.Tags, "language"),
+			Title:         probeTag(stream.Tags, "title"),
+			Default:       stream.Disposition["default"] == 1,
+			Width:         stream.Width,
+			Height:        stream.Height,
+			Rotation:      streamRotation(stream.Tags["rotate"], stream.SideDataList),
+			FPS:           parseFrameRate(stream.AvgFrameRate),
+			PixFmt:        stream.PixFmt,
+			Channels:      stream.Channels,
+			ChannelLayout: stream.ChannelLayout,
+			Filename:      probeTag(stream.Tags, "filename"),
+			MimeType:      probeTag(stream.Tags, "mimetype"),
+		}
+		typeCounts[stream.CodecType]++
+		for flag, set := range stream.Disposition {
+			if set == 1 && flag != "default" {
+				ms.Disposition = append(ms.Disposition, flag)
+			}
+		}
+		slices.Sort(ms.Disposition)
+		if sr, err := strconv.Atoi(stream.SampleRate); err == nil {
+			ms.SampleRate = sr
+		}
+		if br, err := strconv.ParseInt(stream.BitRate, 10, 64); err == nil {
+			ms.Bitrate = br
+		}
+		info.Streams = append(info.Streams, ms)
+
 		switch stream.CodecType {
 		case "video":
 			if info.VideoStream == nil {
 				info.VideoStream = &core.VideoStream{
-					Codec:  stream.CodecName,
-					Width:  stream.Width,
-					Height: stream.Height,
+					Codec:    stream.CodecName,
+					Width:    stream.Width,
+					Height:   stream.Height,
+					Rotation: ms.Rotation,
 				}
 				if fps := parseFrameRate(stream.AvgFrameRate); fps > 0 {
 					info.VideoStream.FPS = fps
@@ -174,18 +326,109 @@ func (s *Service) AnalyzeFile(ctx context.Context, filePath string) (*core.Media
 		}
 	}
 
+	for _, ch := range probeData.Chapters {
+		chapter := core.Chapter{Title: probeTag(ch.Tags, "title")}
+		chapter.StartTime, _ = strconv.ParseFloat(ch.StartTime, 64) //nolint:errcheck // zero if missing
+		chapter.EndTime, _ = strconv.ParseFloat(ch.EndTime, 64)     //nolint:errcheck // zero if missing
+		info.Chapters = append(info.Chapters, chapter)
+	}
+
 	return info, nil
 }
 
+// probeTag looks up an ffprobe tag, whose case varies by container.
+func probeTag(tags map[string]string, key string) string {
+	if v, ok := tags[key]; ok {
+		return v
+	}
+	for k, v := range tags {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+// probeSideData is the part of ffprobe's stream side data the converter
+// reads.
+type probeSideData struct {
+	Rotation float64 `json:"rotation"` // Display matrix rotation, anticlockwise
+}
+
+// streamRotation returns the clockwise rotation players apply to a video
+// stream, from its display matrix or, in older files, its rotate tag.
+func streamRotation(tag string, sideData []probeSideData) int {
+	degrees, _ := strconv.Atoi(tag) //nolint:errcheck // zero if missing
+	for _, sd := range sideData {
+		if sd.Rotation != 0 {
+			degrees = -int(math.Round(sd.Rotation))
+		}
+	}
+	return (degrees%360 + 360) % 360
+}
+
 // StartConversion starts a new conversion job.
 func (s *Service) StartConversion(id, inputPath, outputPath, presetID string, customArgs []string) (*core.ConversionJob, error) {
 	return s.StartConversionWithTrim(id, inputPath, outputPath, presetID, customArgs, nil)
 }
 
-// StartConversionWithTrim starts a new conversion job with optional trim options.
+// StartConversionWithTrim queues a new conversion job with optional trim
+// options. It starts once a conversion slot is free.
 func (s *Service) StartConversionWithTrim(id, inputPath, outputPath, presetID string, customArgs []string, trim *core.TrimOptions) (*core.ConversionJob, error) {
+	job, args, err := s.newJob(id, inputPath, outputPath, presetID, customArgs, nil, trim)
+	if err != nil {
+		return nil, err
+	}
+	return s.enqueue(job, args), nil
+}
+
+// StartConversionWithParams queues a preset conversion with opts. Preset
+// parameters not given use their defaults, and no streams keeps FFmpeg's
+// default choice of one stream per type. Video filters go ahead of the
+// preset's own and are checked against the input's size once it is
+// analysed.
+func (s *Service) StartConversionWithParams(id, inputPath, outputPath, presetID string, opts core.ConversionOptions) (*core.ConversionJob, error) {
+	if presetID == "" {
+		return nil, fmt.Errorf("presetId required")
+	}
+	if opts.Trim != nil {
+		if err := opts.Trim.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	job, args, err := s.newJob(id, inputPath, outputPath, presetID, nil, opts.Params, opts.Trim)
+	if err != nil {
+		return nil, err
+	}
+	if filters := opts.VideoFilters; filters != nil {
+		if err := filters.Validate(0, 0); err != nil {
+			return nil, err
+		}
+		if args, err = withVideoFilter(args, filters.Filter()); err != nil {
+			return nil, err
+		}
+		job.VideoFilters = filters
+	}
+	job.Streams = opts.Streams
+	return s.enqueue(job, args), nil
+}
+
+// enqueue adds a new job to the queue and starts it if a slot is free.
+func (s *Service) enqueue(job *core.ConversionJob, args []string) *core.ConversionJob {
+	s.mu.Lock()
+	s.enqueueLocked(job, args)
+	state := s.queueStateLocked()
+	s.mu.Unlock()
+
+	s.emitQueueState(state)
+	return job
+}
+
+// newJob builds a queued job and the FFmpeg arguments for its preset, with
+// params substituted, or custom arguments.
+func (s *Service) newJob(id, inputPath, outputPath, presetID string, customArgs []string, params map[string]string, trim *core.TrimOptions) (*core.ConversionJob, []string, error) {
 	if s.ffmpegPath == "" {
-		return nil, fmt.Errorf("ffmpeg not available")
+		return nil, nil, fmt.Errorf("ffmpeg not available")
 	}
 
 	// Get preset if specified
@@ -194,30 +437,23 @@ func (s *Service) StartConversionWithTrim(id, inputPath, outputPath, presetID st
 	case presetID != "":
 		preset, err := s.GetPreset(presetID)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+		if args, err = preset.ResolveArgs(params); err == nil {
+			params, err = preset.ResolveParams(params)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", core.ErrInvalidPreset, err)
 		}
-		args = preset.FFmpegArgs
 
 		// Set output extension based on preset if not already set
 		if outputPath == "" {
-			dir := filepath.Dir(inputPath)
-			ext := filepath.Ext(inputPath)
-			base := strings.TrimSuffix(filepath.Base(inputPath), ext)
-			suffix := "_converted"
-			if trim != nil && (trim.StartTime > 0 || trim.EndTime > 0) {
-				suffix = "_trimmed"
-			}
-			// Preserve original extension if preset.OutputExt is empty
-			outExt := preset.OutputExt
-			if outExt == "" {
-				outExt = strings.TrimPrefix(ext, ".")
-			}
-			outputPath = filepath.Join(dir, base+suffix+"."+outExt)
+			outputPath = defaultOutputPath(inputPath, outputExt(preset, inputPath), trim)
 		}
 	case len(customArgs) > 0:
 		args = customArgs
 	default:
-		return nil, fmt.Errorf("either presetId or customArgs required")
+		return nil, nil, fmt.Errorf("either presetId or customArgs required")
 	}
 
 	job := &core.ConversionJob{
@@ -225,37 +461,47 @@ func (s *Service) StartConversionWithTrim(id, inputPath, outputPath, presetID st
 		InputPath:   inputPath,
 		OutputPath:  outputPath,
 		PresetID:    presetID,
+		Params:      params,
 		CustomArgs:  customArgs,
 		TrimOptions: trim,
 		State:       core.ConversionQueued,
 	}
-
-	s.mu.Lock()
-	s.jobs[id] = job
-	s.mu.Unlock()
-
-	go s.runConversion(job, args)
-
-	return job, nil
+	return job, args, nil
 }
 
-func (s *Service) runConversion(job *core.ConversionJob, ffmpegArgs []string) {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+// defaultOutputPath names the output after the input, next to it.
+func defaultOutputPath(inputPath, ext string, trim *core.TrimOptions) string {
+	dir := filepath.Dir(inputPath)
+	base := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	suffix := "_converted"
+	if trim != nil && (trim.StartTime > 0 || trim.EndTime > 0) {
+		suffix = "_trimmed"
+	}
+	return filepath.Join(dir, base+suffix+"."+ext)
+}
 
-	s.mu.Lock()
-	s.cancelFuncs[job.ID] = cancel
-	s.mu.Unlock()
+// outputExt returns the preset's output extension, or the input's own when
+// the preset keeps the container.
+func outputExt(preset *core.ConversionPreset, inputPath string) string {
+	if preset.OutputExt != "" {
+		return preset.OutputExt
+	}
+	return strings.TrimPrefix(filepath.Ext(inputPath), ".")
+}
 
-	defer func() {
-		s.mu.Lock()
-		delete(s.cancelFuncs, job.ID)
-		s.mu.Unlock()
-	}()
+// runConversion runs a job dispatched from the queue; ctx is cancelled by
+// CancelConversion.
+func (s *Service) runConversion(ctx context.Context, job *core.ConversionJob, ffmpegArgs []string) {
+	defer s.finishJob(job.ID)
 
 	// Analyze input file first
 	s.updateJobState(job.ID, core.ConversionAnalyzing, 0, "")
 
+	if job.Concat != nil {
+		s.endJob(ctx, job, s.runConcat(ctx, job, ffmpegArgs))
+		return
+	}
+
 	info, err := s.AnalyzeFile(ctx, job.InputPath)
 	if err != nil {
 		s.updateJobState(job.ID, core.ConversionFailed, 0, fmt.Sprintf("Analysis failed: %v", err))
@@ -280,7 +526,64 @@ func (s *Service) runConversion(job *core.ConversionJob, ffmpegArgs []string) {
 	job.Duration = effectiveDuration
 	s.mu.Unlock()
 
-	// Build FFmpeg command - trim args must come BEFORE input for fast seeking
+	maps, err := info.StreamMapArgs(job.Streams)
+	if err != nil {
+		s.updateJobState(job.ID, core.ConversionFailed, 0, fmt.Sprintf("Stream selection: %v", err))
+		return
+	}
+	if job.VideoFilters != nil {
+		if err := checkVideoFilters(job.VideoFilters, info); err != nil {
+			s.updateJobState(job.ID, core.ConversionFailed, 0, fmt.Sprintf("Video filters: %v", err))
+			return
+		}
+	}
+
+	switch {
+	case job.TargetSize != nil:
+		err = s.runTwoPass(ctx, job, info, maps)
+	case job.Animation != nil:
+		err = s.runAnimation(ctx, job, info)
+	default:
+		args := append(inputArgs(job, maps), ffmpegArgs...)
+		err = s.runFFmpeg(ctx, job, append(args, job.OutputPath), 0, 100)
+	}
+	s.endJob(ctx, job, err)
+}
+
+// endJob records how a job's FFmpeg run ended.
+func (s *Service) endJob(ctx context.Context, job *core.ConversionJob, err error) {
+	if ctx.Err() == context.Canceled {
+		s.updateJobState(job.ID, core.ConversionCancelled, 0, "")
+		// Clean up partial output
+		_ = os.Remove(job.OutputPath) //nolint:errcheck // best-effort cleanup
+		return
+	}
+
+	if err != nil {
+		s.updateJobState(job.ID, core.ConversionFailed, 0, err.Error())
+		return
+	}
+
+	// Batch reports add up the sizes, so they are read once here rather
+	// than on every report.
+	var inputBytes, outputBytes int64
+	if info, err := os.Stat(job.InputPath); err == nil {
+		inputBytes = info.Size()
+	}
+	if info, err := os.Stat(job.OutputPath); err == nil {
+		outputBytes = info.Size()
+	}
+	s.mu.Lock()
+	job.InputBytes, job.OutputBytes = inputBytes, outputBytes
+	s.mu.Unlock()
+
+	s.updateJobState(job.ID, core.ConversionCompleted, 100, "")
+}
+
+// inputArgs returns the FFmpeg arguments up to the job's own: the input,
+// its trim range, the streams to keep and progress reporting.
+func inputArgs(job *core.ConversionJob, maps []string) []string {
+	// Trim args must come BEFORE input for fast seeking
 	var args []string
 	args = append(args, "-y") // Overwrite output
 
@@ -300,24 +603,25 @@ func (s *Service) runConversion(job *core.ConversionJob, ffmpegArgs []string) {
 		}
 	}
 
-	args = append(args, "-progress", "pipe:1", "-nostats")
-	args = append(args, ffmpegArgs...)
-	args = append(args, job.OutputPath)
+	args = append(args, maps...)
+	return append(args, "-progress", "pipe:1", "-nostats")
+}
 
+// runFFmpeg runs FFmpeg for job, reporting its progress mapped onto
+// base..base+span percent of the job.
+func (s *Service) runFFmpeg(ctx context.Context, job *core.ConversionJob, args []string, base, span float64) error {
 	cmd := exec.CommandContext(ctx, s.ffmpegPath, args...) //nolint:gosec // G204: ffmpeg subprocess expected
 
 	// Capture progress from stdout
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		s.updateJobState(job.ID, core.ConversionFailed, 0, err.Error())
-		return
+		return err
 	}
 
-	s.updateJobState(job.ID, core.ConversionConverting, 0, "")
+	s.updateJobState(job.ID, core.ConversionConverting, base, "")
 
 	if err := cmd.Start(); err != nil {
-		s.updateJobState(job.ID, core.ConversionFailed, 0, err.Error())
-		return
+		return err
 	}
 
 	// Parse progress
@@ -346,12 +650,9 @@ func (s *Service) runConversion(job *core.ConversionJob, ffmpegArgs []string) {
 		if strings.HasPrefix(line, "progress=") {
 			// Calculate progress
 			currentTime := float64(currentTimeMs) / 1000000.0
-			var progress float64
+			progress := base
 			if job.Duration > 0 {
-				progress = (currentTime / job.Duration) * 100
-				if progress > 100 {
-					progress = 100
-				}
+				progress += min(currentTime/job.Duration, 1) * span
 			}
 
 			s.mu.Lock()
@@ -364,33 +665,27 @@ func (s *Service) runConversion(job *core.ConversionJob, ffmpegArgs []string) {
 	}
 
 	// Wait for completion
-	err = cmd.Wait()
-	if ctx.Err() == context.Canceled {
-		s.updateJobState(job.ID, core.ConversionCancelled, 0, "")
-		// Clean up partial output
-		_ = os.Remove(job.OutputPath) //nolint:errcheck // best-effort cleanup
-		return
-	}
-
-	if err != nil {
-		s.updateJobState(job.ID, core.ConversionFailed, 0, err.Error())
-		return
-	}
-
-	s.updateJobState(job.ID, core.ConversionCompleted, 100, "")
+	return cmd.Wait()
 }
 
-// CancelConversion cancels a running conversion.
+// CancelConversion cancels a running or queued conversion.
 func (s *Service) CancelConversion(id string) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	cancel, ok := s.cancelFuncs[id]
-	if !ok {
+	if cancel, ok := s.cancelFuncs[id]; ok {
+		s.mu.Unlock()
+		cancel()
+		return nil
+	}
+	if _, ok := s.pending[id]; !ok {
+		s.mu.Unlock()
 		return fmt.Errorf("conversion not found or not running: %s", id)
 	}
+	delete(s.pending, id)
+	state := s.queueStateLocked()
+	s.mu.Unlock()
 
-	cancel()
+	s.updateJobState(id, core.ConversionCancelled, 0, "")
+	s.emitQueueState(state)
 	return nil
 }
 
@@ -433,6 +728,7 @@ func (s *Service) RemoveJob(id string) error {
 	}
 
 	delete(s.jobs, id)
+	delete(s.pending, id)
 	return nil
 }
 
@@ -450,27 +746,47 @@ func (s *Service) ClearCompletedJobs() {
 
 func (s *Service) updateJobState(id string, state core.ConversionState, progress float64, errMsg string) {
 	s.mu.Lock()
+	var batchID string
 	if job, ok := s.jobs[id]; ok {
 		job.State = state
 		job.Progress = progress
 		if errMsg != "" {
 			job.Error = errMsg
 		}
+		batchID = job.BatchID
+	}
+	var report *core.ConversionBatchReport
+	if batch, ok := s.batches[batchID]; ok {
+		report = s.batchReportLocked(batch)
 	}
 	s.mu.Unlock()
 
 	s.emitProgress(id, state, progress, 0, 0, errMsg)
+	if report != nil {
+		s.emitBatch(report)
+	}
 }
 
 func (s *Service) emitProgress(id string, state core.ConversionState, progress, currentTime, speed float64, errMsg string) {
 	if s.emit != nil {
+		var pass int
+		var estimated int64
+		s.mu.RLock()
+		if job, ok := s.jobs[id]; ok {
+			pass = job.Pass
+			estimated = job.EstimatedBytes
+		}
+		s.mu.RUnlock()
+
 		s.emit("conversion:progress", core.ConversionProgress{
-			JobID:       id,
-			State:       state,
-			Progress:    progress,
-			CurrentTime: currentTime,
-			Speed:       speed,
-			Error:       errMsg,
+			JobID:          id,
+			State:          state,
+			Progress:       progress,
+			CurrentTime:    currentTime,
+			Speed:          speed,
+			Pass:           pass,
+			EstimatedBytes: estimated,
+			Error:          errMsg,
 		})
 	}
 }
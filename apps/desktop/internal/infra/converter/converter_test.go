@@ -2,8 +2,10 @@ package converter
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 
@@ -1210,3 +1212,184 @@ func TestStartConversion_DelegatesTo_StartConversionWithTrim(t *testing.T) {
 		t.Errorf("StartConversion and StartConversionWithTrim should produce same error: %q vs %q", err1.Error(), err2.Error())
 	}
 }
+
+func TestValidatePreset(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as a fake ffmpeg")
+	}
+	dir := t.TempDir()
+	ffmpeg := filepath.Join(dir, "ffmpeg")
+	script := "#!/bin/sh\ncase \"$*\" in *libnope*) echo \"[aost#0:0] Unknown encoder 'libnope'\" >&2; exit 1;; esac\nfor a; do out=$a; done\ntouch \"$out\"\n"
+	if err := os.WriteFile(ffmpeg, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	service := New(ffmpeg, nil)
+
+	ok := core.ConversionPreset{ID: "a", Name: "Voice", OutputExt: "ogg", FFmpegArgs: []string{"-vn", "-c:a", "libvorbis"}}
+	if err := service.ValidatePreset(context.Background(), ok); err != nil {
+		t.Errorf("ValidatePreset() error = %v", err)
+	}
+
+	bad := core.ConversionPreset{ID: "b", Name: "Broken", FFmpegArgs: []string{"-c:a", "libnope"}}
+	err := service.ValidatePreset(context.Background(), bad)
+	if err == nil || !strings.Contains(err.Error(), "Unknown encoder 'libnope'") {
+		t.Errorf("ValidatePreset() error = %v, want FFmpeg's message", err)
+	}
+
+	if err := service.ValidatePreset(context.Background(), core.ConversionPreset{ID: "c", Name: "C"}); err == nil {
+		t.Error("ValidatePreset() accepted a preset without arguments")
+	}
+	if err := New("", nil).ValidatePreset(context.Background(), ok); err == nil {
+		t.Error("ValidatePreset() worked without ffmpeg")
+	}
+
+	// Hostile presets are refused before FFmpeg runs.
+	if err := os.WriteFile(ffmpeg, []byte("#!/bin/sh\ntouch \""+filepath.Join(dir, "ran")+"\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	owned := filepath.Join(dir, "owned")
+	for _, args := range [][]string{
+		{"-c:a", "aac", owned},
+		{"-dump_attachment:t", owned},
+		{"-f", "tee", "-c", "copy"},
+		{"-vf", "null,drawtext=textfile=/etc/passwd"},
+	} {
+		if err := service.ValidatePreset(context.Background(), core.ConversionPreset{ID: "h", Name: "Hostile", FFmpegArgs: args}); err == nil {
+			t.Errorf("ValidatePreset() accepted %q", args)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ran")); err == nil {
+		t.Error("ValidatePreset() ran FFmpeg for a hostile preset")
+	}
+}
+
+func TestSetUserPresets(t *testing.T) {
+	service := New("", nil)
+	service.SetUserPresets([]core.ConversionPreset{{ID: "user-1", Name: "Mine", Category: "speech", FFmpegArgs: []string{"-vn"}}})
+
+	if p, err := service.GetPreset("user-1"); err != nil || p.Source != core.PresetUser {
+		t.Errorf("GetPreset() = %+v, %v", p, err)
+	}
+	if got := service.GetPresetsByCategory("speech"); len(got) != 1 {
+		t.Errorf("GetPresetsByCategory(speech) = %+v", got)
+	}
+	if got := len(service.GetPresets()); got != len(core.GetDefaultPresets())+1 {
+		t.Errorf("GetPresets() = %d presets", got)
+	}
+}
+
+func TestStartConversionWithParams(t *testing.T) {
+	s, dir := newFakeService(t, nil)
+	release(t, dir)
+	input := filepath.Join(dir, "in.mkv")
+
+	job, err := s.StartConversionWithParams("j1", input, "", "video-mp4-h264", core.ConversionOptions{Params: map[string]string{"crf": "18", "height": "720"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Params["crf"] != "18" || job.Params["height"] != "720" || job.Params["speed"] != "medium" {
+		t.Errorf("Params = %v, want the given values and defaults", job.Params)
+	}
+
+	_, err = s.StartConversionWithParams("j2", input, "", "video-mp4-h264", core.ConversionOptions{Params: map[string]string{"crf": "99"}})
+	if !errors.Is(err, core.ErrInvalidPreset) {
+		t.Errorf("StartConversionWithParams() error = %v, want ErrInvalidPreset", err)
+	}
+	if _, err := s.GetJob("j2"); err == nil {
+		t.Error("a rejected conversion was queued")
+	}
+	if _, err := s.StartConversionWithParams("j3", input, "", "", core.ConversionOptions{}); err == nil {
+		t.Error("StartConversionWithParams() accepted no preset")
+	}
+}
+
+// multiStreamProbe is ffprobe output for an MKV with two audio languages,
+// subtitles, a font attachment and chapters.
+const multiStreamProbe = `{
+  "streams": [
+    {"index": 0, "codec_type": "video", "codec_name": "h264", "profile": "High", "width": 1920, "height": 1080, "avg_frame_rate": "24000/1001", "pix_fmt": "yuv420p", "disposition": {"default": 1}},
+    {"index": 1, "codec_type": "audio", "codec_name": "aac", "channels": 6, "channel_layout": "5.1", "sample_rate": "48000", "disposition": {"default": 1}, "tags": {"language": "eng", "title": "Surround"}},
+    {"index": 2, "codec_type": "audio", "codec_name": "opus", "channels": 2, "sample_rate": "48000", "disposition": {"default": 0, "comment": 1}, "tags": {"LANGUAGE": "deu"}},
+    {"index": 3, "codec_type": "subtitle", "codec_name": "subrip", "disposition": {"default": 0, "forced": 1, "hearing_impaired": 1}, "tags": {"language": "eng"}},
+    {"index": 4, "codec_type": "attachment", "codec_name": "ttf", "tags": {"filename": "font.ttf", "mimetype": "font/ttf"}}
+  ],
+  "chapters": [
+    {"id": 0, "start_time": "0.000000", "end_time": "90.500000", "tags": {"title": "Intro"}},
+    {"id": 1, "start_time": "90.500000", "end_time": "600.000000", "tags": {"title": "Main"}}
+  ],
+  "format": {"duration": "600.0", "format_name": "matroska,webm", "tags": {"title": "Film", "encoder": "libebml"}}
+}`
+
+func TestAnalyzeFile_Streams(t *testing.T) {
+	s, dir := newFakeService(t, nil)
+	writeProbe(t, dir, multiStreamProbe)
+
+	info, err := s.AnalyzeFile(context.Background(), filepath.Join(dir, "film.mkv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Streams) != 5 {
+		t.Fatalf("Streams = %+v, want 5", info.Streams)
+	}
+	de := info.Streams[2]
+	if de.Type != core.StreamAudio || de.TypeIndex != 1 || de.Language != "deu" || de.Default || len(de.Disposition) != 1 || de.Disposition[0] != "comment" {
+		t.Errorf("second audio stream = %+v", de)
+	}
+	if v := info.Streams[0]; v.Profile != "High" || v.PixFmt != "yuv420p" {
+		t.Errorf("video stream = %+v", v)
+	}
+	if en := info.Streams[1]; en.Title != "Surround" || !en.Default || en.ChannelLayout != "5.1" || en.SampleRate != 48000 {
+		t.Errorf("first audio stream = %+v", en)
+	}
+	if sub := info.Streams[3]; strings.Join(sub.Disposition, ",") != "forced,hearing_impaired" {
+		t.Errorf("subtitle disposition = %v", sub.Disposition)
+	}
+	if font := info.Streams[4]; font.Filename != "font.ttf" || font.MimeType != "font/ttf" {
+		t.Errorf("attachment = %+v", font)
+	}
+	if info.AudioStream == nil || info.AudioStream.Codec != "aac" || info.VideoStream == nil || info.VideoStream.Width != 1920 {
+		t.Errorf("first streams = %+v, %+v", info.VideoStream, info.AudioStream)
+	}
+	if info.Tags["title"] != "Film" {
+		t.Errorf("Tags = %v", info.Tags)
+	}
+	if len(info.Chapters) != 2 || info.Chapters[1].Title != "Main" || info.Chapters[1].StartTime != 90.5 || info.Chapters[1].EndTime != 600 {
+		t.Errorf("Chapters = %+v", info.Chapters)
+	}
+}
+
+func TestStartConversion_StreamSelection(t *testing.T) {
+	s, dir := newFakeService(t, nil)
+	writeProbe(t, dir, multiStreamProbe)
+	// Record the arguments instead of waiting for a release.
+	script := "#!/bin/sh\necho \"$*\" >> \"$(dirname \"$0\")/args\"\nfor a; do out=$a; done\necho converted > \"$out\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "ffmpeg"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	input := filepath.Join(dir, "film.mkv")
+
+	if _, err := s.StartConversionWithParams("keep", input, filepath.Join(dir, "out.mkv"), "trim-copy", core.ConversionOptions{Streams: []int{0, 2, 1}}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return jobState(s, "keep") == core.ConversionCompleted })
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if !strings.Contains(string(args), "-i "+input+" -map 0:0 -map 0:2 -map 0:1 -progress") {
+		t.Errorf("FFmpeg args = %q, want the selected streams mapped in order", args)
+	}
+
+	if _, err := s.StartConversionWithParams("missing", input, filepath.Join(dir, "out2.mkv"), "trim-copy", core.ConversionOptions{Streams: []int{7}}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return jobState(s, "missing") == core.ConversionFailed })
+	if job, _ := s.GetJob("missing"); !strings.Contains(job.Error, "no stream 7") {
+		t.Errorf("Error = %q, want the missing stream named", job.Error)
+	}
+}
+
+func writeProbe(t *testing.T, dir, output string) {
+	t.Helper()
+	script := "#!/bin/sh\ncat <<'EOF'\n" + output + "\nEOF\n"
+	if err := os.WriteFile(filepath.Join(dir, "ffprobe"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
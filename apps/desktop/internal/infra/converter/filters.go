@@ -0,0 +1,55 @@
+package converter
+
+import (
+	"fmt"
+	"slices"
+
+	"ybdownloader/internal/core"
+)
+
+// withVideoFilter puts filter ahead of the video filters in a preset's
+// arguments, or adds it when the preset has none. Presets that drop or
+// stream-copy the video, or build their own filtergraph, cannot take it.
+func withVideoFilter(args []string, filter string) ([]string, error) {
+	if filter == "" {
+		return args, nil
+	}
+	for i, arg := range args {
+		switch arg {
+		case "-vn":
+			return nil, fmt.Errorf("the preset drops the video, so video filters cannot apply")
+		case "-filter_complex", "-lavfi":
+			return nil, fmt.Errorf("the preset builds its own filtergraph, so video filters cannot be added")
+		case "-c", "-codec", "-c:v", "-codec:v", "-vcodec":
+			if i+1 < len(args) && args[i+1] == "copy" {
+				return nil, fmt.Errorf("the preset copies the video without re-encoding, so video filters cannot apply")
+			}
+		}
+	}
+
+	args = slices.Clone(args)
+	for i, arg := range args {
+		if (arg == "-vf" || arg == "-filter:v") && i+1 < len(args) {
+			args[i+1] = filter + "," + args[i+1]
+			return args, nil
+		}
+	}
+	return append(args, "-vf", filter), nil
+}
+
+// videoFilter returns the filter chain for filters, which may be nil.
+func videoFilter(filters *core.VideoFilterOptions) string {
+	if filters == nil {
+		return ""
+	}
+	return filters.Filter()
+}
+
+// checkVideoFilters validates a job's video filters against the input's
+// frames as FFmpeg's autorotation leaves them.
+func checkVideoFilters(filters *core.VideoFilterOptions, info *core.MediaInfo) error {
+	if info.VideoStream == nil {
+		return fmt.Errorf("the input has no video")
+	}
+	return filters.Validate(info.VideoStream.DisplaySize())
+}
@@ -0,0 +1,136 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"ybdownloader/internal/core"
+)
+
+func TestWithVideoFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{"prepends to the preset's filters", []string{"-vf", "scale=-2:720", "-c:v", "libx264"},
+			[]string{"-vf", "hflip,scale=-2:720", "-c:v", "libx264"}, false},
+		{"adds a filter option", []string{"-c:v", "libx264", "-crf", "23"},
+			[]string{"-c:v", "libx264", "-crf", "23", "-vf", "hflip"}, false},
+		{"audio only", []string{"-vn", "-c:a", "libmp3lame"}, nil, true},
+		{"stream copy", []string{"-codec", "copy"}, nil, true},
+		{"video copy", []string{"-c:v", "copy", "-c:a", "aac"}, nil, true},
+		{"own filtergraph", []string{"-filter_complex", "[0:v]null[v]", "-map", "[v]"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := withVideoFilter(tt.args, "hflip")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("withVideoFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("withVideoFilter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	args := []string{"-vf", "scale=-2:720"}
+	if _, err := withVideoFilter(args, "hflip"); err != nil || args[1] != "scale=-2:720" {
+		t.Errorf("withVideoFilter() changed the preset's arguments to %q", args)
+	}
+}
+
+func TestStartConversionWithParams_VideoFilters(t *testing.T) {
+	s, dir := newConcatService(t)
+	input := writeInputs(t, dir, "clip.mkv")[0]
+
+	filters := &core.VideoFilterOptions{Crop: &core.CropRect{X: 40, Width: 600, Height: 360}, FlipV: true}
+	job, err := s.StartConversionWithParams("crop", input, "", "video-mp4-h264", core.ConversionOptions{VideoFilters: filters})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.VideoFilters != filters {
+		t.Errorf("VideoFilters = %+v, want the filters recorded", job.VideoFilters)
+	}
+	waitFor(t, func() bool { return jobState(s, "crop").IsFinished() })
+	if job, _ := s.GetJob("crop"); job.State != core.ConversionCompleted {
+		t.Fatalf("job = %s %q", job.State, job.Error)
+	}
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if !strings.Contains(string(args), "-vf crop=600:360:40:0,vflip,scale=-2:ih") {
+		t.Errorf("FFmpeg args = %q, want the filters ahead of the preset's", args)
+	}
+
+	// The fake probe reports a 640x360 frame.
+	outside := &core.VideoFilterOptions{Crop: &core.CropRect{X: 100, Width: 600, Height: 360}}
+	if _, err := s.StartConversionWithParams("outside", input, "", "video-mp4-h264", core.ConversionOptions{VideoFilters: outside}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return jobState(s, "outside").IsFinished() })
+	if job, _ := s.GetJob("outside"); job.State != core.ConversionFailed || !strings.Contains(job.Error, "outside the 640x360 frame") {
+		t.Errorf("job = %s %q, want the crop refused", job.State, job.Error)
+	}
+
+	if _, err := s.StartConversionWithParams("copy", input, "", "trim-copy", core.ConversionOptions{VideoFilters: filters}); err == nil {
+		t.Error("StartConversionWithParams() applied filters to a stream copy")
+	}
+	if _, err := s.StartConversionWithParams("bad", input, "", "video-mp4-h264", core.ConversionOptions{VideoFilters: &core.VideoFilterOptions{Rotate: 45}}); err == nil {
+		t.Error("StartConversionWithParams() accepted a 45 degree rotation")
+	}
+}
+
+// portraitProbe is ffprobe output for a phone clip stored as 640x360 and
+// displayed rotated a quarter turn clockwise.
+const portraitProbe = `{
+  "streams": [
+    {"index": 0, "codec_type": "video", "codec_name": "h264", "width": 640, "height": 360, "side_data_list": [{"side_data_type": "Display Matrix", "rotation": -90}]}
+  ],
+  "format": {"duration": "2.0", "format_name": "mov,mp4,m4a,3gp,3g2,mj2"}
+}`
+
+func TestStartConversionWithParams_VideoFiltersRotated(t *testing.T) {
+	s, dir := newConcatService(t)
+	writeProbe(t, dir, portraitProbe)
+	input := writeInputs(t, dir, "phone.mp4")[0]
+
+	// FFmpeg rotates the frames to 360x640 before the crop.
+	tall := &core.VideoFilterOptions{Crop: &core.CropRect{Width: 360, Height: 600}}
+	if _, err := s.StartConversionWithParams("tall", input, "", "video-mp4-h264", core.ConversionOptions{VideoFilters: tall}); err != nil {
+		t.Fatal(err)
+	}
+	wide := &core.VideoFilterOptions{Crop: &core.CropRect{Width: 600, Height: 360}}
+	if _, err := s.StartConversionWithParams("wide", input, "", "video-mp4-h264", core.ConversionOptions{VideoFilters: wide}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return jobState(s, "tall").IsFinished() && jobState(s, "wide").IsFinished() })
+	if job, _ := s.GetJob("tall"); job.State != core.ConversionCompleted {
+		t.Errorf("job = %s %q, want the crop inside the rotated frame accepted", job.State, job.Error)
+	}
+	if job, _ := s.GetJob("wide"); job.State != core.ConversionFailed || !strings.Contains(job.Error, "outside the 360x640 frame") {
+		t.Errorf("job = %s %q, want the crop checked against the rotated frame", job.State, job.Error)
+	}
+}
+
+func TestStreamRotation(t *testing.T) {
+	tests := []struct {
+		tag      string
+		sideData []probeSideData
+		want     int
+	}{
+		{"", nil, 0},
+		{"90", nil, 90},
+		{"", []probeSideData{{Rotation: -90}}, 90},
+		{"", []probeSideData{{Rotation: 90}}, 270},
+		{"", []probeSideData{{}, {Rotation: 180}}, 180},
+		{"270", []probeSideData{{Rotation: -90}}, 90},
+	}
+	for _, tt := range tests {
+		if got := streamRotation(tt.tag, tt.sideData); got != tt.want {
+			t.Errorf("streamRotation(%q, %v) = %d, want %d", tt.tag, tt.sideData, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+
+	"ybdownloader/internal/core"
+)
+
+// defaultMaxConcurrent is the number of FFmpeg processes run at once until
+// SetMaxConcurrent is called.
+const defaultMaxConcurrent = 2
+
+// pendingJob is a queued job waiting for a conversion slot.
+type pendingJob struct {
+	args []string
+	seq  uint64 // Enqueue order; breaks priority ties
+}
+
+// enqueueLocked queues job and starts it if a slot is free. Callers hold s.mu.
+func (s *Service) enqueueLocked(job *core.ConversionJob, args []string) {
+	s.seq++
+	s.jobs[job.ID] = job
+	s.pending[job.ID] = &pendingJob{args: args, seq: s.seq}
+	s.dispatchLocked()
+}
+
+// dispatchLocked starts queued jobs, highest priority first and then in
+// the order they were queued, until every slot is taken. Callers hold s.mu.
+func (s *Service) dispatchLocked() {
+	for !s.paused && s.running < s.maxConcurrent {
+		id := s.nextPendingLocked()
+		if id == "" {
+			return
+		}
+		p := s.pending[id]
+		delete(s.pending, id)
+
+		job := s.jobs[id]
+		job.State = core.ConversionAnalyzing
+		ctx, cancel := context.WithCancel(context.Background())
+		s.cancelFuncs[id] = cancel
+		s.running++
+		go s.runConversion(ctx, job, p.args)
+	}
+}
+
+func (s *Service) nextPendingLocked() string {
+	var next string
+	for id, p := range s.pending {
+		job, ok := s.jobs[id]
+		if !ok {
+			delete(s.pending, id)
+			continue
+		}
+		if next == "" {
+			next = id
+			continue
+		}
+		best := s.jobs[next]
+		if job.Priority > best.Priority || (job.Priority == best.Priority && p.seq < s.pending[next].seq) {
+			next = id
+		}
+	}
+	return next
+}
+
+// finishJob frees the slot of a job that stopped running and starts the
+// next one.
+func (s *Service) finishJob(id string) {
+	s.mu.Lock()
+	if cancel, ok := s.cancelFuncs[id]; ok {
+		cancel()
+		delete(s.cancelFuncs, id)
+	}
+	s.running--
+	s.dispatchLocked()
+	state := s.queueStateLocked()
+	s.mu.Unlock()
+
+	s.emitQueueState(state)
+}
+
+// SetMaxConcurrent sets how many conversions run at once. Lowering it lets
+// running jobs finish; raising it starts queued jobs right away.
+func (s *Service) SetMaxConcurrent(n int) {
+	n = min(max(n, 1), core.MaxConcurrentConversionsLimit)
+
+	s.mu.Lock()
+	if n == s.maxConcurrent {
+		s.mu.Unlock()
+		return
+	}
+	s.maxConcurrent = n
+	s.dispatchLocked()
+	state := s.queueStateLocked()
+	s.mu.Unlock()
+
+	s.emitQueueState(state)
+}
+
+// PauseQueue stops queued jobs from starting. Running jobs are not
+// interrupted.
+func (s *Service) PauseQueue() {
+	s.setPaused(true)
+}
+
+// ResumeQueue starts queued jobs again.
+func (s *Service) ResumeQueue() {
+	s.setPaused(false)
+}
+
+func (s *Service) setPaused(paused bool) {
+	s.mu.Lock()
+	s.paused = paused
+	s.dispatchLocked()
+	state := s.queueStateLocked()
+	s.mu.Unlock()
+
+	s.emitQueueState(state)
+}
+
+// SetJobPriority changes the priority of a job. It only matters while the
+// job is queued; higher priorities start first.
+func (s *Service) SetJobPriority(id string, priority int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return fmt.Errorf("job not found: %s", id)
+	}
+	job.Priority = priority
+	return nil
+}
+
+// QueueState returns the conversion queue's state.
+func (s *Service) QueueState() core.ConversionQueueState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.queueStateLocked()
+}
+
+func (s *Service) queueStateLocked() core.ConversionQueueState {
+	return core.ConversionQueueState{
+		Paused:        s.paused,
+		MaxConcurrent: s.maxConcurrent,
+		Running:       s.running,
+		Queued:        len(s.pending),
+	}
+}
+
+func (s *Service) emitQueueState(state core.ConversionQueueState) {
+	if s.emit != nil {
+		s.emit("conversion:queue", state)
+	}
+}
@@ -0,0 +1,212 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ybdownloader/internal/core"
+)
+
+// fakeFFmpeg is an ffmpeg stand-in: it marks itself running, waits for a
+// "release" file next to it, then writes the output (the last argument) or
+// fails when the output name contains "fail". Every started output is
+// appended to "started".
+const fakeFFmpeg = `#!/bin/sh
+dir=$(dirname "$0")
+for a; do out=$a; done
+echo "$out" >> "$dir/started"
+touch "$dir/running.$$"
+while [ ! -f "$dir/release" ]; do sleep 0.01; done
+rm "$dir/running.$$"
+case "$out" in *fail*) exit 1;; esac
+echo converted > "$out"
+echo progress=end
+`
+
+const fakeFFprobe = `#!/bin/sh
+echo '{"format":{"duration":"1.0","format_name":"matroska"},"streams":[]}'
+`
+
+// eventRecorder collects emitted events.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []string
+	data   []interface{}
+}
+
+func (r *eventRecorder) emit(event string, data interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	r.data = append(r.data, data)
+}
+
+// newFakeService returns a service running fakeFFmpeg from dir.
+func newFakeService(t *testing.T, emit func(string, interface{})) (*Service, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as fake binaries")
+	}
+	dir := t.TempDir()
+	for name, script := range map[string]string{"ffmpeg": fakeFFmpeg, "ffprobe": fakeFFprobe} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := New(filepath.Join(dir, "ffmpeg"), emit)
+	t.Cleanup(func() {
+		release(t, dir)
+		waitFor(t, func() bool { return s.QueueState().Running == 0 })
+	})
+	return s, dir
+}
+
+func release(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, "release"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runningProcesses(dir string) int {
+	matches, _ := filepath.Glob(filepath.Join(dir, "running.*"))
+	return len(matches)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func jobState(s *Service, id string) core.ConversionState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.jobs[id].State
+}
+
+func TestQueue_ConcurrencyLimit(t *testing.T) {
+	s, dir := newFakeService(t, nil)
+	s.SetMaxConcurrent(2)
+
+	ids := []string{"a", "b", "c", "d", "e"}
+	for _, id := range ids {
+		if _, err := s.StartConversion(id, filepath.Join(dir, id+".mkv"), filepath.Join(dir, id+".mp3"), "", []string{"-vn"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	waitFor(t, func() bool { return runningProcesses(dir) == 2 })
+	time.Sleep(100 * time.Millisecond)
+	if n := runningProcesses(dir); n != 2 {
+		t.Errorf("%d ffmpeg processes running, want 2", n)
+	}
+	if state := s.QueueState(); state.Running != 2 || state.Queued != 3 {
+		t.Errorf("QueueState() = %+v, want 2 running and 3 queued", state)
+	}
+
+	release(t, dir)
+	for _, id := range ids {
+		waitFor(t, func() bool { return jobState(s, id) == core.ConversionCompleted })
+	}
+	waitFor(t, func() bool { return s.QueueState() == core.ConversionQueueState{MaxConcurrent: 2} })
+}
+
+func TestQueue_PriorityAndPause(t *testing.T) {
+	s, dir := newFakeService(t, nil)
+	s.SetMaxConcurrent(1)
+	s.PauseQueue()
+	release(t, dir)
+
+	for _, id := range []string{"low", "normal", "high"} {
+		if _, err := s.StartConversion(id, filepath.Join(dir, id+".mkv"), filepath.Join(dir, id+".mp3"), "", []string{"-vn"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.SetJobPriority("high", 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetJobPriority("low", -1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetJobPriority("missing", 1); err == nil {
+		t.Error("SetJobPriority() accepted an unknown job")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if state := s.QueueState(); !state.Paused || state.Running != 0 || state.Queued != 3 {
+		t.Fatalf("QueueState() = %+v, want 3 jobs held while paused", state)
+	}
+
+	s.ResumeQueue()
+	waitFor(t, func() bool { return jobState(s, "low") == core.ConversionCompleted })
+
+	started, err := os.ReadFile(filepath.Join(dir, "started"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	for _, line := range strings.Fields(string(started)) {
+		order = append(order, strings.TrimSuffix(filepath.Base(line), ".mp3"))
+	}
+	if strings.Join(order, ",") != "high,normal,low" {
+		t.Errorf("start order = %v, want high,normal,low", order)
+	}
+}
+
+func TestQueue_CancelQueued(t *testing.T) {
+	rec := &eventRecorder{}
+	s, dir := newFakeService(t, rec.emit)
+	s.PauseQueue()
+
+	if _, err := s.StartConversion("queued", filepath.Join(dir, "in.mkv"), filepath.Join(dir, "out.mp3"), "", []string{"-vn"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CancelConversion("queued"); err != nil {
+		t.Fatalf("CancelConversion() error = %v", err)
+	}
+	if state := jobState(s, "queued"); state != core.ConversionCancelled {
+		t.Errorf("state = %s, want cancelled", state)
+	}
+	if state := s.QueueState(); state.Queued != 0 {
+		t.Errorf("QueueState() = %+v, want nothing queued", state)
+	}
+
+	s.ResumeQueue()
+	time.Sleep(50 * time.Millisecond)
+	if _, err := os.Stat(filepath.Join(dir, "started")); err == nil {
+		t.Error("cancelled job was started")
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	last := rec.data[len(rec.data)-1].(core.ConversionQueueState)
+	if rec.events[len(rec.events)-1] != "conversion:queue" || last.Paused {
+		t.Errorf("last event = %s %+v, want the resumed queue state", rec.events[len(rec.events)-1], last)
+	}
+}
+
+func TestSetMaxConcurrent_Clamped(t *testing.T) {
+	s := New("", nil)
+	if got := s.QueueState().MaxConcurrent; got != defaultMaxConcurrent {
+		t.Errorf("default MaxConcurrent = %d, want %d", got, defaultMaxConcurrent)
+	}
+	s.SetMaxConcurrent(0)
+	if got := s.QueueState().MaxConcurrent; got != 1 {
+		t.Errorf("MaxConcurrent = %d, want 1", got)
+	}
+	s.SetMaxConcurrent(100)
+	if got := s.QueueState().MaxConcurrent; got != core.MaxConcurrentConversionsLimit {
+		t.Errorf("MaxConcurrent = %d, want %d", got, core.MaxConcurrentConversionsLimit)
+	}
+}
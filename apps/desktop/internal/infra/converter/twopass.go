@@ -0,0 +1,171 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"ybdownloader/internal/core"
+)
+
+// containerOverhead is the share of a target size kept free for the
+// container, so outputs land just under the target rather than over it.
+const containerOverhead = 0.02
+
+// minTargetVideoBitrate is the lowest video bitrate in kbps a target size
+// may leave; below it the result is not worth watching.
+const minTargetVideoBitrate = 64
+
+// StartTargetSizeConversion queues a two-pass encode whose video bitrate is
+// chosen so the output fits in target.TargetBytes. An empty outputPath
+// writes an MP4 or WebM next to the input. opts selects streams, a trim
+// range and video filters as for StartConversionWithParams; there is no
+// preset to take parameters.
+func (s *Service) StartTargetSizeConversion(id, inputPath, outputPath string, target core.TargetSizeOptions, opts core.ConversionOptions) (*core.ConversionJob, error) {
+	if s.ffmpegPath == "" {
+		return nil, fmt.Errorf("ffmpeg not available")
+	}
+	if err := target.Validate(); err != nil {
+		return nil, err
+	}
+	if len(opts.Params) > 0 {
+		return nil, fmt.Errorf("target-size encodes take no preset parameters")
+	}
+	if opts.Trim != nil {
+		if err := opts.Trim.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if opts.VideoFilters != nil {
+		if err := opts.VideoFilters.Validate(0, 0); err != nil {
+			return nil, err
+		}
+	}
+	if outputPath == "" {
+		outputPath = defaultOutputPath(inputPath, target.OutputExt(), opts.Trim)
+	}
+
+	job := &core.ConversionJob{
+		ID:           id,
+		InputPath:    inputPath,
+		OutputPath:   outputPath,
+		TrimOptions:  opts.Trim,
+		Streams:      opts.Streams,
+		VideoFilters: opts.VideoFilters,
+		TargetSize:   &target,
+		State:        core.ConversionQueued,
+	}
+	return s.enqueue(job, nil), nil
+}
+
+// runTwoPass encodes a target-size job keeping the streams maps selects.
+// The bitrates apply to each kept stream, so the budget is shared between
+// them. Each pass reports half of the job's progress.
+func (s *Service) runTwoPass(ctx context.Context, job *core.ConversionJob, info *core.MediaInfo, maps []string) error {
+	target := job.TargetSize
+	audioKbps := target.AudioBitrateKbps
+	audioStreams := info.SelectedStreams(core.StreamAudio, job.Streams)
+	if audioStreams == 0 {
+		audioKbps = 0
+	}
+	videoStreams := max(info.SelectedStreams(core.StreamVideo, job.Streams), 1)
+	videoKbps, err := targetVideoBitrate(target.TargetBytes, job.Duration, audioKbps*audioStreams, videoStreams)
+	if err != nil {
+		return err
+	}
+
+	result := &core.TargetSizeResult{
+		TargetBytes:      target.TargetBytes,
+		VideoBitrateKbps: videoKbps,
+		AudioBitrateKbps: audioKbps,
+	}
+	s.mu.Lock()
+	job.SizeResult = result
+	s.mu.Unlock()
+
+	dir, err := os.MkdirTemp("", "twopass-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck // best-effort cleanup
+	passlog := filepath.Join(dir, "pass")
+
+	for pass := 1; pass <= 2; pass++ {
+		s.mu.Lock()
+		job.Pass = pass
+		s.mu.Unlock()
+
+		output := job.OutputPath
+		if pass == 1 {
+			output = os.DevNull
+		}
+		args := append(inputArgs(job, maps), twoPassArgs(target.VideoCodec, videoKbps, audioKbps, pass, passlog)...)
+		// Both passes must see the same frames for the log to fit.
+		if vf := videoFilter(job.VideoFilters); vf != "" {
+			args = append(args, "-vf", vf)
+		}
+		if err := s.runFFmpeg(ctx, job, append(args, output), float64(pass-1)*50, 50); err != nil {
+			return fmt.Errorf("pass %d: %w", pass, err)
+		}
+	}
+
+	fi, err := os.Stat(job.OutputPath)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	result.OutputBytes = fi.Size()
+	result.Deviation = float64(fi.Size()-target.TargetBytes) / float64(target.TargetBytes) * 100
+	s.mu.Unlock()
+	return nil
+}
+
+// targetVideoBitrate returns the bitrate in kbps of each of videoStreams
+// video streams that together fill targetBytes over duration seconds next
+// to audioKbps of audio in all.
+func targetVideoBitrate(targetBytes int64, duration float64, audioKbps, videoStreams int) (int, error) {
+	if duration <= 0 {
+		return 0, fmt.Errorf("the input duration is unknown")
+	}
+	totalKbps := float64(targetBytes) * 8 / 1000 / duration * (1 - containerOverhead)
+	videoKbps := (int(totalKbps) - audioKbps) / videoStreams
+	if videoKbps < minTargetVideoBitrate {
+		need := float64(minTargetVideoBitrate*videoStreams+audioKbps) * 1000 / 8 * duration / (1 - containerOverhead)
+		return 0, fmt.Errorf("target size too small for %s of video: needs at least %.1f MB", formatDuration(duration), need/1e6)
+	}
+	return videoKbps, nil
+}
+
+// twoPassArgs returns the encoder arguments for one pass. The first pass
+// only analyses the video and writes the pass log.
+func twoPassArgs(codec string, videoKbps, audioKbps, pass int, passlog string) []string {
+	var args []string
+	if codec == core.TargetCodecVP9 {
+		args = []string{"-c:v", "libvpx-vp9", "-b:v", kbps(videoKbps), "-row-mt", "1"}
+	} else {
+		args = []string{"-c:v", "libx264", "-preset", "medium", "-b:v", kbps(videoKbps)}
+	}
+	args = append(args, "-pass", strconv.Itoa(pass), "-passlogfile", passlog)
+	if pass == 1 {
+		return append(args, "-an", "-sn", "-dn", "-f", "null")
+	}
+
+	switch {
+	case audioKbps == 0:
+		args = append(args, "-an")
+	case codec == core.TargetCodecVP9:
+		args = append(args, "-c:a", "libopus", "-b:a", kbps(audioKbps))
+	default:
+		args = append(args, "-c:a", "aac", "-b:a", kbps(audioKbps))
+	}
+	if codec != core.TargetCodecVP9 {
+		args = append(args, "-movflags", "+faststart")
+	}
+	return args
+}
+
+func kbps(n int) string {
+	return strconv.Itoa(n) + "k"
+}
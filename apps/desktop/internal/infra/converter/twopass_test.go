@@ -0,0 +1,166 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"ybdownloader/internal/core"
+)
+
+func TestTargetVideoBitrate(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    int64
+		duration  float64
+		audioKbps int
+		videos    int
+		want      int
+		wantErr   bool
+	}{
+		{"25 MB over 2 minutes", 25_000_000, 120, 128, 1, 1505, false},
+		{"no audio", 10_000_000, 60, 0, 1, 1306, false},
+		{"two video streams", 10_000_000, 60, 0, 2, 653, false},
+		{"too small", 1_000_000, 600, 128, 1, 0, true},
+		{"too small for two", 1_000_000, 120, 0, 2, 0, true},
+		{"unknown duration", 25_000_000, 0, 128, 1, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := targetVideoBitrate(tt.target, tt.duration, tt.audioKbps, tt.videos)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("targetVideoBitrate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("targetVideoBitrate() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTwoPassArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		codec     string
+		audioKbps int
+		pass      int
+		want      []string
+	}{
+		{"h264 first pass", core.TargetCodecH264, 128, 1,
+			[]string{"-c:v", "libx264", "-preset", "medium", "-b:v", "1000k", "-pass", "1", "-passlogfile", "log", "-an", "-sn", "-dn", "-f", "null"}},
+		{"h264 second pass", core.TargetCodecH264, 128, 2,
+			[]string{"-c:v", "libx264", "-preset", "medium", "-b:v", "1000k", "-pass", "2", "-passlogfile", "log", "-c:a", "aac", "-b:a", "128k", "-movflags", "+faststart"}},
+		{"vp9 second pass", core.TargetCodecVP9, 96, 2,
+			[]string{"-c:v", "libvpx-vp9", "-b:v", "1000k", "-row-mt", "1", "-pass", "2", "-passlogfile", "log", "-c:a", "libopus", "-b:a", "96k"}},
+		{"vp9 without audio", core.TargetCodecVP9, 0, 2,
+			[]string{"-c:v", "libvpx-vp9", "-b:v", "1000k", "-row-mt", "1", "-pass", "2", "-passlogfile", "log", "-an"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := twoPassArgs(tt.codec, 1000, tt.audioKbps, tt.pass, "log"); !slices.Equal(got, tt.want) {
+				t.Errorf("twoPassArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartTargetSizeConversion(t *testing.T) {
+	rec := &eventRecorder{}
+	s, dir := newFakeService(t, rec.emit)
+	release(t, dir)
+	input := filepath.Join(dir, "clip.mkv")
+
+	job, err := s.StartTargetSizeConversion("j1", input, "", core.TargetSizeOptions{TargetBytes: 1_000_000, VideoCodec: core.TargetCodecVP9}, core.ConversionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "clip_converted.webm"); job.OutputPath != want {
+		t.Errorf("OutputPath = %q, want %q", job.OutputPath, want)
+	}
+	waitFor(t, func() bool { return jobState(s, "j1") == core.ConversionCompleted })
+
+	started, _ := os.ReadFile(filepath.Join(dir, "started"))
+	if want := os.DevNull + "\n" + job.OutputPath + "\n"; string(started) != want {
+		t.Errorf("FFmpeg outputs = %q, want the null first pass then the output", started)
+	}
+
+	got, _ := s.GetJob("j1")
+	result := got.SizeResult
+	// The fake probe reports 1 s without audio, so all 7840 kbps go to video.
+	if result == nil || result.VideoBitrateKbps != 7840 || result.AudioBitrateKbps != 0 {
+		t.Fatalf("SizeResult = %+v", result)
+	}
+	if result.OutputBytes != 10 || result.Deviation >= 0 {
+		t.Errorf("SizeResult = %+v, want the output size below the target", result)
+	}
+
+	rec.mu.Lock()
+	var passes []int
+	for i, event := range rec.events {
+		if p, ok := rec.data[i].(core.ConversionProgress); ok && event == "conversion:progress" && p.Pass > 0 && !slices.Contains(passes, p.Pass) {
+			passes = append(passes, p.Pass)
+		}
+	}
+	rec.mu.Unlock()
+	if !slices.Equal(passes, []int{1, 2}) {
+		t.Errorf("progress passes = %v, want 1 then 2", passes)
+	}
+}
+
+// twoAngleProbe is ffprobe output for a 10-second file with two video and
+// two audio streams.
+const twoAngleProbe = `{
+  "streams": [
+    {"index": 0, "codec_type": "video", "codec_name": "h264", "width": 1280, "height": 720},
+    {"index": 1, "codec_type": "audio", "codec_name": "aac"},
+    {"index": 2, "codec_type": "audio", "codec_name": "aac"},
+    {"index": 3, "codec_type": "video", "codec_name": "h264", "width": 640, "height": 360}
+  ],
+  "format": {"duration": "10.0", "format_name": "matroska,webm"}
+}`
+
+func TestStartTargetSizeConversion_Streams(t *testing.T) {
+	s, dir := newConcatService(t)
+	writeProbe(t, dir, twoAngleProbe)
+	input := writeInputs(t, dir, "multi.mkv")[0]
+
+	target := core.TargetSizeOptions{TargetBytes: 10_000_000, AudioBitrateKbps: 128}
+	if _, err := s.StartTargetSizeConversion("all", input, "", target, core.ConversionOptions{Streams: []int{0, 1, 2, 3}, VideoFilters: &core.VideoFilterOptions{Height: 360}}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return jobState(s, "all").IsFinished() })
+
+	got, _ := s.GetJob("all")
+	// 7840 kbps in all: 2 x 128 for audio leaves 2 x 3792 for video.
+	if result := got.SizeResult; result == nil || result.VideoBitrateKbps != 3792 || result.AudioBitrateKbps != 128 {
+		t.Fatalf("job = %s %q, SizeResult = %+v", got.State, got.Error, got.SizeResult)
+	}
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if !strings.Contains(string(args), "-b:v 3792k") {
+		t.Errorf("FFmpeg args = %q, want the per-stream video bitrate", args)
+	}
+	if strings.Count(string(args), "-vf scale=-2:360,setsar=1") != 2 {
+		t.Errorf("FFmpeg args = %q, want the video filters in both passes", args)
+	}
+}
+
+func TestStartTargetSizeConversion_TooSmall(t *testing.T) {
+	s, dir := newFakeService(t, nil)
+	release(t, dir)
+
+	if _, err := s.StartTargetSizeConversion("j1", filepath.Join(dir, "clip.mkv"), "", core.TargetSizeOptions{TargetBytes: 1000}, core.ConversionOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return jobState(s, "j1") == core.ConversionFailed })
+	if job, _ := s.GetJob("j1"); !strings.Contains(job.Error, "too small") {
+		t.Errorf("Error = %q, want the size explained", job.Error)
+	}
+	if _, err := s.StartTargetSizeConversion("j2", "in.mkv", "", core.TargetSizeOptions{}, core.ConversionOptions{}); err == nil {
+		t.Error("StartTargetSizeConversion() accepted no target size")
+	}
+	if _, err := s.StartTargetSizeConversion("j3", "in.mkv", "", core.TargetSizeOptions{TargetBytes: 1000}, core.ConversionOptions{Params: map[string]string{"crf": "20"}}); err == nil {
+		t.Error("StartTargetSizeConversion() accepted preset parameters")
+	}
+}
@@ -0,0 +1,267 @@
+package downloader
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ybdownloader/internal/core"
+	"ybdownloader/internal/infra/network"
+)
+
+const (
+	// checksumsFileName records the SHA-256 of every binary installed into
+	// the bin directory, so later launches can detect changes.
+	checksumsFileName = "checksums.json"
+	quarantineDirName = "quarantine"
+)
+
+// checksumsMu serializes updates to checksums.json; the FFmpeg and yt-dlp
+// managers share the bin directory.
+var checksumsMu sync.Mutex
+
+// binaryChecksum is one entry of checksums.json.
+type binaryChecksum struct {
+	SHA256      string    `json:"sha256"`
+	Source      string    `json:"source"`   // Where the file came from
+	Verified    bool      `json:"verified"` // Matched a published checksum, as opposed to recorded on first install
+	InstalledAt time.Time `json:"installedAt"`
+}
+
+// BinaryIntegrity is the result of re-hashing an installed binary.
+type BinaryIntegrity struct {
+	Name     string `json:"name"` // Path relative to the bin directory
+	Path     string `json:"path"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual,omitempty"`
+	OK       bool   `json:"ok"`
+	Error    string `json:"error,omitempty"`
+}
+
+// sha256File returns the hex SHA-256 of a file.
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path) //nolint:gosec // path is a binary we installed
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() //nolint:errcheck
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// parseChecksumFile finds the SHA-256 for name in a checksum listing. It
+// accepts sha256sum output ("<hash>  <name>", "<hash> *<name>"), a bare hash,
+// and PowerShell's Get-FileHash list format ("Hash : <HASH>").
+func parseChecksumFile(data []byte, name string) (string, error) {
+	var bare string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if key, value, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(key) == "Hash" {
+			if hash := strings.ToLower(strings.TrimSpace(value)); isSHA256(hash) {
+				bare = hash
+			}
+			continue
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 1 && isSHA256(strings.ToLower(fields[0])):
+			bare = strings.ToLower(fields[0])
+		case len(fields) >= 2 && isSHA256(strings.ToLower(fields[0])):
+			file := strings.TrimPrefix(strings.Join(fields[1:], " "), "*")
+			if filepath.Base(file) == name {
+				return strings.ToLower(fields[0]), nil
+			}
+		}
+	}
+	if bare != "" {
+		return bare, nil
+	}
+	return "", fmt.Errorf("no checksum for %s in listing", name)
+}
+
+func isSHA256(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// fetchChecksum downloads a checksum listing and returns the hash for name.
+func fetchChecksum(ctx context.Context, getSettings func() (*core.Settings, error), url, name string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := network.NewClient(getSettings, time.Minute).Do(req) //nolint:gosec // G704: checksum URL sits next to the release asset
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch checksums: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch checksums: HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read checksums: %w", err)
+	}
+	return parseChecksumFile(data, name)
+}
+
+// verifyChecksum hashes path and compares it with want. On a mismatch the
+// file is moved to the quarantine directory under binDir.
+func verifyChecksum(path, want, binDir string) (string, error) {
+	got, err := sha256File(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", filepath.Base(path), err)
+	}
+	if strings.EqualFold(got, want) {
+		return got, nil
+	}
+
+	slog.Error("checksum mismatch", "file", path, "expected", want, "actual", got)
+	if quarantined, qerr := quarantineFile(path, binDir); qerr != nil {
+		slog.Warn("failed to quarantine file, removing it", "file", path, "error", qerr)
+		_ = os.Remove(path) //nolint:errcheck
+	} else {
+		slog.Warn("file quarantined", "path", quarantined)
+	}
+	return "", core.NewAppError(core.ErrCodeChecksumMismatch,
+		fmt.Sprintf("%s does not match its published checksum", filepath.Base(path)), core.ErrChecksumMismatch)
+}
+
+// quarantineFile moves path out of the way and makes it non-executable.
+func quarantineFile(path, binDir string) (string, error) {
+	dir := filepath.Join(binDir, quarantineDirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	dest := filepath.Join(dir, fmt.Sprintf("%s.%d", filepath.Base(path), time.Now().UnixNano()))
+	if err := os.Rename(path, dest); err != nil {
+		return "", err
+	}
+	_ = os.Chmod(dest, 0o600) //nolint:errcheck
+	return dest, nil
+}
+
+func loadChecksums(binDir string) (map[string]binaryChecksum, error) {
+	records := map[string]binaryChecksum{}
+	data, err := os.ReadFile(filepath.Join(binDir, checksumsFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", checksumsFileName, err)
+	}
+	return records, nil
+}
+
+// recordChecksum stores the hash of an installed binary. path must be
+// inside binDir.
+func recordChecksum(binDir, path, hash, source string, verified bool) {
+	name, err := filepath.Rel(binDir, path)
+	if err != nil {
+		slog.Warn("not recording checksum outside bin dir", "path", path)
+		return
+	}
+
+	checksumsMu.Lock()
+	defer checksumsMu.Unlock()
+
+	records, err := loadChecksums(binDir)
+	if err != nil {
+		slog.Warn("replacing unreadable checksum records", "error", err)
+		records = map[string]binaryChecksum{}
+	}
+	records[filepath.ToSlash(name)] = binaryChecksum{
+		SHA256:      hash,
+		Source:      source,
+		Verified:    verified,
+		InstalledAt: time.Now(),
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(binDir, checksumsFileName), data, 0o644)
+	}
+	if err != nil {
+		slog.Warn("failed to record checksum", "path", path, "error", err)
+	}
+}
+
+// forgetChecksums drops the records for name and everything below it.
+func forgetChecksums(binDir, name string) {
+	checksumsMu.Lock()
+	defer checksumsMu.Unlock()
+
+	records, err := loadChecksums(binDir)
+	if err != nil {
+		return
+	}
+	prefix := filepath.ToSlash(name)
+	for key := range records {
+		if key == prefix || strings.HasPrefix(key, prefix+"/") {
+			delete(records, key)
+		}
+	}
+	if data, err := json.MarshalIndent(records, "", "  "); err == nil {
+		_ = os.WriteFile(filepath.Join(binDir, checksumsFileName), data, 0o644) //nolint:errcheck
+	}
+}
+
+// VerifyInstalledBinaries re-hashes every recorded binary under binDir.
+// Binaries that have since been removed are skipped.
+func VerifyInstalledBinaries(binDir string) ([]BinaryIntegrity, error) {
+	checksumsMu.Lock()
+	records, err := loadChecksums(binDir)
+	checksumsMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(records))
+	for name := range records {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := []BinaryIntegrity{}
+	for _, name := range names {
+		path := filepath.Join(binDir, filepath.FromSlash(name))
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		result := BinaryIntegrity{Name: name, Path: path, Expected: records[name].SHA256}
+		actual, err := sha256File(path)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Actual = actual
+			result.OK = strings.EqualFold(actual, result.Expected)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
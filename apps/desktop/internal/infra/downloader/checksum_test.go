@@ -0,0 +1,113 @@
+package downloader
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ybdownloader/internal/core"
+)
+
+const testHash = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestParseChecksumFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		file    string
+		want    string
+		wantErr bool
+	}{
+		{"sha256sum", "ffff" + testHash[4:] + "  yt-dlp.exe\n" + testHash + "  yt-dlp_linux\n", "yt-dlp_linux", testHash, false},
+		{"binary mode", testHash + " *yt-dlp_macos\n", "yt-dlp_macos", testHash, false},
+		{"uppercase", "0123456789ABCDEF0123456789ABCDEF0123456789ABCDEF0123456789ABCDEF  deno.zip", "deno.zip", testHash, false},
+		{"bare hash", testHash + "\n", "deno.zip", testHash, false},
+		{"powershell", "\r\nAlgorithm : SHA256\r\nHash      : " + testHash + "\r\nPath      : C:\\a\\deno.zip\r\n", "deno.zip", testHash, false},
+		{"missing entry", testHash + "  other\n", "yt-dlp_linux", "", true},
+		{"not a hash", "hello  yt-dlp_linux\n", "yt-dlp_linux", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChecksumFile([]byte(tt.data), tt.file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseChecksumFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseChecksumFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	binDir := t.TempDir()
+	path := filepath.Join(binDir, "yt-dlp.part")
+	content := []byte("binary")
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("%x", sha256.Sum256(content))
+
+	got, err := verifyChecksum(path, want, binDir)
+	if err != nil || got != want {
+		t.Fatalf("verifyChecksum() = %q, %v", got, err)
+	}
+
+	_, err = verifyChecksum(path, testHash, binDir)
+	if !errors.Is(err, core.ErrChecksumMismatch) {
+		t.Fatalf("verifyChecksum() error = %v, want ErrChecksumMismatch", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("mismatched file was left in place")
+	}
+	quarantined, _ := os.ReadDir(filepath.Join(binDir, quarantineDirName))
+	if len(quarantined) != 1 {
+		t.Fatalf("quarantine holds %d files, want 1", len(quarantined))
+	}
+	if info, _ := quarantined[0].Info(); info.Mode().Perm()&0o111 != 0 {
+		t.Errorf("quarantined file is executable: %v", info.Mode())
+	}
+}
+
+func TestVerifyInstalledBinaries(t *testing.T) {
+	binDir := t.TempDir()
+	ffmpeg := filepath.Join(binDir, "ffmpeg")
+	deno := filepath.Join(binDir, "deno")
+	for _, p := range []string{ffmpeg, deno} {
+		if err := os.WriteFile(p, []byte(filepath.Base(p)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := sha256File(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recordChecksum(binDir, p, hash, "https://example.com/"+filepath.Base(p), false)
+	}
+	recordChecksum(binDir, filepath.Join(binDir, "gone"), testHash, "", false)
+
+	if err := os.WriteFile(ffmpeg, []byte("patched"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := VerifyInstalledBinaries(binDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("results = %+v, want deno and ffmpeg only", results)
+	}
+	if results[0].Name != "deno" || !results[0].OK {
+		t.Errorf("deno = %+v, want OK", results[0])
+	}
+	if results[1].Name != "ffmpeg" || results[1].OK || results[1].Actual == "" {
+		t.Errorf("ffmpeg = %+v, want mismatch", results[1])
+	}
+
+	forgetChecksums(binDir, "ffmpeg")
+	if results, _ := VerifyInstalledBinaries(binDir); len(results) != 1 {
+		t.Errorf("results after forget = %+v, want deno only", results)
+	}
+}
@@ -0,0 +1,212 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"ybdownloader/internal/core"
+	"ybdownloader/internal/infra/network"
+)
+
+// ffmpegTimeRe matches the position in ffmpeg's stats line, which yt-dlp
+// prints while downloading a section, e.g. "... time=00:01:02.50 bitrate=...".
+var ffmpegTimeRe = regexp.MustCompile(`time=(\d+):(\d{2}):(\d{2}(?:\.\d+)?)`)
+
+// clipFileName appends the time range to a file name, e.g.
+// "Title (1m30s-3m30s)", so clips of one video don't overwrite each other.
+func clipFileName(base string, clip *core.TrimOptions) string {
+	if clip == nil {
+		return base
+	}
+	end := "end"
+	if clip.EndTime > 0 {
+		end = formatClipTime(clip.EndTime)
+	}
+	return fmt.Sprintf("%s (%s-%s)", base, formatClipTime(clip.StartTime), end)
+}
+
+// formatClipTime formats seconds for a file name; colons are not allowed on
+// Windows, so "1:02:03" becomes "1h02m03s".
+func formatClipTime(seconds float64) string {
+	total := int(seconds)
+	h, m, s := total/3600, total%3600/60, total%60
+	switch {
+	case h > 0:
+		return fmt.Sprintf("%dh%02dm%02ds", h, m, s)
+	case m > 0:
+		return fmt.Sprintf("%dm%02ds", m, s)
+	}
+	return fmt.Sprintf("%ds", s)
+}
+
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', -1, 64)
+}
+
+// ytDlpClipArgs maps a time range to --download-sections.
+func ytDlpClipArgs(clip *core.TrimOptions) []string {
+	if clip == nil {
+		return nil
+	}
+	end := "inf"
+	if clip.EndTime > 0 {
+		end = formatSeconds(clip.EndTime)
+	}
+	return []string{"--download-sections", "*" + formatSeconds(clip.StartTime) + "-" + end}
+}
+
+// clipProgress converts a position within the clip to download progress.
+// A zero length means the clip length is unknown and no percentage is given.
+func clipProgress(itemID string, position, length float64) core.DownloadProgress {
+	progress := core.DownloadProgress{
+		ItemID:         itemID,
+		State:          core.StateDownloading,
+		ElapsedSeconds: position,
+	}
+	if length > 0 {
+		progress.Percent = min(position/length*100, 100)
+	}
+	return progress
+}
+
+// parseYtDlpClipProgress parses the ffmpeg stats yt-dlp prints while
+// downloading a section, relative to the clip length.
+func parseYtDlpClipProgress(line, itemID string, length float64) (core.DownloadProgress, bool) {
+	m := ffmpegTimeRe.FindStringSubmatch(line)
+	if m == nil {
+		return core.DownloadProgress{}, false
+	}
+	return clipProgress(itemID, parseClock(m[1]+":"+m[2]+":"+m[3]), length), true
+}
+
+// clipSource describes what the builtin backend hands to FFmpeg for a clip.
+type clipSource struct {
+	videoURL  string
+	audioURL  string // Separate audio stream to merge; empty for a single stream
+	copyVideo bool
+	copyAudio bool
+}
+
+// buildClipArgs seeks each input to the clip start so FFmpeg only fetches
+// the requested range. With stream copy the cut lands on the nearest
+// keyframe, like yt-dlp's --download-sections.
+func buildClipArgs(src clipSource, clip *core.TrimOptions, output string, format core.Format, quality core.AudioQuality) []string {
+	args := []string{"-y"}
+
+	addInput := func(input string) {
+		args = append(args, "-ss", formatSeconds(clip.StartTime))
+		if clip.EndTime > 0 {
+			args = append(args, "-t", formatSeconds(clip.EndTime-clip.StartTime))
+		}
+		args = append(args, "-i", input)
+	}
+
+	addInput(src.videoURL)
+	if src.audioURL != "" {
+		addInput(src.audioURL)
+	}
+
+	switch {
+	case format.IsAudioOnly():
+		args = append(args, "-vn")
+		if src.audioURL != "" {
+			args = append(args, "-map", "1:a:0")
+		}
+		if format == core.FormatMP3 {
+			args = append(args, "-codec:a", "libmp3lame")
+		} else {
+			args = append(args, "-codec:a", "aac")
+		}
+		args = append(args, "-b:a", qualityToFFmpegBitrate(quality))
+	default:
+		if src.audioURL != "" {
+			args = append(args, "-map", "0:v:0", "-map", "1:a:0")
+		}
+		if src.copyVideo {
+			args = append(args, "-codec:v", "copy")
+		}
+		switch {
+		case src.copyAudio:
+			args = append(args, "-codec:a", "copy")
+		case format == core.FormatWebM:
+			args = append(args, "-codec:a", "libopus", "-b:a", "160k")
+		default:
+			args = append(args, "-codec:a", "aac", "-b:a", "192k")
+		}
+		if format == core.FormatMP4 {
+			args = append(args, "-movflags", "+faststart")
+		}
+	}
+
+	return append(args, "-progress", "pipe:1", "-nostats", output)
+}
+
+// ffmpegHTTPProxy returns the proxy FFmpeg should use for streamURL. FFmpeg
+// only supports plain HTTP proxies for its HTTP input. The proxy may carry
+// credentials, so it is passed in FFmpeg's environment (http_proxy) rather
+// than on the command line, where other local users could read it.
+func ffmpegHTTPProxy(n core.NetworkSettings, streamURL string) string {
+	proxy, err := url.Parse(n.ProxyURL)
+	if n.ProxyURL == "" || err != nil || proxy.Scheme != "http" {
+		return ""
+	}
+	u, err := url.Parse(streamURL)
+	if err != nil || network.BypassesProxy(u.Hostname(), n.NoProxy) {
+		return ""
+	}
+	return n.ProxyURL
+}
+
+// downloadClip has FFmpeg read only the requested range straight from the
+// stream URLs instead of downloading the whole video.
+func (d *Downloader) downloadClip(ctx context.Context, item *core.QueueItem, stream *StreamInfo, ffmpeg *FFmpeg, settings *core.Settings, safeTitle string, onProgress func(core.DownloadProgress)) error {
+	if ffmpeg == nil {
+		return core.NewAppError(core.ErrCodeFFmpegNotFound, "FFmpeg is required to download a time range", nil)
+	}
+
+	videoURL, err := d.youtube.GetStreamURL(ctx, stream.Video, stream.Format)
+	if err != nil {
+		return err
+	}
+	src := clipSource{
+		videoURL:  videoURL,
+		copyVideo: videoCodecFitsContainer(videoCodecFromMime(stream.Format.MimeType), item.Format),
+		copyAudio: audioFitsContainer(stream.Format.MimeType, item.Format),
+	}
+	if stream.NeedsMerge() {
+		if src.audioURL, err = d.youtube.GetStreamURL(ctx, stream.Video, stream.AudioFormat); err != nil {
+			return err
+		}
+		src.copyAudio = audioFitsContainer(stream.AudioFormat.MimeType, item.Format)
+	}
+
+	finalPath := filepath.Join(item.SavePath, fmt.Sprintf("%s.%s", clipFileName(safeTitle, item.Clip), item.Format))
+	length := item.Clip.Length(stream.Video.Duration.Seconds())
+	slog.Info("downloading time range",
+		"itemId", item.ID,
+		"start", item.Clip.StartTime,
+		"end", item.Clip.EndTime,
+		"output", finalPath,
+	)
+
+	args := buildClipArgs(src, item.Clip, finalPath, item.Format, settings.DefaultAudioQuality)
+	var env []string
+	if proxy := ffmpegHTTPProxy(settings.Network, videoURL); proxy != "" {
+		env = append(env, "http_proxy="+proxy)
+	}
+	if err := ffmpeg.RunWithProgress(ctx, args, env, func(position float64) {
+		onProgress(clipProgress(item.ID, position, length))
+	}); err != nil {
+		slog.Error("clip download failed", "itemId", item.ID, "error", err)
+		return newDownloadError(core.ErrCodeConversionFailed, err)
+	}
+
+	item.FilePath = finalPath
+	slog.Info("download complete", "itemId", item.ID, "filePath", finalPath)
+	return nil
+}
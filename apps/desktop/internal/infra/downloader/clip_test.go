@@ -0,0 +1,191 @@
+package downloader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"ybdownloader/internal/core"
+)
+
+func TestClipFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		clip *core.TrimOptions
+		want string
+	}{
+		{"no clip", nil, "Title"},
+		{"seconds", &core.TrimOptions{StartTime: 5, EndTime: 45}, "Title (5s-45s)"},
+		{"minutes", &core.TrimOptions{StartTime: 90, EndTime: 210}, "Title (1m30s-3m30s)"},
+		{"hours", &core.TrimOptions{StartTime: 3723, EndTime: 3843.7}, "Title (1h02m03s-1h04m03s)"},
+		{"to end", &core.TrimOptions{StartTime: 60}, "Title (1m00s-end)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clipFileName("Title", tt.clip); got != tt.want {
+				t.Errorf("clipFileName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYtDlpClipArgs(t *testing.T) {
+	if args := ytDlpClipArgs(nil); args != nil {
+		t.Errorf("ytDlpClipArgs(nil) = %v", args)
+	}
+	tests := []struct {
+		clip core.TrimOptions
+		want string
+	}{
+		{core.TrimOptions{StartTime: 90, EndTime: 210}, "--download-sections *90-210"},
+		{core.TrimOptions{StartTime: 1.5}, "--download-sections *1.5-inf"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(ytDlpClipArgs(&tt.clip), " "); got != tt.want {
+			t.Errorf("ytDlpClipArgs(%+v) = %q, want %q", tt.clip, got, tt.want)
+		}
+	}
+}
+
+func TestParseYtDlpClipProgress(t *testing.T) {
+	line := "frame= 1500 fps=250 q=-1.0 size=   10240kB time=00:01:00.00 bitrate=1398.1kbits/s speed=10x"
+	got, ok := parseYtDlpClipProgress(line, "item", 120)
+	if !ok {
+		t.Fatal("parseYtDlpClipProgress() ok = false")
+	}
+	if got.State != core.StateDownloading || got.Percent != 50 || got.ElapsedSeconds != 60 {
+		t.Errorf("parseYtDlpClipProgress() = %+v, want 50%% at 60s", got)
+	}
+
+	if got, _ := parseYtDlpClipProgress("size= 1kB time=00:05:00.00", "item", 120); got.Percent != 100 {
+		t.Errorf("Percent = %v, want clamped to 100", got.Percent)
+	}
+	if got, _ := parseYtDlpClipProgress(line, "item", 0); got.Percent != 0 {
+		t.Errorf("Percent = %v, want 0 for unknown length", got.Percent)
+	}
+	if _, ok := parseYtDlpClipProgress("[download] Destination: x.mp4", "item", 120); ok {
+		t.Error("unrelated line parsed as progress")
+	}
+}
+
+func TestParseFFmpegProgressTime(t *testing.T) {
+	tests := []struct {
+		line   string
+		want   float64
+		wantOK bool
+	}{
+		{"out_time_us=1500000", 1.5, true},
+		{"out_time_ms=2000000", 2, true},
+		{"out_time=00:00:02.000000", 0, false},
+		{"out_time_us=N/A", 0, false},
+		{"progress=continue", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseFFmpegProgressTime(tt.line)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("parseFFmpegProgressTime(%q) = %v, %v, want %v, %v", tt.line, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestBuildClipArgs(t *testing.T) {
+	clip := &core.TrimOptions{StartTime: 90, EndTime: 210}
+
+	tests := []struct {
+		name    string
+		src     clipSource
+		clip    *core.TrimOptions
+		format  core.Format
+		want    []string
+		wantNot []string
+	}{
+		{
+			name:   "merged video",
+			src:    clipSource{videoURL: "v", audioURL: "a", copyVideo: true, copyAudio: true},
+			clip:   clip,
+			format: core.FormatMP4,
+			want: []string{
+				"-ss 90 -t 120 -i v", "-ss 90 -t 120 -i a",
+				"-map 0:v:0 -map 1:a:0", "-codec:v copy", "-codec:a copy", "-movflags +faststart",
+			},
+		},
+		{
+			name:    "re-encoded webm audio",
+			src:     clipSource{videoURL: "v", copyVideo: true},
+			clip:    clip,
+			format:  core.FormatWebM,
+			want:    []string{"-codec:a libopus"},
+			wantNot: []string{"-map", "-movflags"},
+		},
+		{
+			name:    "audio to end",
+			src:     clipSource{videoURL: "a"},
+			clip:    &core.TrimOptions{StartTime: 30},
+			format:  core.FormatMP3,
+			want:    []string{"-y -ss 30 -i a", "-vn", "-codec:a libmp3lame -b:a 192k"},
+			wantNot: []string{"-t ", "-codec:v"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := buildClipArgs(tt.src, tt.clip, "/out/clip."+string(tt.format), tt.format, core.AudioQuality192)
+			joined := strings.Join(args, " ")
+			for _, w := range tt.want {
+				if !strings.Contains(joined, w) {
+					t.Errorf("args missing %q: %s", w, joined)
+				}
+			}
+			for _, w := range tt.wantNot {
+				if strings.Contains(joined, w) {
+					t.Errorf("args should not contain %q: %s", w, joined)
+				}
+			}
+			if !strings.HasSuffix(joined, "-progress pipe:1 -nostats /out/clip."+string(tt.format)) {
+				t.Errorf("args should end with progress flags and output: %s", joined)
+			}
+		})
+	}
+}
+
+func TestFFmpegHTTPProxy(t *testing.T) {
+	const stream = "https://rr1.googlevideo.com/videoplayback"
+	tests := []struct {
+		name string
+		n    core.NetworkSettings
+		want string
+	}{
+		{"no proxy", core.NetworkSettings{}, ""},
+		{"http proxy", core.NetworkSettings{ProxyURL: "http://proxy:3128"}, "http://proxy:3128"},
+		{"socks proxy unsupported", core.NetworkSettings{ProxyURL: "socks5://proxy:1080"}, ""},
+		{"bypassed", core.NetworkSettings{ProxyURL: "http://proxy:3128", NoProxy: []string{"googlevideo.com"}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ffmpegHTTPProxy(tt.n, stream); got != tt.want {
+				t.Errorf("ffmpegHTTPProxy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFFmpeg_RunWithProgressEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as a fake ffmpeg")
+	}
+	// The fake reports progress only when the proxy arrives in its
+	// environment and not on its command line.
+	script := "#!/bin/sh\ncase \"$*\" in *proxy*) exit 1;; esac\n[ \"$http_proxy\" = http://user:pw@proxy:3128 ] || exit 1\necho out_time_us=5000000\n"
+	path := filepath.Join(t.TempDir(), "ffmpeg")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var got float64
+	err := (&FFmpeg{binaryPath: path}).RunWithProgress(context.Background(), []string{"-i", "in"}, []string{"http_proxy=http://user:pw@proxy:3128"}, func(s float64) { got = s })
+	if err != nil || got != 5 {
+		t.Errorf("RunWithProgress() = %v, position %v; want the proxy passed in the environment", err, got)
+	}
+}
@@ -0,0 +1,270 @@
+package downloader
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+
+	"ybdownloader/internal/core"
+)
+
+// cookieExpiryWarningWindow is how far ahead ValidateCookieFile warns about
+// cookies that are about to expire.
+const cookieExpiryWarningWindow = 7 * 24 * time.Hour
+
+// CookieFileInfo summarizes a validated Netscape cookies.txt file.
+type CookieFileInfo struct {
+	Path           string     `json:"path"`
+	CookieCount    int        `json:"cookieCount"`
+	ExpiredCount   int        `json:"expiredCount"`
+	Domains        []string   `json:"domains"`
+	HasYouTube     bool       `json:"hasYouTube"`
+	EarliestExpiry *time.Time `json:"earliestExpiry,omitempty"` // First expiry among valid persistent cookies
+	Warnings       []string   `json:"warnings,omitempty"`
+}
+
+// ParseCookieFile reads cookies in the Netscape format used by browsers'
+// "cookies.txt" exporters and by yt-dlp's --cookies option.
+// Each line is: domain, include-subdomains, path, secure, expiry, name, value.
+func ParseCookieFile(path string) ([]*http.Cookie, error) {
+	f, err := os.Open(path) //nolint:gosec // user-selected cookies file
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cookies file: %w", err)
+	}
+	defer f.Close() //nolint:errcheck // deferred close
+
+	var cookies []*http.Cookie
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		httpOnly := false
+		if strings.HasPrefix(line, "#HttpOnly_") {
+			httpOnly = true
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %d: expected 7 tab-separated fields, got %d: %w", lineNum, len(fields), core.ErrCookiesInvalid)
+		}
+
+		expiry, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expiry %q: %w", lineNum, fields[4], core.ErrCookiesInvalid)
+		}
+
+		cookie := &http.Cookie{
+			Domain:   fields[0],
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HttpOnly: httpOnly,
+		}
+		if expiry > 0 {
+			cookie.Expires = time.Unix(expiry, 0)
+		}
+		cookies = append(cookies, cookie)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cookies file: %w", err)
+	}
+
+	if len(cookies) == 0 {
+		return nil, fmt.Errorf("no cookies found: %w", core.ErrCookiesInvalid)
+	}
+	return cookies, nil
+}
+
+// ValidateCookieFile parses a cookies file and reports what it contains,
+// with warnings for expired or soon-to-expire cookies.
+func ValidateCookieFile(path string) (*CookieFileInfo, error) {
+	cookies, err := ParseCookieFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info := summarizeCookies(cookies, time.Now())
+	info.Path = path
+	return info, nil
+}
+
+func summarizeCookies(cookies []*http.Cookie, now time.Time) *CookieFileInfo {
+	info := &CookieFileInfo{CookieCount: len(cookies)}
+
+	domains := make(map[string]bool)
+	for _, c := range cookies {
+		domain := strings.TrimPrefix(c.Domain, ".")
+		domains[domain] = true
+		if isYouTubeCookieDomain(domain) {
+			info.HasYouTube = true
+		}
+
+		if c.Expires.IsZero() {
+			continue
+		}
+		if c.Expires.Before(now) {
+			info.ExpiredCount++
+			continue
+		}
+		if info.EarliestExpiry == nil || c.Expires.Before(*info.EarliestExpiry) {
+			expires := c.Expires
+			info.EarliestExpiry = &expires
+		}
+	}
+
+	info.Domains = make([]string, 0, len(domains))
+	for d := range domains {
+		info.Domains = append(info.Domains, d)
+	}
+	sort.Strings(info.Domains)
+
+	if !info.HasYouTube {
+		info.Warnings = append(info.Warnings, "No YouTube or Google cookies found; sign-in protected videos will still fail")
+	}
+	switch {
+	case info.ExpiredCount == info.CookieCount:
+		info.Warnings = append(info.Warnings, "All cookies have expired; export a fresh cookies file")
+	case info.ExpiredCount > 0:
+		info.Warnings = append(info.Warnings, fmt.Sprintf("%d of %d cookies have expired", info.ExpiredCount, info.CookieCount))
+	}
+	if info.EarliestExpiry != nil && info.EarliestExpiry.Sub(now) < cookieExpiryWarningWindow {
+		info.Warnings = append(info.Warnings, fmt.Sprintf("Some cookies expire on %s", info.EarliestExpiry.Format("2006-01-02")))
+	}
+
+	return info
+}
+
+func isYouTubeCookieDomain(domain string) bool {
+	for _, d := range []string{"youtube.com", "google.com"} {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// newCookieJarFromFile builds an in-memory jar holding the cookies of a file.
+func newCookieJarFromFile(path string) (http.CookieJar, error) {
+	cookies, err := ParseCookieFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range cookies {
+		scheme := "http"
+		if c.Secure {
+			scheme = "https"
+		}
+		u := &url.URL{Scheme: scheme, Host: strings.TrimPrefix(c.Domain, "."), Path: "/"}
+		jar.SetCookies(u, []*http.Cookie{c})
+	}
+	return jar, nil
+}
+
+// swappableJar is an http.CookieJar whose backing jar can be replaced while
+// requests are in flight, so a changed cookies file takes effect without
+// recreating the HTTP client. A nil backing jar stores nothing.
+type swappableJar struct {
+	mu  sync.RWMutex
+	jar http.CookieJar
+}
+
+func (j *swappableJar) set(jar http.CookieJar) {
+	j.mu.Lock()
+	j.jar = jar
+	j.mu.Unlock()
+}
+
+func (j *swappableJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.RLock()
+	jar := j.jar
+	j.mu.RUnlock()
+	if jar != nil {
+		jar.SetCookies(u, cookies)
+	}
+}
+
+func (j *swappableJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.RLock()
+	jar := j.jar
+	j.mu.RUnlock()
+	if jar == nil {
+		return nil
+	}
+	return jar.Cookies(u)
+}
+
+// ytDlpCookieErrorPatterns map yt-dlp error output to cookie-related codes.
+var ytDlpCookieErrorPatterns = []struct {
+	substr string
+	code   string
+}{
+	{"does not look like a netscape format cookies file", core.ErrCodeCookiesInvalid},
+	{"cookies are no longer valid", core.ErrCodeCookiesInvalid},
+	{"failed to load cookies", core.ErrCodeCookiesInvalid},
+	{"sign in to confirm your age", core.ErrCodeAgeRestricted},
+	{"members-only content", core.ErrCodeAuthRequired},
+	{"available to this channel's members", core.ErrCodeAuthRequired},
+}
+
+// classifyYtDlpCookieError returns a cookie-related *core.AppError for yt-dlp
+// error output, or nil if the failure is unrelated to authentication.
+func classifyYtDlpCookieError(output string, hasCookies bool) error {
+	lower := strings.ToLower(output)
+	for _, p := range ytDlpCookieErrorPatterns {
+		if strings.Contains(lower, p.substr) {
+			return newCookieAppError(p.code, hasCookies, errors.New(strings.TrimSpace(output)))
+		}
+	}
+	return nil
+}
+
+// classifyBuiltinCookieError does the same for errors from the kkdai client.
+func classifyBuiltinCookieError(err error, hasCookies bool) error {
+	if errors.Is(err, youtube.ErrLoginRequired) {
+		return newCookieAppError(core.ErrCodeAgeRestricted, hasCookies, err)
+	}
+	var status *youtube.ErrPlayabiltyStatus
+	if errors.As(err, &status) && status.Status == "LOGIN_REQUIRED" {
+		return newCookieAppError(core.ErrCodeAuthRequired, hasCookies, err)
+	}
+	return nil
+}
+
+func newCookieAppError(code string, hasCookies bool, err error) *core.AppError {
+	if code == core.ErrCodeCookiesInvalid {
+		return core.NewAppError(code, "The cookies file is invalid or expired. Export a fresh cookies.txt and import it in Settings", err)
+	}
+	if code == core.ErrCodeAgeRestricted {
+		err = fmt.Errorf("%w: %w", core.ErrAgeRestricted, err)
+	}
+	if hasCookies {
+		return core.NewAppError(code, "The imported cookies do not grant access to this video", err)
+	}
+	if code == core.ErrCodeAgeRestricted {
+		return core.NewAppError(code, "This video is age-restricted", err)
+	}
+	return core.NewAppError(code, "This video requires signing in. Import a cookies.txt file in Settings", err)
+}
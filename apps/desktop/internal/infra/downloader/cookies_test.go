@@ -0,0 +1,197 @@
+package downloader
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+
+	"ybdownloader/internal/core"
+)
+
+func writeCookieFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const sampleCookies = "# Netscape HTTP Cookie File\n" +
+	"# comment line\n" +
+	"\n" +
+	".youtube.com\tTRUE\t/\tTRUE\t4102444800\tSID\tabc\r\n" +
+	"#HttpOnly_.youtube.com\tTRUE\t/\tTRUE\t0\tHSID\tdef\n" +
+	"example.com\tFALSE\t/\tFALSE\t1000\told\tx\n"
+
+func TestParseCookieFile(t *testing.T) {
+	cookies, err := ParseCookieFile(writeCookieFile(t, sampleCookies))
+	if err != nil {
+		t.Fatalf("ParseCookieFile() error = %v", err)
+	}
+	if len(cookies) != 3 {
+		t.Fatalf("got %d cookies, want 3", len(cookies))
+	}
+
+	sid := cookies[0]
+	if sid.Domain != ".youtube.com" || sid.Name != "SID" || sid.Value != "abc" || !sid.Secure {
+		t.Errorf("SID cookie = %+v", sid)
+	}
+	if !sid.Expires.Equal(time.Unix(4102444800, 0)) {
+		t.Errorf("SID expires = %v", sid.Expires)
+	}
+
+	hsid := cookies[1]
+	if !hsid.HttpOnly || !hsid.Expires.IsZero() {
+		t.Errorf("HSID cookie = %+v, want HttpOnly session cookie", hsid)
+	}
+}
+
+func TestParseCookieFile_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"empty", ""},
+		{"comments only", "# Netscape HTTP Cookie File\n"},
+		{"wrong field count", ".youtube.com\tTRUE\t/\tSID\tabc\n"},
+		{"bad expiry", ".youtube.com\tTRUE\t/\tTRUE\tsoon\tSID\tabc\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCookieFile(writeCookieFile(t, tt.content))
+			if !errors.Is(err, core.ErrCookiesInvalid) {
+				t.Errorf("ParseCookieFile() error = %v, want ErrCookiesInvalid", err)
+			}
+		})
+	}
+}
+
+func TestParseCookieFile_Missing(t *testing.T) {
+	if _, err := ParseCookieFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("ParseCookieFile() expected error for missing file")
+	}
+}
+
+func TestSummarizeCookies(t *testing.T) {
+	cookies, err := ParseCookieFile(writeCookieFile(t, sampleCookies))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := summarizeCookies(cookies, time.Unix(2000, 0))
+	if info.CookieCount != 3 || info.ExpiredCount != 1 || !info.HasYouTube {
+		t.Errorf("info = %+v", info)
+	}
+	if len(info.Domains) != 2 || info.Domains[0] != "example.com" || info.Domains[1] != "youtube.com" {
+		t.Errorf("Domains = %v", info.Domains)
+	}
+	if info.EarliestExpiry == nil || info.EarliestExpiry.Unix() != 4102444800 {
+		t.Errorf("EarliestExpiry = %v", info.EarliestExpiry)
+	}
+	if len(info.Warnings) != 1 {
+		t.Errorf("Warnings = %v, want one expired warning", info.Warnings)
+	}
+}
+
+func TestSummarizeCookies_Warnings(t *testing.T) {
+	now := time.Unix(1_000_000, 0) // cookie below expires one day later
+
+	cookies, err := ParseCookieFile(writeCookieFile(t,
+		"example.com\tFALSE\t/\tFALSE\t1086400\ta\tb\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := summarizeCookies(cookies, now)
+	if info.HasYouTube {
+		t.Error("HasYouTube = true for example.com")
+	}
+	if len(info.Warnings) != 2 {
+		t.Errorf("Warnings = %v, want no-YouTube and expiring-soon warnings", info.Warnings)
+	}
+
+	info = summarizeCookies(cookies, time.Unix(2_000_000, 0))
+	if info.ExpiredCount != 1 || info.EarliestExpiry != nil {
+		t.Errorf("info = %+v, want all expired", info)
+	}
+}
+
+func TestYouTubeClient_UseCookieFile(t *testing.T) {
+	path := writeCookieFile(t, sampleCookies)
+	y := NewYouTubeClient()
+	u := &url.URL{Scheme: "https", Host: "www.youtube.com", Path: "/watch"}
+
+	if err := y.UseCookieFile(path); err != nil {
+		t.Fatalf("UseCookieFile() error = %v", err)
+	}
+	if got := y.jar.Cookies(u); len(got) != 2 {
+		t.Errorf("Cookies() = %v, want SID and HSID", got)
+	}
+
+	if err := y.UseCookieFile(""); err != nil {
+		t.Fatalf("UseCookieFile(\"\") error = %v", err)
+	}
+	if got := y.jar.Cookies(u); len(got) != 0 {
+		t.Errorf("Cookies() after clear = %v, want none", got)
+	}
+
+	if err := y.UseCookieFile(writeCookieFile(t, "garbage\n")); err == nil {
+		t.Error("UseCookieFile() expected error for invalid file")
+	}
+}
+
+func TestClassifyYtDlpCookieError(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"ERROR: [youtube] abc: Sign in to confirm your age. This video may be inappropriate", core.ErrCodeAgeRestricted},
+		{"ERROR: [youtube] abc: Join this channel to get access to members-only content like this video", core.ErrCodeAuthRequired},
+		{"ERROR: 'c.txt' does not look like a Netscape format cookies file", core.ErrCodeCookiesInvalid},
+		{"WARNING: The provided YouTube account cookies are no longer valid", core.ErrCodeCookiesInvalid},
+		{"ERROR: Video unavailable", ""},
+	}
+	for _, tt := range tests {
+		err := classifyYtDlpCookieError(tt.output, false)
+		var appErr *core.AppError
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("classify(%q) = %v, want nil", tt.output, err)
+			}
+			continue
+		}
+		if !errors.As(err, &appErr) || appErr.Code != tt.want {
+			t.Errorf("classify(%q) = %v, want %s", tt.output, err, tt.want)
+		}
+	}
+}
+
+func TestClassifyBuiltinCookieError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"login required", youtube.ErrLoginRequired, core.ErrCodeAgeRestricted},
+		{"login status", &youtube.ErrPlayabiltyStatus{Status: "LOGIN_REQUIRED", Reason: "members only"}, core.ErrCodeAuthRequired},
+		{"other status", &youtube.ErrPlayabiltyStatus{Status: "UNPLAYABLE"}, ""},
+		{"unrelated", errors.New("boom"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyBuiltinCookieError(tt.err, true)
+			var got string
+			if appErr := (*core.AppError)(nil); errors.As(err, &appErr) {
+				got = appErr.Code
+			}
+			if got != tt.want {
+				t.Errorf("classifyBuiltinCookieError() = %v, want code %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -2,13 +2,22 @@ package downloader
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"log/slog"
+	"sync"
+	"time"
 
 	"ybdownloader/internal/core"
 )
 
-var _ core.Downloader = (*DelegatingDownloader)(nil)
+var (
+	_ core.Downloader       = (*DelegatingDownloader)(nil)
+	_ core.FormatLister     = (*DelegatingDownloader)(nil)
+	_ core.RecordingStopper = (*DelegatingDownloader)(nil)
+	_ core.RequestPacer     = (*DelegatingDownloader)(nil)
+	_ core.MetadataCacher   = (*DelegatingDownloader)(nil)
+	_ core.BackendReporter  = (*DelegatingDownloader)(nil)
+)
 
 // DelegatingDownloader implements core.Downloader by routing to the active
 // backend based on the current settings. This allows switching backends
@@ -17,6 +26,10 @@ type DelegatingDownloader struct {
 	builtin     *Downloader
 	ytdlp       *YtDlpDownloader
 	getSettings func() (*core.Settings, error)
+	cache       *MetadataCache // Optional; nil always fetches
+
+	statsMu sync.Mutex
+	stats   map[core.DownloadBackend]*core.BackendStats
 }
 
 // NewDelegatingDownloader creates a downloader that delegates to the backend
@@ -33,6 +46,12 @@ func NewDelegatingDownloader(
 	}
 }
 
+// SetMetadataCache makes FetchMetadata reuse lookups through cache. Every
+// caller sharing this downloader shares the cache.
+func (d *DelegatingDownloader) SetMetadataCache(cache *MetadataCache) {
+	d.cache = cache
+}
+
 func (d *DelegatingDownloader) active() core.Downloader {
 	settings, err := d.getSettings()
 	if err != nil {
@@ -61,20 +80,262 @@ func (d *DelegatingDownloader) active() core.Downloader {
 	return nil
 }
 
-func (d *DelegatingDownloader) FetchMetadata(ctx context.Context, url string) (*core.VideoMetadata, error) {
+// backendFor returns the active backend if it can handle the URL. Sites
+// other than YouTube are only supported by yt-dlp.
+func (d *DelegatingDownloader) backendFor(url string) (core.Downloader, error) {
 	backend := d.active()
 	if backend == nil {
 		return nil, core.NewAppError(core.ErrCodeDownloadFailed, "No download backend available", nil)
 	}
-	slog.Debug("delegating FetchMetadata", "backend", fmt.Sprintf("%T", backend))
-	return backend.FetchMetadata(ctx, url)
+	if IsYouTubeURL(url) {
+		return backend, nil
+	}
+	if _, ok := backend.(*YtDlpDownloader); ok {
+		return backend, nil
+	}
+	return nil, core.NewAppError(core.ErrCodeUnsupportedSite,
+		"This site is only supported by the yt-dlp backend. Switch the download backend in Settings", core.ErrUnsupportedSite)
+}
+
+// backendAttempt is a backend to try, named as in settings.
+type backendAttempt struct {
+	name    core.DownloadBackend
+	backend core.Downloader
+}
+
+func (d *DelegatingDownloader) attempt(backend core.Downloader) backendAttempt {
+	if _, ok := backend.(*YtDlpDownloader); ok {
+		return backendAttempt{name: core.BackendYtDlp, backend: backend}
+	}
+	return backendAttempt{name: core.BackendBuiltin, backend: backend}
+}
+
+// attemptsFor returns the backends to try for a URL: the configured one and,
+// in fallback mode, the other one if it can handle the URL. Only yt-dlp
+// handles other sites and live streams.
+func (d *DelegatingDownloader) attemptsFor(url string, live bool) ([]backendAttempt, error) {
+	backend, err := d.backendFor(url)
+	if err != nil {
+		return nil, err
+	}
+	attempts := []backendAttempt{d.attempt(backend)}
+
+	settings, err := d.getSettings()
+	if err != nil || !settings.BackendFallback {
+		return attempts, nil
+	}
+	if _, ok := backend.(*YtDlpDownloader); ok {
+		if d.builtin != nil && IsYouTubeURL(url) && !live {
+			attempts = append(attempts, d.attempt(d.builtin))
+		}
+	} else if d.ytdlp != nil {
+		attempts = append(attempts, d.attempt(d.ytdlp))
+	}
+	return attempts, nil
+}
+
+// fallbackCodes are the failures the other backend may get past: broken
+// extraction or ciphers, and a missing or outdated yt-dlp. Failures tied to
+// the video, the network or the disk are not retried. Neither are rate
+// limits: both backends hit the same site, and the queue has to see the
+// rate limit to throttle down.
+var fallbackCodes = map[string]bool{
+	core.ErrCodeDownloadFailed: true,
+	core.ErrCodeGeneric:        true,
+	core.ErrCodeYtDlpNotFound:  true,
+	core.ErrCodeYtDlpOutdated:  true,
+}
+
+// shouldFallback reports whether a failure is worth retrying with the other
+// backend.
+func shouldFallback(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, core.ErrCancelled) {
+		return false
+	}
+	return fallbackCodes[core.AsAppError(err, core.ErrCodeGeneric).Code]
+}
+
+// runAttempts calls fn with each backend in turn until one succeeds or a
+// failure is not worth retrying, and returns the backend that succeeded.
+func runAttempts(ctx context.Context, attempts []backendAttempt, fn func(backendAttempt) error) (core.DownloadBackend, error) {
+	var err error
+	for i, a := range attempts {
+		if i > 0 {
+			slog.Warn("backend failed, falling back",
+				"from", attempts[i-1].name, "to", a.name, "error", err)
+		}
+		if err = fn(a); err == nil {
+			return a.name, nil
+		}
+		if !shouldFallback(ctx, err) {
+			break
+		}
+	}
+	return "", err
+}
+
+// FetchMetadata returns cached metadata when available and otherwise asks
+// the active backend.
+func (d *DelegatingDownloader) FetchMetadata(ctx context.Context, url string) (*core.VideoMetadata, error) {
+	attempts, err := d.attemptsFor(url, false)
+	if err != nil {
+		return nil, err
+	}
+	if d.cache != nil {
+		if meta, ok := d.cache.Get(url); ok {
+			slog.Debug("metadata cache hit", "url", url)
+			return meta, nil
+		}
+	}
+	return d.fetchMetadata(ctx, attempts, url)
+}
+
+// RefreshMetadata bypasses the cache and stores the fresh result.
+func (d *DelegatingDownloader) RefreshMetadata(ctx context.Context, url string) (*core.VideoMetadata, error) {
+	attempts, err := d.attemptsFor(url, false)
+	if err != nil {
+		return nil, err
+	}
+	if d.cache != nil {
+		d.cache.Invalidate(url)
+	}
+	return d.fetchMetadata(ctx, attempts, url)
+}
+
+func (d *DelegatingDownloader) fetchMetadata(ctx context.Context, attempts []backendAttempt, url string) (*core.VideoMetadata, error) {
+	var meta *core.VideoMetadata
+	_, err := runAttempts(ctx, attempts, func(a backendAttempt) error {
+		slog.Debug("delegating FetchMetadata", "backend", a.name)
+		var err error
+		meta, err = a.backend.FetchMetadata(ctx, url)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if d.cache != nil {
+		d.cache.Put(url, meta)
+	}
+	return meta, nil
+}
+
+// InvalidateMetadata forgets cached metadata for a URL.
+func (d *DelegatingDownloader) InvalidateMetadata(url string) {
+	if d.cache != nil {
+		d.cache.Invalidate(url)
+	}
+}
+
+// ClearMetadataCache forgets all cached metadata.
+func (d *DelegatingDownloader) ClearMetadataCache() error {
+	if d.cache == nil {
+		return nil
+	}
+	return d.cache.Clear()
 }
 
+// Download downloads through the configured backend and, in fallback mode,
+// retries with the other backend when the failure may be backend-specific.
+// The backend that succeeded is recorded on the item.
 func (d *DelegatingDownloader) Download(ctx context.Context, item *core.QueueItem, onProgress func(core.DownloadProgress)) error {
-	backend := d.active()
-	if backend == nil {
-		return core.NewAppError(core.ErrCodeDownloadFailed, "No download backend available", nil)
+	attempts, err := d.attemptsFor(item.URL, item.Live != nil)
+	if err != nil {
+		return err
+	}
+	if _, ok := attempts[0].backend.(*YtDlpDownloader); item.Live != nil && !ok {
+		return core.NewAppError(core.ErrCodeDownloadFailed,
+			"Live streams can only be recorded with the yt-dlp backend. Switch the download backend in Settings", nil)
+	}
+	return d.download(ctx, attempts, item, onProgress)
+}
+
+func (d *DelegatingDownloader) download(ctx context.Context, attempts []backendAttempt, item *core.QueueItem, onProgress func(core.DownloadProgress)) error {
+	name, err := runAttempts(ctx, attempts, func(a backendAttempt) error {
+		slog.Info("delegating Download", "backend", a.name, "itemId", item.ID)
+		err := a.backend.Download(ctx, item, onProgress)
+		if ctx.Err() == nil {
+			d.recordOutcome(a.name, err, a.name != attempts[0].name)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	item.Backend = name
+	return nil
+}
+
+// recordOutcome counts a finished download attempt for a backend.
+func (d *DelegatingDownloader) recordOutcome(backend core.DownloadBackend, err error, fallback bool) {
+	d.statsMu.Lock()
+	defer d.statsMu.Unlock()
+
+	if d.stats == nil {
+		d.stats = make(map[core.DownloadBackend]*core.BackendStats)
+	}
+	s, ok := d.stats[backend]
+	if !ok {
+		s = &core.BackendStats{Backend: backend}
+		d.stats[backend] = s
+	}
+	switch {
+	case err != nil:
+		s.Failures++
+		s.LastError = err.Error()
+	case fallback:
+		s.Successes++
+		s.Fallbacks++
+	default:
+		s.Successes++
+	}
+}
+
+// BackendStats returns download outcomes per backend since startup, for
+// every backend that is available.
+func (d *DelegatingDownloader) BackendStats() []core.BackendStats {
+	d.statsMu.Lock()
+	defer d.statsMu.Unlock()
+
+	var stats []core.BackendStats
+	for _, backend := range []core.DownloadBackend{core.BackendYtDlp, core.BackendBuiltin} {
+		if (backend == core.BackendYtDlp && d.ytdlp == nil) || (backend == core.BackendBuiltin && d.builtin == nil) {
+			continue
+		}
+		if s, ok := d.stats[backend]; ok {
+			stats = append(stats, *s)
+		} else {
+			stats = append(stats, core.BackendStats{Backend: backend})
+		}
+	}
+	return stats
+}
+
+// ListFormats lists formats through the active backend.
+func (d *DelegatingDownloader) ListFormats(ctx context.Context, url string) ([]core.FormatInfo, error) {
+	backend, err := d.backendFor(url)
+	if err != nil {
+		return nil, err
+	}
+	lister, ok := backend.(core.FormatLister)
+	if !ok {
+		return nil, core.NewAppError(core.ErrCodeDownloadFailed, "Active backend cannot list formats", nil)
+	}
+	slog.Debug("delegating ListFormats", "backend", d.attempt(backend).name)
+	return lister.ListFormats(ctx, url)
+}
+
+// StopRecording ends a live recording early. Only yt-dlp records live streams.
+func (d *DelegatingDownloader) StopRecording(itemID string) error {
+	if d.ytdlp == nil {
+		return core.NewAppError(core.ErrCodeDownloadFailed, "No live recording backend available", nil)
+	}
+	return d.ytdlp.StopRecording(itemID)
+}
+
+// SetRequestDelay paces yt-dlp's requests; the builtin backend makes few
+// requests per item and relies on the queue's own pacing.
+func (d *DelegatingDownloader) SetRequestDelay(delay time.Duration) {
+	if d.ytdlp != nil {
+		d.ytdlp.SetRequestDelay(delay)
 	}
-	slog.Info("delegating Download", "backend", fmt.Sprintf("%T", backend), "itemId", item.ID)
-	return backend.Download(ctx, item, onProgress)
 }
@@ -3,6 +3,7 @@ package downloader
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 
 	"ybdownloader/internal/core"
@@ -221,3 +222,232 @@ func TestDelegatingDownloader_Download_withBackend(t *testing.T) {
 		t.Error("should not get 'no backend available' error when builtin is provided")
 	}
 }
+
+func TestDelegatingDownloader_ListFormats_nilBackend(t *testing.T) {
+	d := NewDelegatingDownloader(nil, nil, func() (*core.Settings, error) {
+		return &core.Settings{DownloadBackend: core.BackendYtDlp}, nil
+	})
+
+	_, err := d.ListFormats(context.Background(), "https://example.com")
+
+	var appErr *core.AppError
+	if !errors.As(err, &appErr) {
+		t.Fatalf("ListFormats() error = %v, want *core.AppError", err)
+	}
+	if appErr.Code != core.ErrCodeDownloadFailed {
+		t.Errorf("AppError.Code = %q, want %q", appErr.Code, core.ErrCodeDownloadFailed)
+	}
+}
+
+func TestDelegatingDownloader_nonYouTubeRequiresYtDlp(t *testing.T) {
+	d := NewDelegatingDownloader(&Downloader{}, &YtDlpDownloader{}, func() (*core.Settings, error) {
+		return &core.Settings{DownloadBackend: core.BackendBuiltin}, nil
+	})
+
+	checks := map[string]error{}
+	_, checks["FetchMetadata"] = d.FetchMetadata(context.Background(), "https://vimeo.com/123")
+	checks["Download"] = d.Download(context.Background(), &core.QueueItem{URL: "https://vimeo.com/123"}, func(core.DownloadProgress) {})
+	_, checks["ListFormats"] = d.ListFormats(context.Background(), "https://vimeo.com/123")
+
+	for name, err := range checks {
+		var appErr *core.AppError
+		if !errors.As(err, &appErr) || appErr.Code != core.ErrCodeUnsupportedSite {
+			t.Errorf("%s() error = %v, want %s", name, err, core.ErrCodeUnsupportedSite)
+		}
+		if !errors.Is(err, core.ErrUnsupportedSite) {
+			t.Errorf("%s() error should wrap ErrUnsupportedSite", name)
+		}
+	}
+}
+
+func TestDelegatingDownloader_backendFor(t *testing.T) {
+	ytdlp := &YtDlpDownloader{}
+	d := NewDelegatingDownloader(&Downloader{}, ytdlp, func() (*core.Settings, error) {
+		return &core.Settings{DownloadBackend: core.BackendYtDlp}, nil
+	})
+
+	backend, err := d.backendFor("https://soundcloud.com/artist/track")
+	if err != nil {
+		t.Fatalf("backendFor() error = %v", err)
+	}
+	if backend != ytdlp {
+		t.Errorf("backendFor() = %T, want yt-dlp backend", backend)
+	}
+}
+
+func TestDelegatingDownloader_liveRequiresYtDlp(t *testing.T) {
+	d := NewDelegatingDownloader(&Downloader{}, &YtDlpDownloader{}, func() (*core.Settings, error) {
+		return &core.Settings{DownloadBackend: core.BackendBuiltin}, nil
+	})
+
+	item := &core.QueueItem{URL: "https://www.youtube.com/watch?v=dQw4w9WgXcQ", Live: &core.LiveOptions{}}
+	err := d.Download(context.Background(), item, func(core.DownloadProgress) {})
+	var appErr *core.AppError
+	if !errors.As(err, &appErr) || !strings.Contains(appErr.Message, "yt-dlp") {
+		t.Errorf("Download() error = %v, want yt-dlp backend error", err)
+	}
+
+	if err := NewDelegatingDownloader(nil, nil, nil).StopRecording("x"); err == nil {
+		t.Error("StopRecording() expected error without yt-dlp backend")
+	}
+}
+
+// fakeBackend is a core.Downloader that fails with err.
+type fakeBackend struct {
+	err   error
+	calls int
+}
+
+func (f *fakeBackend) FetchMetadata(context.Context, string) (*core.VideoMetadata, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &core.VideoMetadata{ID: "test"}, nil
+}
+
+func (f *fakeBackend) Download(context.Context, *core.QueueItem, func(core.DownloadProgress)) error {
+	f.calls++
+	return f.err
+}
+
+func TestDelegatingDownloader_attemptsFor(t *testing.T) {
+	settings := &core.Settings{DownloadBackend: core.BackendYtDlp, BackendFallback: true}
+	d := NewDelegatingDownloader(&Downloader{}, &YtDlpDownloader{}, func() (*core.Settings, error) { return settings, nil })
+	names := func(url string, live bool) string {
+		attempts, err := d.attemptsFor(url, live)
+		if err != nil {
+			return err.Error()
+		}
+		var out []string
+		for _, a := range attempts {
+			out = append(out, string(a.name))
+		}
+		return strings.Join(out, ",")
+	}
+
+	if got := names("https://www.youtube.com/watch?v=dQw4w9WgXcQ", false); got != "yt-dlp,builtin" {
+		t.Errorf("YouTube attempts = %q, want yt-dlp,builtin", got)
+	}
+	if got := names("https://vimeo.com/1", false); got != "yt-dlp" {
+		t.Errorf("other site attempts = %q, want yt-dlp only", got)
+	}
+	if got := names("https://www.youtube.com/watch?v=dQw4w9WgXcQ", true); got != "yt-dlp" {
+		t.Errorf("live attempts = %q, want yt-dlp only", got)
+	}
+	settings.DownloadBackend = core.BackendBuiltin
+	if got := names("https://www.youtube.com/watch?v=dQw4w9WgXcQ", false); got != "builtin,yt-dlp" {
+		t.Errorf("builtin attempts = %q, want builtin,yt-dlp", got)
+	}
+	settings.BackendFallback = false
+	if got := names("https://www.youtube.com/watch?v=dQw4w9WgXcQ", false); got != "builtin" {
+		t.Errorf("attempts without fallback = %q, want builtin only", got)
+	}
+}
+
+func TestDelegatingDownloader_download_fallback(t *testing.T) {
+	tests := []struct {
+		name         string
+		primaryErr   error
+		fallbackErr  error
+		wantErr      bool
+		wantFallback bool
+		wantBackend  core.DownloadBackend
+	}{
+		{"primary succeeds", nil, nil, false, false, core.BackendBuiltin},
+		{"cipher failure falls back", core.NewAppError(core.ErrCodeDownloadFailed, "cipher", nil), nil, false, true, core.BackendYtDlp},
+		{"rate limit does not", core.NewAppError(core.ErrCodeRateLimited, "429", core.ErrRateLimited), nil, true, false, ""},
+		{"unclassified error falls back", errors.New("signature extraction failed"), nil, false, true, core.BackendYtDlp},
+		{"private video does not", core.NewAppError(core.ErrCodeVideoPrivate, "private", core.ErrVideoPrivate), nil, true, false, ""},
+		{"network error does not", core.NewAppError(core.ErrCodeNetworkError, "offline", core.ErrNetwork), nil, true, false, ""},
+		{"both fail", core.NewAppError(core.ErrCodeDownloadFailed, "cipher", nil), core.NewAppError(core.ErrCodeYtDlpNotFound, "missing", nil), true, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDelegatingDownloader(&Downloader{}, &YtDlpDownloader{}, nil)
+			primary := &fakeBackend{err: tt.primaryErr}
+			fallback := &fakeBackend{err: tt.fallbackErr}
+			attempts := []backendAttempt{
+				{name: core.BackendBuiltin, backend: primary},
+				{name: core.BackendYtDlp, backend: fallback},
+			}
+			item := core.NewQueueItem("id", "https://www.youtube.com/watch?v=dQw4w9WgXcQ", core.FormatMP3, "/tmp")
+
+			err := d.download(context.Background(), attempts, item, func(core.DownloadProgress) {})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("download() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (fallback.calls > 0) != tt.wantFallback {
+				t.Errorf("fallback called %d times, wantFallback %v", fallback.calls, tt.wantFallback)
+			}
+			if item.Backend != tt.wantBackend {
+				t.Errorf("item.Backend = %q, want %q", item.Backend, tt.wantBackend)
+			}
+		})
+	}
+}
+
+func TestDelegatingDownloader_download_cancelled(t *testing.T) {
+	d := NewDelegatingDownloader(&Downloader{}, &YtDlpDownloader{}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fallback := &fakeBackend{}
+	attempts := []backendAttempt{
+		{name: core.BackendBuiltin, backend: &fakeBackend{err: context.Canceled}},
+		{name: core.BackendYtDlp, backend: fallback},
+	}
+	item := core.NewQueueItem("id", "https://www.youtube.com/watch?v=dQw4w9WgXcQ", core.FormatMP3, "/tmp")
+	if err := d.download(ctx, attempts, item, func(core.DownloadProgress) {}); err == nil || fallback.calls != 0 {
+		t.Errorf("download() = %v, fallback calls %d, want cancellation without fallback", err, fallback.calls)
+	}
+	for _, s := range d.BackendStats() {
+		if s.Successes+s.Failures != 0 {
+			t.Errorf("cancelled download counted: %+v", s)
+		}
+	}
+}
+
+func TestDelegatingDownloader_BackendStats(t *testing.T) {
+	d := NewDelegatingDownloader(&Downloader{}, &YtDlpDownloader{}, nil)
+	cipher := core.NewAppError(core.ErrCodeDownloadFailed, "cipher", nil)
+	item := core.NewQueueItem("id", "https://www.youtube.com/watch?v=dQw4w9WgXcQ", core.FormatMP3, "/tmp")
+	run := func(primaryErr error) {
+		attempts := []backendAttempt{
+			{name: core.BackendBuiltin, backend: &fakeBackend{err: primaryErr}},
+			{name: core.BackendYtDlp, backend: &fakeBackend{}},
+		}
+		_ = d.download(context.Background(), attempts, item, func(core.DownloadProgress) {})
+	}
+	run(nil)
+	run(cipher)
+	run(cipher)
+
+	stats := d.BackendStats()
+	if len(stats) != 2 {
+		t.Fatalf("BackendStats() = %+v, want both backends", stats)
+	}
+	ytdlp, builtin := stats[0], stats[1]
+	if ytdlp.Backend != core.BackendYtDlp || ytdlp.Successes != 2 || ytdlp.Fallbacks != 2 || ytdlp.Failures != 0 {
+		t.Errorf("yt-dlp stats = %+v, want 2 fallback successes", ytdlp)
+	}
+	if builtin.Successes != 1 || builtin.Failures != 2 || builtin.LastError == "" {
+		t.Errorf("builtin stats = %+v, want 1 success and 2 failures", builtin)
+	}
+
+	if stats := NewDelegatingDownloader(nil, &YtDlpDownloader{}, nil).BackendStats(); len(stats) != 1 {
+		t.Errorf("BackendStats() = %+v, want only the available backend", stats)
+	}
+}
+
+func TestDelegatingDownloader_FetchMetadata_fallback(t *testing.T) {
+	d := NewDelegatingDownloader(&Downloader{}, &YtDlpDownloader{}, nil)
+	fallback := &fakeBackend{}
+	attempts := []backendAttempt{
+		{name: core.BackendYtDlp, backend: &fakeBackend{err: core.NewAppError(core.ErrCodeYtDlpNotFound, "missing", core.ErrYtDlpNotFound)}},
+		{name: core.BackendBuiltin, backend: fallback},
+	}
+	meta, err := d.fetchMetadata(context.Background(), attempts, "https://www.youtube.com/watch?v=dQw4w9WgXcQ")
+	if err != nil || meta == nil || fallback.calls != 1 {
+		t.Errorf("fetchMetadata() = %+v, %v, want builtin to answer", meta, err)
+	}
+}
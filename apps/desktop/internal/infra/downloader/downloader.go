@@ -10,9 +10,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/kkdai/youtube/v2"
+
 	"ybdownloader/internal/core"
+	"ybdownloader/internal/infra/network"
 )
 
+var _ core.FormatLister = (*Downloader)(nil)
+
 // Downloader implements core.Downloader using YouTube library and FFmpeg.
 type Downloader struct {
 	youtube       *YouTubeClient
@@ -28,8 +33,10 @@ type Config struct {
 
 // New sets up the downloader with FFmpeg for conversions.
 func New(fs core.FileSystem, getSettings func() (*core.Settings, error)) (*Downloader, error) {
+	yt := NewYouTubeClient()
+	yt.SetTransport(network.NewTransport(getSettings))
 	return &Downloader{
-		youtube:       NewYouTubeClient(),
+		youtube:       yt,
 		fs:            fs,
 		settings:      getSettings,
 		ffmpegManager: NewFFmpegManager(fs, getSettings, nil),
@@ -50,14 +57,44 @@ func (d *Downloader) getFFmpeg() *FFmpeg {
 	return ffmpeg
 }
 
+// applyCookies loads the configured cookies file into the YouTube client.
+// It reports whether cookies are in use so auth failures can say so.
+func (d *Downloader) applyCookies(settings *core.Settings) (bool, error) {
+	if err := d.youtube.UseCookieFile(settings.CookiesFile); err != nil {
+		return false, newCookieAppError(core.ErrCodeCookiesInvalid, true, err)
+	}
+	return settings.CookiesFile != "", nil
+}
+
+// prepareCookies loads settings and applies the cookies file for requests
+// that don't otherwise need settings.
+func (d *Downloader) prepareCookies() (bool, error) {
+	settings, err := d.settings()
+	if err != nil {
+		return false, err
+	}
+	return d.applyCookies(settings)
+}
+
 // FetchMetadata retrieves video metadata from YouTube.
 func (d *Downloader) FetchMetadata(ctx context.Context, url string) (*core.VideoMetadata, error) {
 	slog.Debug("fetching video metadata", "url", url)
 
+	hasCookies, err := d.prepareCookies()
+	if err != nil {
+		return nil, err
+	}
+
 	meta, err := d.youtube.FetchMetadata(ctx, url)
 	if err != nil {
 		slog.Error("failed to fetch metadata", "url", url, "error", err)
-		return nil, err
+		if authErr := classifyBuiltinCookieError(err, hasCookies); authErr != nil {
+			return nil, authErr
+		}
+		if liveErr := classifyBuiltinLiveError(err); liveErr != nil {
+			return nil, liveErr
+		}
+		return nil, classifyBuiltinError(err)
 	}
 
 	slog.Info("metadata fetched successfully",
@@ -68,8 +105,28 @@ func (d *Downloader) FetchMetadata(ctx context.Context, url string) (*core.Video
 	return meta, nil
 }
 
-// Download downloads a video/audio from YouTube.
-func (d *Downloader) Download(ctx context.Context, item *core.QueueItem, onProgress func(core.DownloadProgress)) error {
+// ListFormats lists the streams available for a YouTube URL.
+func (d *Downloader) ListFormats(ctx context.Context, url string) ([]core.FormatInfo, error) {
+	hasCookies, err := d.prepareCookies()
+	if err != nil {
+		return nil, err
+	}
+
+	formats, err := d.youtube.ListFormats(ctx, url)
+	if err != nil {
+		if authErr := classifyBuiltinCookieError(err, hasCookies); authErr != nil {
+			return nil, authErr
+		}
+		return nil, classifyBuiltinError(err)
+	}
+	return formats, nil
+}
+
+// Download downloads a video/audio from YouTube. Failures are returned as
+// typed *core.AppError values where the cause is recognized.
+func (d *Downloader) Download(ctx context.Context, item *core.QueueItem, onProgress func(core.DownloadProgress)) (err error) {
+	defer func() { err = classifyBuiltinError(err) }()
+
 	slog.Info("starting download",
 		"itemId", item.ID,
 		"url", item.URL,
@@ -82,11 +139,35 @@ func (d *Downloader) Download(ctx context.Context, item *core.QueueItem, onProgr
 		return err
 	}
 
+	hasCookies, err := d.applyCookies(settings)
+	if err != nil {
+		return err
+	}
+
+	// Get FFmpeg lazily - allows using FFmpeg that was installed after app startup
+	ffmpeg := d.getFFmpeg()
+
 	// Get stream info
-	stream, err := d.youtube.SelectStream(ctx, item.URL, item.Format, settings.DefaultAudioQuality, settings.DefaultVideoQuality)
+	stream, err := d.youtube.SelectStream(ctx, item.URL, item.Format, StreamPreferences{
+		AudioQuality: settings.DefaultAudioQuality,
+		VideoQuality: settings.DefaultVideoQuality,
+		VideoCodec:   settings.PreferredVideoCodec,
+		AllowMerge:   ffmpeg != nil,
+		FormatID:     item.FormatID,
+	})
 	if err != nil {
+		if authErr := classifyBuiltinCookieError(err, hasCookies); authErr != nil {
+			return authErr
+		}
+		if liveErr := classifyBuiltinLiveError(err); liveErr != nil {
+			return liveErr
+		}
 		return fmt.Errorf("failed to select stream: %w", err)
 	}
+	if builtinLiveStatus(stream.Video) == core.LiveStatusLive {
+		return core.NewAppError(core.ErrCodeDownloadFailed,
+			"Live streams can only be recorded with the yt-dlp backend. Switch the download backend in Settings", nil)
+	}
 
 	// Prepare output path
 	safeTitle := d.fs.SanitizeFilename(stream.Video.Title)
@@ -107,6 +188,14 @@ func (d *Downloader) Download(ctx context.Context, item *core.QueueItem, onProgr
 		return fmt.Errorf("failed to create save directory: %w", err)
 	}
 
+	if item.Clip != nil {
+		return d.downloadClip(ctx, item, stream, ffmpeg, settings, safeTitle, onProgress)
+	}
+
+	if stream.NeedsMerge() {
+		return d.downloadMerged(ctx, item, stream, ffmpeg, tempDir, safeTitle, onProgress)
+	}
+
 	// Get stream reader
 	reader, size, err := d.youtube.GetStream(ctx, stream.Video, stream.Format)
 	if err != nil {
@@ -139,9 +228,6 @@ func (d *Downloader) Download(ctx context.Context, item *core.QueueItem, onProgr
 		"needsConversion", needsConversion,
 	)
 
-	// Get FFmpeg lazily - allows using FFmpeg that was installed after app startup
-	ffmpeg := d.getFFmpeg()
-
 	if needsConversion && ffmpeg != nil {
 		slog.Info("starting conversion",
 			"itemId", item.ID,
@@ -158,7 +244,7 @@ func (d *Downloader) Download(ctx context.Context, item *core.QueueItem, onProgr
 
 		if err := ffmpeg.Convert(ctx, tempPath, finalPath, item.Format, settings.DefaultAudioQuality); err != nil {
 			slog.Error("conversion failed", "itemId", item.ID, "error", err)
-			return fmt.Errorf("conversion failed: %w", err)
+			return newDownloadError(core.ErrCodeConversionFailed, err)
 		}
 
 		slog.Info("conversion complete", "itemId", item.ID, "outputPath", finalPath)
@@ -192,6 +278,108 @@ func (d *Downloader) Download(ctx context.Context, item *core.QueueItem, onProgr
 	return nil
 }
 
+// downloadMerged fetches the adaptive video and audio streams separately and
+// muxes them with FFmpeg. Progress is reported across both downloads.
+func (d *Downloader) downloadMerged(ctx context.Context, item *core.QueueItem, stream *StreamInfo, ffmpeg *FFmpeg, tempDir, safeTitle string, onProgress func(core.DownloadProgress)) error {
+	slog.Info("downloading adaptive streams for merge",
+		"itemId", item.ID,
+		"videoItag", stream.Format.ItagNo,
+		"videoMime", stream.Format.MimeType,
+		"height", stream.Format.Height,
+		"audioItag", stream.AudioFormat.ItagNo,
+		"audioMime", stream.AudioFormat.MimeType,
+	)
+
+	videoPath := filepath.Join(tempDir, fmt.Sprintf("%s_%s.video.%s", item.ID, safeTitle, getDownloadExtension(stream.Format.MimeType)))
+	audioPath := filepath.Join(tempDir, fmt.Sprintf("%s_%s.audio.%s", item.ID, safeTitle, getDownloadExtension(stream.AudioFormat.MimeType)))
+	finalPath := filepath.Join(item.SavePath, fmt.Sprintf("%s.%s", safeTitle, item.Format))
+
+	defer func() {
+		_ = os.Remove(videoPath) //nolint:errcheck // best-effort cleanup
+		_ = os.Remove(audioPath) //nolint:errcheck // best-effort cleanup
+	}()
+
+	videoSize, err := d.downloadStreamToFile(ctx, stream.Video, stream.Format, videoPath, item.ID,
+		combinedProgress(0, stream.ContentSize, onProgress))
+	if err != nil {
+		return err
+	}
+
+	if _, err := d.downloadStreamToFile(ctx, stream.Video, stream.AudioFormat, audioPath, item.ID,
+		combinedProgress(videoSize, stream.ContentSize, onProgress)); err != nil {
+		return err
+	}
+
+	onProgress(core.DownloadProgress{
+		ItemID:  item.ID,
+		State:   core.StateConverting,
+		Percent: 0,
+	})
+
+	copyAudio := audioFitsContainer(stream.AudioFormat.MimeType, item.Format)
+	if err := ffmpeg.Merge(ctx, videoPath, audioPath, finalPath, item.Format, copyAudio); err != nil {
+		slog.Error("merge failed", "itemId", item.ID, "error", err)
+		return newDownloadError(core.ErrCodeConversionFailed, err)
+	}
+
+	item.FilePath = finalPath
+	slog.Info("download complete", "itemId", item.ID, "filePath", finalPath)
+	return nil
+}
+
+// downloadStreamToFile writes a single stream to path and returns the number
+// of bytes written.
+func (d *Downloader) downloadStreamToFile(ctx context.Context, video *youtube.Video, format *youtube.Format, path, itemID string, onProgress func(core.DownloadProgress)) (int64, error) {
+	reader, size, err := d.youtube.GetStream(ctx, video, format)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stream: %w", err)
+	}
+	defer reader.Close() //nolint:errcheck // deferred close
+
+	file, err := os.Create(path) //nolint:gosec // controlled path
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer file.Close() //nolint:errcheck // deferred close
+
+	counter := &countingWriter{w: file}
+	if err := d.downloadWithProgress(ctx, reader, counter, size, itemID, onProgress); err != nil {
+		return 0, err
+	}
+	return counter.n, nil
+}
+
+// combinedProgress rescales progress from one of several sequential stream
+// downloads onto the combined total, offsetting by the bytes already fetched.
+func combinedProgress(offset, total int64, onProgress func(core.DownloadProgress)) func(core.DownloadProgress) {
+	return func(p core.DownloadProgress) {
+		p.DownloadedBytes += offset
+		if total > 0 {
+			p.TotalBytes = total
+			p.Percent = float64(p.DownloadedBytes) / float64(total) * 100
+			if p.Percent > 100 {
+				p.Percent = 100
+			}
+			p.ETA = 0
+			if p.Speed > 0 && total > p.DownloadedBytes {
+				p.ETA = (total - p.DownloadedBytes) / p.Speed
+			}
+		}
+		onProgress(p)
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
 func (d *Downloader) downloadWithProgress(ctx context.Context, reader io.Reader, writer io.Writer, totalSize int64, itemID string, onProgress func(core.DownloadProgress)) error {
 	var downloaded int64
 	buffer := make([]byte, 32*1024) // 32KB buffer
@@ -495,3 +495,58 @@ func TestDownloadProgress_Fields(t *testing.T) {
 		t.Error("Percent not set correctly")
 	}
 }
+
+func TestCombinedProgress(t *testing.T) {
+	var got []core.DownloadProgress
+	report := func(p core.DownloadProgress) { got = append(got, p) }
+
+	video := combinedProgress(0, 1000, report)
+	audio := combinedProgress(800, 1000, report)
+
+	video(core.DownloadProgress{State: core.StateDownloading, Percent: 50, DownloadedBytes: 400, TotalBytes: 800, Speed: 100})
+	video(core.DownloadProgress{State: core.StateDownloading, Percent: 100, DownloadedBytes: 800, TotalBytes: 800})
+	audio(core.DownloadProgress{State: core.StateDownloading, Percent: 100, DownloadedBytes: 200, TotalBytes: 200})
+
+	if len(got) != 3 {
+		t.Fatalf("expected 3 progress reports, got %d", len(got))
+	}
+
+	want := []struct {
+		percent    float64
+		downloaded int64
+		eta        int64
+	}{
+		{40, 400, 6},
+		{80, 800, 0},
+		{100, 1000, 0},
+	}
+	for i, w := range want {
+		if got[i].Percent != w.percent {
+			t.Errorf("report %d: Percent = %v, want %v", i, got[i].Percent, w.percent)
+		}
+		if got[i].DownloadedBytes != w.downloaded {
+			t.Errorf("report %d: DownloadedBytes = %d, want %d", i, got[i].DownloadedBytes, w.downloaded)
+		}
+		if got[i].TotalBytes != 1000 {
+			t.Errorf("report %d: TotalBytes = %d, want 1000", i, got[i].TotalBytes)
+		}
+		if got[i].ETA != w.eta {
+			t.Errorf("report %d: ETA = %d, want %d", i, got[i].ETA, w.eta)
+		}
+	}
+}
+
+func TestCountingWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := &countingWriter{w: &buf}
+
+	_, _ = w.Write([]byte("hello"))
+	_, _ = w.Write([]byte(" world"))
+
+	if w.n != 11 {
+		t.Errorf("countingWriter.n = %d, want 11", w.n)
+	}
+	if buf.String() != "hello world" {
+		t.Errorf("underlying writer got %q", buf.String())
+	}
+}
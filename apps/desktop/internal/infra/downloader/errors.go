@@ -0,0 +1,169 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"github.com/kkdai/youtube/v2"
+
+	"ybdownloader/internal/core"
+)
+
+// downloadErrors describe each typed download failure: the sentinel it
+// wraps and the message shown on the queue item. Remediation hints come from
+// core.ErrorHint.
+var downloadErrors = map[string]struct {
+	sentinel error
+	message  string
+}{
+	core.ErrCodeVideoNotFound:    {core.ErrVideoNotFound, "Video not found or unavailable"},
+	core.ErrCodeVideoPrivate:     {core.ErrVideoPrivate, "This video is private"},
+	core.ErrCodeVideoRemoved:     {core.ErrVideoRemoved, "This video has been removed"},
+	core.ErrCodeGeoBlocked:       {core.ErrGeoBlocked, "This video is not available in your country"},
+	core.ErrCodeAgeRestricted:    {core.ErrAgeRestricted, "This video is age-restricted"},
+	core.ErrCodeRateLimited:      {core.ErrRateLimited, "The site is limiting requests from this network"},
+	core.ErrCodeNetworkError:     {core.ErrNetwork, "Could not connect to the server"},
+	core.ErrCodeConversionFailed: {core.ErrConversionFailed, "FFmpeg failed to process the download"},
+	core.ErrCodeFFmpegNotFound:   {core.ErrFFmpegNotFound, "FFmpeg is required for this format but is not installed"},
+	core.ErrCodeDiskFull:         {core.ErrDiskFull, "Not enough disk space to save the download"},
+	core.ErrCodeYtDlpOutdated:    {core.ErrYtDlpOutdated, "yt-dlp could not process this video and may be outdated"},
+	core.ErrCodeYtDlpNotFound:    {core.ErrYtDlpNotFound, "yt-dlp is not installed"},
+}
+
+// newDownloadError returns the typed error for code, wrapping its sentinel
+// and the underlying cause.
+func newDownloadError(code string, err error) *core.AppError {
+	desc := downloadErrors[code]
+	return core.NewAppError(code, desc.message, fmt.Errorf("%w: %w", desc.sentinel, err))
+}
+
+// ytDlpExtractorError matches the start of an error yt-dlp reports for an
+// extractor, such as "ERROR: [youtube] abc: ...", in lowercased output.
+var ytDlpExtractorError = regexp.MustCompile(`(?m)^error: \[[\w:.-]+\] .*$`)
+
+// ytDlpErrorPatterns map yt-dlp error output to error codes. The first match
+// wins, so specific messages come before generic ones such as "video
+// unavailable", which yt-dlp prefixes to many of them.
+var ytDlpErrorPatterns = []struct {
+	substr string
+	code   string
+}{
+	{"no space left on device", core.ErrCodeDiskFull},
+	{"not enough space on the disk", core.ErrCodeDiskFull},
+	{"private video", core.ErrCodeVideoPrivate},
+	{"this video is private", core.ErrCodeVideoPrivate},
+	{"has been removed", core.ErrCodeVideoRemoved},
+	{"has been terminated", core.ErrCodeVideoRemoved},
+	{"no longer available", core.ErrCodeVideoRemoved},
+	{"available in your country", core.ErrCodeGeoBlocked},
+	{"geo restriction", core.ErrCodeGeoBlocked},
+	{"geo-restricted", core.ErrCodeGeoBlocked},
+	{"http error 429", core.ErrCodeRateLimited},
+	{"too many requests", core.ErrCodeRateLimited},
+	{"not a bot", core.ErrCodeRateLimited},
+	{"ffmpeg is not installed", core.ErrCodeFFmpegNotFound},
+	{"ffmpeg not found", core.ErrCodeFFmpegNotFound},
+	{"postprocessing:", core.ErrCodeConversionFailed},
+	{"conversion failed", core.ErrCodeConversionFailed},
+	{"confirm you are on the latest version", core.ErrCodeYtDlpOutdated},
+	{"nsig extraction failed", core.ErrCodeYtDlpOutdated},
+	{"signature extraction failed", core.ErrCodeYtDlpOutdated},
+	{"video unavailable", core.ErrCodeVideoNotFound},
+	{"http error 404", core.ErrCodeVideoNotFound},
+	{"unable to download webpage", core.ErrCodeNetworkError},
+	{"connection reset", core.ErrCodeNetworkError},
+	{"connection refused", core.ErrCodeNetworkError},
+	{"name resolution", core.ErrCodeNetworkError},
+	{"name or service not known", core.ErrCodeNetworkError},
+	{"getaddrinfo failed", core.ErrCodeNetworkError},
+	{"network is unreachable", core.ErrCodeNetworkError},
+}
+
+// ytDlpExtractorErrorPatterns are too vague to match anywhere in the output,
+// where they would catch warnings and unrelated messages, so they only match
+// extractor errors. They are tried after ytDlpErrorPatterns.
+var ytDlpExtractorErrorPatterns = []struct {
+	substr string
+	code   string
+}{
+	{"unable to extract", core.ErrCodeYtDlpOutdated},
+	{"timed out", core.ErrCodeNetworkError},
+}
+
+// classifyYtDlpError returns a typed error for yt-dlp error output, or nil if
+// the failure is not recognized.
+func classifyYtDlpError(output string) error {
+	lower := strings.ToLower(output)
+	for _, p := range ytDlpErrorPatterns {
+		if strings.Contains(lower, p.substr) {
+			return newDownloadError(p.code, errors.New(strings.TrimSpace(output)))
+		}
+	}
+	extractorErrors := strings.Join(ytDlpExtractorError.FindAllString(lower, -1), "\n")
+	for _, p := range ytDlpExtractorErrorPatterns {
+		if strings.Contains(extractorErrors, p.substr) {
+			return newDownloadError(p.code, errors.New(strings.TrimSpace(output)))
+		}
+	}
+	return nil
+}
+
+// classifyBuiltinError maps an error from the builtin backend to a typed
+// error. Typed errors and cancellation pass through; unrecognized errors are
+// returned unchanged.
+func classifyBuiltinError(err error) error {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	if errors.Is(err, syscall.ENOSPC) || strings.Contains(strings.ToLower(err.Error()), "no space left on device") {
+		return newDownloadError(core.ErrCodeDiskFull, err)
+	}
+
+	var appErr *core.AppError
+	if errors.As(err, &appErr) {
+		return err
+	}
+
+	if errors.Is(err, youtube.ErrVideoPrivate) {
+		return newDownloadError(core.ErrCodeVideoPrivate, err)
+	}
+
+	var status *youtube.ErrPlayabiltyStatus
+	if errors.As(err, &status) {
+		reason := strings.ToLower(status.Reason)
+		switch {
+		case strings.Contains(reason, "private"):
+			return newDownloadError(core.ErrCodeVideoPrivate, err)
+		case strings.Contains(reason, "country"):
+			return newDownloadError(core.ErrCodeGeoBlocked, err)
+		case strings.Contains(reason, "removed"), strings.Contains(reason, "terminated"),
+			strings.Contains(reason, "no longer available"):
+			return newDownloadError(core.ErrCodeVideoRemoved, err)
+		default:
+			return newDownloadError(core.ErrCodeVideoNotFound, err)
+		}
+	}
+
+	var statusCode youtube.ErrUnexpectedStatusCode
+	if errors.As(err, &statusCode) {
+		switch statusCode {
+		case 429:
+			return newDownloadError(core.ErrCodeRateLimited, err)
+		case 404:
+			return newDownloadError(core.ErrCodeVideoNotFound, err)
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return newDownloadError(core.ErrCodeNetworkError, err)
+	}
+
+	return err
+}
@@ -0,0 +1,91 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/kkdai/youtube/v2"
+
+	"ybdownloader/internal/core"
+)
+
+func appErrorCode(err error) string {
+	var appErr *core.AppError
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return ""
+}
+
+func TestClassifyYtDlpError(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"ERROR: [youtube] abc: Private video. Sign in if you've been granted access to this video", core.ErrCodeVideoPrivate},
+		{"ERROR: [youtube] abc: Video unavailable. This video has been removed by the uploader", core.ErrCodeVideoRemoved},
+		{"ERROR: [youtube] abc: Video unavailable. The uploader has not made this video available in your country", core.ErrCodeGeoBlocked},
+		{"ERROR: [youtube] abc: Video unavailable", core.ErrCodeVideoNotFound},
+		{"ERROR: [youtube] abc: Unable to download webpage: HTTP Error 429: Too Many Requests", core.ErrCodeRateLimited},
+		{"ERROR: [youtube] abc: Sign in to confirm you’re not a bot", core.ErrCodeRateLimited},
+		{"ERROR: [youtube] abc: Unable to download webpage: <urlopen error [Errno -3] Temporary failure in name resolution>", core.ErrCodeNetworkError},
+		{"ERROR: unable to write data: [Errno 28] No space left on device", core.ErrCodeDiskFull},
+		{"ERROR: Postprocessing: Conversion failed!", core.ErrCodeConversionFailed},
+		{"ERROR: You have requested merging of multiple formats but ffmpeg is not installed", core.ErrCodeFFmpegNotFound},
+		{"ERROR: [youtube] abc: nsig extraction failed: Some formats may be missing", core.ErrCodeYtDlpOutdated},
+		{"ERROR: [generic] Unsupported URL: https://example.com", ""},
+		{"ERROR: [youtube] abc: Unable to extract uploader id", core.ErrCodeYtDlpOutdated},
+		{"ERROR: [youtube:tab] abc: Read timed out", core.ErrCodeNetworkError},
+		{"WARNING: [youtube] abc: unable to extract chapters\nERROR: unable to rename file: timed out", ""},
+		{"[download] Got error: The read operation timed out. Retrying (1/10)...", ""},
+	}
+	for _, tt := range tests {
+		err := classifyYtDlpError(tt.output)
+		if got := appErrorCode(err); got != tt.want {
+			t.Errorf("classifyYtDlpError(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+
+	err := classifyYtDlpError("ERROR: [youtube] abc: Private video")
+	if !errors.Is(err, core.ErrVideoPrivate) {
+		t.Errorf("classifyYtDlpError() = %v, want it to wrap ErrVideoPrivate", err)
+	}
+}
+
+func TestClassifyBuiltinError(t *testing.T) {
+	typed := core.NewAppError(core.ErrCodeAuthRequired, "sign in", nil)
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"private", youtube.ErrVideoPrivate, core.ErrCodeVideoPrivate},
+		{"geo", &youtube.ErrPlayabiltyStatus{Status: "UNPLAYABLE", Reason: "The uploader has not made this video available in your country"}, core.ErrCodeGeoBlocked},
+		{"removed", &youtube.ErrPlayabiltyStatus{Status: "ERROR", Reason: "This video has been removed by the uploader"}, core.ErrCodeVideoRemoved},
+		{"unavailable", &youtube.ErrPlayabiltyStatus{Status: "ERROR", Reason: "Video unavailable"}, core.ErrCodeVideoNotFound},
+		{"rate limited", fmt.Errorf("failed to get stream: %w", youtube.ErrUnexpectedStatusCode(429)), core.ErrCodeRateLimited},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, core.ErrCodeNetworkError},
+		{"disk full", fmt.Errorf("write error: %w", syscall.ENOSPC), core.ErrCodeDiskFull},
+		{"typed passes through", typed, core.ErrCodeAuthRequired},
+		{"unrecognized", errors.New("boom"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appErrorCode(classifyBuiltinError(tt.err)); got != tt.want {
+				t.Errorf("classifyBuiltinError() code = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if err := classifyBuiltinError(context.Canceled); err != context.Canceled {
+		t.Errorf("classifyBuiltinError(Canceled) = %v", err)
+	}
+	if err := classifyBuiltinError(nil); err != nil {
+		t.Errorf("classifyBuiltinError(nil) = %v", err)
+	}
+}
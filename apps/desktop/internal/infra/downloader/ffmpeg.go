@@ -1,12 +1,15 @@
 package downloader
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"ybdownloader/internal/core"
@@ -96,6 +99,98 @@ func buildConvertArgs(input, output string, format core.Format, quality core.Aud
 	return args
 }
 
+// Merge muxes a video-only and an audio-only stream into one file.
+// The video is always stream-copied; the audio is copied when copyAudio is
+// set and re-encoded to the container's native codec otherwise.
+func (f *FFmpeg) Merge(ctx context.Context, videoPath, audioPath, outputPath string, format core.Format, copyAudio bool) error {
+	args := buildMergeArgs(videoPath, audioPath, outputPath, format, copyAudio)
+	cmd := exec.CommandContext(ctx, f.binaryPath, args...) //nolint:gosec // G204: ffmpeg subprocess expected
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg merge failed: %w\nOutput: %s", err, string(output))
+	}
+
+	return nil
+}
+
+// RunWithProgress runs ffmpeg with args that write "-progress pipe:1"
+// output, reporting the output position in seconds as it advances. env is
+// added to the inherited environment.
+func (f *FFmpeg) RunWithProgress(ctx context.Context, args, env []string, onTime func(seconds float64)) error {
+	cmd := exec.CommandContext(ctx, f.binaryPath, args...) //nolint:gosec // G204: ffmpeg subprocess expected
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		if seconds, ok := parseFFmpegProgressTime(scanner.Text()); ok && onTime != nil {
+			onTime(seconds)
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("ffmpeg failed: %w\nOutput: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// parseFFmpegProgressTime reads the position from an "out_time_us=" line
+// of ffmpeg's -progress output. ffmpeg also writes "out_time_ms=", which is
+// in microseconds despite its name.
+func parseFFmpegProgressTime(line string) (float64, bool) {
+	key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+	if !ok || (key != "out_time_us" && key != "out_time_ms") {
+		return 0, false
+	}
+	us, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || us < 0 {
+		return 0, false
+	}
+	return float64(us) / 1e6, true
+}
+
+func buildMergeArgs(videoPath, audioPath, output string, format core.Format, copyAudio bool) []string {
+	args := []string{
+		"-y",
+		"-i", videoPath,
+		"-i", audioPath,
+		"-map", "0:v:0",
+		"-map", "1:a:0",
+		"-codec:v", "copy",
+	}
+
+	switch {
+	case copyAudio:
+		args = append(args, "-codec:a", "copy")
+	case format == core.FormatWebM:
+		args = append(args, "-codec:a", "libopus", "-b:a", "160k")
+	default:
+		args = append(args, "-codec:a", "aac", "-b:a", "192k")
+	}
+
+	if format == core.FormatMP4 {
+		args = append(args, "-movflags", "+faststart")
+	}
+
+	args = append(args, output)
+	return args
+}
+
 func qualityToFFmpegBitrate(q core.AudioQuality) string {
 	switch q {
 	case core.AudioQuality128:
@@ -1,7 +1,6 @@
 package downloader
 
 import (
-	"archive/zip"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -12,9 +11,9 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
-	"strings"
 
 	"ybdownloader/internal/core"
+	"ybdownloader/internal/infra/network"
 )
 
 const (
@@ -163,12 +162,12 @@ func getPlatformKey() string {
 
 // fetchBinaryURLs fetches the download URLs for ffmpeg and ffprobe from ffbinaries.com API.
 func (m *FFmpegManager) fetchBinaryURLs(ctx context.Context) (ffmpegURL, ffprobeURL string, err error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", ffbinariesAPIURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", mirroredURL(m.getSettings, ffbinariesAPIURL), nil)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := http.DefaultClient.Do(req) //nolint:gosec // G704: URL is from hardcoded ffbinaries.com API
+	resp, err := network.NewClient(m.getSettings, 0).Do(req) //nolint:gosec // G704: URL is from hardcoded ffbinaries.com API
 	if err != nil {
 		return "", "", fmt.Errorf("failed to fetch ffbinaries API: %w", err)
 	}
@@ -193,7 +192,7 @@ func (m *FFmpegManager) fetchBinaryURLs(ctx context.Context) (ffmpegURL, ffprobe
 		return "", "", fmt.Errorf("no binaries available for platform: %s", platformKey)
 	}
 
-	return platform.FFmpeg, platform.FFprobe, nil
+	return mirroredURL(m.getSettings, platform.FFmpeg), mirroredURL(m.getSettings, platform.FFprobe), nil
 }
 
 // DownloadFFmpeg downloads the appropriate FFmpeg and FFprobe binaries for the current platform.
@@ -238,6 +237,7 @@ func (m *FFmpegManager) DownloadFFmpeg(ctx context.Context, onProgress func(perc
 	if err := m.extractZipBinary(ffmpegZip, bundledDir, ffmpegBinary); err != nil {
 		return fmt.Errorf("failed to extract FFmpeg: %w", err)
 	}
+	m.recordInstalled(filepath.Join(bundledDir, ffmpegBinary), ffmpegURL)
 
 	// Download FFprobe (50-90%)
 	onProgress(50, "Downloading FFprobe...")
@@ -261,11 +261,54 @@ func (m *FFmpegManager) DownloadFFmpeg(ctx context.Context, onProgress func(perc
 	if err := m.extractZipBinary(ffprobeZip, bundledDir, ffprobeBinary); err != nil {
 		return fmt.Errorf("failed to extract FFprobe: %w", err)
 	}
+	m.recordInstalled(filepath.Join(bundledDir, ffprobeBinary), ffprobeURL)
 
 	onProgress(100, "FFmpeg and FFprobe installed successfully")
 	return nil
 }
 
+// InstallFromFile installs FFmpeg from a local archive or binary, for
+// machines without internet access. FFprobe is installed as well when the
+// archive contains it.
+func (m *FFmpegManager) InstallFromFile(ctx context.Context, archivePath string) (*ToolInstallResult, error) {
+	bundledDir := m.getBundledDir()
+	dest := func(name string) func(string) string {
+		return func(string) string { return m.getBundledBinaryPath(name) }
+	}
+
+	ffmpegName := "ffmpeg" + binaryExt()
+	path, version, err := installFromArchive(ctx, archivePath, []string{ffmpegName}, "-version",
+		versionAfter("ffmpeg version"), bundledDir, dest("ffmpeg"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to install FFmpeg: %w", err)
+	}
+	result := &ToolInstallResult{Tool: ToolFFmpeg, Version: version, Paths: []string{path}}
+
+	ffprobeName := "ffprobe" + binaryExt()
+	if probe, _, err := installFromArchive(ctx, archivePath, []string{ffprobeName}, "-version",
+		versionAfter("ffprobe version"), bundledDir, dest("ffprobe")); err == nil {
+		result.Paths = append(result.Paths, probe)
+	} else {
+		slog.Info("no usable ffprobe in archive", "file", archivePath, "error", err)
+	}
+
+	slog.Info("FFmpeg installed from file", "version", version, "file", archivePath)
+	return result, nil
+}
+
+// recordInstalled remembers the hash of a freshly extracted binary.
+// ffbinaries publishes no checksums, so unlike yt-dlp and deno the archive
+// cannot be verified before install; the recorded hash still lets later
+// launches detect that the binary changed.
+func (m *FFmpegManager) recordInstalled(path, source string) {
+	hash, err := sha256File(path)
+	if err != nil {
+		slog.Warn("failed to hash installed binary", "path", path, "error", err)
+		return
+	}
+	recordChecksum(m.getBundledDir(), path, hash, source, false)
+}
+
 func (m *FFmpegManager) getBundledDir() string {
 	configDir, _ := m.fs.GetConfigDir()
 	return filepath.Join(configDir, "bin")
@@ -285,7 +328,7 @@ func (m *FFmpegManager) downloadFile(ctx context.Context, url, dest string, onPr
 		return err
 	}
 
-	resp, err := http.DefaultClient.Do(req) //nolint:gosec // G704: URL is from hardcoded ffbinaries.com API
+	resp, err := network.NewClient(m.getSettings, 0).Do(req) //nolint:gosec // G704: URL is from hardcoded ffbinaries.com API
 	if err != nil {
 		return err
 	}
@@ -335,36 +378,8 @@ func (m *FFmpegManager) downloadFile(ctx context.Context, url, dest string, onPr
 }
 
 func (m *FFmpegManager) extractZipBinary(zipPath, destDir, binaryName string) error {
-	r, err := zip.OpenReader(zipPath)
-	if err != nil {
-		return err
-	}
-	defer r.Close() //nolint:errcheck // deferred close
-
-	for _, f := range r.File {
-		// Look for the specific binary in the archive
-		// ffbinaries zips contain the binary at the root level
-		if f.Name == binaryName || strings.HasSuffix(f.Name, "/"+binaryName) {
-			rc, err := f.Open()
-			if err != nil {
-				return err
-			}
-			defer rc.Close() //nolint:errcheck // deferred close
-
-			destPath := filepath.Join(destDir, binaryName)
-			//nolint:gosec // G302: executable needs 0755
-			out, err := os.OpenFile(destPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
-			if err != nil {
-				return err
-			}
-			defer out.Close() //nolint:errcheck // deferred close
-
-			_, err = io.Copy(out, rc) //nolint:gosec // G110: trusted source
-			return err
-		}
-	}
-
-	return fmt.Errorf("%s binary not found in archive", binaryName)
+	// ffbinaries zips contain the binary at the root level
+	return extractBinaryFromZip(zipPath, binaryName, filepath.Join(destDir, binaryName))
 }
 
 // findSystemFFmpeg finds FFmpeg in system PATH
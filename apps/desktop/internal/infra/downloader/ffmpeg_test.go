@@ -142,6 +142,41 @@ func TestBuildConvertArgs_MP4(t *testing.T) {
 	}
 }
 
+func TestBuildMergeArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    core.Format
+		copyAudio bool
+		wantAudio []string
+		faststart bool
+	}{
+		{"mp4 copy", core.FormatMP4, true, []string{"-codec:a", "copy"}, true},
+		{"mp4 transcode", core.FormatMP4, false, []string{"-codec:a", "aac", "-b:a", "192k"}, true},
+		{"webm copy", core.FormatWebM, true, []string{"-codec:a", "copy"}, false},
+		{"webm transcode", core.FormatWebM, false, []string{"-codec:a", "libopus", "-b:a", "160k"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := buildMergeArgs("/v.mp4", "/a.m4a", "/out."+string(tt.format), tt.format, tt.copyAudio)
+			joined := strings.Join(args, " ")
+
+			if !strings.HasPrefix(joined, "-y -i /v.mp4 -i /a.m4a -map 0:v:0 -map 1:a:0 -codec:v copy") {
+				t.Errorf("unexpected merge args prefix: %s", joined)
+			}
+			if !strings.Contains(joined, strings.Join(tt.wantAudio, " ")) {
+				t.Errorf("args %q missing audio args %q", joined, tt.wantAudio)
+			}
+			if got := strings.Contains(joined, "+faststart"); got != tt.faststart {
+				t.Errorf("faststart = %v, want %v", got, tt.faststart)
+			}
+			if args[len(args)-1] != "/out."+string(tt.format) {
+				t.Errorf("last arg = %s, want output path", args[len(args)-1])
+			}
+		})
+	}
+}
+
 func TestFFmpeg_GetVersion(t *testing.T) {
 	if !IsFFmpegInstalled() {
 		t.Skip("ffmpeg not installed, skipping test")
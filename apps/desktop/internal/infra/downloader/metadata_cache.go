@@ -0,0 +1,298 @@
+package downloader
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"ybdownloader/internal/core"
+)
+
+const (
+	// DefaultMetadataCacheSize is how many entries stay in memory.
+	DefaultMetadataCacheSize = 256
+	// DefaultMetadataCacheTTL is how long cached metadata is trusted.
+	DefaultMetadataCacheTTL = 24 * time.Hour
+	// DefaultMetadataCacheFiles is how many entries stay on disk.
+	DefaultMetadataCacheFiles = 4096
+)
+
+// MetadataCache keeps recently fetched video metadata in an in-memory LRU
+// backed by one JSON file per entry on disk. Entries are keyed by video ID
+// for YouTube and by URL for other sites, and expire after a TTL. Expired
+// and least recently written files are swept from disk by the first Put and
+// whenever the files outgrow maxFiles.
+type MetadataCache struct {
+	mu       sync.Mutex
+	dir      string // Empty keeps the cache in memory only
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+
+	maxFiles  int
+	diskFiles int  // Files on disk, counted exactly by the last sweep
+	swept     bool // Whether the files were swept since startup
+
+	entries map[string]*list.Element
+	order   *list.List // Front is most recently used
+}
+
+type metadataCacheEntry struct {
+	Key       string              `json:"key"`
+	Metadata  *core.VideoMetadata `json:"metadata"`
+	FetchedAt time.Time           `json:"fetchedAt"`
+}
+
+// NewMetadataCache creates a cache that persists entries under dir.
+func NewMetadataCache(dir string, capacity int, ttl time.Duration) *MetadataCache {
+	if capacity <= 0 {
+		capacity = DefaultMetadataCacheSize
+	}
+	if ttl <= 0 {
+		ttl = DefaultMetadataCacheTTL
+	}
+	return &MetadataCache{
+		dir:      dir,
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+		maxFiles: max(DefaultMetadataCacheFiles, capacity),
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// metadataCacheKey identifies a video independently of how its URL is
+// spelled, so youtu.be and watch?v= links share an entry.
+func metadataCacheKey(url string) string {
+	if id, err := ExtractVideoID(url); err == nil {
+		return "youtube:" + id
+	}
+	return strings.TrimSpace(url)
+}
+
+// cacheable reports whether metadata can be reused later. Live and upcoming
+// streams change state, so they are always fetched fresh.
+func cacheable(meta *core.VideoMetadata) bool {
+	return meta != nil && meta.LiveStatus == core.LiveStatusNone
+}
+
+// Get returns a copy of the cached metadata for url if it has not expired.
+func (c *MetadataCache) Get(url string) (*core.VideoMetadata, bool) {
+	key := metadataCacheKey(url)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*metadataCacheEntry)
+		if c.expired(entry) {
+			c.removeLocked(key)
+			return nil, false
+		}
+		c.order.MoveToFront(el)
+		return entry.Metadata.Clone(), true
+	}
+
+	entry := c.readEntry(key)
+	if entry == nil {
+		return nil, false
+	}
+	if c.expired(entry) {
+		c.removeLocked(key)
+		return nil, false
+	}
+	c.insertLocked(entry)
+	return entry.Metadata.Clone(), true
+}
+
+// Put stores metadata for url. Live and upcoming streams are not cached.
+func (c *MetadataCache) Put(url string, meta *core.VideoMetadata) {
+	if !cacheable(meta) {
+		return
+	}
+	entry := &metadataCacheEntry{
+		Key:       metadataCacheKey(url),
+		Metadata:  meta.Clone(),
+		FetchedAt: c.now(),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.insertLocked(entry)
+	c.writeEntry(entry)
+	if !c.swept || c.diskFiles > c.maxFiles {
+		c.sweepLocked()
+	}
+}
+
+// Invalidate drops the entry for url from memory and disk.
+func (c *MetadataCache) Invalidate(url string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeLocked(metadataCacheKey(url))
+}
+
+// Clear drops every entry from memory and disk.
+func (c *MetadataCache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+	c.diskFiles = 0
+	if c.dir == "" {
+		return nil
+	}
+	if err := os.RemoveAll(c.dir); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Len returns the number of entries held in memory.
+func (c *MetadataCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *MetadataCache) expired(entry *metadataCacheEntry) bool {
+	return c.now().Sub(entry.FetchedAt) >= c.ttl
+}
+
+func (c *MetadataCache) insertLocked(entry *metadataCacheEntry) {
+	if el, ok := c.entries[entry.Key]; ok {
+		el.Value = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[entry.Key] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*metadataCacheEntry).Key)
+	}
+}
+
+// removeLocked drops key from memory and disk. Evicting from the LRU alone
+// keeps the disk copy, which is what makes the disk layer useful.
+func (c *MetadataCache) removeLocked(key string) {
+	if el, ok := c.entries[key]; ok {
+		c.order.Remove(el)
+		delete(c.entries, key)
+	}
+	if c.dir == "" {
+		return
+	}
+	c.removeFile(c.entryPath(key))
+}
+
+func (c *MetadataCache) removeFile(path string) {
+	err := os.Remove(path)
+	switch {
+	case err == nil:
+		c.diskFiles--
+	case !errors.Is(err, os.ErrNotExist):
+		slog.Debug("failed to remove cached metadata", "path", path, "error", err)
+	}
+}
+
+// sweepLocked removes expired files, then the oldest ones beyond maxFiles.
+// It trims a tenth below the limit so that a full cache is not swept on
+// every Put. Each file's modification time is set to its FetchedAt when
+// written, so the directory listing is enough to decide.
+func (c *MetadataCache) sweepLocked() {
+	if c.dir == "" {
+		return
+	}
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Debug("failed to sweep cached metadata", "error", err)
+		}
+		return
+	}
+	c.swept = true
+
+	type file struct {
+		path    string
+		written time.Time
+	}
+	var files []file
+	for _, de := range dirEntries {
+		info, err := de.Info()
+		if err != nil || !info.Mode().IsRegular() || filepath.Ext(de.Name()) != ".json" {
+			continue
+		}
+		files = append(files, file{filepath.Join(c.dir, de.Name()), info.ModTime()})
+	}
+	c.diskFiles = len(files)
+
+	// Newest first: the files past the limit are the oldest.
+	slices.SortFunc(files, func(a, b file) int { return b.written.Compare(a.written) })
+	keep := c.maxFiles
+	if len(files) > c.maxFiles {
+		keep -= c.maxFiles / 10
+	}
+	for i, f := range files {
+		if i >= keep || c.now().Sub(f.written) >= c.ttl {
+			c.removeFile(f.path)
+		}
+	}
+}
+
+func (c *MetadataCache) entryPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:16])+".json")
+}
+
+func (c *MetadataCache) readEntry(key string) *metadataCacheEntry {
+	if c.dir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.entryPath(key))
+	if err != nil {
+		return nil
+	}
+	var entry metadataCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key || entry.Metadata == nil {
+		return nil
+	}
+	return &entry
+}
+
+func (c *MetadataCache) writeEntry(entry *metadataCacheEntry) {
+	if c.dir == "" {
+		return
+	}
+	path := c.entryPath(entry.Key)
+	_, statErr := os.Stat(path)
+
+	data, err := json.Marshal(entry)
+	if err == nil {
+		err = os.MkdirAll(c.dir, 0o755)
+	}
+	if err == nil {
+		err = os.WriteFile(path, data, 0o644)
+	}
+	if err == nil {
+		if errors.Is(statErr, os.ErrNotExist) {
+			c.diskFiles++
+		}
+		err = os.Chtimes(path, entry.FetchedAt, entry.FetchedAt)
+	}
+	if err != nil {
+		slog.Debug("failed to persist cached metadata", "key", entry.Key, "error", err)
+	}
+}
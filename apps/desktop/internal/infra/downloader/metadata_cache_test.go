@@ -0,0 +1,195 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"ybdownloader/internal/core"
+)
+
+func TestMetadataCacheKey(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ", "youtube:dQw4w9WgXcQ"},
+		{"https://youtu.be/dQw4w9WgXcQ?t=10", "youtube:dQw4w9WgXcQ"},
+		{" https://vimeo.com/123 ", "https://vimeo.com/123"},
+	}
+	for _, tt := range tests {
+		if got := metadataCacheKey(tt.url); got != tt.want {
+			t.Errorf("metadataCacheKey(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestMetadataCache_GetPut(t *testing.T) {
+	c := NewMetadataCache("", 0, 0)
+	url := "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+
+	if _, ok := c.Get(url); ok {
+		t.Fatal("Get() hit on empty cache")
+	}
+	c.Put(url, &core.VideoMetadata{ID: "dQw4w9WgXcQ", Title: "Test"})
+
+	meta, ok := c.Get("https://youtu.be/dQw4w9WgXcQ")
+	if !ok || meta.Title != "Test" {
+		t.Fatalf("Get() = %+v, %v", meta, ok)
+	}
+
+	meta.Title = "changed"
+	if again, _ := c.Get(url); again.Title != "Test" {
+		t.Error("Get() returned shared metadata that callers can modify")
+	}
+}
+
+func TestMetadataCache_SkipsLive(t *testing.T) {
+	c := NewMetadataCache("", 0, 0)
+	url := "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+
+	c.Put(url, &core.VideoMetadata{ID: "dQw4w9WgXcQ", LiveStatus: core.LiveStatusLive})
+	if _, ok := c.Get(url); ok {
+		t.Error("Get() hit for live stream metadata")
+	}
+}
+
+func TestMetadataCache_TTL(t *testing.T) {
+	dir := t.TempDir()
+	c := NewMetadataCache(dir, 0, time.Hour)
+	now := time.Unix(1_000_000, 0)
+	c.now = func() time.Time { return now }
+	url := "https://vimeo.com/123"
+
+	c.Put(url, &core.VideoMetadata{ID: "123"})
+	now = now.Add(59 * time.Minute)
+	if _, ok := c.Get(url); !ok {
+		t.Fatal("Get() missed before TTL")
+	}
+	now = now.Add(time.Minute)
+	if _, ok := c.Get(url); ok {
+		t.Fatal("Get() hit after TTL")
+	}
+
+	fresh := NewMetadataCache(dir, 0, time.Hour)
+	fresh.now = c.now
+	if _, ok := fresh.Get(url); ok {
+		t.Error("expired entry was not removed from disk")
+	}
+}
+
+func TestMetadataCache_LRU(t *testing.T) {
+	dir := t.TempDir()
+	c := NewMetadataCache(dir, 2, 0)
+
+	c.Put("https://vimeo.com/1", &core.VideoMetadata{ID: "1"})
+	c.Put("https://vimeo.com/2", &core.VideoMetadata{ID: "2"})
+	c.Get("https://vimeo.com/1")
+	c.Put("https://vimeo.com/3", &core.VideoMetadata{ID: "3"})
+
+	if c.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", c.Len())
+	}
+	if _, ok := c.entries["https://vimeo.com/2"]; ok {
+		t.Error("least recently used entry was not evicted from memory")
+	}
+
+	// Evicted entries are still served from disk.
+	if meta, ok := c.Get("https://vimeo.com/2"); !ok || meta.ID != "2" {
+		t.Errorf("Get() after eviction = %+v, %v, want disk hit", meta, ok)
+	}
+}
+
+func TestMetadataCache_Persist(t *testing.T) {
+	dir := t.TempDir()
+	url := "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+
+	NewMetadataCache(dir, 0, 0).Put(url, &core.VideoMetadata{ID: "dQw4w9WgXcQ", Title: "Test"})
+
+	c := NewMetadataCache(dir, 0, 0)
+	if meta, ok := c.Get(url); !ok || meta.Title != "Test" {
+		t.Fatalf("Get() from disk = %+v, %v", meta, ok)
+	}
+
+	c.Invalidate(url)
+	if _, ok := NewMetadataCache(dir, 0, 0).Get(url); ok {
+		t.Error("Invalidate() left the entry on disk")
+	}
+
+	c.Put(url, &core.VideoMetadata{ID: "dQw4w9WgXcQ"})
+	if err := c.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if _, ok := NewMetadataCache(dir, 0, 0).Get(url); ok || c.Len() != 0 {
+		t.Error("Clear() left entries behind")
+	}
+}
+
+func TestMetadataCache_Sweep(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Unix(1_000_000, 0)
+	clock := func() time.Time { return now }
+
+	old := NewMetadataCache(dir, 0, time.Hour)
+	old.now = clock
+	old.Put("https://vimeo.com/old", &core.VideoMetadata{ID: "old"})
+
+	// A new session sweeps the entry that expired meanwhile from disk,
+	// although it is never read again.
+	now = now.Add(2 * time.Hour)
+	c := NewMetadataCache(dir, 2, time.Hour)
+	c.now = clock
+	c.maxFiles = 10
+	c.Put("https://vimeo.com/0", &core.VideoMetadata{ID: "0"})
+	if n := countFiles(t, dir); n != 1 {
+		t.Fatalf("%d files on disk, want the expired one removed", n)
+	}
+
+	for i := 1; i <= 10; i++ {
+		now = now.Add(time.Second)
+		c.Put(fmt.Sprintf("https://vimeo.com/%d", i), &core.VideoMetadata{ID: strconv.Itoa(i)})
+	}
+	if n := countFiles(t, dir); n != 9 {
+		t.Fatalf("%d files on disk, want 9 after trimming past the limit", n)
+	}
+	if _, ok := NewMetadataCache(dir, 0, time.Hour).Get("https://vimeo.com/1"); ok {
+		t.Error("the oldest file survived the sweep")
+	}
+	if _, ok := c.Get("https://vimeo.com/10"); !ok {
+		t.Error("the newest entry was swept")
+	}
+}
+
+func countFiles(t *testing.T, dir string) int {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
+
+func TestDelegatingDownloader_FetchMetadataCached(t *testing.T) {
+	cache := NewMetadataCache("", 0, 0)
+	url := "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	cache.Put(url, &core.VideoMetadata{ID: "dQw4w9WgXcQ", Title: "Cached"})
+
+	// The builtin backend has no client, so only a cache hit can succeed.
+	d := NewDelegatingDownloader(&Downloader{}, nil, func() (*core.Settings, error) {
+		return &core.Settings{DownloadBackend: core.BackendBuiltin}, nil
+	})
+	d.SetMetadataCache(cache)
+
+	meta, err := d.FetchMetadata(context.Background(), url)
+	if err != nil || meta.Title != "Cached" {
+		t.Fatalf("FetchMetadata() = %+v, %v, want cache hit", meta, err)
+	}
+
+	d.InvalidateMetadata(url)
+	if _, ok := cache.Get(url); ok {
+		t.Error("InvalidateMetadata() kept the entry")
+	}
+}
@@ -13,3 +13,9 @@ func setupProcessGroup(cmd *exec.Cmd) {
 		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
 	}
 }
+
+// interruptProcess asks the process group to finish like Ctrl+C would, which
+// makes yt-dlp and ffmpeg finalize a live recording instead of discarding it.
+func interruptProcess(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGINT)
+}
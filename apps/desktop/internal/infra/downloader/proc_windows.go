@@ -9,3 +9,10 @@ func setupProcessGroup(cmd *exec.Cmd) {
 		return cmd.Process.Kill()
 	}
 }
+
+// interruptProcess stops the process. Windows has no SIGINT for child
+// processes, so it is killed; live recordings are written as MPEG-TS, which
+// stays playable up to the point where it was cut.
+func interruptProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
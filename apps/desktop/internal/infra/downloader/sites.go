@@ -0,0 +1,54 @@
+package downloader
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"ybdownloader/internal/core"
+)
+
+// SiteYouTube is the site name reported for YouTube URLs, matching
+// yt-dlp's extractor name.
+const SiteYouTube = "youtube"
+
+// URLInfo is the result of classifying a URL with ClassifyURL.
+type URLInfo struct {
+	YouTube bool   `json:"youtube"`
+	Site    string `json:"site"` // "youtube", or the allow-list entry that matched
+}
+
+// ClassifyURL decides whether a URL can be queued. YouTube video URLs are
+// always accepted. Other http(s) URLs are accepted when their host matches an
+// allow-list entry (the domain itself or any subdomain); "*" accepts any host.
+// Non-YouTube URLs can only be handled by the yt-dlp backend.
+func ClassifyURL(rawURL string, allowedSites []string) (URLInfo, error) {
+	if _, err := ExtractVideoID(rawURL); err == nil {
+		return URLInfo{YouTube: true, Site: SiteYouTube}, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return URLInfo{}, core.ErrInvalidURL
+	}
+
+	host := strings.ToLower(strings.TrimPrefix(u.Hostname(), "www."))
+	for _, entry := range allowedSites {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "*" {
+			return URLInfo{Site: host}, nil
+		}
+		entry = strings.TrimPrefix(entry, "www.")
+		if entry != "" && (host == entry || strings.HasSuffix(host, "."+entry)) {
+			return URLInfo{Site: entry}, nil
+		}
+	}
+
+	return URLInfo{}, fmt.Errorf("%w: %s is not in the allowed sites list", core.ErrUnsupportedSite, host)
+}
+
+// IsYouTubeURL reports whether the URL is a YouTube video URL.
+func IsYouTubeURL(rawURL string) bool {
+	_, err := ExtractVideoID(rawURL)
+	return err == nil
+}
@@ -0,0 +1,51 @@
+package downloader
+
+import (
+	"errors"
+	"testing"
+
+	"ybdownloader/internal/core"
+)
+
+func TestClassifyURL(t *testing.T) {
+	allowed := []string{"vimeo.com", "soundcloud.com", "www.bandcamp.com"}
+
+	tests := []struct {
+		name        string
+		url         string
+		allowed     []string
+		wantYouTube bool
+		wantSite    string
+		wantErr     error
+	}{
+		{"youtube watch", "https://www.youtube.com/watch?v=dQw4w9WgXcQ", nil, true, SiteYouTube, nil},
+		{"youtube short link", "https://youtu.be/dQw4w9WgXcQ", nil, true, SiteYouTube, nil},
+		{"allowed domain", "https://vimeo.com/76979871", allowed, false, "vimeo.com", nil},
+		{"allowed www host", "https://www.vimeo.com/76979871", allowed, false, "vimeo.com", nil},
+		{"allowed subdomain", "https://artist.bandcamp.com/track/song", allowed, false, "bandcamp.com", nil},
+		{"wildcard", "https://example.org/video/1", []string{"*"}, false, "example.org", nil},
+		{"not allowed", "https://example.org/video/1", allowed, false, "", core.ErrUnsupportedSite},
+		{"suffix is not a subdomain", "https://notvimeo.com/1", allowed, false, "", core.ErrUnsupportedSite},
+		{"youtube channel is not a video", "https://www.youtube.com/@channel", allowed, false, "", core.ErrUnsupportedSite},
+		{"not a URL", "not-a-url", allowed, false, "", core.ErrInvalidURL},
+		{"unsupported scheme", "ftp://vimeo.com/1", allowed, false, "", core.ErrInvalidURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ClassifyURL(tt.url, tt.allowed)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ClassifyURL() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ClassifyURL() error = %v", err)
+			}
+			if info.YouTube != tt.wantYouTube || info.Site != tt.wantSite {
+				t.Errorf("ClassifyURL() = %+v, want youtube=%v site=%q", info, tt.wantYouTube, tt.wantSite)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package downloader
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"ybdownloader/internal/core"
+)
+
+// Tools that can be installed from a local file.
+const (
+	ToolFFmpeg = "ffmpeg"
+	ToolYtDlp  = "yt-dlp"
+	ToolDeno   = "deno"
+)
+
+// ToolInstallResult describes a tool installed from a local file.
+type ToolInstallResult struct {
+	Tool    string   `json:"tool"`
+	Version string   `json:"version"`
+	Paths   []string `json:"paths"` // Installed binaries; an FFmpeg archive may also provide ffprobe
+}
+
+// mirrorURL routes a tool download through mirror, which serves upstream
+// files under /<host>/<path>, e.g. https://mirror.example/github.com/...
+func mirrorURL(mirror, raw string) string {
+	if mirror == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	mirrored := strings.TrimRight(mirror, "/") + "/" + u.Host + u.EscapedPath()
+	if u.RawQuery != "" {
+		mirrored += "?" + u.RawQuery
+	}
+	return mirrored
+}
+
+// mirroredURL applies the configured tool mirror, if any, to raw.
+func mirroredURL(getSettings func() (*core.Settings, error), raw string) string {
+	if settings, err := getSettings(); err == nil {
+		return mirrorURL(settings.ToolMirrorURL, raw)
+	}
+	return raw
+}
+
+// extractBinary copies the first regular file named one of names out of an
+// archive to destPath. Zip and tar (plain, gzip or xz) archives are
+// recognized by extension; any other file is taken to be the binary itself.
+func extractBinary(archivePath string, names []string, destPath string) error {
+	lower := strings.ToLower(archivePath)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return extractFromZip(archivePath, names, destPath)
+	case strings.HasSuffix(lower, ".tar"), strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return extractFromTar(archivePath, names, destPath)
+	case strings.HasSuffix(lower, ".tar.xz"), strings.HasSuffix(lower, ".txz"):
+		return extractFromTarXz(archivePath, names, destPath)
+	default:
+		src, err := os.Open(archivePath) //nolint:gosec // path chosen by the user
+		if err != nil {
+			return err
+		}
+		defer src.Close() //nolint:errcheck
+		return writeBinary(src, destPath)
+	}
+}
+
+func extractFromTar(archivePath string, names []string, destPath string) error {
+	f, err := os.Open(archivePath) //nolint:gosec // path chosen by the user
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck
+
+	var r io.Reader = bufio.NewReader(f)
+	if lower := strings.ToLower(archivePath); strings.HasSuffix(lower, ".gz") || strings.HasSuffix(lower, ".tgz") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close() //nolint:errcheck
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag == tar.TypeReg && slices.Contains(names, filepath.Base(hdr.Name)) {
+			return writeBinary(tr, destPath)
+		}
+	}
+	return fmt.Errorf("%s not found in archive", strings.Join(names, " or "))
+}
+
+// extractFromTarXz unpacks with the system tar, since the standard library
+// has no xz reader. GNU tar, bsdtar and Windows' tar.exe all understand -J.
+func extractFromTarXz(archivePath string, names []string, destPath string) error {
+	tmp, err := os.MkdirTemp(filepath.Dir(destPath), ".unpack-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp) //nolint:errcheck
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, "tar", "-xJf", archivePath, "-C", tmp).CombinedOutput(); err != nil { //nolint:gosec
+		return fmt.Errorf("failed to unpack %s: %w: %s", filepath.Base(archivePath), err, strings.TrimSpace(string(out)))
+	}
+
+	var found string
+	err = filepath.WalkDir(tmp, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() && slices.Contains(names, d.Name()) {
+			found = p
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if found == "" {
+		return fmt.Errorf("%s not found in archive", strings.Join(names, " or "))
+	}
+	return os.Rename(found, destPath)
+}
+
+func writeBinary(src io.Reader, destPath string) error {
+	//nolint:gosec // G302: executable needs 0755
+	out, err := os.OpenFile(destPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil { //nolint:gosec // G110: size bounded by the archive the user picked
+		_ = out.Close() //nolint:errcheck
+		return err
+	}
+	return out.Close()
+}
+
+// toolVersion runs a staged binary and returns the first line it prints
+// for versionArg, which proves it runs on this machine.
+func toolVersion(ctx context.Context, binPath, versionArg string) (string, error) {
+	if runtime.GOOS != "windows" {
+		//nolint:gosec // G302: executable needs 0755
+		if err := os.Chmod(binPath, 0755); err != nil {
+			return "", err
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, binPath, versionArg).Output() //nolint:gosec
+	if err != nil {
+		return "", fmt.Errorf("%s does not run on this machine: %w", filepath.Base(binPath), err)
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(line), nil
+}
+
+// installFromArchive extracts one of names from archivePath into a staging
+// directory under binDir, runs it with versionArg and hands the first output
+// line to parseVersion. Only a binary that runs and reports a version is
+// moved to dest(version); its hash is recorded as unverified.
+func installFromArchive(
+	ctx context.Context,
+	archivePath string,
+	names []string,
+	versionArg string,
+	parseVersion func(line string) (string, error),
+	binDir string,
+	dest func(version string) string,
+) (installed, version string, err error) {
+	if err := os.MkdirAll(binDir, 0o755); err != nil {
+		return "", "", fmt.Errorf("failed to create bundled dir: %w", err)
+	}
+	stagingDir, err := os.MkdirTemp(binDir, ".install-*")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.RemoveAll(stagingDir) //nolint:errcheck
+
+	staged := filepath.Join(stagingDir, names[0])
+	if err := extractBinary(archivePath, names, staged); err != nil {
+		return "", "", err
+	}
+	line, err := toolVersion(ctx, staged, versionArg)
+	if err != nil {
+		return "", "", err
+	}
+	if version, err = parseVersion(line); err != nil {
+		return "", "", err
+	}
+
+	installed = dest(version)
+	if err := os.MkdirAll(filepath.Dir(installed), 0o755); err != nil {
+		return "", "", err
+	}
+	if err := os.Rename(staged, installed); err != nil {
+		return "", "", fmt.Errorf("failed to install %s: %w", filepath.Base(installed), err)
+	}
+	if hash, err := sha256File(installed); err == nil {
+		recordChecksum(binDir, installed, hash, "file://"+filepath.ToSlash(archivePath), false)
+	}
+	return installed, version, nil
+}
+
+// versionAfter returns the field following prefix in a version line such as
+// "ffmpeg version 6.1.1 Copyright ..." or "deno 1.46.3 (stable, ...)".
+func versionAfter(prefix string) func(string) (string, error) {
+	return func(line string) (string, error) {
+		rest, ok := strings.CutPrefix(line, prefix+" ")
+		if fields := strings.Fields(rest); ok && len(fields) > 0 {
+			return fields[0], nil
+		}
+		return "", fmt.Errorf("not a %s binary: %q", strings.Fields(prefix)[0], line)
+	}
+}
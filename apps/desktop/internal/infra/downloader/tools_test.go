@@ -0,0 +1,228 @@
+package downloader
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"ybdownloader/internal/core"
+)
+
+func TestMirrorURL(t *testing.T) {
+	tests := []struct {
+		mirror, raw, want string
+	}{
+		{"", "https://github.com/yt-dlp/yt-dlp/releases", "https://github.com/yt-dlp/yt-dlp/releases"},
+		{"https://mirror.example.com/tools/", "https://github.com/yt-dlp/yt-dlp/releases", "https://mirror.example.com/tools/github.com/yt-dlp/yt-dlp/releases"},
+		{"https://mirror.example.com", "https://ffbinaries.com/api/v1/version/latest?x=1", "https://mirror.example.com/ffbinaries.com/api/v1/version/latest?x=1"},
+		{"https://mirror.example.com", "not a url", "not a url"},
+	}
+	for _, tt := range tests {
+		if got := mirrorURL(tt.mirror, tt.raw); got != tt.want {
+			t.Errorf("mirrorURL(%q, %q) = %q, want %q", tt.mirror, tt.raw, got, tt.want)
+		}
+	}
+}
+
+// writeTestArchive packs files into dir/name, choosing the format from the
+// extension the same way extractBinary does.
+func writeTestArchive(t *testing.T, dir, name string, files map[string]string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		zw := zip.NewWriter(&buf)
+		for n, body := range files {
+			w, err := zw.Create(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _ = w.Write([]byte(body))
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tar"), strings.HasSuffix(name, ".tar.xz"):
+		var tarBuf bytes.Buffer
+		tw := tar.NewWriter(&tarBuf)
+		for n, body := range files {
+			hdr := &tar.Header{Name: n, Mode: 0o755, Size: int64(len(body)), Typeflag: tar.TypeReg}
+			if strings.HasSuffix(n, "/") {
+				hdr = &tar.Header{Name: n, Mode: 0o755, Typeflag: tar.TypeDir}
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				t.Fatal(err)
+			}
+			_, _ = tw.Write([]byte(body))
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case strings.HasSuffix(name, ".gz"):
+			gz := gzip.NewWriter(&buf)
+			_, _ = gz.Write(tarBuf.Bytes())
+			_ = gz.Close()
+		case strings.HasSuffix(name, ".xz"):
+			cmd := exec.Command("xz", "-c")
+			cmd.Stdin = &tarBuf
+			out, err := cmd.Output()
+			if err != nil {
+				t.Skipf("xz unavailable: %v", err)
+			}
+			buf.Write(out)
+		default:
+			buf = tarBuf
+		}
+	default:
+		buf.WriteString(files[name])
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractBinary(t *testing.T) {
+	files := map[string]string{
+		"ffmpeg-6.1-amd64-static/":        "",
+		"ffmpeg-6.1-amd64-static/readme":  "docs",
+		"ffmpeg-6.1-amd64-static/ffmpeg":  "ffmpeg binary",
+		"ffmpeg-6.1-amd64-static/ffprobe": "ffprobe binary",
+	}
+	for _, name := range []string{"ffmpeg.zip", "ffmpeg.tar", "ffmpeg.tar.gz", "ffmpeg.tar.xz"} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			archive := writeTestArchive(t, dir, name, files)
+			dest := filepath.Join(dir, "out")
+			if err := extractBinary(archive, []string{"ffprobe"}, dest); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := os.ReadFile(dest); string(got) != "ffprobe binary" {
+				t.Errorf("extracted %q, want ffprobe", got)
+			}
+			if err := extractBinary(archive, []string{"deno"}, dest); err == nil {
+				t.Error("extractBinary() found a binary that is not in the archive")
+			}
+		})
+	}
+
+	t.Run("raw binary", func(t *testing.T) {
+		dir := t.TempDir()
+		src := writeTestArchive(t, dir, "yt-dlp_linux", map[string]string{"yt-dlp_linux": "yt-dlp binary"})
+		dest := filepath.Join(dir, "out")
+		if err := extractBinary(src, []string{"yt-dlp"}, dest); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := os.ReadFile(dest); string(got) != "yt-dlp binary" {
+			t.Errorf("copied %q", got)
+		}
+	})
+}
+
+func TestVersionAfter(t *testing.T) {
+	parse := versionAfter("ffmpeg version")
+	if v, err := parse("ffmpeg version 6.1.1-static https://johnvansickle.com Copyright"); err != nil || v != "6.1.1-static" {
+		t.Errorf("parse() = %q, %v", v, err)
+	}
+	if _, err := parse("ffprobe version 6.1.1"); err == nil {
+		t.Error("parse() accepted ffprobe output as ffmpeg")
+	}
+}
+
+// fakeTool is a shell script that prints line for any arguments.
+func fakeTool(line string) string {
+	return "#!/bin/sh\necho '" + line + "'\n"
+}
+
+func TestFFmpegManager_InstallFromFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as fake binaries")
+	}
+	fs := newTestFS()
+	fs.configDir = t.TempDir()
+	m := NewFFmpegManager(diskFS{fs}, func() (*core.Settings, error) { return &core.Settings{}, nil }, nil)
+
+	archive := writeTestArchive(t, t.TempDir(), "ffmpeg.tar.gz", map[string]string{
+		"ffmpeg-6.1/ffmpeg":  fakeTool("ffmpeg version 6.1.1 Copyright (c) 2000-2023"),
+		"ffmpeg-6.1/ffprobe": fakeTool("ffprobe version 6.1.1 Copyright (c) 2007-2023"),
+	})
+	result, err := m.InstallFromFile(context.Background(), archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Version != "6.1.1" || len(result.Paths) != 2 {
+		t.Errorf("InstallFromFile() = %+v, want ffmpeg and ffprobe 6.1.1", result)
+	}
+	if path, err := m.GetFFprobePath(); err != nil || path != m.getBundledBinaryPath("ffprobe") {
+		t.Errorf("GetFFprobePath() = %q, %v, want bundled", path, err)
+	}
+	results, _ := VerifyInstalledBinaries(m.getBundledDir())
+	if len(results) != 2 || !results[0].OK {
+		t.Errorf("VerifyInstalledBinaries() = %+v, want both recorded", results)
+	}
+
+	// A binary that is not FFmpeg is rejected and nothing is replaced.
+	bogus := writeTestArchive(t, t.TempDir(), "ffmpeg", map[string]string{"ffmpeg": fakeTool("hello")})
+	if _, err := m.InstallFromFile(context.Background(), bogus); err == nil {
+		t.Error("InstallFromFile() accepted a binary that is not FFmpeg")
+	}
+	if got, _ := os.ReadFile(m.getBundledBinaryPath("ffmpeg")); !strings.Contains(string(got), "6.1.1") {
+		t.Error("rejected binary replaced the installed FFmpeg")
+	}
+	if staging, _ := filepath.Glob(filepath.Join(m.getBundledDir(), ".install-*")); len(staging) != 0 {
+		t.Errorf("staging dirs left behind: %v", staging)
+	}
+}
+
+func TestYtDlpManager_InstallFromFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as fake binaries")
+	}
+	m, source := newVersionTestManager(t, "2024.10.22", &core.Settings{})
+
+	bin := writeTestArchive(t, t.TempDir(), "yt-dlp", map[string]string{"yt-dlp": fakeTool("2024.08.06")})
+	result, err := m.InstallFromFile(context.Background(), bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Version != "2024.08.06" || m.currentVersion() != "2024.08.06" {
+		t.Errorf("InstallFromFile() = %+v, current %q", result, m.currentVersion())
+	}
+	if path, _ := m.GetYtDlpPath(); path != m.versionBinaryPath("2024.08.06") {
+		t.Errorf("GetYtDlpPath() = %q, want managed binary", path)
+	}
+	if source.downloads != 0 {
+		t.Errorf("downloads = %d, want offline install", source.downloads)
+	}
+
+	deno := writeTestArchive(t, t.TempDir(), "deno.zip", map[string]string{"deno": fakeTool("deno 1.46.3 (stable, release, x86_64-unknown-linux-gnu)")})
+	result, err = m.InstallDenoFromFile(context.Background(), deno)
+	if err != nil || result.Version != "1.46.3" {
+		t.Fatalf("InstallDenoFromFile() = %+v, %v", result, err)
+	}
+	if name, path := m.GetJSRuntimePath(); name != "deno" || path != result.Paths[0] {
+		t.Errorf("GetJSRuntimePath() = %q, %q, want bundled deno", name, path)
+	}
+}
+
+func TestYtDlpManager_ReleaseSourceMirror(t *testing.T) {
+	settings := &core.Settings{ToolMirrorURL: "https://mirror.example.com"}
+	m := NewYtDlpManager(newTestFS(), func() (*core.Settings, error) { return settings, nil })
+	if got := m.releaseSource(); got != "https://mirror.example.com/github.com/yt-dlp/yt-dlp/releases" {
+		t.Errorf("releaseSource() = %q, want mirrored", got)
+	}
+	settings.YtDlpReleaseURL = "https://releases.example.com/yt-dlp"
+	if got := m.releaseSource(); got != settings.YtDlpReleaseURL {
+		t.Errorf("releaseSource() = %q, want explicit release URL", got)
+	}
+}
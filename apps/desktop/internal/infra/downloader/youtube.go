@@ -2,9 +2,15 @@ package downloader
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"os"
 	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/kkdai/youtube/v2"
@@ -23,15 +29,60 @@ var videoIDPatterns = []*regexp.Regexp{
 // YouTubeClient wraps the kkdai/youtube library for video metadata and stream fetching.
 type YouTubeClient struct {
 	client youtube.Client
+	jar    *swappableJar
+
+	cookiesMu      sync.Mutex
+	cookiesPath    string
+	cookiesModTime time.Time
 }
 
 // NewYouTubeClient creates a new YouTube client instance.
 func NewYouTubeClient() *YouTubeClient {
+	jar := &swappableJar{}
 	return &YouTubeClient{
-		client: youtube.Client{},
+		client: youtube.Client{HTTPClient: &http.Client{Jar: jar}},
+		jar:    jar,
 	}
 }
 
+// SetTransport routes all YouTube requests through rt, e.g. to apply proxy
+// settings.
+func (y *YouTubeClient) SetTransport(rt http.RoundTripper) {
+	y.client.HTTPClient.Transport = rt
+}
+
+// UseCookieFile loads cookies from a Netscape cookies.txt file into the
+// client's requests. The file is only re-read when its path or modification
+// time changes; an empty path removes any loaded cookies.
+func (y *YouTubeClient) UseCookieFile(path string) error {
+	y.cookiesMu.Lock()
+	defer y.cookiesMu.Unlock()
+
+	if path == "" {
+		y.jar.set(nil)
+		y.cookiesPath = ""
+		y.cookiesModTime = time.Time{}
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("cookies file: %w", err)
+	}
+	if path == y.cookiesPath && info.ModTime().Equal(y.cookiesModTime) {
+		return nil
+	}
+
+	jar, err := newCookieJarFromFile(path)
+	if err != nil {
+		return err
+	}
+	y.jar.set(jar)
+	y.cookiesPath = path
+	y.cookiesModTime = info.ModTime()
+	return nil
+}
+
 // ExtractVideoID extracts the 11-character video ID from a YouTube URL.
 func ExtractVideoID(url string) (string, error) {
 	for _, p := range videoIDPatterns {
@@ -54,14 +105,122 @@ func (y *YouTubeClient) FetchMetadata(ctx context.Context, url string) (*core.Vi
 		return nil, fmt.Errorf("failed to get video info: %w", err)
 	}
 
-	return &core.VideoMetadata{
+	return convertBuiltinMetadata(video), nil
+}
+
+// convertBuiltinMetadata maps a kkdai video onto core.VideoMetadata. The
+// player response has no likes, tags or chapters.
+func convertBuiltinMetadata(video *youtube.Video) *core.VideoMetadata {
+	meta := &core.VideoMetadata{
 		ID:          video.ID,
 		Title:       video.Title,
 		Author:      video.Author,
 		Duration:    video.Duration.Seconds(),
 		Thumbnail:   getBestThumbnail(video.Thumbnails),
 		Description: video.Description,
-	}, nil
+		Site:        SiteYouTube,
+		LiveStatus:  builtinLiveStatus(video),
+		ChannelID:   video.ChannelID,
+		ViewCount:   int64(video.Views),
+	}
+	if !video.PublishDate.IsZero() {
+		meta.UploadDate = video.PublishDate.Format(time.DateOnly)
+	}
+	switch {
+	case video.ChannelHandle != "":
+		meta.ChannelURL = "https://www.youtube.com/" + video.ChannelHandle
+	case video.ChannelID != "":
+		meta.ChannelURL = "https://www.youtube.com/channel/" + video.ChannelID
+	}
+
+	formats := make([]core.FormatInfo, 0, len(video.Formats))
+	for i := range video.Formats {
+		formats = append(formats, toFormatInfo(&video.Formats[i]))
+	}
+	meta.Formats = core.SummarizeFormats(formats)
+	return meta
+}
+
+// ListFormats returns every stream YouTube offers for the video.
+func (y *YouTubeClient) ListFormats(ctx context.Context, url string) ([]core.FormatInfo, error) {
+	videoID, err := ExtractVideoID(url)
+	if err != nil {
+		return nil, err
+	}
+
+	video, err := y.client.GetVideoContext(ctx, videoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video info: %w", err)
+	}
+
+	formats := make([]core.FormatInfo, 0, len(video.Formats))
+	for i := range video.Formats {
+		formats = append(formats, toFormatInfo(&video.Formats[i]))
+	}
+	return formats, nil
+}
+
+// toFormatInfo normalizes a kkdai format. Codecs come from the MIME type,
+// e.g. `video/mp4; codecs="avc1.42001E, mp4a.40.2"` for a progressive stream.
+func toFormatInfo(f *youtube.Format) core.FormatInfo {
+	info := core.FormatInfo{
+		ID:            strconv.Itoa(f.ItagNo),
+		Ext:           getDownloadExtension(f.MimeType),
+		Width:         f.Width,
+		Height:        f.Height,
+		FPS:           float64(f.FPS),
+		Bitrate:       int64(f.Bitrate),
+		FileSize:      f.ContentLength,
+		AudioChannels: f.AudioChannels,
+		HDR:           strings.Contains(f.QualityLabel, "HDR"),
+		Note:          f.QualityLabel,
+	}
+	if f.AverageBitrate > 0 {
+		info.Bitrate = int64(f.AverageBitrate)
+	}
+	if sr, err := strconv.Atoi(f.AudioSampleRate); err == nil {
+		info.SampleRate = sr
+	}
+
+	codecs := mimeCodecs(f.MimeType)
+	if strings.HasPrefix(f.MimeType, "audio/") {
+		info.HasAudio = true
+		if info.Ext == "mp4" {
+			info.Ext = "m4a"
+		}
+		if len(codecs) > 0 {
+			info.AudioCodec = codecs[0]
+		}
+		if info.Note == "" {
+			info.Note = strings.TrimPrefix(f.AudioQuality, "AUDIO_QUALITY_")
+		}
+		return info
+	}
+
+	info.HasVideo = true
+	info.HasAudio = f.AudioChannels > 0
+	if len(codecs) > 0 {
+		info.VideoCodec = codecs[0]
+	}
+	if len(codecs) > 1 {
+		info.AudioCodec = codecs[1]
+	}
+	return info
+}
+
+// mimeCodecs extracts the codecs parameter from a stream MIME type.
+func mimeCodecs(mimeType string) []string {
+	_, after, ok := strings.Cut(mimeType, "codecs=")
+	if !ok {
+		return nil
+	}
+	var codecs []string
+	for _, c := range strings.Split(strings.Trim(after, `"`), ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			codecs = append(codecs, c)
+		}
+	}
+	return codecs
 }
 
 // StreamInfo contains information about a selected stream for download.
@@ -70,10 +229,30 @@ type StreamInfo struct {
 	Video       *youtube.Video
 	ContentSize int64
 	IsAudioOnly bool
+	// AudioFormat is the separate adaptive audio stream to mux with Format.
+	// It is nil for progressive and audio-only downloads.
+	AudioFormat *youtube.Format
+}
+
+// NeedsMerge reports whether the video and audio come from separate streams.
+func (s *StreamInfo) NeedsMerge() bool {
+	return s.AudioFormat != nil
+}
+
+// StreamPreferences controls how SelectStream picks formats.
+type StreamPreferences struct {
+	AudioQuality core.AudioQuality
+	VideoQuality core.VideoQuality
+	VideoCodec   core.VideoCodec
+	// AllowMerge permits separate video-only and audio-only adaptive streams.
+	// Only set it when FFmpeg is available to mux them afterwards.
+	AllowMerge bool
+	// FormatID pins a specific itag and bypasses automatic selection.
+	FormatID string
 }
 
 // SelectStream chooses the best stream based on format preference.
-func (y *YouTubeClient) SelectStream(ctx context.Context, url string, format core.Format, audioQuality core.AudioQuality, videoQuality core.VideoQuality) (*StreamInfo, error) {
+func (y *YouTubeClient) SelectStream(ctx context.Context, url string, format core.Format, prefs StreamPreferences) (*StreamInfo, error) {
 	videoID, err := ExtractVideoID(url)
 	if err != nil {
 		return nil, err
@@ -84,15 +263,39 @@ func (y *YouTubeClient) SelectStream(ctx context.Context, url string, format cor
 		return nil, fmt.Errorf("failed to get video info: %w", err)
 	}
 
-	var selected *youtube.Format
+	if prefs.FormatID != "" {
+		return selectPinnedStream(video, format, prefs)
+	}
+
 	isAudioOnly := format.IsAudioOnly()
 
 	if isAudioOnly {
-		selected = selectAudioFormat(video.Formats, audioQuality)
-	} else {
-		selected = selectVideoFormat(video.Formats, videoQuality)
+		selected := selectAudioFormat(video.Formats, prefs.AudioQuality)
+		if selected == nil {
+			return nil, fmt.Errorf("no suitable format found for %s", format)
+		}
+		return &StreamInfo{
+			Format:      selected,
+			Video:       video,
+			ContentSize: selected.ContentLength,
+			IsAudioOnly: true,
+		}, nil
+	}
+
+	if prefs.AllowMerge {
+		videoFmt := selectAdaptiveVideoFormat(video.Formats, prefs.VideoQuality, prefs.VideoCodec, format)
+		audioFmt := selectAdaptiveAudioFormat(video.Formats, prefs.AudioQuality, format)
+		if videoFmt != nil && audioFmt != nil && videoFmt.Height > progressiveHeight(video.Formats, prefs.VideoQuality) {
+			return &StreamInfo{
+				Format:      videoFmt,
+				Video:       video,
+				ContentSize: videoFmt.ContentLength + audioFmt.ContentLength,
+				AudioFormat: audioFmt,
+			}, nil
+		}
 	}
 
+	selected := selectVideoFormat(video.Formats, prefs.VideoQuality)
 	if selected == nil {
 		return nil, fmt.Errorf("no suitable format found for %s", format)
 	}
@@ -101,10 +304,46 @@ func (y *YouTubeClient) SelectStream(ctx context.Context, url string, format cor
 		Format:      selected,
 		Video:       video,
 		ContentSize: selected.ContentLength,
-		IsAudioOnly: isAudioOnly,
 	}, nil
 }
 
+// selectPinnedStream uses the itag pinned on the queue item. A video-only
+// stream is paired with the best matching audio stream when merging is
+// allowed and the requested output keeps video.
+func selectPinnedStream(video *youtube.Video, format core.Format, prefs StreamPreferences) (*StreamInfo, error) {
+	itag, err := strconv.Atoi(prefs.FormatID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format ID %q: %w", prefs.FormatID, core.ErrInvalidFormat)
+	}
+
+	var selected *youtube.Format
+	for i := range video.Formats {
+		if video.Formats[i].ItagNo == itag {
+			selected = &video.Formats[i]
+			break
+		}
+	}
+	if selected == nil {
+		return nil, fmt.Errorf("format %s is not available for this video: %w", prefs.FormatID, core.ErrInvalidFormat)
+	}
+
+	info := &StreamInfo{
+		Format:      selected,
+		Video:       video,
+		ContentSize: selected.ContentLength,
+		IsAudioOnly: strings.HasPrefix(selected.MimeType, "audio/"),
+	}
+
+	if !info.IsAudioOnly && selected.AudioChannels == 0 && !format.IsAudioOnly() && prefs.AllowMerge {
+		if audioFmt := selectAdaptiveAudioFormat(video.Formats, prefs.AudioQuality, format); audioFmt != nil {
+			info.AudioFormat = audioFmt
+			info.ContentSize += audioFmt.ContentLength
+		}
+	}
+
+	return info, nil
+}
+
 // GetStream returns a reader for the video/audio stream.
 func (y *YouTubeClient) GetStream(ctx context.Context, video *youtube.Video, format *youtube.Format) (io.ReadCloser, int64, error) {
 	stream, size, err := y.client.GetStreamContext(ctx, video, format)
@@ -114,6 +353,16 @@ func (y *YouTubeClient) GetStream(ctx context.Context, video *youtube.Video, for
 	return stream, size, nil
 }
 
+// GetStreamURL returns the direct URL of a stream for tools that read it
+// themselves, such as FFmpeg when seeking to a time range.
+func (y *YouTubeClient) GetStreamURL(ctx context.Context, video *youtube.Video, format *youtube.Format) (string, error) {
+	streamURL, err := y.client.GetStreamURLContext(ctx, video, format)
+	if err != nil {
+		return "", fmt.Errorf("failed to get stream URL: %w", err)
+	}
+	return streamURL, nil
+}
+
 func selectAudioFormat(formats youtube.FormatList, quality core.AudioQuality) *youtube.Format {
 	// Filter to audio-only formats
 	audioFormats := formats.Type("audio")
@@ -162,6 +411,115 @@ func selectVideoFormat(formats youtube.FormatList, quality core.VideoQuality) *y
 	return best
 }
 
+// selectAdaptiveVideoFormat picks the best video-only stream for a DASH
+// download. Streams at or below the requested height win over taller ones,
+// then the preferred codec, then frame rate and bitrate break ties.
+func selectAdaptiveVideoFormat(formats youtube.FormatList, quality core.VideoQuality, codec core.VideoCodec, container core.Format) *youtube.Format {
+	targetHeight := qualityToHeight(quality)
+
+	var best *youtube.Format
+	for i := range formats {
+		f := &formats[i]
+		if !strings.HasPrefix(f.MimeType, "video/") || f.AudioChannels > 0 || f.Height == 0 {
+			continue
+		}
+		fc := videoCodecFromMime(f.MimeType)
+		if fc == "" || !videoCodecFitsContainer(fc, container) {
+			continue
+		}
+		if best == nil || betterAdaptiveVideo(f, best, targetHeight, codec) {
+			best = f
+		}
+	}
+
+	return best
+}
+
+func betterAdaptiveVideo(a, b *youtube.Format, targetHeight int, codec core.VideoCodec) bool {
+	aFits, bFits := a.Height <= targetHeight, b.Height <= targetHeight
+	if aFits != bFits {
+		return aFits
+	}
+	if a.Height != b.Height {
+		if aFits {
+			return a.Height > b.Height
+		}
+		return a.Height < b.Height
+	}
+
+	aCodec, bCodec := videoCodecFromMime(a.MimeType) == codec, videoCodecFromMime(b.MimeType) == codec
+	if aCodec != bCodec {
+		return aCodec
+	}
+	if a.FPS != b.FPS {
+		return a.FPS > b.FPS
+	}
+	return a.AverageBitrate > b.AverageBitrate
+}
+
+// selectAdaptiveAudioFormat picks the audio-only stream to mux with a DASH
+// video stream, preferring one that can be copied into the output container.
+func selectAdaptiveAudioFormat(formats youtube.FormatList, quality core.AudioQuality, container core.Format) *youtube.Format {
+	var compatible youtube.FormatList
+	for _, f := range formats.Type("audio") {
+		if audioFitsContainer(f.MimeType, container) {
+			compatible = append(compatible, f)
+		}
+	}
+	if len(compatible) > 0 {
+		return selectAudioFormat(compatible, quality)
+	}
+	return selectAudioFormat(formats, quality)
+}
+
+// progressiveHeight returns the height of the progressive stream that
+// selectVideoFormat would choose, or 0 if there is none.
+func progressiveHeight(formats youtube.FormatList, quality core.VideoQuality) int {
+	var progressive youtube.FormatList
+	for _, f := range formats {
+		if strings.HasPrefix(f.MimeType, "video/") && f.AudioChannels > 0 {
+			progressive = append(progressive, f)
+		}
+	}
+	if f := selectVideoFormat(progressive, quality); f != nil && f.Height <= qualityToHeight(quality) {
+		return f.Height
+	}
+	return 0
+}
+
+// videoCodecFromMime maps a stream MIME type such as
+// `video/mp4; codecs="avc1.640028"` to a core.VideoCodec.
+func videoCodecFromMime(mimeType string) core.VideoCodec {
+	switch {
+	case strings.Contains(mimeType, "avc1"):
+		return core.VideoCodecH264
+	case strings.Contains(mimeType, "vp9"), strings.Contains(mimeType, "vp09"):
+		return core.VideoCodecVP9
+	case strings.Contains(mimeType, "av01"):
+		return core.VideoCodecAV1
+	default:
+		return ""
+	}
+}
+
+// videoCodecFitsContainer reports whether a video stream can be stream-copied
+// into the output container. WebM does not allow H.264.
+func videoCodecFitsContainer(codec core.VideoCodec, container core.Format) bool {
+	if container == core.FormatWebM {
+		return codec == core.VideoCodecVP9 || codec == core.VideoCodecAV1
+	}
+	return true
+}
+
+// audioFitsContainer reports whether an audio stream can be stream-copied
+// into the output container: AAC for MP4, Opus/Vorbis for WebM.
+func audioFitsContainer(mimeType string, container core.Format) bool {
+	if container == core.FormatWebM {
+		return strings.HasPrefix(mimeType, "audio/webm")
+	}
+	return strings.HasPrefix(mimeType, "audio/mp4")
+}
+
 func scoreVideoFormat(f *youtube.Format, targetHeight int) int {
 	score := 0
 
@@ -238,3 +596,22 @@ func getBestThumbnail(thumbs youtube.Thumbnails) string {
 func FormatDuration(d time.Duration) int64 {
 	return int64(d.Seconds())
 }
+
+// builtinLiveStatus reports streams that are currently live; YouTube only
+// serves an HLS manifest for those.
+func builtinLiveStatus(video *youtube.Video) core.LiveStatus {
+	if video.HLSManifestURL != "" {
+		return core.LiveStatusLive
+	}
+	return core.LiveStatusNone
+}
+
+// classifyBuiltinLiveError returns a LIVE_NOT_STARTED error for upcoming
+// streams, which the kkdai client reports as LIVE_STREAM_OFFLINE, or nil.
+func classifyBuiltinLiveError(err error) error {
+	var status *youtube.ErrPlayabiltyStatus
+	if errors.As(err, &status) && status.Status == "LIVE_STREAM_OFFLINE" {
+		return newLiveNotStartedError(err)
+	}
+	return nil
+}
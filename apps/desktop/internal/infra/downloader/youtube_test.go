@@ -1,6 +1,7 @@
 package downloader
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -346,3 +347,291 @@ func TestSelectVideoFormat_EmptyList(t *testing.T) {
 		t.Error("selectVideoFormat() should return nil for empty list")
 	}
 }
+
+func TestSelectAdaptiveVideoFormat(t *testing.T) {
+	formats := youtube.FormatList{
+		{ItagNo: 18, MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`, Height: 360, AudioChannels: 2},
+		{ItagNo: 136, MimeType: `video/mp4; codecs="avc1.4d401f"`, Height: 720, FPS: 30, AverageBitrate: 1500000},
+		{ItagNo: 247, MimeType: `video/webm; codecs="vp9"`, Height: 720, FPS: 30, AverageBitrate: 1200000},
+		{ItagNo: 137, MimeType: `video/mp4; codecs="avc1.640028"`, Height: 1080, FPS: 30, AverageBitrate: 4000000},
+		{ItagNo: 248, MimeType: `video/webm; codecs="vp9"`, Height: 1080, FPS: 30, AverageBitrate: 3000000},
+		{ItagNo: 399, MimeType: `video/mp4; codecs="av01.0.08M.08"`, Height: 1080, FPS: 30, AverageBitrate: 2500000},
+		{ItagNo: 299, MimeType: `video/mp4; codecs="avc1.64002a"`, Height: 1080, FPS: 60, AverageBitrate: 6000000},
+		{ItagNo: 271, MimeType: `video/webm; codecs="vp9"`, Height: 1440, FPS: 30, AverageBitrate: 9000000},
+		{ItagNo: 140, MimeType: `audio/mp4; codecs="mp4a.40.2"`, AudioChannels: 2},
+	}
+
+	tests := []struct {
+		name      string
+		quality   core.VideoQuality
+		codec     core.VideoCodec
+		container core.Format
+		wantItag  int
+	}{
+		{"1080p h264 prefers 60fps", core.VideoQuality1080p, core.VideoCodecH264, core.FormatMP4, 299},
+		{"1080p vp9", core.VideoQuality1080p, core.VideoCodecVP9, core.FormatMP4, 248},
+		{"1080p av1", core.VideoQuality1080p, core.VideoCodecAV1, core.FormatMP4, 399},
+		{"720p h264", core.VideoQuality720p, core.VideoCodecH264, core.FormatMP4, 136},
+		{"webm excludes h264", core.VideoQuality720p, core.VideoCodecH264, core.FormatWebM, 247},
+		{"best picks tallest", core.VideoQualityBest, core.VideoCodecH264, core.FormatMP4, 271},
+		{"below smallest falls back to closest", core.VideoQuality480p, core.VideoCodecH264, core.FormatMP4, 136},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectAdaptiveVideoFormat(formats, tt.quality, tt.codec, tt.container)
+			if got == nil {
+				t.Fatal("selectAdaptiveVideoFormat() returned nil")
+			}
+			if got.ItagNo != tt.wantItag {
+				t.Errorf("selectAdaptiveVideoFormat() itag = %d, want %d", got.ItagNo, tt.wantItag)
+			}
+		})
+	}
+}
+
+func TestSelectAdaptiveVideoFormat_NoVideoOnly(t *testing.T) {
+	formats := youtube.FormatList{
+		{ItagNo: 18, MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`, Height: 360, AudioChannels: 2},
+	}
+	if got := selectAdaptiveVideoFormat(formats, core.VideoQuality1080p, core.VideoCodecH264, core.FormatMP4); got != nil {
+		t.Errorf("selectAdaptiveVideoFormat() = itag %d, want nil", got.ItagNo)
+	}
+}
+
+func TestSelectAdaptiveAudioFormat(t *testing.T) {
+	formats := youtube.FormatList{
+		{ItagNo: 140, MimeType: `audio/mp4; codecs="mp4a.40.2"`, AverageBitrate: 128000, AudioChannels: 2},
+		{ItagNo: 251, MimeType: `audio/webm; codecs="opus"`, AverageBitrate: 160000, AudioChannels: 2},
+	}
+
+	if got := selectAdaptiveAudioFormat(formats, core.AudioQuality320, core.FormatMP4); got == nil || got.ItagNo != 140 {
+		t.Errorf("MP4 container should pick AAC stream, got %+v", got)
+	}
+	if got := selectAdaptiveAudioFormat(formats, core.AudioQuality128, core.FormatWebM); got == nil || got.ItagNo != 251 {
+		t.Errorf("WebM container should pick Opus stream, got %+v", got)
+	}
+
+	opusOnly := youtube.FormatList{formats[1]}
+	if got := selectAdaptiveAudioFormat(opusOnly, core.AudioQuality192, core.FormatMP4); got == nil || got.ItagNo != 251 {
+		t.Errorf("should fall back to any audio stream, got %+v", got)
+	}
+}
+
+func TestProgressiveHeight(t *testing.T) {
+	formats := youtube.FormatList{
+		{ItagNo: 18, MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`, Height: 360, AudioChannels: 2},
+		{ItagNo: 22, MimeType: `video/mp4; codecs="avc1.64001F, mp4a.40.2"`, Height: 720, AudioChannels: 2},
+		{ItagNo: 137, MimeType: `video/mp4; codecs="avc1.640028"`, Height: 1080},
+	}
+
+	if got := progressiveHeight(formats, core.VideoQuality1080p); got != 720 {
+		t.Errorf("progressiveHeight(1080p) = %d, want 720", got)
+	}
+	if got := progressiveHeight(formats, core.VideoQuality360p); got != 360 {
+		t.Errorf("progressiveHeight(360p) = %d, want 360", got)
+	}
+	if got := progressiveHeight(formats[2:], core.VideoQuality720p); got != 0 {
+		t.Errorf("progressiveHeight() without progressive streams = %d, want 0", got)
+	}
+}
+
+func TestVideoCodecFromMime(t *testing.T) {
+	tests := []struct {
+		mime string
+		want core.VideoCodec
+	}{
+		{`video/mp4; codecs="avc1.640028"`, core.VideoCodecH264},
+		{`video/webm; codecs="vp9"`, core.VideoCodecVP9},
+		{`video/mp4; codecs="vp09.00.40.08"`, core.VideoCodecVP9},
+		{`video/mp4; codecs="av01.0.08M.08"`, core.VideoCodecAV1},
+		{`video/3gpp; codecs="mp4v.20.3"`, ""},
+	}
+
+	for _, tt := range tests {
+		if got := videoCodecFromMime(tt.mime); got != tt.want {
+			t.Errorf("videoCodecFromMime(%q) = %q, want %q", tt.mime, got, tt.want)
+		}
+	}
+}
+
+func TestStreamInfo_NeedsMerge(t *testing.T) {
+	progressive := &StreamInfo{Format: &youtube.Format{ItagNo: 22}}
+	if progressive.NeedsMerge() {
+		t.Error("progressive stream should not need merge")
+	}
+
+	adaptive := &StreamInfo{Format: &youtube.Format{ItagNo: 137}, AudioFormat: &youtube.Format{ItagNo: 140}}
+	if !adaptive.NeedsMerge() {
+		t.Error("adaptive stream with separate audio should need merge")
+	}
+}
+
+func TestToFormatInfo(t *testing.T) {
+	progressive := toFormatInfo(&youtube.Format{
+		ItagNo:          18,
+		MimeType:        `video/mp4; codecs="avc1.42001E, mp4a.40.2"`,
+		QualityLabel:    "360p",
+		Width:           640,
+		Height:          360,
+		FPS:             30,
+		Bitrate:         500000,
+		AverageBitrate:  450000,
+		ContentLength:   1234,
+		AudioChannels:   2,
+		AudioSampleRate: "44100",
+	})
+	if progressive.ID != "18" || progressive.Ext != "mp4" {
+		t.Errorf("ID/Ext = %q/%q, want 18/mp4", progressive.ID, progressive.Ext)
+	}
+	if !progressive.HasVideo || !progressive.HasAudio {
+		t.Error("progressive format should have both video and audio")
+	}
+	if progressive.VideoCodec != "avc1.42001E" || progressive.AudioCodec != "mp4a.40.2" {
+		t.Errorf("codecs = %q/%q", progressive.VideoCodec, progressive.AudioCodec)
+	}
+	if progressive.Bitrate != 450000 {
+		t.Errorf("Bitrate = %d, want average bitrate 450000", progressive.Bitrate)
+	}
+	if progressive.SampleRate != 44100 || progressive.FileSize != 1234 {
+		t.Errorf("SampleRate/FileSize = %d/%d", progressive.SampleRate, progressive.FileSize)
+	}
+
+	hdr := toFormatInfo(&youtube.Format{
+		ItagNo:       337,
+		MimeType:     `video/webm; codecs="vp09.02.51.10.01.09.16.09.00"`,
+		QualityLabel: "2160p60 HDR",
+		Height:       2160,
+		FPS:          60,
+	})
+	if !hdr.HDR || hdr.HasAudio || hdr.AudioCodec != "" {
+		t.Errorf("HDR video-only format parsed incorrectly: %+v", hdr)
+	}
+
+	audio := toFormatInfo(&youtube.Format{
+		ItagNo:        140,
+		MimeType:      `audio/mp4; codecs="mp4a.40.2"`,
+		AudioChannels: 2,
+		AudioQuality:  "AUDIO_QUALITY_MEDIUM",
+	})
+	if audio.HasVideo || !audio.HasAudio {
+		t.Error("audio format should only have audio")
+	}
+	if audio.Ext != "m4a" || audio.AudioCodec != "mp4a.40.2" || audio.Note != "MEDIUM" {
+		t.Errorf("audio format parsed incorrectly: %+v", audio)
+	}
+}
+
+func TestMimeCodecs(t *testing.T) {
+	tests := []struct {
+		mime string
+		want []string
+	}{
+		{`video/mp4; codecs="avc1.42001E, mp4a.40.2"`, []string{"avc1.42001E", "mp4a.40.2"}},
+		{`audio/webm; codecs="opus"`, []string{"opus"}},
+		{"video/mp4", nil},
+	}
+
+	for _, tt := range tests {
+		got := mimeCodecs(tt.mime)
+		if len(got) != len(tt.want) {
+			t.Errorf("mimeCodecs(%q) = %v, want %v", tt.mime, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("mimeCodecs(%q)[%d] = %q, want %q", tt.mime, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestSelectPinnedStream(t *testing.T) {
+	video := &youtube.Video{
+		Formats: youtube.FormatList{
+			{ItagNo: 18, MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`, Height: 360, AudioChannels: 2, ContentLength: 100},
+			{ItagNo: 137, MimeType: `video/mp4; codecs="avc1.640028"`, Height: 1080, ContentLength: 1000},
+			{ItagNo: 140, MimeType: `audio/mp4; codecs="mp4a.40.2"`, AverageBitrate: 128000, AudioChannels: 2, ContentLength: 50},
+		},
+	}
+
+	t.Run("progressive format is used as-is", func(t *testing.T) {
+		info, err := selectPinnedStream(video, core.FormatMP4, StreamPreferences{FormatID: "18", AllowMerge: true})
+		if err != nil {
+			t.Fatalf("selectPinnedStream() error = %v", err)
+		}
+		if info.Format.ItagNo != 18 || info.NeedsMerge() {
+			t.Errorf("got itag %d, merge %v", info.Format.ItagNo, info.NeedsMerge())
+		}
+	})
+
+	t.Run("video-only format is paired with audio", func(t *testing.T) {
+		info, err := selectPinnedStream(video, core.FormatMP4, StreamPreferences{FormatID: "137", AllowMerge: true})
+		if err != nil {
+			t.Fatalf("selectPinnedStream() error = %v", err)
+		}
+		if !info.NeedsMerge() || info.AudioFormat.ItagNo != 140 {
+			t.Errorf("expected merge with itag 140, got %+v", info.AudioFormat)
+		}
+		if info.ContentSize != 1050 {
+			t.Errorf("ContentSize = %d, want 1050", info.ContentSize)
+		}
+	})
+
+	t.Run("video-only without merge", func(t *testing.T) {
+		info, err := selectPinnedStream(video, core.FormatMP4, StreamPreferences{FormatID: "137"})
+		if err != nil {
+			t.Fatalf("selectPinnedStream() error = %v", err)
+		}
+		if info.NeedsMerge() {
+			t.Error("should not merge when merging is not allowed")
+		}
+	})
+
+	t.Run("audio format marks stream audio-only", func(t *testing.T) {
+		info, err := selectPinnedStream(video, core.FormatMP3, StreamPreferences{FormatID: "140", AllowMerge: true})
+		if err != nil {
+			t.Fatalf("selectPinnedStream() error = %v", err)
+		}
+		if !info.IsAudioOnly || info.NeedsMerge() {
+			t.Errorf("IsAudioOnly = %v, NeedsMerge = %v", info.IsAudioOnly, info.NeedsMerge())
+		}
+	})
+
+	for _, id := range []string{"999", "abc"} {
+		t.Run("unknown format "+id, func(t *testing.T) {
+			_, err := selectPinnedStream(video, core.FormatMP4, StreamPreferences{FormatID: id})
+			if !errors.Is(err, core.ErrInvalidFormat) {
+				t.Errorf("selectPinnedStream() error = %v, want ErrInvalidFormat", err)
+			}
+		})
+	}
+}
+
+func TestConvertBuiltinMetadata(t *testing.T) {
+	video := &youtube.Video{
+		ID:            "dQw4w9WgXcQ",
+		Title:         "Video",
+		Author:        "Author",
+		ChannelID:     "UC1",
+		ChannelHandle: "@author",
+		Views:         42,
+		Duration:      213 * time.Second,
+		PublishDate:   time.Date(2009, 10, 25, 0, 0, 0, 0, time.UTC),
+		Formats: youtube.FormatList{
+			{ItagNo: 137, MimeType: `video/mp4; codecs="avc1.640028"`, Height: 1080, QualityLabel: "1080p"},
+			{ItagNo: 140, MimeType: `audio/mp4; codecs="mp4a.40.2"`, Bitrate: 130000, AudioChannels: 2},
+		},
+	}
+
+	meta := convertBuiltinMetadata(video)
+	if meta.UploadDate != "2009-10-25" || meta.ViewCount != 42 || meta.Duration != 213 {
+		t.Errorf("meta = %+v", meta)
+	}
+	if meta.ChannelID != "UC1" || meta.ChannelURL != "https://www.youtube.com/@author" {
+		t.Errorf("channel = %q %q", meta.ChannelID, meta.ChannelURL)
+	}
+	if meta.Formats == nil || meta.Formats.Count != 2 || meta.Formats.MaxHeight != 1080 {
+		t.Errorf("Formats = %+v", meta.Formats)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"ybdownloader/internal/core"
@@ -29,16 +30,64 @@ var ytDlpSpeedRe = regexp.MustCompile(
 
 // ytDlpMetadata represents the JSON output of `yt-dlp --dump-json`.
 type ytDlpMetadata struct {
-	ID          string  `json:"id"`
-	Title       string  `json:"title"`
-	Uploader    string  `json:"uploader"`
-	Channel     string  `json:"channel"`
-	Duration    float64 `json:"duration"`
-	Thumbnail   string  `json:"thumbnail"`
-	Description string  `json:"description"`
+	ID          string        `json:"id"`
+	Title       string        `json:"title"`
+	Uploader    string        `json:"uploader"`
+	Channel     string        `json:"channel"`
+	Duration    float64       `json:"duration"`
+	Thumbnail   string        `json:"thumbnail"`
+	Description string        `json:"description"`
+	Extractor   string        `json:"extractor"`
+	LiveStatus  string        `json:"live_status"`
+	ReleaseTime int64         `json:"release_timestamp"`
+	Formats     []ytDlpFormat `json:"formats"`
+
+	UploadDate    string         `json:"upload_date"` // YYYYMMDD
+	ChannelID     string         `json:"channel_id"`
+	ChannelURL    string         `json:"channel_url"`
+	UploaderURL   string         `json:"uploader_url"`
+	ViewCount     int64          `json:"view_count"`
+	LikeCount     int64          `json:"like_count"`
+	Categories    []string       `json:"categories"`
+	Tags          []string       `json:"tags"`
+	Chapters      []ytDlpChapter `json:"chapters"`
+	Availability  string         `json:"availability"`
+	PlaylistID    string         `json:"playlist_id"`
+	PlaylistTitle string         `json:"playlist_title"`
+	PlaylistIndex int            `json:"playlist_index"`
+	PlaylistCount int            `json:"playlist_count"`
 }
 
-var _ core.Downloader = (*YtDlpDownloader)(nil)
+// ytDlpChapter is one entry of the "chapters" array.
+type ytDlpChapter struct {
+	Title     string  `json:"title"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+}
+
+// ytDlpFormat is one entry of the "formats" array in yt-dlp's JSON output.
+type ytDlpFormat struct {
+	FormatID       string  `json:"format_id"`
+	Ext            string  `json:"ext"`
+	Width          int     `json:"width"`
+	Height         int     `json:"height"`
+	FPS            float64 `json:"fps"`
+	VCodec         string  `json:"vcodec"`
+	ACodec         string  `json:"acodec"`
+	TBR            float64 `json:"tbr"` // kbit/s
+	Filesize       int64   `json:"filesize"`
+	FilesizeApprox int64   `json:"filesize_approx"`
+	ASR            int     `json:"asr"`
+	AudioChannels  int     `json:"audio_channels"`
+	DynamicRange   string  `json:"dynamic_range"`
+	FormatNote     string  `json:"format_note"`
+}
+
+var (
+	_ core.Downloader   = (*YtDlpDownloader)(nil)
+	_ core.FormatLister = (*YtDlpDownloader)(nil)
+	_ core.RequestPacer = (*YtDlpDownloader)(nil)
+)
 
 // YtDlpDownloader implements core.Downloader using the yt-dlp binary.
 type YtDlpDownloader struct {
@@ -48,6 +97,11 @@ type YtDlpDownloader struct {
 	settings      func() (*core.Settings, error)
 	jsRuntime     string // cached JS runtime detection result
 	jsRuntimeOnce sync.Once
+
+	recordingsMu sync.Mutex
+	recordings   map[string]*recording // Running live recordings by item ID
+
+	requestDelay atomic.Int64 // Nanoseconds between requests while rate limited
 }
 
 // NewYtDlpDownloader creates a new yt-dlp based downloader.
@@ -91,13 +145,11 @@ func (d *YtDlpDownloader) getJSRuntime() string {
 	return d.jsRuntime
 }
 
-// FetchMetadata retrieves video metadata using yt-dlp --dump-json.
-func (d *YtDlpDownloader) FetchMetadata(ctx context.Context, url string) (*core.VideoMetadata, error) {
-	slog.Debug("fetching metadata via yt-dlp", "url", url)
-
+// dumpJSON runs yt-dlp --dump-json for a single video and decodes the result.
+func (d *YtDlpDownloader) dumpJSON(ctx context.Context, url string) (*ytDlpMetadata, error) {
 	ytdlpPath, err := d.ytdlpManager.GetYtDlpPath()
 	if err != nil {
-		return nil, fmt.Errorf("yt-dlp not available: %w", err)
+		return nil, newDownloadError(core.ErrCodeYtDlpNotFound, err)
 	}
 
 	args := []string{
@@ -111,6 +163,15 @@ func (d *YtDlpDownloader) FetchMetadata(ctx context.Context, url string) (*core.
 		args = append(args, "--js-runtimes", rt)
 	}
 
+	hasCookies := false
+	if d.settings != nil {
+		if settings, err := d.settings(); err == nil {
+			args = append(args, ytDlpSessionArgs(settings)...)
+			hasCookies = settings.CookiesFile != ""
+		}
+	}
+	args = append(args, d.pacingArgs()...)
+
 	args = append(args, url)
 
 	cmd := exec.CommandContext(ctx, ytdlpPath, args...) //nolint:gosec
@@ -120,6 +181,15 @@ func (d *YtDlpDownloader) FetchMetadata(ctx context.Context, url string) (*core.
 		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
 			stderr := strings.TrimSpace(string(exitErr.Stderr))
 			slog.Error("yt-dlp metadata fetch failed", "url", url, "stderr", stderr)
+			if authErr := classifyYtDlpCookieError(stderr, hasCookies); authErr != nil {
+				return nil, authErr
+			}
+			if liveErr := classifyLiveNotStarted(stderr); liveErr != nil {
+				return nil, liveErr
+			}
+			if typedErr := classifyYtDlpError(stderr); typedErr != nil {
+				return nil, typedErr
+			}
 			return nil, fmt.Errorf("yt-dlp: %s", stderr)
 		}
 		slog.Error("yt-dlp metadata fetch failed", "url", url, "error", err)
@@ -130,31 +200,142 @@ func (d *YtDlpDownloader) FetchMetadata(ctx context.Context, url string) (*core.
 	if err := json.Unmarshal(output, &meta); err != nil {
 		return nil, fmt.Errorf("failed to parse yt-dlp metadata: %w", err)
 	}
+	return &meta, nil
+}
+
+// FetchMetadata retrieves video metadata using yt-dlp --dump-json.
+func (d *YtDlpDownloader) FetchMetadata(ctx context.Context, url string) (*core.VideoMetadata, error) {
+	slog.Debug("fetching metadata via yt-dlp", "url", url)
 
+	meta, err := d.dumpJSON(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("metadata fetched via yt-dlp",
+		"videoId", meta.ID,
+		"title", meta.Title,
+		"duration", meta.Duration,
+	)
+
+	return convertYtDlpMetadata(meta), nil
+}
+
+// convertYtDlpMetadata maps yt-dlp's JSON onto core.VideoMetadata.
+func convertYtDlpMetadata(meta *ytDlpMetadata) *core.VideoMetadata {
 	author := meta.Channel
 	if author == "" {
 		author = meta.Uploader
 	}
 
+	site := strings.ToLower(meta.Extractor)
+
 	thumbnail := meta.Thumbnail
-	if thumbnail == "" && meta.ID != "" {
+	if thumbnail == "" && meta.ID != "" && site == SiteYouTube {
 		thumbnail = fmt.Sprintf("https://i.ytimg.com/vi/%s/hqdefault.jpg", meta.ID)
 	}
 
-	slog.Info("metadata fetched via yt-dlp",
-		"videoId", meta.ID,
-		"title", meta.Title,
-		"duration", meta.Duration,
-	)
+	channelURL := meta.ChannelURL
+	if channelURL == "" {
+		channelURL = meta.UploaderURL
+	}
+
+	result := &core.VideoMetadata{
+		ID:             meta.ID,
+		Title:          meta.Title,
+		Author:         author,
+		Duration:       meta.Duration,
+		Thumbnail:      thumbnail,
+		Description:    meta.Description,
+		Site:           site,
+		LiveStatus:     ytDlpLiveStatus(meta.LiveStatus),
+		ScheduledStart: meta.ReleaseTime,
+		UploadDate:     formatUploadDate(meta.UploadDate),
+		ChannelID:      meta.ChannelID,
+		ChannelURL:     channelURL,
+		ViewCount:      meta.ViewCount,
+		LikeCount:      meta.LikeCount,
+		Categories:     meta.Categories,
+		Tags:           meta.Tags,
+		Availability:   meta.Availability,
+		Formats:        core.SummarizeFormats(convertYtDlpFormats(meta.Formats)),
+	}
+	for _, ch := range meta.Chapters {
+		result.Chapters = append(result.Chapters, core.Chapter{
+			Title:     ch.Title,
+			StartTime: ch.StartTime,
+			EndTime:   ch.EndTime,
+		})
+	}
+	if meta.PlaylistID != "" {
+		result.Playlist = &core.PlaylistContext{
+			ID:    meta.PlaylistID,
+			Title: meta.PlaylistTitle,
+			Index: meta.PlaylistIndex,
+			Count: meta.PlaylistCount,
+		}
+	}
+	return result
+}
 
-	return &core.VideoMetadata{
-		ID:          meta.ID,
-		Title:       meta.Title,
-		Author:      author,
-		Duration:    meta.Duration,
-		Thumbnail:   thumbnail,
-		Description: meta.Description,
-	}, nil
+// formatUploadDate turns yt-dlp's YYYYMMDD into YYYY-MM-DD.
+func formatUploadDate(date string) string {
+	t, err := time.Parse("20060102", date)
+	if err != nil {
+		return ""
+	}
+	return t.Format(time.DateOnly)
+}
+
+// ListFormats lists the formats yt-dlp reports for a URL.
+func (d *YtDlpDownloader) ListFormats(ctx context.Context, url string) ([]core.FormatInfo, error) {
+	slog.Debug("listing formats via yt-dlp", "url", url)
+
+	meta, err := d.dumpJSON(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return convertYtDlpFormats(meta.Formats), nil
+}
+
+// convertYtDlpFormats normalizes yt-dlp formats, dropping entries that carry
+// neither audio nor video (storyboards).
+func convertYtDlpFormats(formats []ytDlpFormat) []core.FormatInfo {
+	result := make([]core.FormatInfo, 0, len(formats))
+	for _, f := range formats {
+		hasVideo := f.VCodec != "" && f.VCodec != "none"
+		hasAudio := f.ACodec != "" && f.ACodec != "none"
+		if !hasVideo && !hasAudio {
+			continue
+		}
+
+		info := core.FormatInfo{
+			ID:            f.FormatID,
+			Ext:           f.Ext,
+			HasVideo:      hasVideo,
+			HasAudio:      hasAudio,
+			Width:         f.Width,
+			Height:        f.Height,
+			FPS:           f.FPS,
+			Bitrate:       int64(f.TBR * 1000),
+			FileSize:      f.Filesize,
+			SampleRate:    f.ASR,
+			AudioChannels: f.AudioChannels,
+			HDR:           f.DynamicRange != "" && f.DynamicRange != "SDR",
+			Note:          f.FormatNote,
+		}
+		if info.FileSize == 0 {
+			info.FileSize = f.FilesizeApprox
+		}
+		if hasVideo {
+			info.VideoCodec = f.VCodec
+		}
+		if hasAudio {
+			info.AudioCodec = f.ACodec
+		}
+		result = append(result, info)
+	}
+	return result
 }
 
 // Download downloads a video/audio using yt-dlp.
@@ -167,7 +348,7 @@ func (d *YtDlpDownloader) Download(ctx context.Context, item *core.QueueItem, on
 
 	ytdlpPath, err := d.ytdlpManager.GetYtDlpPath()
 	if err != nil {
-		return fmt.Errorf("yt-dlp not available: %w", err)
+		return newDownloadError(core.ErrCodeYtDlpNotFound, err)
 	}
 
 	settings, err := d.settings()
@@ -179,7 +360,7 @@ func (d *YtDlpDownloader) Download(ctx context.Context, item *core.QueueItem, on
 		return fmt.Errorf("failed to create save directory: %w", err)
 	}
 
-	outputTemplate := filepath.Join(item.SavePath, "%(title)s.%(ext)s")
+	outputTemplate := filepath.Join(item.SavePath, clipFileName("%(title)s", item.Clip)+".%(ext)s")
 
 	args := d.buildDownloadArgs(item, settings, outputTemplate)
 
@@ -191,7 +372,9 @@ func (d *YtDlpDownloader) Download(ctx context.Context, item *core.QueueItem, on
 		args = append(args, "--ffmpeg-location", ffmpegPath)
 	}
 
-	args = append(args, settings.YtDlpExtraFlags...)
+	// Settings are validated on save; filtering again keeps flags written to
+	// the file by hand from overriding managed args or running commands
+	args = append(args, core.AllowedYtDlpFlags(settings.YtDlpExtraFlags)...)
 	args = append(args, item.URL)
 
 	slog.Debug("yt-dlp command", "path", ytdlpPath, "args", args)
@@ -211,16 +394,93 @@ func (d *YtDlpDownloader) Download(ctx context.Context, item *core.QueueItem, on
 		return fmt.Errorf("failed to start yt-dlp: %w", err)
 	}
 
+	var rec *recording
+	var stopTimer *time.Timer
+	var maxDuration time.Duration
+	if item.Live != nil {
+		rec = d.trackRecording(item.ID, cmd)
+		defer d.untrackRecording(item.ID)
+
+		// The limit counts from the start of the recording; while yt-dlp is
+		// waiting for the stream to go live the timer is pushed back.
+		if item.Live.MaxDurationSeconds > 0 {
+			maxDuration = time.Duration(item.Live.MaxDurationSeconds) * time.Second
+			stopTimer = time.AfterFunc(maxDuration, func() {
+				slog.Info("live recording reached max duration", "itemId", item.ID)
+				_ = rec.stop()
+			})
+			defer stopTimer.Stop()
+		}
+	}
+
 	scanner := bufio.NewScanner(stdout)
 	scanner.Split(scanLinesOrCR)
 	var finalFilePath string
 	var printedPath string
+	var reportedPath string
+	var errorLines []string
 	var lineCount int
 
+	var clipLength float64
+	if item.Clip != nil {
+		var total float64
+		if item.Metadata != nil {
+			total = item.Metadata.Duration
+		}
+		clipLength = item.Clip.Length(total)
+	}
+
+	reportRecording := func(progress core.DownloadProgress) {
+		if rec.stopped.Load() {
+			progress.State = core.StateStopRequested
+		}
+		onProgress(progress)
+	}
+
 	for scanner.Scan() {
 		line := scanner.Text()
 		lineCount++
 		slog.Debug("yt-dlp output", "line", line, "lineNum", lineCount) //nolint:gosec // G706: yt-dlp output is not user-controlled input
+
+		if ev, ok := parseYtDlpEvent(line); ok {
+			switch {
+			case ev.kind == ytDlpEventFile:
+				reportedPath = ev.path
+			case ev.kind == ytDlpEventPostprocess:
+				onProgress(ev.progress.postprocessProgress(item.ID))
+			case rec != nil:
+				reportRecording(ev.progress.recordingProgress(item.ID))
+			case item.Clip != nil && !ev.progress.hasPercent():
+				// Sections are fetched by FFmpeg; its stats lines carry the position.
+			default:
+				onProgress(ev.progress.downloadProgress(item.ID))
+			}
+			continue
+		}
+
+		// The text parsers below are a fallback for output the templates
+		// don't cover, such as FFmpeg stats, and for older yt-dlp versions.
+		if rec != nil {
+			if progress, ok := parseYtDlpLiveProgress(line, item.ID); ok {
+				reportRecording(progress)
+				continue
+			}
+			if isYtDlpWaitLine(line) {
+				if stopTimer != nil {
+					stopTimer.Reset(maxDuration)
+				}
+				onProgress(core.DownloadProgress{ItemID: item.ID, State: core.StateWaitingForLive})
+				continue
+			}
+		}
+
+		if item.Clip != nil {
+			if progress, ok := parseYtDlpClipProgress(line, item.ID, clipLength); ok {
+				onProgress(progress)
+				continue
+			}
+		}
+
 		if progress, ok := parseYtDlpProgress(line, item.ID); ok {
 			slog.Info("yt-dlp progress", "percent", progress.Percent, "speed", progress.Speed, "total", progress.TotalBytes, "itemId", item.ID) //nolint:gosec // G706: progress values are parsed numbers
 			onProgress(progress)
@@ -242,25 +502,56 @@ func (d *YtDlpDownloader) Download(ctx context.Context, item *core.QueueItem, on
 			continue
 		}
 
-		// Lines not matching any known pattern may be from --print after_move:filepath.
-		// These are bare paths printed after all processing is done.
 		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "ERROR:") {
+			errorLines = append(errorLines, trimmed)
+			continue
+		}
+
+		// Fallback for a missing templated path: a bare line printed after
+		// all processing is done may be the final path.
 		if trimmed != "" && !strings.HasPrefix(trimmed, "[") && !strings.HasPrefix(trimmed, "WARNING") {
 			printedPath = trimmed
 		}
 	}
 
-	if err := cmd.Wait(); err != nil {
-		if ctx.Err() == context.Canceled {
-			return ctx.Err()
-		}
-		return fmt.Errorf("yt-dlp download failed: %w", err)
+	waitErr := cmd.Wait()
+	if waitErr != nil && ctx.Err() == context.Canceled {
+		return ctx.Err()
 	}
 
+	outputPath := finalFilePath
 	if printedPath != "" {
-		item.FilePath = printedPath
-	} else if finalFilePath != "" {
-		item.FilePath = finalFilePath
+		outputPath = printedPath
+	}
+	if reportedPath != "" {
+		outputPath = reportedPath
+	}
+
+	switch {
+	case waitErr != nil && rec != nil && rec.stopped.Load():
+		// Interrupting yt-dlp may end with a non-zero exit; keep the capture.
+		kept, err := keepStoppedRecording(outputPath)
+		if err != nil {
+			return err
+		}
+		outputPath = kept
+	case waitErr != nil:
+		errOutput := strings.Join(errorLines, "\n")
+		if authErr := classifyYtDlpCookieError(errOutput, settings.CookiesFile != ""); authErr != nil {
+			return authErr
+		}
+		if liveErr := classifyLiveNotStarted(errOutput); liveErr != nil {
+			return liveErr
+		}
+		if typedErr := classifyYtDlpError(errOutput); typedErr != nil {
+			return typedErr
+		}
+		return fmt.Errorf("yt-dlp download failed: %w", waitErr)
+	}
+
+	if outputPath != "" {
+		item.FilePath = outputPath
 	}
 
 	onProgress(core.DownloadProgress{
@@ -274,51 +565,150 @@ func (d *YtDlpDownloader) Download(ctx context.Context, item *core.QueueItem, on
 }
 
 func (d *YtDlpDownloader) buildDownloadArgs(item *core.QueueItem, settings *core.Settings, outputTemplate string) []string {
+	args := ytDlpBaseArgs(outputTemplate)
+	args = append(args, ytDlpFormatArgs(item.Format, settings)...)
+
+	if item.FormatID != "" {
+		args = withPinnedFormat(args, ytDlpPinnedFormat(item.FormatID, item.Format.IsAudioOnly()))
+	}
+
+	args = append(args, ytDlpLiveArgs(item.Live)...)
+	args = append(args, ytDlpClipArgs(item.Clip)...)
+	args = append(args, ytDlpSessionArgs(settings)...)
+	args = append(args, d.pacingArgs()...)
+
+	return args
+}
+
+// ytDlpBaseArgs are the flags every download starts with; the app relies on
+// them to find the output file and parse progress.
+func ytDlpBaseArgs(outputTemplate string) []string {
 	args := []string{
 		"--newline",
 		"--no-colors",
 		"--no-playlist",
 		"--no-overwrites",
 		"--windows-filenames",
-		"--print", "after_move:filepath",
 		"-o", outputTemplate,
 	}
+	return append(args, ytDlpProgressArgs()...)
+}
 
-	switch item.Format {
+// ytDlpFormatArgs maps an output format and the quality settings to flags.
+func ytDlpFormatArgs(format core.Format, settings *core.Settings) []string {
+	switch format {
 	case core.FormatMP3:
-		args = append(args,
+		return []string{
 			"-x",
 			"--audio-format", "mp3",
 			"--audio-quality", ytDlpAudioQuality(settings.DefaultAudioQuality),
 			"--format-sort", "acodec:aac",
-		)
+		}
 	case core.FormatM4A:
-		args = append(args,
+		return []string{
 			"-x",
 			"--audio-format", "m4a",
 			"--audio-quality", ytDlpAudioQuality(settings.DefaultAudioQuality),
 			"--format-sort", "acodec:aac",
-		)
+		}
 	case core.FormatMP4:
-		args = append(args,
+		return []string{
 			"-f", ytDlpVideoFormat(settings.DefaultVideoQuality),
 			"--format-sort", "vcodec:h264,acodec:aac",
 			"--merge-output-format", "mp4",
 			"--remux-video", "mp4",
-		)
+		}
 	case core.FormatWebM:
-		args = append(args,
+		return []string{
 			"-f", ytDlpVideoFormat(settings.DefaultVideoQuality),
 			"--format-sort", "vcodec:vp9,acodec:opus",
 			"--merge-output-format", "webm",
-		)
+		}
 	default:
-		slog.Warn("unknown format for yt-dlp, using best available", "format", item.Format)
+		slog.Warn("unknown format for yt-dlp, using best available", "format", format)
+		return nil
+	}
+}
+
+// YtDlpDefaultFlags returns the flags the app passes to yt-dlp: "common" for
+// every download, one entry per format, and "extra" for the user's allowed
+// extra flags, which come last.
+func YtDlpDefaultFlags(settings *core.Settings) map[string][]string {
+	flags := map[string][]string{
+		"common": append(ytDlpBaseArgs("%(title)s.%(ext)s"), ytDlpSessionArgs(settings)...),
+		"extra":  core.AllowedYtDlpFlags(settings.YtDlpExtraFlags),
 	}
+	for _, format := range []core.Format{core.FormatMP3, core.FormatM4A, core.FormatMP4, core.FormatWebM} {
+		flags[string(format)] = ytDlpFormatArgs(format, settings)
+	}
+	return flags
+}
+
+// SetRequestDelay sets the pause yt-dlp takes between requests, used while
+// the queue is throttled after a rate limit. Zero disables it.
+func (d *YtDlpDownloader) SetRequestDelay(delay time.Duration) {
+	d.requestDelay.Store(int64(delay))
+}
 
+// pacingArgs maps the request delay to --sleep-requests.
+func (d *YtDlpDownloader) pacingArgs() []string {
+	delay := time.Duration(d.requestDelay.Load())
+	if delay <= 0 {
+		return nil
+	}
+	return []string{"--sleep-requests", formatSeconds(delay.Seconds())}
+}
+
+// ytDlpSessionArgs maps the cookies and network settings shared by every
+// yt-dlp invocation.
+func ytDlpSessionArgs(settings *core.Settings) []string {
+	var args []string
+	if settings.CookiesFile != "" {
+		args = append(args, "--cookies", settings.CookiesFile)
+	}
+	return append(args, ytDlpNetworkArgs(settings.Network)...)
+}
+
+// ytDlpNetworkArgs maps network settings to yt-dlp flags. yt-dlp has no
+// equivalent of the no-proxy list or a custom CA bundle, so those only apply
+// to the app's own HTTP clients.
+func ytDlpNetworkArgs(n core.NetworkSettings) []string {
+	var args []string
+	if n.ProxyURL != "" {
+		args = append(args, "--proxy", n.ProxyURL)
+	}
+	switch n.IPVersion {
+	case core.IPVersion4:
+		args = append(args, "--force-ipv4")
+	case core.IPVersion6:
+		args = append(args, "--force-ipv6")
+	}
+	if timeout := max(n.ConnectTimeoutSeconds, n.ReadTimeoutSeconds); timeout > 0 {
+		args = append(args, "--socket-timeout", strconv.Itoa(timeout))
+	}
 	return args
 }
 
+// ytDlpPinnedFormat builds the -f selector for a pinned format ID. For video
+// output a video-only format is merged with the best audio.
+func ytDlpPinnedFormat(formatID string, audioOnly bool) string {
+	if audioOnly {
+		return formatID
+	}
+	return fmt.Sprintf("%s[acodec=none]+ba/%s", formatID, formatID)
+}
+
+// withPinnedFormat replaces an existing -f selector or appends one.
+func withPinnedFormat(args []string, selector string) []string {
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == "-f" {
+			args[i+1] = selector
+			return args
+		}
+	}
+	return append(args, "-f", selector)
+}
+
 func ytDlpAudioQuality(q core.AudioQuality) string {
 	switch q {
 	case core.AudioQuality128:
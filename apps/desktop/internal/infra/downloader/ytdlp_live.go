@@ -0,0 +1,191 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"ybdownloader/internal/core"
+)
+
+var _ core.RecordingStopper = (*YtDlpDownloader)(nil)
+
+// liveWaitRetrySeconds is how often yt-dlp re-checks a scheduled stream.
+const liveWaitRetrySeconds = 60
+
+// ytDlpLiveProgressRe matches yt-dlp's progress for downloads without a
+// known size, e.g. "[download]   12.34MiB at    1.20MiB/s (00:01:02)".
+var ytDlpLiveProgressRe = regexp.MustCompile(
+	`\[download\]\s+([\d.]+\s*\w+)\s+at\s+(\S+)\s+\((\d+(?::\d{2}){1,2})\)`,
+)
+
+// ffmpegLiveProgressRe matches ffmpeg's stats line when yt-dlp hands a live
+// HLS stream to ffmpeg, e.g. "size=    2048kB time=00:00:12.34 bitrate=...".
+var ffmpegLiveProgressRe = regexp.MustCompile(
+	`size=\s*(\d+)\s*(kB|KiB|MB|MiB)\s+time=(\d+):(\d{2}):(\d{2}(?:\.\d+)?)`,
+)
+
+// ytDlpWaitRe matches the output of --wait-for-video while a scheduled
+// stream has not started.
+var ytDlpWaitRe = regexp.MustCompile(`^\[wait\]|Remaining time until next attempt`)
+
+// liveNotStartedPatterns identify yt-dlp errors for streams that are not live yet.
+var liveNotStartedPatterns = []string{
+	"this live event will begin",
+	"premieres in",
+	"premiere will begin",
+	"will begin shortly",
+	"live stream offline",
+}
+
+// recording tracks a running yt-dlp process so a live recording can be
+// stopped early without discarding what was captured.
+type recording struct {
+	cmd     *exec.Cmd
+	stopped atomic.Bool
+}
+
+func (r *recording) stop() error {
+	if r.stopped.Swap(true) {
+		return nil
+	}
+	return interruptProcess(r.cmd)
+}
+
+func (d *YtDlpDownloader) trackRecording(itemID string, cmd *exec.Cmd) *recording {
+	rec := &recording{cmd: cmd}
+	d.recordingsMu.Lock()
+	if d.recordings == nil {
+		d.recordings = make(map[string]*recording)
+	}
+	d.recordings[itemID] = rec
+	d.recordingsMu.Unlock()
+	return rec
+}
+
+func (d *YtDlpDownloader) untrackRecording(itemID string) {
+	d.recordingsMu.Lock()
+	delete(d.recordings, itemID)
+	d.recordingsMu.Unlock()
+}
+
+// StopRecording ends a live recording and keeps the captured part.
+func (d *YtDlpDownloader) StopRecording(itemID string) error {
+	d.recordingsMu.Lock()
+	rec, ok := d.recordings[itemID]
+	d.recordingsMu.Unlock()
+	if !ok {
+		return fmt.Errorf("no active recording for item %s", itemID)
+	}
+	return rec.stop()
+}
+
+// ytDlpLiveArgs maps live options to yt-dlp flags.
+func ytDlpLiveArgs(opts *core.LiveOptions) []string {
+	if opts == nil {
+		return nil
+	}
+	var args []string
+	if opts.FromStart {
+		args = append(args, "--live-from-start")
+	}
+	if opts.WaitForStart {
+		args = append(args, "--wait-for-video", strconv.Itoa(liveWaitRetrySeconds))
+	}
+	return args
+}
+
+// ytDlpLiveStatus maps yt-dlp's live_status; finished streams ("was_live",
+// "post_live") download like regular videos.
+func ytDlpLiveStatus(status string) core.LiveStatus {
+	switch status {
+	case string(core.LiveStatusLive), string(core.LiveStatusUpcoming):
+		return core.LiveStatus(status)
+	}
+	return core.LiveStatusNone
+}
+
+// parseYtDlpLiveProgress parses progress for a recording, which reports
+// elapsed time and bytes since the total size is unknown.
+func parseYtDlpLiveProgress(line string, itemID string) (core.DownloadProgress, bool) {
+	if m := ytDlpLiveProgressRe.FindStringSubmatch(line); m != nil {
+		return core.DownloadProgress{
+			ItemID:          itemID,
+			State:           core.StateRecording,
+			DownloadedBytes: parseSizeString(m[1]),
+			Speed:           parseSizeString(strings.TrimSuffix(m[2], "/s")),
+			ElapsedSeconds:  parseClock(m[3]),
+		}, true
+	}
+
+	if m := ffmpegLiveProgressRe.FindStringSubmatch(line); m != nil {
+		size, _ := strconv.ParseFloat(m[1], 64)
+		hours, _ := strconv.ParseFloat(m[3], 64)
+		minutes, _ := strconv.ParseFloat(m[4], 64)
+		seconds, _ := strconv.ParseFloat(m[5], 64)
+		return core.DownloadProgress{
+			ItemID:          itemID,
+			State:           core.StateRecording,
+			DownloadedBytes: parseSizeToBytes(size, m[2]),
+			ElapsedSeconds:  hours*3600 + minutes*60 + seconds,
+		}, true
+	}
+
+	return core.DownloadProgress{}, false
+}
+
+// parseClock converts "MM:SS" or "HH:MM:SS" to seconds.
+func parseClock(s string) float64 {
+	var total float64
+	for _, part := range strings.Split(s, ":") {
+		v, _ := strconv.ParseFloat(part, 64)
+		total = total*60 + v
+	}
+	return total
+}
+
+func isYtDlpWaitLine(line string) bool {
+	return ytDlpWaitRe.MatchString(strings.TrimSpace(line))
+}
+
+// classifyLiveNotStarted returns a LIVE_NOT_STARTED error for yt-dlp output
+// about a scheduled stream or premiere, or nil.
+func classifyLiveNotStarted(output string) error {
+	lower := strings.ToLower(output)
+	for _, p := range liveNotStartedPatterns {
+		if strings.Contains(lower, p) {
+			return newLiveNotStartedError(errors.New(strings.TrimSpace(output)))
+		}
+	}
+	return nil
+}
+
+func newLiveNotStartedError(err error) *core.AppError {
+	return core.NewAppError(core.ErrCodeLiveNotStarted,
+		"This stream has not started yet. Enable waiting for the stream to record it when it begins",
+		fmt.Errorf("%w: %w", core.ErrLiveNotStarted, err))
+}
+
+// keepStoppedRecording resolves the file left behind by a recording that was
+// stopped early, renaming a leftover .part file into place.
+func keepStoppedRecording(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("recording stopped before any data was captured")
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	part := path + ".part"
+	if _, err := os.Stat(part); err != nil {
+		return "", fmt.Errorf("recording stopped before any data was captured: %w", err)
+	}
+	if err := os.Rename(part, path); err != nil {
+		return "", fmt.Errorf("failed to keep recording: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,199 @@
+package downloader
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+
+	"ybdownloader/internal/core"
+)
+
+func TestParseYtDlpLiveProgress(t *testing.T) {
+	tests := []struct {
+		name        string
+		line        string
+		wantOK      bool
+		wantBytes   int64
+		wantSpeed   int64
+		wantElapsed float64
+	}{
+		{
+			name:        "native downloader",
+			line:        "[download]   12.00MiB at    1.00MiB/s (00:01:02)",
+			wantOK:      true,
+			wantBytes:   12 * 1024 * 1024,
+			wantSpeed:   1024 * 1024,
+			wantElapsed: 62,
+		},
+		{
+			name:        "fragments",
+			line:        "[download]    2.50KiB at  512.00B/s (01:00:05) (frag 12)",
+			wantOK:      true,
+			wantBytes:   2560,
+			wantSpeed:   512,
+			wantElapsed: 3605,
+		},
+		{
+			name:        "ffmpeg stats",
+			line:        "size=    2048kB time=00:01:30.50 bitrate= 185.4kbits/s speed=1.0x",
+			wantOK:      true,
+			wantBytes:   2048 * 1024,
+			wantElapsed: 90.5,
+		},
+		{"percent progress is not live", "[download]  45.2% of 10.00MiB at 1.00MiB/s ETA 00:05", false, 0, 0, 0},
+		{"ffmpeg without size", "size=N/A time=00:00:01.00 bitrate=N/A", false, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseYtDlpLiveProgress(tt.line, "item")
+			if ok != tt.wantOK {
+				t.Fatalf("parseYtDlpLiveProgress() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got.State != core.StateRecording || got.Percent != 0 {
+				t.Errorf("State = %q, Percent = %v", got.State, got.Percent)
+			}
+			if got.DownloadedBytes != tt.wantBytes || got.Speed != tt.wantSpeed || got.ElapsedSeconds != tt.wantElapsed {
+				t.Errorf("got bytes=%d speed=%d elapsed=%v, want %d %d %v",
+					got.DownloadedBytes, got.Speed, got.ElapsedSeconds, tt.wantBytes, tt.wantSpeed, tt.wantElapsed)
+			}
+		})
+	}
+}
+
+func TestIsYtDlpWaitLine(t *testing.T) {
+	if !isYtDlpWaitLine("[wait] Waiting for 00:10:00 - Press Ctrl+C to try now") {
+		t.Error("expected [wait] line to match")
+	}
+	if !isYtDlpWaitLine("\rRemaining time until next attempt: 00:09:58") {
+		t.Error("expected countdown line to match")
+	}
+	if isYtDlpWaitLine("[download] Destination: video.mp4") {
+		t.Error("download line should not match")
+	}
+}
+
+func TestYtDlpLiveArgs(t *testing.T) {
+	if args := ytDlpLiveArgs(nil); args != nil {
+		t.Errorf("ytDlpLiveArgs(nil) = %v", args)
+	}
+	got := strings.Join(ytDlpLiveArgs(&core.LiveOptions{FromStart: true, WaitForStart: true, MaxDurationSeconds: 60}), " ")
+	if got != "--live-from-start --wait-for-video 60" {
+		t.Errorf("ytDlpLiveArgs() = %q", got)
+	}
+}
+
+func TestYtDlpLiveStatus(t *testing.T) {
+	tests := map[string]core.LiveStatus{
+		"is_live":     core.LiveStatusLive,
+		"is_upcoming": core.LiveStatusUpcoming,
+		"was_live":    core.LiveStatusNone,
+		"not_live":    core.LiveStatusNone,
+		"":            core.LiveStatusNone,
+	}
+	for in, want := range tests {
+		if got := ytDlpLiveStatus(in); got != want {
+			t.Errorf("ytDlpLiveStatus(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestClassifyLiveNotStarted(t *testing.T) {
+	for _, msg := range []string{
+		"ERROR: [youtube] abc: This live event will begin in 3 hours.",
+		"ERROR: [youtube] abc: Premieres in 10 minutes",
+	} {
+		err := classifyLiveNotStarted(msg)
+		var appErr *core.AppError
+		if !errors.As(err, &appErr) || appErr.Code != core.ErrCodeLiveNotStarted || !errors.Is(err, core.ErrLiveNotStarted) {
+			t.Errorf("classifyLiveNotStarted(%q) = %v", msg, err)
+		}
+	}
+	if err := classifyLiveNotStarted("ERROR: Video unavailable"); err != nil {
+		t.Errorf("unrelated error classified as live: %v", err)
+	}
+}
+
+func TestClassifyBuiltinLiveError(t *testing.T) {
+	err := classifyBuiltinLiveError(&youtube.ErrPlayabiltyStatus{Status: "LIVE_STREAM_OFFLINE"})
+	if !errors.Is(err, core.ErrLiveNotStarted) {
+		t.Errorf("classifyBuiltinLiveError() = %v, want ErrLiveNotStarted", err)
+	}
+	if err := classifyBuiltinLiveError(errors.New("boom")); err != nil {
+		t.Errorf("classifyBuiltinLiveError() = %v, want nil", err)
+	}
+}
+
+func TestKeepStoppedRecording(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := keepStoppedRecording(""); err == nil {
+		t.Error("expected error without an output path")
+	}
+
+	done := filepath.Join(dir, "done.ts")
+	if err := os.WriteFile(done, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := keepStoppedRecording(done); err != nil || got != done {
+		t.Errorf("keepStoppedRecording(existing) = %q, %v", got, err)
+	}
+
+	partial := filepath.Join(dir, "partial.ts")
+	if err := os.WriteFile(partial+".part", []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := keepStoppedRecording(partial); err != nil || got != partial {
+		t.Errorf("keepStoppedRecording(.part) = %q, %v", got, err)
+	}
+	if _, err := os.Stat(partial); err != nil {
+		t.Errorf(".part file was not renamed: %v", err)
+	}
+
+	if _, err := keepStoppedRecording(filepath.Join(dir, "missing.ts")); err == nil {
+		t.Error("expected error when nothing was captured")
+	}
+}
+
+func TestYtDlpDownloader_StopRecording(t *testing.T) {
+	d := &YtDlpDownloader{}
+	if err := d.StopRecording("missing"); err == nil {
+		t.Error("StopRecording() expected error for unknown item")
+	}
+
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX sleep process")
+	}
+	cmd := exec.Command("sleep", "10")
+	setupProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+	rec := d.trackRecording("item", cmd)
+	defer d.untrackRecording("item")
+
+	if err := d.StopRecording("item"); err != nil {
+		t.Fatalf("StopRecording() error = %v", err)
+	}
+	if !rec.stopped.Load() {
+		t.Error("recording not marked as stopped")
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		_ = cmd.Process.Kill()
+		t.Fatal("process was not interrupted")
+	}
+}
@@ -9,34 +9,44 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
 	"ybdownloader/internal/core"
+	"ybdownloader/internal/infra/network"
 )
 
 const (
-	ytDlpReleasesBaseURL = "https://github.com/yt-dlp/yt-dlp/releases/latest/download"
+	// ytDlpReleasesURL is the default release source. Mirrors must use the
+	// same layout: /latest redirecting to /tag/<version>, and assets under
+	// /download/<version>/.
+	ytDlpReleasesURL = "https://github.com/yt-dlp/yt-dlp/releases"
 )
 
 // YtDlpManager handles yt-dlp detection, download, and management.
 type YtDlpManager struct {
 	fs          core.FileSystem
 	getSettings func() (*core.Settings, error)
+	smokeTest   func(ctx context.Context, binPath string) error
 }
 
 // NewYtDlpManager creates a new yt-dlp manager.
 func NewYtDlpManager(fs core.FileSystem, getSettings func() (*core.Settings, error)) *YtDlpManager {
-	return &YtDlpManager{
+	m := &YtDlpManager{
 		fs:          fs,
 		getSettings: getSettings,
 	}
+	m.smokeTest = m.smokeTestYtDlp
+	return m
 }
 
 // GetYtDlpPath returns the path to the yt-dlp binary.
-// Priority: 1) User-configured path, 2) Bundled yt-dlp, 3) System yt-dlp
+// Priority: 1) User-configured path, 2) Pinned or active managed version,
+// 3) Legacy bundled yt-dlp, 4) System yt-dlp
 func (m *YtDlpManager) GetYtDlpPath() (string, error) {
 	settings, err := m.getSettings()
 	if err != nil {
@@ -47,6 +57,10 @@ func (m *YtDlpManager) GetYtDlpPath() (string, error) {
 		return settings.YtDlpPath, nil
 	}
 
+	if managed := m.managedBinaryPath(settings.YtDlpVersion); managed != "" {
+		return managed, nil
+	}
+
 	bundledPath := m.getBundledBinaryPath()
 	if m.fs.FileExists(bundledPath) {
 		return bundledPath, nil
@@ -67,69 +81,11 @@ func (m *YtDlpManager) EnsureYtDlp(ctx context.Context, onProgress func(percent
 	return m.DownloadYtDlp(ctx, onProgress)
 }
 
-// DownloadYtDlp downloads the appropriate yt-dlp binary for the current platform.
+// DownloadYtDlp installs the pinned yt-dlp version, or the latest release
+// when none is pinned.
 func (m *YtDlpManager) DownloadYtDlp(ctx context.Context, onProgress func(percent float64, status string)) error {
-	downloadURL, err := getYtDlpDownloadURL()
-	if err != nil {
-		return err
-	}
-
-	slog.Info("starting yt-dlp download", "url", downloadURL, "platform", runtime.GOOS+"/"+runtime.GOARCH)
-	onProgress(0, "Fetching yt-dlp...")
-
-	bundledDir := m.getBundledDir()
-	if err := m.fs.EnsureDir(bundledDir); err != nil {
-		return fmt.Errorf("failed to create bundled dir: %w", err)
-	}
-
-	destPath := m.getBundledBinaryPath()
-	tempDir, err := m.fs.GetTempDir()
-	if err != nil {
-		return fmt.Errorf("failed to get temp dir: %w", err)
-	}
-
-	tempPath := filepath.Join(tempDir, "yt-dlp-download"+ytDlpBinaryExt())
-	defer os.Remove(tempPath) //nolint:errcheck
-
-	onProgress(5, "Downloading yt-dlp...")
-	if err := m.downloadFile(ctx, downloadURL, tempPath, func(percent float64, status string) {
-		scaledPercent := 5 + (percent/100.0)*85
-		onProgress(scaledPercent, status)
-	}); err != nil {
-		return fmt.Errorf("failed to download yt-dlp: %w", err)
-	}
-
-	onProgress(92, "Installing yt-dlp...")
-	//nolint:gosec // G302: executable needs 0755
-	if err := os.Rename(tempPath, destPath); err != nil {
-		in, err := os.Open(tempPath) //nolint:gosec
-		if err != nil {
-			return fmt.Errorf("failed to open downloaded file: %w", err)
-		}
-		defer in.Close() //nolint:errcheck
-
-		//nolint:gosec // G302: executable needs 0755
-		out, err := os.OpenFile(destPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
-		if err != nil {
-			return fmt.Errorf("failed to create binary: %w", err)
-		}
-		defer out.Close() //nolint:errcheck
-
-		if _, err := io.Copy(out, in); err != nil {
-			return fmt.Errorf("failed to copy binary: %w", err)
-		}
-	}
-
-	if runtime.GOOS != "windows" {
-		//nolint:gosec
-		if err := os.Chmod(destPath, 0755); err != nil {
-			slog.Warn("failed to chmod yt-dlp binary", "error", err)
-		}
-	}
-
-	onProgress(100, "yt-dlp installed successfully")
-	slog.Info("yt-dlp installed", "path", destPath)
-	return nil
+	_, err := m.InstallVersion(ctx, m.pinnedVersion(), onProgress)
+	return err
 }
 
 // GetVersion returns the yt-dlp version string.
@@ -161,35 +117,37 @@ func ytDlpBinaryExt() string {
 	return binaryExt()
 }
 
-func getYtDlpDownloadURL() (string, error) {
-	var filename string
-
+// ytDlpAssetName returns the release asset for the current platform.
+func ytDlpAssetName() (string, error) {
 	switch runtime.GOOS {
 	case "linux":
 		switch runtime.GOARCH {
 		case "amd64":
-			filename = "yt-dlp_linux"
+			return "yt-dlp_linux", nil
 		case "arm64":
-			filename = "yt-dlp_linux_aarch64"
+			return "yt-dlp_linux_aarch64", nil
 		default:
 			return "", fmt.Errorf("unsupported Linux architecture: %s", runtime.GOARCH)
 		}
 	case "darwin":
-		filename = "yt-dlp_macos"
+		return "yt-dlp_macos", nil
 	case "windows":
-		switch runtime.GOARCH {
-		case "amd64":
-			filename = "yt-dlp.exe"
-		case "arm64":
-			filename = "yt-dlp_arm64.exe"
-		default:
-			filename = "yt-dlp.exe"
+		if runtime.GOARCH == "arm64" {
+			return "yt-dlp_arm64.exe", nil
 		}
+		return "yt-dlp.exe", nil
 	default:
 		return "", fmt.Errorf("unsupported OS: %s", runtime.GOOS)
 	}
+}
 
-	return ytDlpReleasesBaseURL + "/" + filename, nil
+// ytDlpDownloadURL returns where source publishes version for this platform.
+func ytDlpDownloadURL(source, version string) (string, error) {
+	filename, err := ytDlpAssetName()
+	if err != nil {
+		return "", err
+	}
+	return source + "/download/" + version + "/" + filename, nil
 }
 
 // GetJSRuntimePath returns the path to a JS runtime for yt-dlp's signature solver.
@@ -241,6 +199,7 @@ func (m *YtDlpManager) downloadDeno(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	downloadURL = mirroredURL(m.getSettings, downloadURL)
 
 	slog.Info("downloading deno", "url", downloadURL)
 
@@ -254,12 +213,21 @@ func (m *YtDlpManager) downloadDeno(ctx context.Context) error {
 		return fmt.Errorf("failed to get temp dir: %w", err)
 	}
 
+	zipName := path.Base(downloadURL)
+	want, err := fetchChecksum(ctx, m.getSettings, downloadURL+".sha256sum", zipName)
+	if err != nil {
+		return fmt.Errorf("failed to get deno checksum: %w", err)
+	}
+
 	zipPath := filepath.Join(tempDir, "deno-download.zip")
 	defer os.Remove(zipPath) //nolint:errcheck
 
 	if err := m.downloadFile(ctx, downloadURL, zipPath, func(float64, string) {}); err != nil {
 		return fmt.Errorf("failed to download deno: %w", err)
 	}
+	if _, err := verifyChecksum(zipPath, want, bundledDir); err != nil {
+		return err
+	}
 
 	destPath := filepath.Join(bundledDir, "deno"+binaryExt())
 	if err := extractBinaryFromZip(zipPath, "deno"+binaryExt(), destPath); err != nil {
@@ -269,11 +237,27 @@ func (m *YtDlpManager) downloadDeno(ctx context.Context) error {
 	if runtime.GOOS != "windows" {
 		_ = os.Chmod(destPath, 0755) //nolint:errcheck,gosec
 	}
+	if hash, err := sha256File(destPath); err == nil {
+		recordChecksum(bundledDir, destPath, hash, downloadURL, true)
+	}
 
 	slog.Info("deno installed", "path", destPath)
 	return nil
 }
 
+// InstallDenoFromFile installs deno from a local release zip or binary.
+func (m *YtDlpManager) InstallDenoFromFile(ctx context.Context, archivePath string) (*ToolInstallResult, error) {
+	binName := "deno" + binaryExt()
+	dest := func(string) string { return filepath.Join(m.getBundledDir(), binName) }
+	path, version, err := installFromArchive(ctx, archivePath, []string{binName}, "--version",
+		versionAfter("deno"), m.getBundledDir(), dest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to install deno: %w", err)
+	}
+	slog.Info("deno installed from file", "version", version, "file", archivePath)
+	return &ToolInstallResult{Tool: ToolDeno, Version: version, Paths: []string{path}}, nil
+}
+
 func getDenoDownloadURL() (string, error) {
 	const base = "https://github.com/denoland/deno/releases/latest/download"
 	var filename string
@@ -307,6 +291,11 @@ func getDenoDownloadURL() (string, error) {
 }
 
 func extractBinaryFromZip(zipPath, binaryName, destPath string) error {
+	return extractFromZip(zipPath, []string{binaryName}, destPath)
+}
+
+// extractFromZip copies the first file named one of names out of a zip.
+func extractFromZip(zipPath string, names []string, destPath string) error {
 	r, err := zip.OpenReader(zipPath)
 	if err != nil {
 		return err
@@ -314,7 +303,7 @@ func extractBinaryFromZip(zipPath, binaryName, destPath string) error {
 	defer r.Close() //nolint:errcheck
 
 	for _, f := range r.File {
-		if filepath.Base(f.Name) != binaryName {
+		if f.FileInfo().IsDir() || !slices.Contains(names, filepath.Base(f.Name)) {
 			continue
 		}
 
@@ -324,21 +313,10 @@ func extractBinaryFromZip(zipPath, binaryName, destPath string) error {
 		}
 		defer src.Close() //nolint:errcheck
 
-		//nolint:gosec // G302: executable needs 0755
-		dst, err := os.OpenFile(destPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
-		if err != nil {
-			return err
-		}
-		defer dst.Close() //nolint:errcheck
-
-		//nolint:gosec // G110: deno binary is trusted from official GitHub releases
-		if _, err := io.Copy(dst, src); err != nil {
-			return err
-		}
-		return nil
+		return writeBinary(src, destPath)
 	}
 
-	return fmt.Errorf("binary %q not found in zip", binaryName)
+	return fmt.Errorf("%s not found in archive", strings.Join(names, " or "))
 }
 
 func binaryExt() string {
@@ -354,7 +332,7 @@ func (m *YtDlpManager) downloadFile(ctx context.Context, url, dest string, onPro
 		return err
 	}
 
-	client := &http.Client{Timeout: 10 * time.Minute}
+	client := network.NewClient(m.getSettings, 10*time.Minute)
 	resp, err := client.Do(req) //nolint:gosec // G704: URL is constructed from hardcoded GitHub release base URLs
 	if err != nil {
 		return err
@@ -365,8 +343,8 @@ func (m *YtDlpManager) downloadFile(ctx context.Context, url, dest string, onPro
 		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
 	}
 
-	//nolint:gosec // G302: executable needs 0755
-	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	// Not executable until the checksum has been verified.
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644) //nolint:gosec
 	if err != nil {
 		return err
 	}
@@ -12,16 +12,16 @@ import (
 	"ybdownloader/internal/core"
 )
 
-func TestGetYtDlpDownloadURL(t *testing.T) {
-	url, err := getYtDlpDownloadURL()
+func TestYtDlpDownloadURL(t *testing.T) {
+	url, err := ytDlpDownloadURL(ytDlpReleasesURL, "2024.08.06")
 	if err != nil {
-		t.Fatalf("getYtDlpDownloadURL() error = %v", err)
+		t.Fatalf("ytDlpDownloadURL() error = %v", err)
 	}
 	if url == "" {
-		t.Error("getYtDlpDownloadURL() returned empty URL")
+		t.Error("ytDlpDownloadURL() returned empty URL")
 	}
-	if !strings.HasPrefix(url, ytDlpReleasesBaseURL+"/") {
-		t.Errorf("URL %q does not start with expected base %q", url, ytDlpReleasesBaseURL)
+	if want := ytDlpReleasesURL + "/download/2024.08.06/"; !strings.HasPrefix(url, want) {
+		t.Errorf("URL %q does not start with expected base %q", url, want)
 	}
 
 	tests := []struct {
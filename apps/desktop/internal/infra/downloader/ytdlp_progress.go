@@ -0,0 +1,154 @@
+package downloader
+
+import (
+	"encoding/json"
+	"strings"
+
+	"ybdownloader/internal/core"
+)
+
+// Markers prefixed to yt-dlp's machine-readable output. Each template is
+// printed on its own line, so the JSON after a marker decodes directly
+// instead of scraping messages that change between versions and locales.
+const (
+	ytDlpProgressMarker    = "__YBD_PROGRESS__"
+	ytDlpPostprocessMarker = "__YBD_POSTPROCESS__"
+	ytDlpFileMarker        = "__YBD_FILE__"
+)
+
+// ytDlpProgressArgs asks yt-dlp for JSON progress and the final file path.
+// --progress is needed because --print otherwise implies --quiet.
+func ytDlpProgressArgs() []string {
+	return []string{
+		"--progress",
+		"--progress-template", "download:" + ytDlpProgressMarker + " %(progress)j",
+		"--progress-template", "postprocess:" + ytDlpPostprocessMarker + " %(progress)j",
+		"--print", "after_move:" + ytDlpFileMarker + " %(filepath)j",
+	}
+}
+
+// ytDlpProgressJSON is the progress dict yt-dlp passes to its progress hooks.
+// Unknown values are null and decode as zero.
+type ytDlpProgressJSON struct {
+	Status             string  `json:"status"`
+	DownloadedBytes    float64 `json:"downloaded_bytes"`
+	TotalBytes         float64 `json:"total_bytes"`
+	TotalBytesEstimate float64 `json:"total_bytes_estimate"`
+	Speed              float64 `json:"speed"`
+	ETA                float64 `json:"eta"`
+	Elapsed            float64 `json:"elapsed"`
+	FragmentIndex      int     `json:"fragment_index"`
+	FragmentCount      int     `json:"fragment_count"`
+	Filename           string  `json:"filename"`
+	Postprocessor      string  `json:"postprocessor"` // Set for postprocess events, e.g. "Merger"
+}
+
+type ytDlpEventKind int
+
+const (
+	ytDlpEventProgress ytDlpEventKind = iota
+	ytDlpEventPostprocess
+	ytDlpEventFile
+)
+
+// ytDlpEvent is one machine-readable line of yt-dlp output.
+type ytDlpEvent struct {
+	kind     ytDlpEventKind
+	progress ytDlpProgressJSON
+	path     string // Final file path for ytDlpEventFile
+}
+
+// parseYtDlpEvent decodes a line printed by the templates from
+// ytDlpProgressArgs. Other lines, and lines with malformed JSON, are left to
+// the text parsers.
+func parseYtDlpEvent(line string) (ytDlpEvent, bool) {
+	line = strings.TrimSpace(line)
+
+	if data, ok := strings.CutPrefix(line, ytDlpFileMarker); ok {
+		var path string
+		if err := json.Unmarshal([]byte(data), &path); err != nil || path == "" {
+			return ytDlpEvent{}, false
+		}
+		return ytDlpEvent{kind: ytDlpEventFile, path: path}, true
+	}
+
+	kind := ytDlpEventProgress
+	data, ok := strings.CutPrefix(line, ytDlpProgressMarker)
+	if !ok {
+		kind = ytDlpEventPostprocess
+		if data, ok = strings.CutPrefix(line, ytDlpPostprocessMarker); !ok {
+			return ytDlpEvent{}, false
+		}
+	}
+
+	var progress ytDlpProgressJSON
+	if err := json.Unmarshal([]byte(data), &progress); err != nil {
+		return ytDlpEvent{}, false
+	}
+	return ytDlpEvent{kind: kind, progress: progress}, true
+}
+
+// total returns the exact or estimated size of the file being downloaded.
+func (p ytDlpProgressJSON) total() int64 {
+	if p.TotalBytes > 0 {
+		return int64(p.TotalBytes)
+	}
+	return int64(p.TotalBytesEstimate)
+}
+
+// hasPercent reports whether the download's completion can be computed.
+func (p ytDlpProgressJSON) hasPercent() bool {
+	return p.total() > 0 || p.FragmentCount > 0 || p.Status == "finished"
+}
+
+// downloadProgress converts a download event. Fragmented downloads without
+// a size estimate report progress by fragment.
+func (p ytDlpProgressJSON) downloadProgress(itemID string) core.DownloadProgress {
+	progress := core.DownloadProgress{
+		ItemID:          itemID,
+		State:           core.StateDownloading,
+		DownloadedBytes: int64(p.DownloadedBytes),
+		TotalBytes:      p.total(),
+		Speed:           int64(p.Speed),
+		ETA:             int64(p.ETA),
+		Fragment:        p.FragmentIndex,
+		FragmentCount:   p.FragmentCount,
+	}
+
+	switch {
+	case p.Status == "finished":
+		progress.Percent = 100
+	case progress.TotalBytes > 0:
+		progress.Percent = min(float64(progress.DownloadedBytes)/float64(progress.TotalBytes)*100, 100)
+	case p.FragmentCount > 0:
+		progress.Percent = min(float64(p.FragmentIndex)/float64(p.FragmentCount)*100, 100)
+	}
+	return progress
+}
+
+// recordingProgress converts a download event of a live recording, which
+// has no known total.
+func (p ytDlpProgressJSON) recordingProgress(itemID string) core.DownloadProgress {
+	return core.DownloadProgress{
+		ItemID:          itemID,
+		State:           core.StateRecording,
+		DownloadedBytes: int64(p.DownloadedBytes),
+		Speed:           int64(p.Speed),
+		ElapsedSeconds:  p.Elapsed,
+		Fragment:        p.FragmentIndex,
+	}
+}
+
+// postprocessProgress converts a postprocessor event into the converting
+// state, naming the running stage.
+func (p ytDlpProgressJSON) postprocessProgress(itemID string) core.DownloadProgress {
+	progress := core.DownloadProgress{
+		ItemID: itemID,
+		State:  core.StateConverting,
+		Stage:  p.Postprocessor,
+	}
+	if p.Status == "finished" {
+		progress.Percent = 100
+	}
+	return progress
+}
@@ -0,0 +1,125 @@
+package downloader
+
+import (
+	"testing"
+
+	"ybdownloader/internal/core"
+)
+
+func TestParseYtDlpEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		wantOK   bool
+		wantKind ytDlpEventKind
+		wantPath string
+	}{
+		{
+			name:     "download progress",
+			line:     `__YBD_PROGRESS__ {"status": "downloading", "downloaded_bytes": 1024, "total_bytes": 4096}`,
+			wantOK:   true,
+			wantKind: ytDlpEventProgress,
+		},
+		{
+			name:     "postprocess",
+			line:     `__YBD_POSTPROCESS__ {"status": "started", "postprocessor": "Merger"}`,
+			wantOK:   true,
+			wantKind: ytDlpEventPostprocess,
+		},
+		{
+			name:     "final path with escapes",
+			line:     `__YBD_FILE__ "C:\\Music\\Song \"Live\".mp3"`,
+			wantOK:   true,
+			wantKind: ytDlpEventFile,
+			wantPath: `C:\Music\Song "Live".mp3`,
+		},
+		{name: "malformed JSON", line: `__YBD_PROGRESS__ {"status":`, wantOK: false},
+		{name: "empty path", line: `__YBD_FILE__ ""`, wantOK: false},
+		{name: "text progress", line: "[download]  45.2% of 10.00MiB at 1.00MiB/s ETA 00:05", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, ok := parseYtDlpEvent(tt.line)
+			if ok != tt.wantOK {
+				t.Fatalf("parseYtDlpEvent() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if ev.kind != tt.wantKind || ev.path != tt.wantPath {
+				t.Errorf("parseYtDlpEvent() = kind %v path %q, want kind %v path %q", ev.kind, ev.path, tt.wantKind, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestYtDlpProgressJSON_downloadProgress(t *testing.T) {
+	tests := []struct {
+		name        string
+		progress    ytDlpProgressJSON
+		wantPercent float64
+		wantTotal   int64
+	}{
+		{
+			name:        "exact size",
+			progress:    ytDlpProgressJSON{Status: "downloading", DownloadedBytes: 2048, TotalBytes: 8192, Speed: 1024, ETA: 6},
+			wantPercent: 25,
+			wantTotal:   8192,
+		},
+		{
+			name:        "estimated size",
+			progress:    ytDlpProgressJSON{Status: "downloading", DownloadedBytes: 500, TotalBytesEstimate: 1000},
+			wantPercent: 50,
+			wantTotal:   1000,
+		},
+		{
+			name:        "fragments only",
+			progress:    ytDlpProgressJSON{Status: "downloading", DownloadedBytes: 500, FragmentIndex: 3, FragmentCount: 12},
+			wantPercent: 25,
+		},
+		{
+			name:        "finished",
+			progress:    ytDlpProgressJSON{Status: "finished", DownloadedBytes: 8192, TotalBytes: 8192},
+			wantPercent: 100,
+			wantTotal:   8192,
+		},
+		{
+			name:        "unknown size",
+			progress:    ytDlpProgressJSON{Status: "downloading", DownloadedBytes: 500},
+			wantPercent: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.progress.downloadProgress("item")
+			if got.State != core.StateDownloading || got.ItemID != "item" {
+				t.Errorf("State = %q, ItemID = %q", got.State, got.ItemID)
+			}
+			if got.Percent != tt.wantPercent || got.TotalBytes != tt.wantTotal {
+				t.Errorf("Percent = %v, TotalBytes = %d, want %v, %d", got.Percent, got.TotalBytes, tt.wantPercent, tt.wantTotal)
+			}
+			if got.Fragment != tt.progress.FragmentIndex || got.FragmentCount != tt.progress.FragmentCount {
+				t.Errorf("Fragment = %d/%d", got.Fragment, got.FragmentCount)
+			}
+		})
+	}
+
+	if (ytDlpProgressJSON{Status: "downloading", DownloadedBytes: 1}).hasPercent() {
+		t.Error("hasPercent() = true for a download of unknown size")
+	}
+}
+
+func TestYtDlpProgressJSON_otherStates(t *testing.T) {
+	rec := ytDlpProgressJSON{Status: "downloading", DownloadedBytes: 4096, Speed: 512, Elapsed: 42.5, FragmentIndex: 7}.recordingProgress("item")
+	if rec.State != core.StateRecording || rec.ElapsedSeconds != 42.5 || rec.DownloadedBytes != 4096 || rec.Percent != 0 {
+		t.Errorf("recordingProgress() = %+v", rec)
+	}
+
+	pp := ytDlpProgressJSON{Status: "started", Postprocessor: "Merger"}.postprocessProgress("item")
+	if pp.State != core.StateConverting || pp.Stage != "Merger" || pp.Percent != 0 {
+		t.Errorf("postprocessProgress() = %+v", pp)
+	}
+	if done := (ytDlpProgressJSON{Status: "finished", Postprocessor: "Merger"}).postprocessProgress("item"); done.Percent != 100 {
+		t.Errorf("finished postprocessProgress() Percent = %v, want 100", done.Percent)
+	}
+}
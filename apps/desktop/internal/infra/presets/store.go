@@ -0,0 +1,173 @@
+// Package presets provides persistence and sharing of user conversion presets.
+package presets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"ybdownloader/internal/core"
+)
+
+const presetsFileName = "presets.json"
+
+// Store implements core.PresetStore with a JSON file in the config dir.
+type Store struct {
+	mu         sync.Mutex
+	filePath   string
+	appVersion string
+}
+
+// NewStore creates a preset store. appVersion is recorded in the files it
+// writes.
+func NewStore(fs core.FileSystem, appVersion string) (*Store, error) {
+	configDir, err := fs.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.EnsureDir(configDir); err != nil {
+		return nil, err
+	}
+	return &Store{
+		filePath:   filepath.Join(configDir, presetsFileName),
+		appVersion: appVersion,
+	}, nil
+}
+
+// Ensure Store implements core.PresetStore.
+var _ core.PresetStore = (*Store)(nil)
+
+// List returns the saved user presets. A missing file means none.
+func (s *Store) List() ([]core.ConversionPreset, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+func (s *Store) load() ([]core.ConversionPreset, error) {
+	presets, err := ReadFile(s.filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return []core.ConversionPreset{}, nil
+	}
+	return presets, err
+}
+
+// Save adds presets, replacing saved presets with the same ID. Each preset
+// must already be valid.
+func (s *Store) Save(presets ...core.ConversionPreset) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved, err := s.load()
+	if err != nil {
+		return err
+	}
+	for _, p := range presets {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+		p.Source = ""
+		if i := slices.IndexFunc(saved, func(o core.ConversionPreset) bool { return o.ID == p.ID }); i >= 0 {
+			saved[i] = p
+		} else {
+			saved = append(saved, p)
+		}
+	}
+	return WriteFile(s.filePath, saved, s.appVersion)
+}
+
+// Delete removes a saved preset. Deleting a modified built-in restores the
+// original.
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved, err := s.load()
+	if err != nil {
+		return err
+	}
+	n := len(saved)
+	saved = slices.DeleteFunc(saved, func(p core.ConversionPreset) bool { return p.ID == id })
+	if len(saved) == n {
+		return fmt.Errorf("preset not found: %s", id)
+	}
+	return WriteFile(s.filePath, saved, s.appVersion)
+}
+
+// ReadFile reads a preset file, migrating older versions. Files written by
+// a newer app are rejected rather than read with fields missing.
+func ReadFile(path string) ([]core.ConversionPreset, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path chosen by the user or the config dir
+	if err != nil {
+		return nil, err
+	}
+	file, err := parseFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return file.Presets, nil
+}
+
+func parseFile(data []byte) (*core.PresetFile, error) {
+	var file core.PresetFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		// Version 0: a bare list of presets
+		var presets []core.ConversionPreset
+		if json.Unmarshal(data, &presets) != nil {
+			return nil, fmt.Errorf("not a preset file: %w", err)
+		}
+		file = core.PresetFile{Presets: presets}
+	}
+	if file.Version > core.PresetFileVersion {
+		return nil, fmt.Errorf("presets were saved by a newer version of the app (format %d, supported %d)", file.Version, core.PresetFileVersion)
+	}
+	migrate(&file)
+	if file.Presets == nil {
+		file.Presets = []core.ConversionPreset{}
+	}
+	return &file, nil
+}
+
+// migrate upgrades a preset file to the current format version.
+func migrate(file *core.PresetFile) {
+	if file.Version < 1 {
+		// Version 0 files may hold whole preset lists copied from the app,
+		// built-in source markers included.
+		for i := range file.Presets {
+			file.Presets[i].Source = ""
+		}
+	}
+	file.Version = core.PresetFileVersion
+}
+
+// WriteFile writes presets in the current format, atomically.
+func WriteFile(path string, presets []core.ConversionPreset, appVersion string) error {
+	out := make([]core.ConversionPreset, len(presets))
+	for i, p := range presets {
+		p.Source = ""
+		out[i] = p
+	}
+	data, err := json.MarshalIndent(core.PresetFile{
+		Version:    core.PresetFileVersion,
+		AppVersion: appVersion,
+		Presets:    out,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	//nolint:gosec // G306: presets are meant to be shared
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath) //nolint:errcheck // best-effort cleanup
+		return err
+	}
+	return nil
+}
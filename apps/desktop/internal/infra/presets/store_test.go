@@ -0,0 +1,141 @@
+package presets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ybdownloader/internal/core"
+)
+
+// mockFS is a mock filesystem rooted in a temp dir.
+type mockFS struct {
+	configDir string
+}
+
+func (m *mockFS) GetConfigDir() (string, error)       { return m.configDir, nil }
+func (m *mockFS) GetMusicDir() (string, error)        { return m.configDir, nil }
+func (m *mockFS) GetDownloadsDir() (string, error)    { return m.configDir, nil }
+func (m *mockFS) GetTempDir() (string, error)         { return os.TempDir(), nil }
+func (m *mockFS) EnsureDir(path string) error         { return os.MkdirAll(path, 0o755) }
+func (m *mockFS) FileExists(path string) bool         { _, err := os.Stat(path); return err == nil }
+func (m *mockFS) DirExists(path string) bool          { _, err := os.Stat(path); return err == nil }
+func (m *mockFS) IsWritable(string) bool              { return true }
+func (m *mockFS) SanitizeFilename(name string) string { return name }
+
+func newTestStore(t *testing.T) (*Store, string) {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := NewStore(&mockFS{configDir: dir}, "1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, dir
+}
+
+func testPreset(id, name string) core.ConversionPreset {
+	return core.ConversionPreset{ID: id, Name: name, Category: "custom", OutputExt: "mp3", FFmpegArgs: []string{"-vn", "-b:a", "96k"}}
+}
+
+func TestStore_SaveListDelete(t *testing.T) {
+	store, dir := newTestStore(t)
+
+	if list, err := store.List(); err != nil || len(list) != 0 {
+		t.Fatalf("List() on a new store = %v, %v", list, err)
+	}
+
+	if err := store.Save(testPreset("a", "A"), testPreset("b", "B")); err != nil {
+		t.Fatal(err)
+	}
+	updated := testPreset("a", "A v2")
+	updated.Source = core.PresetUser
+	if err := store.Save(updated); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Name != "A v2" || list[1].ID != "b" || list[0].Source != "" {
+		t.Errorf("List() = %+v, want A v2 replaced in place and B", list)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, presetsFileName))
+	if !strings.Contains(string(data), `"version": 1`) || !strings.Contains(string(data), `"appVersion": "1.2.3"`) {
+		t.Errorf("presets file is not versioned:\n%s", data)
+	}
+
+	if err := store.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete("a"); err == nil {
+		t.Error("Delete() of a missing preset succeeded")
+	}
+	if list, _ := store.List(); len(list) != 1 {
+		t.Errorf("List() after delete = %+v", list)
+	}
+}
+
+func TestStore_SaveRejectsInvalid(t *testing.T) {
+	store, _ := newTestStore(t)
+	bad := testPreset("bad", "")
+	if err := store.Save(testPreset("ok", "OK"), bad); err == nil {
+		t.Fatal("Save() accepted a preset without a name")
+	}
+	if list, _ := store.List(); len(list) != 0 {
+		t.Errorf("Save() wrote part of a rejected batch: %+v", list)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{"current", `{"version":1,"presets":[{"id":"a","name":"A","ffmpegArgs":["-vn"]}]}`, 1, false},
+		{"bare list", `[{"id":"a","name":"A","source":"builtin","ffmpegArgs":["-vn"]},{"id":"b","name":"B","ffmpegArgs":["-vn"]}]`, 2, false},
+		{"unknown fields", `{"version":1,"presets":[{"id":"a","name":"A","ffmpegArgs":["-vn"],"color":"red"}]}`, 1, false},
+		{"newer version", `{"version":99,"presets":[]}`, 0, true},
+		{"not json", `ffmpeg -i in.mp4 out.mp3`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "shared.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("ReadFile() = %d presets, want %d", len(got), tt.want)
+			}
+			for _, p := range got {
+				if p.Source != "" {
+					t.Errorf("ReadFile() kept source %q", p.Source)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteFile_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.json")
+	in := []core.ConversionPreset{testPreset("a", "A")}
+	in[0].Source = core.PresetModified
+	if err := WriteFile(path, in, "1.2.3"); err != nil {
+		t.Fatal(err)
+	}
+	if in[0].Source != core.PresetModified {
+		t.Error("WriteFile() modified its input")
+	}
+	out, err := ReadFile(path)
+	if err != nil || len(out) != 1 || out[0].Name != "A" || out[0].Source != "" {
+		t.Errorf("ReadFile() = %+v, %v", out, err)
+	}
+}
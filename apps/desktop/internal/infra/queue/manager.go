@@ -2,6 +2,7 @@ package queue
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -10,6 +11,10 @@ import (
 	"ybdownloader/internal/core"
 )
 
+// errClipLive is returned when an item would record a live stream and
+// download only a time range of it, which yt-dlp cannot do together.
+var errClipLive = errors.New("cannot download a time range of a live recording")
+
 // Manager controls the download queue and concurrency.
 type Manager struct {
 	mu         sync.RWMutex
@@ -23,6 +28,10 @@ type Manager struct {
 	downloadSlots chan struct{}
 	cancelFuncs   map[string]context.CancelFunc
 	pendingRemove map[string]bool // Track items to remove after cancellation
+
+	// Rate-limit handling
+	throttle         *throttle
+	rateLimitRetries map[string]int // Requeues per item after rate limits
 }
 
 // New creates a queue manager that handles concurrent downloads.
@@ -42,6 +51,9 @@ func New(downloader core.Downloader, getSettings func() (*core.Settings, error),
 		downloadSlots: make(chan struct{}, maxConcurrent),
 		cancelFuncs:   make(map[string]context.CancelFunc),
 		pendingRemove: make(map[string]bool),
+
+		throttle:         newThrottle(),
+		rateLimitRetries: make(map[string]int),
 	}
 }
 
@@ -266,6 +278,8 @@ func (m *Manager) RetryItem(id string) error {
 
 	item.State = core.StateQueued
 	item.Error = ""
+	item.ErrorCode = ""
+	item.ErrorHint = ""
 	item.UpdatedAt = time.Now()
 	items := m.getAllItemsLocked()
 	m.mu.Unlock()
@@ -274,6 +288,138 @@ func (m *Manager) RetryItem(id string) error {
 	return m.StartDownload(id)
 }
 
+// SetFormatID pins a specific stream format on an item. An empty formatID
+// restores automatic format selection.
+func (m *Manager) SetFormatID(id, formatID string) error {
+	m.mu.Lock()
+	item, exists := m.items[id]
+	if !exists {
+		m.mu.Unlock()
+		return core.ErrQueueItemNotFound
+	}
+
+	if item.State.IsActive() {
+		m.mu.Unlock()
+		return fmt.Errorf("cannot change format while downloading")
+	}
+
+	slog.Debug("pinning format", "id", id, "formatId", formatID)
+	item.FormatID = formatID
+	item.UpdatedAt = time.Now()
+	items := m.getAllItemsLocked()
+	m.mu.Unlock()
+
+	m.emitQueueUpdate(items)
+	return nil
+}
+
+// SetLiveOptions configures how a live stream or premiere is recorded.
+// Passing nil treats the item as a regular video again.
+func (m *Manager) SetLiveOptions(id string, opts *core.LiveOptions) error {
+	m.mu.Lock()
+	item, exists := m.items[id]
+	if !exists {
+		m.mu.Unlock()
+		return core.ErrQueueItemNotFound
+	}
+
+	if item.State.IsActive() {
+		m.mu.Unlock()
+		return fmt.Errorf("cannot change live options while downloading")
+	}
+	if opts != nil && item.Clip != nil {
+		m.mu.Unlock()
+		return errClipLive
+	}
+
+	if opts != nil {
+		copied := *opts
+		copied.MaxDurationSeconds = max(copied.MaxDurationSeconds, 0)
+		opts = &copied
+	}
+	item.Live = opts
+	item.UpdatedAt = time.Now()
+	items := m.getAllItemsLocked()
+	m.mu.Unlock()
+
+	m.emitQueueUpdate(items)
+	return nil
+}
+
+// SetClip limits an item to a time range of the video. Passing nil downloads
+// the whole video again.
+func (m *Manager) SetClip(id string, clip *core.TrimOptions) error {
+	if clip != nil {
+		if err := clip.Validate(); err != nil {
+			return err
+		}
+		copied := *clip
+		clip = &copied
+	}
+
+	m.mu.Lock()
+	item, exists := m.items[id]
+	if !exists {
+		m.mu.Unlock()
+		return core.ErrQueueItemNotFound
+	}
+
+	if item.State.IsActive() {
+		m.mu.Unlock()
+		return fmt.Errorf("cannot change time range while downloading")
+	}
+	if clip != nil && item.Live != nil {
+		m.mu.Unlock()
+		return errClipLive
+	}
+
+	item.Clip = clip
+	item.UpdatedAt = time.Now()
+	items := m.getAllItemsLocked()
+	m.mu.Unlock()
+
+	m.emitQueueUpdate(items)
+	return nil
+}
+
+// StopRecording ends a live recording early, keeping what was captured.
+// Unlike CancelItem, the item completes with a file.
+func (m *Manager) StopRecording(id string) error {
+	stopper, ok := m.downloader.(core.RecordingStopper)
+	if !ok {
+		return fmt.Errorf("downloader cannot stop recordings")
+	}
+
+	m.mu.Lock()
+	item, exists := m.items[id]
+	if !exists {
+		m.mu.Unlock()
+		return core.ErrQueueItemNotFound
+	}
+	if item.State != core.StateRecording {
+		m.mu.Unlock()
+		return fmt.Errorf("item is not recording")
+	}
+	m.mu.Unlock()
+
+	// Only mark the item once the recording is actually stopping, so a
+	// failed stop leaves it recording.
+	if err := stopper.StopRecording(id); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	if item.State == core.StateRecording {
+		item.State = core.StateStopRequested
+		item.UpdatedAt = time.Now()
+	}
+	items := m.getAllItemsLocked()
+	m.mu.Unlock()
+
+	m.emitQueueUpdate(items)
+	return nil
+}
+
 // ClearCompleted removes all completed items.
 func (m *Manager) ClearCompleted() error {
 	m.mu.Lock()
@@ -326,6 +472,14 @@ func (m *Manager) FetchMetadata(ctx context.Context, id string) error {
 }
 
 func (m *Manager) processDownload(id string) {
+	// Runs after every other deferred cleanup, once the slot is free again.
+	var requeue bool
+	defer func() {
+		if requeue {
+			m.restartThrottled(id)
+		}
+	}()
+
 	// Acquire download slot
 	m.downloadSlots <- struct{}{}
 	defer func() { <-m.downloadSlots }()
@@ -366,23 +520,47 @@ func (m *Manager) processDownload(id string) {
 		m.mu.Unlock()
 	}
 
+	// Wait out any rate-limit backoff
+	if m.throttle.blocked() {
+		m.updateItemState(id, core.StateThrottled, "")
+	}
+	if err := m.throttle.acquire(ctx); err != nil {
+		m.updateItemState(id, core.StateCancelled, "")
+		return
+	}
+	defer m.throttle.release()
+
 	// Fetch metadata if not present
 	if item.Metadata == nil {
 		m.updateItemState(id, core.StateFetchingMetadata, "")
-		if err := m.FetchMetadata(ctx, id); err != nil {
-			if ctx.Err() == context.Canceled {
+		err := m.throttle.waitForRequest(ctx)
+		if err == nil {
+			err = m.FetchMetadata(ctx, id)
+		}
+		if err != nil {
+			switch {
+			case ctx.Err() == context.Canceled:
 				m.updateItemState(id, core.StateCancelled, "")
-			} else {
-				m.updateItemState(id, core.StateFailed, err.Error())
+				return
+			case errors.Is(err, core.ErrLiveNotStarted) && m.waitsForLive(id):
+				// Metadata becomes available once the stream starts.
+				slog.Info("stream not started yet, waiting", "id", id)
+			default:
+				requeue = m.failOrRequeue(id, err)
+				return
 			}
-			return
 		}
 	}
 
 	// Start download
-	m.updateItemState(id, core.StateDownloading, "")
+	state, err := m.initialDownloadState(id)
+	if err != nil {
+		m.failItem(id, err)
+		return
+	}
+	m.updateItemState(id, state, "")
 
-	err := m.downloader.Download(ctx, item, func(progress core.DownloadProgress) {
+	err = m.downloader.Download(ctx, item, func(progress core.DownloadProgress) {
 		m.emit("download:progress", progress)
 
 		// Update item state from progress
@@ -398,7 +576,7 @@ func (m *Manager) processDownload(id string) {
 		if ctx.Err() == context.Canceled {
 			m.updateItemState(id, core.StateCancelled, "")
 		} else {
-			m.updateItemState(id, core.StateFailed, err.Error())
+			requeue = m.failOrRequeue(id, err)
 		}
 		return
 	}
@@ -409,18 +587,142 @@ func (m *Manager) processDownload(id string) {
 		i.State = core.StateCompleted
 		i.UpdatedAt = time.Now()
 	}
+	delete(m.rateLimitRetries, id)
 	items := m.getAllItemsLocked()
 	m.mu.Unlock()
 
+	if state, changed := m.throttle.succeeded(cap(m.downloadSlots)); changed {
+		m.emitThrottle(state)
+	}
 	m.emitQueueUpdate(items)
 	m.emit("download:complete", map[string]string{"itemId": id, "filePath": item.FilePath})
 }
 
+// waitsForLive reports whether an item should wait for its stream to start.
+func (m *Manager) waitsForLive(id string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	item, ok := m.items[id]
+	return ok && item.Live != nil && item.Live.WaitForStart
+}
+
+// initialDownloadState marks detected live streams for recording and picks
+// the state a download starts in. Clipped items that turn out to be live
+// cannot be recorded.
+func (m *Manager) initialDownloadState(id string) (core.DownloadState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.items[id]
+	if !ok {
+		return core.StateDownloading, nil
+	}
+
+	var status core.LiveStatus
+	if item.Metadata != nil {
+		status = item.Metadata.LiveStatus
+	}
+	if status == core.LiveStatusLive && item.Live == nil {
+		if item.Clip != nil {
+			return "", errClipLive
+		}
+		item.Live = &core.LiveOptions{}
+	}
+
+	switch {
+	case item.Live == nil:
+		return core.StateDownloading, nil
+	case item.Live.WaitForStart && (item.Metadata == nil || status == core.LiveStatusUpcoming):
+		return core.StateWaitingForLive, nil
+	default:
+		return core.StateRecording, nil
+	}
+}
+
 func (m *Manager) updateItemState(id string, state core.DownloadState, errMsg string) {
 	m.mu.Lock()
 	if item, ok := m.items[id]; ok {
 		item.State = state
 		item.Error = errMsg
+		item.ErrorCode = ""
+		item.ErrorHint = ""
+		item.UpdatedAt = time.Now()
+	}
+	items := m.getAllItemsLocked()
+	m.mu.Unlock()
+
+	m.emitQueueUpdate(items)
+}
+
+// failOrRequeue fails an item, unless it was rate limited and has retries
+// left: then the queue throttles down and the item waits to be restarted.
+// It reports whether the item should be requeued.
+func (m *Manager) failOrRequeue(id string, err error) bool {
+	if core.AsAppError(err, "").Code != core.ErrCodeRateLimited {
+		m.failItem(id, err)
+		return false
+	}
+
+	m.emitThrottle(m.throttle.rateLimited(cap(m.downloadSlots)))
+
+	m.mu.Lock()
+	m.rateLimitRetries[id]++
+	retries := m.rateLimitRetries[id]
+	if retries > maxRateLimitRetries {
+		delete(m.rateLimitRetries, id)
+	}
+	m.mu.Unlock()
+
+	if retries > maxRateLimitRetries {
+		m.failItem(id, err)
+		return false
+	}
+
+	slog.Warn("rate limited, retrying after backoff", "id", id, "attempt", retries)
+	m.updateItemState(id, core.StateThrottled, "")
+	return true
+}
+
+// restartThrottled starts a requeued item again unless it was cancelled or
+// removed in the meantime.
+func (m *Manager) restartThrottled(id string) {
+	m.mu.RLock()
+	item, ok := m.items[id]
+	restart := ok && item.State == core.StateThrottled
+	m.mu.RUnlock()
+
+	if restart {
+		go m.processDownload(id)
+	}
+}
+
+// ThrottleState returns how the queue is currently adapting to rate limits.
+func (m *Manager) ThrottleState() core.ThrottleState {
+	return m.throttle.state(cap(m.downloadSlots))
+}
+
+// emitThrottle publishes a throttle change and passes the request delay on
+// to downloaders that pace their own requests.
+func (m *Manager) emitThrottle(state core.ThrottleState) {
+	if pacer, ok := m.downloader.(core.RequestPacer); ok {
+		pacer.SetRequestDelay(time.Duration(state.RequestDelaySeconds * float64(time.Second)))
+	}
+	m.emit("queue:throttle", state)
+}
+
+// failItem marks an item as failed, keeping the error code, message and a
+// remediation hint so the UI and retry logic can branch on them.
+// Untyped errors are reported as DOWNLOAD_FAILED with their own text.
+func (m *Manager) failItem(id string, err error) {
+	appErr := core.AsAppError(err, core.ErrCodeDownloadFailed)
+	slog.Error("download failed", "id", id, "code", appErr.Code, "error", err)
+
+	m.mu.Lock()
+	if item, ok := m.items[id]; ok {
+		item.State = core.StateFailed
+		item.Error = appErr.Message
+		item.ErrorCode = appErr.Code
+		item.ErrorHint = core.ErrorHint(appErr.Code)
 		item.UpdatedAt = time.Now()
 	}
 	items := m.getAllItemsLocked()
@@ -2,6 +2,7 @@ package queue
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
 	"time"
@@ -271,6 +272,120 @@ func TestManager_RetryItem_NotRetryable(t *testing.T) {
 	}
 }
 
+func TestManager_SetFormatID(t *testing.T) {
+	var events []string
+	var mu sync.Mutex
+	emit := func(event string, data interface{}) {
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}
+
+	m := New(&mockDownloader{}, defaultSettings, emit)
+	m.AddItem("id1", "https://youtube.com/watch?v=test", core.FormatMP4, "/tmp")
+
+	if err := m.SetFormatID("id1", "137"); err != nil {
+		t.Fatalf("SetFormatID() error = %v", err)
+	}
+	item, _ := m.GetItem("id1")
+	if item.FormatID != "137" {
+		t.Errorf("FormatID = %q, want 137", item.FormatID)
+	}
+
+	if err := m.SetFormatID("id1", ""); err != nil {
+		t.Fatalf("SetFormatID() clear error = %v", err)
+	}
+	if item.FormatID != "" {
+		t.Errorf("FormatID = %q, want empty after clearing", item.FormatID)
+	}
+
+	mu.Lock()
+	if len(events) != 3 {
+		t.Errorf("expected 3 queue:updated events, got %v", events)
+	}
+	mu.Unlock()
+}
+
+func TestManager_SetFormatID_Active(t *testing.T) {
+	m := New(&mockDownloader{}, defaultSettings, func(string, interface{}) {})
+	m.AddItem("id1", "https://youtube.com/watch?v=test", core.FormatMP4, "/tmp")
+
+	m.mu.Lock()
+	m.items["id1"].State = core.StateDownloading
+	m.mu.Unlock()
+
+	if err := m.SetFormatID("id1", "137"); err == nil {
+		t.Error("expected error when pinning format on an active item")
+	}
+}
+
+func TestManager_SetClip(t *testing.T) {
+	m := New(&mockDownloader{}, defaultSettings, func(string, interface{}) {})
+	m.AddItem("id1", "https://youtube.com/watch?v=test", core.FormatMP4, "/tmp")
+
+	clip := &core.TrimOptions{StartTime: 90, EndTime: 210}
+	if err := m.SetClip("id1", clip); err != nil {
+		t.Fatalf("SetClip() error = %v", err)
+	}
+	item, _ := m.GetItem("id1")
+	if item.Clip == nil || item.Clip == clip || *item.Clip != *clip {
+		t.Errorf("Clip = %+v, want a copy of %+v", item.Clip, clip)
+	}
+
+	if err := m.SetClip("id1", &core.TrimOptions{StartTime: 30, EndTime: 10}); err == nil {
+		t.Error("expected error for an end time before the start time")
+	}
+
+	if err := m.SetClip("id1", nil); err != nil {
+		t.Fatalf("SetClip() clear error = %v", err)
+	}
+	if item.Clip != nil {
+		t.Errorf("Clip = %+v, want nil after clearing", item.Clip)
+	}
+
+	m.mu.Lock()
+	m.items["id1"].Live = &core.LiveOptions{}
+	m.mu.Unlock()
+	if err := m.SetClip("id1", clip); err == nil {
+		t.Error("expected error when clipping a live recording")
+	}
+
+	m.mu.Lock()
+	m.items["id1"].Live = nil
+	m.items["id1"].State = core.StateDownloading
+	m.mu.Unlock()
+	if err := m.SetClip("id1", clip); err == nil {
+		t.Error("expected error when changing the range of an active item")
+	}
+
+	if err := m.SetClip("missing", clip); err != core.ErrQueueItemNotFound {
+		t.Errorf("SetClip() error = %v, want ErrQueueItemNotFound", err)
+	}
+}
+
+func TestManager_SetLiveOptions_Clipped(t *testing.T) {
+	m := New(&mockDownloader{}, defaultSettings, func(string, interface{}) {})
+	m.AddItem("id1", "https://youtube.com/watch?v=test", core.FormatMP4, "/tmp")
+
+	if err := m.SetClip("id1", &core.TrimOptions{StartTime: 90, EndTime: 210}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetLiveOptions("id1", &core.LiveOptions{}); err == nil {
+		t.Error("expected error when recording a clipped item")
+	}
+	if err := m.SetLiveOptions("id1", nil); err != nil {
+		t.Errorf("SetLiveOptions() clear error = %v", err)
+	}
+}
+
+func TestManager_SetFormatID_NotFound(t *testing.T) {
+	m := New(&mockDownloader{}, defaultSettings, func(string, interface{}) {})
+
+	if err := m.SetFormatID("nonexistent", "137"); err != core.ErrQueueItemNotFound {
+		t.Errorf("Expected ErrQueueItemNotFound, got %v", err)
+	}
+}
+
 func TestManager_StartDownload(t *testing.T) {
 	progressUpdates := make([]core.DownloadProgress, 0)
 	var mu sync.Mutex
@@ -765,3 +880,366 @@ func TestManager_ProcessDownload_RefreshesSavePath(t *testing.T) {
 		t.Errorf("SavePath = %q, want %q (should refresh from settings)", capturedSavePath, "/new/path")
 	}
 }
+
+// stoppableDownloader records until StopRecording is called.
+type stoppableDownloader struct {
+	mockDownloader
+	stop    chan struct{}
+	stopErr error
+}
+
+func (s *stoppableDownloader) StopRecording(string) error {
+	if s.stopErr != nil {
+		return s.stopErr
+	}
+	close(s.stop)
+	return nil
+}
+
+func waitForState(t *testing.T, m *Manager, id string, want core.DownloadState) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		m.mu.RLock()
+		state := m.items[id].State
+		m.mu.RUnlock()
+		if state == want {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("item %s did not reach state %q", id, want)
+}
+
+func TestManager_LiveStreamDetected(t *testing.T) {
+	var gotLive *core.LiveOptions
+	var mu sync.Mutex
+	mock := &mockDownloader{
+		fetchMetadataFunc: func(context.Context, string) (*core.VideoMetadata, error) {
+			return &core.VideoMetadata{ID: "live", LiveStatus: core.LiveStatusLive}, nil
+		},
+		downloadFunc: func(_ context.Context, item *core.QueueItem, _ func(core.DownloadProgress)) error {
+			mu.Lock()
+			gotLive = item.Live
+			mu.Unlock()
+			return nil
+		},
+	}
+
+	m := New(mock, defaultSettings, func(string, interface{}) {})
+	m.AddItem("id1", "https://youtube.com/watch?v=live", core.FormatMP4, "/tmp")
+	if err := m.StartDownload("id1"); err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, m, "id1", core.StateCompleted)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if gotLive == nil {
+		t.Error("live stream should be downloaded with live options")
+	}
+}
+
+func TestManager_LiveStreamDetected_Clipped(t *testing.T) {
+	var downloaded bool
+	var mu sync.Mutex
+	mock := &mockDownloader{
+		fetchMetadataFunc: func(context.Context, string) (*core.VideoMetadata, error) {
+			return &core.VideoMetadata{ID: "live", LiveStatus: core.LiveStatusLive}, nil
+		},
+		downloadFunc: func(context.Context, *core.QueueItem, func(core.DownloadProgress)) error {
+			mu.Lock()
+			downloaded = true
+			mu.Unlock()
+			return nil
+		},
+	}
+
+	m := New(mock, defaultSettings, func(string, interface{}) {})
+	m.AddItem("id1", "https://youtube.com/watch?v=live", core.FormatMP4, "/tmp")
+	if err := m.SetClip("id1", &core.TrimOptions{StartTime: 0, EndTime: 60}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.StartDownload("id1"); err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, m, "id1", core.StateFailed)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if downloaded {
+		t.Error("a clipped item was downloaded although the stream is live")
+	}
+	if item, _ := m.GetItem("id1"); item.Live != nil {
+		t.Errorf("Live = %+v, want a clipped item left unmarked", item.Live)
+	}
+}
+
+func TestManager_LiveNotStarted(t *testing.T) {
+	notStarted := core.NewAppError(core.ErrCodeLiveNotStarted, "not started", core.ErrLiveNotStarted)
+
+	tests := []struct {
+		name      string
+		live      *core.LiveOptions
+		wantState core.DownloadState
+	}{
+		{"waits when enabled", &core.LiveOptions{WaitForStart: true}, core.StateWaitingForLive},
+		{"fails otherwise", nil, core.StateFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := make(chan struct{})
+			mock := &mockDownloader{
+				fetchMetadataFunc: func(context.Context, string) (*core.VideoMetadata, error) {
+					return nil, notStarted
+				},
+				downloadFunc: func(context.Context, *core.QueueItem, func(core.DownloadProgress)) error {
+					<-release
+					return nil
+				},
+			}
+			defer close(release)
+
+			m := New(mock, defaultSettings, func(string, interface{}) {})
+			m.AddItem("id1", "https://youtube.com/watch?v=soon", core.FormatMP4, "/tmp")
+			if err := m.SetLiveOptions("id1", tt.live); err != nil {
+				t.Fatal(err)
+			}
+			if err := m.StartDownload("id1"); err != nil {
+				t.Fatal(err)
+			}
+			waitForState(t, m, "id1", tt.wantState)
+		})
+	}
+}
+
+func TestManager_StopRecording(t *testing.T) {
+	dl := &stoppableDownloader{stop: make(chan struct{})}
+	dl.downloadFunc = func(_ context.Context, item *core.QueueItem, onProgress func(core.DownloadProgress)) error {
+		onProgress(core.DownloadProgress{ItemID: item.ID, State: core.StateRecording, ElapsedSeconds: 1})
+		<-dl.stop
+		return nil
+	}
+
+	m := New(dl, defaultSettings, func(string, interface{}) {})
+	m.AddItem("id1", "https://youtube.com/watch?v=live", core.FormatMP4, "/tmp")
+	if err := m.StopRecording("id1"); err == nil {
+		t.Error("StopRecording() expected error for item that is not recording")
+	}
+
+	if err := m.SetLiveOptions("id1", &core.LiveOptions{MaxDurationSeconds: -5}); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.items["id1"].Live.MaxDurationSeconds; got != 0 {
+		t.Errorf("MaxDurationSeconds = %d, want negative clamped to 0", got)
+	}
+
+	if err := m.StartDownload("id1"); err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, m, "id1", core.StateRecording)
+
+	if err := m.SetLiveOptions("id1", nil); err == nil {
+		t.Error("SetLiveOptions() expected error while recording")
+	}
+	if err := m.StopRecording("id1"); err != nil {
+		t.Fatalf("StopRecording() error = %v", err)
+	}
+	waitForState(t, m, "id1", core.StateCompleted)
+}
+
+func TestManager_StopRecording_Fails(t *testing.T) {
+	dl := &stoppableDownloader{stop: make(chan struct{}), stopErr: errors.New("no active recording")}
+	dl.downloadFunc = func(_ context.Context, item *core.QueueItem, onProgress func(core.DownloadProgress)) error {
+		onProgress(core.DownloadProgress{ItemID: item.ID, State: core.StateRecording, ElapsedSeconds: 1})
+		<-dl.stop
+		return nil
+	}
+
+	m := New(dl, defaultSettings, func(string, interface{}) {})
+	m.AddItem("id1", "https://youtube.com/watch?v=live", core.FormatMP4, "/tmp")
+	if err := m.SetLiveOptions("id1", &core.LiveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.StartDownload("id1"); err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, m, "id1", core.StateRecording)
+
+	if err := m.StopRecording("id1"); err == nil {
+		t.Fatal("StopRecording() error = nil, want the downloader's error")
+	}
+	m.mu.RLock()
+	state := m.items["id1"].State
+	m.mu.RUnlock()
+	if state != core.StateRecording {
+		t.Errorf("State = %q after a failed stop, want still recording", state)
+	}
+
+	dl.stopErr = nil
+	if err := m.StopRecording("id1"); err != nil {
+		t.Fatalf("StopRecording() error = %v", err)
+	}
+	waitForState(t, m, "id1", core.StateCompleted)
+}
+
+func TestManager_StopRecording_Unsupported(t *testing.T) {
+	m := New(&mockDownloader{}, defaultSettings, func(string, interface{}) {})
+	m.AddItem("id1", "https://youtube.com/watch?v=live", core.FormatMP4, "/tmp")
+	if err := m.StopRecording("id1"); err == nil {
+		t.Error("StopRecording() expected error when downloader cannot stop recordings")
+	}
+}
+
+func TestManager_FailureKeepsErrorCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode string
+		wantMsg  string
+		wantHint bool
+	}{
+		{
+			name:     "typed error",
+			err:      core.NewAppError(core.ErrCodeGeoBlocked, "Not available in your country", core.ErrGeoBlocked),
+			wantCode: core.ErrCodeGeoBlocked,
+			wantMsg:  "Not available in your country",
+			wantHint: true,
+		},
+		{
+			name:     "untyped error",
+			err:      context.DeadlineExceeded,
+			wantCode: core.ErrCodeDownloadFailed,
+			wantMsg:  context.DeadlineExceeded.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockDownloader{
+				downloadFunc: func(context.Context, *core.QueueItem, func(core.DownloadProgress)) error {
+					return tt.err
+				},
+			}
+			m := New(mock, defaultSettings, func(string, interface{}) {})
+			m.AddItem("id1", "https://youtube.com/watch?v=test", core.FormatMP4, "/tmp")
+			if err := m.StartDownload("id1"); err != nil {
+				t.Fatal(err)
+			}
+			waitForState(t, m, "id1", core.StateFailed)
+
+			m.mu.RLock()
+			item := *m.items["id1"]
+			m.mu.RUnlock()
+			if item.ErrorCode != tt.wantCode || item.Error != tt.wantMsg {
+				t.Errorf("ErrorCode = %q, Error = %q, want %q, %q", item.ErrorCode, item.Error, tt.wantCode, tt.wantMsg)
+			}
+			if (item.ErrorHint != "") != tt.wantHint {
+				t.Errorf("ErrorHint = %q, want hint %v", item.ErrorHint, tt.wantHint)
+			}
+		})
+	}
+}
+
+func TestManager_RetryClearsErrorCode(t *testing.T) {
+	m := New(&mockDownloader{}, defaultSettings, func(string, interface{}) {})
+	m.AddItem("id1", "https://youtube.com/watch?v=test", core.FormatMP4, "/tmp")
+	m.failItem("id1", core.NewAppError(core.ErrCodeRateLimited, "Rate limited", nil))
+
+	if err := m.RetryItem("id1"); err != nil {
+		t.Fatalf("RetryItem() error = %v", err)
+	}
+	m.mu.RLock()
+	item := *m.items["id1"]
+	m.mu.RUnlock()
+	if item.Error != "" || item.ErrorCode != "" || item.ErrorHint != "" {
+		t.Errorf("error fields not cleared: %q %q %q", item.Error, item.ErrorCode, item.ErrorHint)
+	}
+}
+
+// pacingDownloader records request delays set by the manager.
+type pacingDownloader struct {
+	mockDownloader
+	mu     sync.Mutex
+	delays []time.Duration
+}
+
+func (p *pacingDownloader) SetRequestDelay(delay time.Duration) {
+	p.mu.Lock()
+	p.delays = append(p.delays, delay)
+	p.mu.Unlock()
+}
+
+func TestManager_RateLimitRequeues(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	var throttleEvents []core.ThrottleState
+
+	dl := &pacingDownloader{}
+	dl.downloadFunc = func(context.Context, *core.QueueItem, func(core.DownloadProgress)) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts == 1 {
+			return core.NewAppError(core.ErrCodeRateLimited, "rate limited", core.ErrRateLimited)
+		}
+		return nil
+	}
+	emit := func(event string, data interface{}) {
+		if event == "queue:throttle" {
+			mu.Lock()
+			throttleEvents = append(throttleEvents, data.(core.ThrottleState))
+			mu.Unlock()
+		}
+	}
+
+	m := New(dl, defaultSettings, emit)
+	m.throttle.initialBackoff = 20 * time.Millisecond
+	m.throttle.initialDelay = time.Millisecond
+	m.AddItem("id1", "https://youtube.com/watch?v=test", core.FormatMP4, "/tmp")
+	if err := m.StartDownload("id1"); err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, m, "id1", core.StateCompleted)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+	if len(throttleEvents) == 0 || !throttleEvents[0].Throttled || throttleEvents[0].Concurrency != 1 {
+		t.Errorf("throttle events = %+v, want a throttled state first", throttleEvents)
+	}
+	dl.mu.Lock()
+	if len(dl.delays) == 0 || dl.delays[0] != time.Millisecond {
+		t.Errorf("request delays = %v, want the throttle delay passed on", dl.delays)
+	}
+	dl.mu.Unlock()
+	if state := m.ThrottleState(); !state.Throttled || state.RateLimitCount != 1 {
+		t.Errorf("ThrottleState() = %+v, want still throttled after one success", state)
+	}
+}
+
+func TestManager_RateLimitGivesUp(t *testing.T) {
+	mock := &mockDownloader{
+		downloadFunc: func(context.Context, *core.QueueItem, func(core.DownloadProgress)) error {
+			return core.NewAppError(core.ErrCodeRateLimited, "rate limited", core.ErrRateLimited)
+		},
+	}
+	m := New(mock, defaultSettings, func(string, interface{}) {})
+	m.throttle.initialBackoff = time.Millisecond
+	m.throttle.initialDelay = time.Millisecond
+	m.AddItem("id1", "https://youtube.com/watch?v=test", core.FormatMP4, "/tmp")
+	if err := m.StartDownload("id1"); err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, m, "id1", core.StateFailed)
+
+	m.mu.RLock()
+	code := m.items["id1"].ErrorCode
+	m.mu.RUnlock()
+	if code != core.ErrCodeRateLimited {
+		t.Errorf("ErrorCode = %q, want %q", code, core.ErrCodeRateLimited)
+	}
+}
@@ -0,0 +1,207 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"ybdownloader/internal/core"
+)
+
+// Throttle tuning. Each rate limit halves the allowed concurrency, doubles
+// the global pause and the gap between metadata requests; every
+// throttleRecoverAfter successful downloads undo one step.
+const (
+	throttleInitialBackoff = 30 * time.Second
+	throttleMaxBackoff     = 10 * time.Minute
+	throttleInitialDelay   = 2 * time.Second
+	throttleMaxDelay       = 30 * time.Second
+	throttleRecoverAfter   = 2
+
+	// maxRateLimitRetries is how often a rate-limited item is requeued
+	// before it fails.
+	maxRateLimitRetries = 3
+)
+
+// throttle adapts how fast the queue works after the site rate limits us.
+// Download slots still cap concurrency at MaxConcurrentDownloads; the
+// throttle lowers that further while engaged.
+type throttle struct {
+	mu  sync.Mutex
+	now func() time.Time
+
+	// First pause and request gap after a rate limit; tests shorten them.
+	initialBackoff time.Duration
+	initialDelay   time.Duration
+
+	limit        int           // Allowed concurrent downloads; 0 when not throttled
+	active       int           // Downloads currently past acquire
+	backoff      time.Duration // Length of the last global pause
+	pauseUntil   time.Time     // No download starts before this
+	requestDelay time.Duration // Minimum gap between metadata requests
+	nextRequest  time.Time
+	successes    int // Successful downloads since the last step
+	rateLimits   int // Rate limits since the throttle engaged
+
+	changed chan struct{} // Closed and replaced whenever waiters may proceed
+}
+
+func newThrottle() *throttle {
+	return &throttle{
+		now:            time.Now,
+		initialBackoff: throttleInitialBackoff,
+		initialDelay:   throttleInitialDelay,
+		changed:        make(chan struct{}),
+	}
+}
+
+// blocked reports whether acquire would wait right now.
+func (t *throttle) blocked() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.pauseUntil.After(t.now()) || (t.limit > 0 && t.active >= t.limit)
+}
+
+// acquire waits until a download may start: outside a global pause and
+// below the throttled concurrency. Each successful acquire must be paired
+// with release.
+func (t *throttle) acquire(ctx context.Context) error {
+	for {
+		t.mu.Lock()
+		wait := t.pauseUntil.Sub(t.now())
+		if wait <= 0 && (t.limit == 0 || t.active < t.limit) {
+			t.active++
+			t.mu.Unlock()
+			return nil
+		}
+		changed := t.changed
+		t.mu.Unlock()
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-timeout:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+func (t *throttle) release() {
+	t.mu.Lock()
+	t.active--
+	t.broadcastLocked()
+	t.mu.Unlock()
+}
+
+// waitForRequest spaces out metadata requests while throttled.
+func (t *throttle) waitForRequest(ctx context.Context) error {
+	t.mu.Lock()
+	now := t.now()
+	start := now
+	if t.nextRequest.After(now) {
+		start = t.nextRequest
+	}
+	t.nextRequest = start.Add(t.requestDelay)
+	wait := start.Sub(now)
+	t.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// rateLimited engages or tightens the throttle after a rate limit.
+func (t *throttle) rateLimited(maxConcurrent int) core.ThrottleState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current := t.limit
+	if current == 0 {
+		current = maxConcurrent
+	}
+	t.limit = max(current/2, 1)
+	t.backoff = min(max(t.backoff*2, t.initialBackoff), throttleMaxBackoff)
+	t.pauseUntil = t.now().Add(t.backoff)
+	t.requestDelay = min(max(t.requestDelay*2, t.initialDelay), throttleMaxDelay)
+	t.successes = 0
+	t.rateLimits++
+	t.broadcastLocked()
+
+	return t.stateLocked(maxConcurrent)
+}
+
+// succeeded records a completed download and, after enough of them, steps
+// back toward normal operation. It reports whether the state changed.
+func (t *throttle) succeeded(maxConcurrent int) (core.ThrottleState, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.limit == 0 {
+		return t.stateLocked(maxConcurrent), false
+	}
+	t.successes++
+	if t.successes < throttleRecoverAfter {
+		return t.stateLocked(maxConcurrent), false
+	}
+
+	t.successes = 0
+	t.limit++
+	t.backoff /= 2
+	t.requestDelay /= 2
+	if t.limit >= maxConcurrent {
+		t.limit = 0
+		t.backoff = 0
+		t.requestDelay = 0
+		t.rateLimits = 0
+	}
+	t.broadcastLocked()
+
+	return t.stateLocked(maxConcurrent), true
+}
+
+func (t *throttle) state(maxConcurrent int) core.ThrottleState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stateLocked(maxConcurrent)
+}
+
+func (t *throttle) stateLocked(maxConcurrent int) core.ThrottleState {
+	state := core.ThrottleState{
+		Throttled:           t.limit > 0,
+		Concurrency:         maxConcurrent,
+		MaxConcurrency:      maxConcurrent,
+		RequestDelaySeconds: t.requestDelay.Seconds(),
+		RateLimitCount:      t.rateLimits,
+	}
+	if t.limit > 0 {
+		state.Concurrency = t.limit
+	}
+	if t.pauseUntil.After(t.now()) {
+		state.BackoffUntil = t.pauseUntil.Unix()
+	}
+	return state
+}
+
+func (t *throttle) broadcastLocked() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
@@ -0,0 +1,125 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestThrottle_RateLimitedAndRecovery(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	th := newThrottle()
+	th.now = func() time.Time { return now }
+
+	state := th.rateLimited(4)
+	if !state.Throttled || state.Concurrency != 2 || state.MaxConcurrency != 4 {
+		t.Errorf("after first rate limit: %+v, want concurrency 2 of 4", state)
+	}
+	if state.BackoffUntil != now.Add(throttleInitialBackoff).Unix() {
+		t.Errorf("BackoffUntil = %d, want %d", state.BackoffUntil, now.Add(throttleInitialBackoff).Unix())
+	}
+	if state.RequestDelaySeconds != throttleInitialDelay.Seconds() {
+		t.Errorf("RequestDelaySeconds = %v", state.RequestDelaySeconds)
+	}
+	if !th.blocked() {
+		t.Error("blocked() = false during backoff")
+	}
+
+	state = th.rateLimited(4)
+	if state.Concurrency != 1 || state.RateLimitCount != 2 || th.backoff != 2*throttleInitialBackoff {
+		t.Errorf("after second rate limit: %+v, backoff %v", state, th.backoff)
+	}
+
+	now = now.Add(time.Hour)
+	if th.blocked() {
+		t.Error("blocked() = true after the backoff ended")
+	}
+
+	steps := []int{1, 2, 2, 3, 3, 4}
+	for i, want := range steps {
+		state, _ = th.succeeded(4)
+		if state.Concurrency != want {
+			t.Errorf("after %d successes: concurrency %d, want %d", i+1, state.Concurrency, want)
+		}
+	}
+	if state.Throttled || state.RequestDelaySeconds != 0 || state.RateLimitCount != 0 {
+		t.Errorf("throttle not fully recovered: %+v", state)
+	}
+	if _, changed := th.succeeded(4); changed {
+		t.Error("succeeded() changed state while not throttled")
+	}
+}
+
+func TestThrottle_BackoffIsCapped(t *testing.T) {
+	th := newThrottle()
+	for range 10 {
+		th.rateLimited(2)
+	}
+	if th.backoff != throttleMaxBackoff || th.requestDelay != throttleMaxDelay {
+		t.Errorf("backoff %v, delay %v, want caps %v, %v", th.backoff, th.requestDelay, throttleMaxBackoff, throttleMaxDelay)
+	}
+}
+
+func TestThrottle_AcquireRespectsLimit(t *testing.T) {
+	th := newThrottle()
+	th.initialBackoff = 0
+	th.rateLimited(2) // limit 1, no pause
+
+	ctx := context.Background()
+	if err := th.acquire(ctx); err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		if err := th.acquire(ctx); err == nil {
+			close(acquired)
+		}
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second acquire should wait for release")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	th.release()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("second acquire did not proceed after release")
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := th.acquire(cancelled); err == nil {
+		t.Error("acquire() should fail when the context is cancelled")
+	}
+}
+
+func TestThrottle_WaitForRequest(t *testing.T) {
+	th := newThrottle()
+	ctx := context.Background()
+
+	start := time.Now()
+	for range 3 {
+		if err := th.waitForRequest(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("unthrottled requests waited %v", elapsed)
+	}
+
+	th.requestDelay = 30 * time.Millisecond
+	th.nextRequest = time.Time{}
+	start = time.Now()
+	for range 3 {
+		if err := th.waitForRequest(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("throttled requests took %v, want at least 60ms", elapsed)
+	}
+}
@@ -3,6 +3,7 @@ package settings
 
 import (
 	"encoding/json"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sync"
@@ -83,6 +84,13 @@ func (s *Store) Load() (*core.Settings, error) {
 	// Migrate if needed
 	settings = s.migrate(settings)
 
+	// Drop extra yt-dlp flags saved before they were validated, rather than
+	// resetting everything below
+	if err := core.ValidateYtDlpFlags(settings.YtDlpExtraFlags); err != nil {
+		slog.Warn("dropping disallowed yt-dlp flags", "error", err)
+		settings.YtDlpExtraFlags = core.AllowedYtDlpFlags(settings.YtDlpExtraFlags)
+	}
+
 	// Validate
 	if err := settings.Validate(); err != nil {
 		musicDir, _ := s.fs.GetMusicDir()
@@ -154,6 +162,16 @@ func (s *Store) migrate(settings core.Settings) core.Settings {
 			settings.UpdateChannel = core.UpdateChannelStable
 		}
 	}
+	if settings.Version < 4 {
+		if settings.AllowedSites == nil {
+			settings.AllowedSites = core.DefaultAllowedSites()
+		}
+	}
+	// Version 5 added BackendFallback. It stays off for existing settings
+	// so that upgrading does not change which backend downloads run on.
+	if settings.Version < 6 {
+		settings.MaxConcurrentConversions = 2
+	}
 
 	settings.Version = core.SettingsVersion
 	return settings
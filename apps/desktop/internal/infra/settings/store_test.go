@@ -1,8 +1,10 @@
 package settings
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"ybdownloader/internal/core"
@@ -397,7 +399,7 @@ func TestSaveAndLoad_YtDlpFields(t *testing.T) {
 		MaxConcurrentDownloads: 2,
 		DownloadBackend:        core.BackendBuiltin,
 		YtDlpPath:              "/custom/yt-dlp",
-		YtDlpExtraFlags:        []string{"--proxy", "http://example.com", "--cookies-from-browser", "firefox"},
+		YtDlpExtraFlags:        []string{"--limit-rate", "2M", "--sub-langs", "en"},
 	}
 
 	if err := store.Save(custom); err != nil {
@@ -420,8 +422,45 @@ func TestSaveAndLoad_YtDlpFields(t *testing.T) {
 	if len(loaded.YtDlpExtraFlags) != 4 {
 		t.Fatalf("YtDlpExtraFlags length = %d, want 4", len(loaded.YtDlpExtraFlags))
 	}
-	if loaded.YtDlpExtraFlags[0] != "--proxy" || loaded.YtDlpExtraFlags[1] != "http://example.com" {
-		t.Errorf("YtDlpExtraFlags = %v, want [--proxy http://example.com ...]", loaded.YtDlpExtraFlags)
+	if loaded.YtDlpExtraFlags[0] != "--limit-rate" || loaded.YtDlpExtraFlags[1] != "2M" {
+		t.Errorf("YtDlpExtraFlags = %v, want [--limit-rate 2M ...]", loaded.YtDlpExtraFlags)
+	}
+}
+
+func TestSave_RejectsDangerousYtDlpFlags(t *testing.T) {
+	store, _ := newTestStore(t)
+	settings := core.DefaultSettings("/music")
+	settings.YtDlpExtraFlags = []string{"--exec", "rm -rf ~", "-o", "/tmp/x"}
+
+	err := store.Save(settings)
+	var flagsErr *core.YtDlpFlagsError
+	if !errors.As(err, &flagsErr) || len(flagsErr.Flags) != 2 {
+		t.Fatalf("Save() error = %v, want two rejected flags", err)
+	}
+	if flagsErr.Flags[0].Class != core.YtDlpFlagDangerous || flagsErr.Flags[1].Class != core.YtDlpFlagConflicting {
+		t.Errorf("rejected flags = %+v", flagsErr.Flags)
+	}
+}
+
+func TestLoad_DropsDisallowedYtDlpFlags(t *testing.T) {
+	store, tmpDir := newTestStore(t)
+
+	data := `{"version":4,"downloadBackend":"yt-dlp","maxConcurrentDownloads":3,"defaultSavePath":"/custom",` +
+		`"ytDlpExtraFlags":["--exec","touch /tmp/pwned","--embed-subs","-r50K"]}`
+	if err := os.WriteFile(filepath.Join(tmpDir, "settings.json"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.DefaultSavePath != "/custom" {
+		t.Errorf("DefaultSavePath = %q, other settings were reset", settings.DefaultSavePath)
+	}
+	want := []string{"--embed-subs", "-r", "50K"}
+	if strings.Join(settings.YtDlpExtraFlags, " ") != strings.Join(want, " ") {
+		t.Errorf("YtDlpExtraFlags = %v, want %v", settings.YtDlpExtraFlags, want)
 	}
 }
 
@@ -530,3 +569,39 @@ func TestReset_RemoveError(t *testing.T) {
 		t.Fatal("Reset() expected error when file path is a non-empty directory")
 	}
 }
+
+func TestMigrate_AllowedSites(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	migrated := store.migrate(core.Settings{Version: 3})
+	if len(migrated.AllowedSites) == 0 {
+		t.Error("migrate() should add the default allowed sites")
+	}
+
+	kept := store.migrate(core.Settings{Version: 3, AllowedSites: []string{}})
+	if len(kept.AllowedSites) != 0 {
+		t.Errorf("migrate() AllowedSites = %v, want explicit empty list kept", kept.AllowedSites)
+	}
+}
+
+func TestMigrate_BackendFallback(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	if migrated := store.migrate(core.Settings{Version: 4}); migrated.BackendFallback {
+		t.Error("migrate() should leave backend fallback off for older settings")
+	}
+	if kept := store.migrate(core.Settings{Version: 5, BackendFallback: true}); !kept.BackendFallback {
+		t.Error("migrate() should keep fallback enabled when the user turned it on")
+	}
+}
+
+func TestMigrate_MaxConcurrentConversions(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	if migrated := store.migrate(core.Settings{Version: 5}); migrated.MaxConcurrentConversions != 2 {
+		t.Errorf("migrate() MaxConcurrentConversions = %d, want 2", migrated.MaxConcurrentConversions)
+	}
+	if kept := store.migrate(core.Settings{Version: 6, MaxConcurrentConversions: 4}); kept.MaxConcurrentConversions != 4 {
+		t.Errorf("migrate() MaxConcurrentConversions = %d, want 4 kept", kept.MaxConcurrentConversions)
+	}
+}
@@ -104,6 +104,12 @@ func (u *Updater) SetUpdateChannel(channel string) {
 	u.updateChannel = UpdateChannelStable
 }
 
+// SetTransport routes update checks and downloads through rt, e.g. to apply
+// proxy settings.
+func (u *Updater) SetTransport(rt http.RoundTripper) {
+	u.httpClient.Transport = rt
+}
+
 // SetProgressCallback sets a callback function for progress updates
 func (u *Updater) SetProgressCallback(callback func(UpdateInfo)) {
 	u.onProgress = callback
@@ -65,6 +65,11 @@ func NewSearcher() *Searcher {
 	}
 }
 
+// SetTransport routes search requests through rt, e.g. to apply proxy settings.
+func (s *Searcher) SetTransport(rt http.RoundTripper) {
+	s.client.Transport = rt
+}
+
 // Search performs a YouTube search and returns results.
 // This uses web scraping which may break if YouTube changes their page structure.
 func (s *Searcher) Search(ctx context.Context, query string, limit int) (*SearchResponse, error) {